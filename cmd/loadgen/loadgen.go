@@ -0,0 +1,238 @@
+// Package loadgen is load test scenario generator command, it replays order creation and listing traffic against
+// target environment through the public api and reports latency percentiles of every operation,
+// e.g. bin loadgen --target %target% -p configs/loadgen.json -r 50 -u 1m --duration 5m
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/paysuper/paysuper-management-api/cmd"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	orderPath       = "/api/v1/order"
+	adminOrderPath  = "/admin/api/v1/order"
+	listOrdersLimit = 20
+	schedulerTick   = 10 * time.Millisecond
+	defaultRps      = 10
+
+	headerSignature = "X-API-SIGNATURE"
+)
+
+var (
+	target      string
+	profilePath string
+	rps         float64
+	rampUp      time.Duration
+	duration    time.Duration
+	workers     int
+	timeout     time.Duration
+	seed        int64
+	maxP99      time.Duration
+
+	// Cmd is registered in root command, the test runs as executor of the entrypoint, so it's stopped
+	// by the same signals as the api daemon
+	Cmd = &cobra.Command{
+		Use:           "loadgen",
+		Short:         "Load test scenario generator",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Run: func(_ *cobra.Command, _ []string) {
+			var profile *Profile
+
+			cmd.Slave.Executor(func(ctx context.Context) (err error) {
+				if profile, err = LoadProfile(profilePath); err != nil {
+					return fmt.Errorf("can't load profile: %v", err)
+				}
+
+				return nil
+			}, func(ctx context.Context) error {
+				return run(ctx, profile)
+			})
+		},
+	}
+)
+
+func init() {
+	// shorthands of persistent flags of root command (-c, -d, -l, -t) aren't used
+	Cmd.Flags().StringVar(&target, "target", "http://127.0.0.1:3001", "base url of target environment")
+	Cmd.Flags().StringVarP(&profilePath, "profile", "p", "configs/loadgen.json", "traffic profile file")
+	Cmd.Flags().Float64VarP(&rps, "rps", "r", defaultRps, "requests per second after ramp up")
+	Cmd.Flags().DurationVarP(&rampUp, "ramp-up", "u", 30*time.Second, "time to increase load linearly from zero to rps")
+	Cmd.Flags().DurationVar(&duration, "duration", time.Minute, "total duration of the test including ramp up")
+	Cmd.Flags().IntVarP(&workers, "workers", "w", 50, "maximum of concurrent requests")
+	Cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "timeout of single request")
+	Cmd.Flags().Int64Var(&seed, "seed", 1, "seed of random generator used for traffic mix")
+	Cmd.Flags().DurationVar(&maxP99, "max-p99", 0, "fail when p99 latency of any operation exceeds it, zero disables check")
+}
+
+type job struct {
+	operation string
+	merchant  *ProfileMerchant
+	project   *ProfileProject
+	amount    float64
+	method    string
+}
+
+func run(ctx context.Context, profile *Profile) error {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var (
+		stats  = NewStats()
+		jobs   = make(chan *job)
+		wg     sync.WaitGroup
+		client = &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{MaxIdleConnsPerHost: workers},
+		}
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				start := time.Now()
+				status, err := execute(client, j)
+				stats.Add(j.operation, time.Since(start), status, err)
+			}
+		}()
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	start := time.Now()
+	ticker := time.NewTicker(schedulerTick)
+	sent := 0
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+
+		for due := expectedRequests(time.Since(start)); sent < due; sent++ {
+			select {
+			case jobs <- newJob(rnd, profile):
+			default:
+				stats.Drop()
+			}
+		}
+	}
+
+	ticker.Stop()
+	close(jobs)
+	wg.Wait()
+
+	fmt.Println(stats.Report(time.Since(start)))
+
+	if maxP99 > 0 {
+		for op := range profile.Operations {
+			if p99 := stats.Percentile(op, 99); p99 > maxP99 {
+				return fmt.Errorf("p99 latency of %s is %s, limit is %s", op, p99, maxP99)
+			}
+		}
+	}
+
+	return nil
+}
+
+// expectedRequests returns count of requests which must be sent since start, rate increases linearly during ramp up
+func expectedRequests(elapsed time.Duration) int {
+	t := elapsed.Seconds()
+	ramp := rampUp.Seconds()
+
+	if t < ramp {
+		return int(rps * t * t / (2 * ramp))
+	}
+
+	return int(rps*ramp/2 + rps*(t-ramp))
+}
+
+func newJob(rnd *rand.Rand, profile *Profile) *job {
+	merchant := profile.pickMerchant(rnd)
+	project := merchant.Projects[rnd.Intn(len(merchant.Projects))]
+	amount := project.MinAmount + rnd.Float64()*(project.MaxAmount-project.MinAmount)
+
+	return &job{
+		operation: profile.pickOperation(rnd),
+		merchant:  merchant,
+		project:   project,
+		amount:    float64(int64(amount*100)) / 100,
+		method:    profile.pickPaymentMethod(rnd),
+	}
+}
+
+func execute(client *http.Client, j *job) (int, error) {
+	var (
+		req *http.Request
+		err error
+	)
+
+	switch j.operation {
+	case operationCreateOrder:
+		// order with customer is created by project host2host request, so it's signed as projects sign it
+		account := "loadgen_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+		body, _ := json.Marshal(map[string]interface{}{
+			"project_id":     j.project.Id,
+			"amount":         j.amount,
+			"currency":       j.project.Currency,
+			"payment_method": j.method,
+			"account":        account,
+			"user":           map[string]string{"id": account},
+		})
+		req, err = http.NewRequest(http.MethodPost, strings.TrimRight(target, "/")+orderPath, bytes.NewReader(body))
+
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(headerSignature, signature(body, j.project.SecretKey))
+		}
+	case operationListOrders:
+		q := url.Values{"project": []string{j.project.Id}, "limit": []string{strconv.Itoa(listOrdersLimit)}}
+		req, err = http.NewRequest(http.MethodGet, strings.TrimRight(target, "/")+adminOrderPath+"?"+q.Encode(), nil)
+
+		if err == nil {
+			req.Header.Set("Authorization", "Bearer "+j.merchant.Token)
+		}
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	rsp, err := client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// body is read fully to let client reuse connection
+	_, _ = io.Copy(ioutil.Discard, rsp.Body)
+	_ = rsp.Body.Close()
+
+	return rsp.StatusCode, nil
+}
+
+// signature is signature of project request body checked by billing server, sha512 of the body followed
+// by secret key of the project
+func signature(body []byte, secretKey string) string {
+	h := sha512.Sum512([]byte(string(body) + secretKey))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,169 @@
+package loadgen
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+)
+
+const (
+	operationCreateOrder = "create_order"
+	operationListOrders  = "list_orders"
+)
+
+// Profile describes traffic mix replayed by load generator
+type Profile struct {
+	Merchants      []*ProfileMerchant      `json:"merchants"`
+	PaymentMethods []*ProfilePaymentMethod `json:"payment_methods"`
+	// Operations contains weights of operations, e.g. {"create_order": 80, "list_orders": 20}
+	Operations map[string]int `json:"operations"`
+}
+
+type ProfileMerchant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+	// Token is access token of merchant user, required to list orders in admin api
+	Token    string            `json:"token"`
+	Projects []*ProfileProject `json:"projects"`
+}
+
+type ProfileProject struct {
+	Id string `json:"id"`
+	// SecretKey is secret key of the project, order create requests are signed with it
+	SecretKey string  `json:"secret_key"`
+	Currency  string  `json:"currency"`
+	MinAmount float64 `json:"min_amount"`
+	MaxAmount float64 `json:"max_amount"`
+}
+
+type ProfilePaymentMethod struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+func LoadProfile(path string) (*Profile, error) {
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Profile{}
+
+	if err = json.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+
+	return p, p.validate()
+}
+
+func (p *Profile) validate() error {
+	if len(p.Merchants) == 0 {
+		return errors.New("profile must contain at least one merchant")
+	}
+
+	if len(p.Operations) == 0 {
+		p.Operations = map[string]int{operationCreateOrder: 1}
+	}
+
+	for _, m := range p.Merchants {
+		if len(m.Projects) == 0 {
+			return errors.New("merchant " + m.Name + " must contain at least one project")
+		}
+
+		for _, project := range m.Projects {
+			if project.SecretKey == "" && p.Operations[operationCreateOrder] > 0 {
+				return errors.New("secret key of project " + project.Id + " is required to create orders")
+			}
+
+			if project.MinAmount <= 0 || project.MaxAmount < project.MinAmount {
+				return errors.New("amounts of project " + project.Id + " are incorrect")
+			}
+		}
+	}
+
+	for op := range p.Operations {
+		if op != operationCreateOrder && op != operationListOrders {
+			return errors.New("unknown operation " + op)
+		}
+	}
+
+	return nil
+}
+
+// pickMerchant returns merchant in accordance with weights of merchants mix
+func (p *Profile) pickMerchant(rnd *rand.Rand) *ProfileMerchant {
+	weights := make([]int, len(p.Merchants))
+
+	for i, m := range p.Merchants {
+		weights[i] = m.Weight
+	}
+
+	return p.Merchants[pickWeighted(rnd, weights)]
+}
+
+// pickPaymentMethod returns payment method in accordance with weights of payment methods mix,
+// empty string means payment method is chosen by customer on payment form
+func (p *Profile) pickPaymentMethod(rnd *rand.Rand) string {
+	if len(p.PaymentMethods) == 0 {
+		return ""
+	}
+
+	weights := make([]int, len(p.PaymentMethods))
+
+	for i, m := range p.PaymentMethods {
+		weights[i] = m.Weight
+	}
+
+	return p.PaymentMethods[pickWeighted(rnd, weights)].Name
+}
+
+func (p *Profile) pickOperation(rnd *rand.Rand) string {
+	var (
+		names   []string
+		weights []int
+	)
+
+	// fixed order of operations keeps runs with the same seed reproducible
+	for _, op := range []string{operationCreateOrder, operationListOrders} {
+		if w, ok := p.Operations[op]; ok {
+			names = append(names, op)
+			weights = append(weights, w)
+		}
+	}
+
+	return names[pickWeighted(rnd, weights)]
+}
+
+// pickWeighted returns index of item chosen randomly with weights, items with zero weights are chosen
+// uniformly when all weights are zero
+func pickWeighted(rnd *rand.Rand, weights []int) int {
+	total := 0
+
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+
+	if total == 0 {
+		return rnd.Intn(len(weights))
+	}
+
+	n := rnd.Intn(total)
+
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+
+		if n < w {
+			return i
+		}
+
+		n -= w
+	}
+
+	return len(weights) - 1
+}
@@ -0,0 +1,146 @@
+package loadgen
+
+import (
+	"fmt"
+	"github.com/alexeyco/simpletable"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+var reportPercentiles = []float64{50, 90, 95, 99}
+
+type operationStats struct {
+	latencies []time.Duration
+	errors    int
+	statuses  map[int]int
+}
+
+// Stats collects latencies of operations executed by workers
+type Stats struct {
+	mx         sync.Mutex
+	operations map[string]*operationStats
+	dropped    int
+}
+
+func NewStats() *Stats {
+	return &Stats{operations: make(map[string]*operationStats)}
+}
+
+// Add records result of operation, status is zero when request wasn't completed
+func (s *Stats) Add(op string, latency time.Duration, status int, err error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	st, ok := s.operations[op]
+
+	if !ok {
+		st = &operationStats{statuses: make(map[int]int)}
+		s.operations[op] = st
+	}
+
+	st.latencies = append(st.latencies, latency)
+	st.statuses[status]++
+
+	if err != nil || status >= 400 {
+		st.errors++
+	}
+}
+
+// Drop records operation which wasn't sent because all workers were busy
+func (s *Stats) Drop() {
+	s.mx.Lock()
+	s.dropped++
+	s.mx.Unlock()
+}
+
+// Percentile returns latency percentile of operation using nearest rank method
+func (s *Stats) Percentile(op string, p float64) time.Duration {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	st, ok := s.operations[op]
+
+	if !ok {
+		return 0
+	}
+
+	return percentile(st.latencies, p)
+}
+
+// Report returns table with count, errors and latency percentiles of every operation
+func (s *Stats) Report(elapsed time.Duration) string {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	table := simpletable.New()
+	table.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "Operation"},
+			{Align: simpletable.AlignCenter, Text: "Count"},
+			{Align: simpletable.AlignCenter, Text: "Errors"},
+			{Align: simpletable.AlignCenter, Text: "RPS"},
+		},
+	}
+
+	for _, p := range reportPercentiles {
+		table.Header.Cells = append(table.Header.Cells, &simpletable.Cell{
+			Align: simpletable.AlignCenter,
+			Text:  fmt.Sprintf("p%v", p),
+		})
+	}
+
+	table.Header.Cells = append(table.Header.Cells, &simpletable.Cell{Align: simpletable.AlignCenter, Text: "Max"})
+
+	var ops []string
+
+	for op := range s.operations {
+		ops = append(ops, op)
+	}
+
+	sort.Strings(ops)
+
+	for _, op := range ops {
+		st := s.operations[op]
+		row := []*simpletable.Cell{
+			{Align: simpletable.AlignLeft, Text: op},
+			{Align: simpletable.AlignRight, Text: fmt.Sprintf("%d", len(st.latencies))},
+			{Align: simpletable.AlignRight, Text: fmt.Sprintf("%d", st.errors)},
+			{Align: simpletable.AlignRight, Text: fmt.Sprintf("%.1f", float64(len(st.latencies))/elapsed.Seconds())},
+		}
+
+		for _, p := range reportPercentiles {
+			row = append(row, &simpletable.Cell{Align: simpletable.AlignRight, Text: percentile(st.latencies, p).String()})
+		}
+
+		row = append(row, &simpletable.Cell{Align: simpletable.AlignRight, Text: percentile(st.latencies, 100).String()})
+		table.Body.Cells = append(table.Body.Cells, row)
+	}
+
+	table.SetStyle(simpletable.StyleMarkdown)
+
+	return fmt.Sprintf("%s\n\nElapsed: %s, dropped: %d", table.String(), elapsed, s.dropped)
+}
+
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+
+	if rank < 0 {
+		rank = 0
+	}
+
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"strconv"
+)
+
+const (
+	// ProductMetadataArchived is metadata field of archived product kept by billing server with the product,
+	// the value is enabled state of the product before archive. The field is reserved, merchants can't set it
+	ProductMetadataArchived = "_archived"
+
+	archivedProductsKeyMask          = "archived_products:%s"
+	archivedProductsFieldInitialized = "_initialized"
+)
+
+// ArchivedProduct is archived product in index of archived products, fields of the product matched by filters
+// of products listing are kept
+type ArchivedProduct struct {
+	ProjectId string            `json:"project_id"`
+	Sku       string            `json:"sku"`
+	Name      map[string]string `json:"name"`
+}
+
+// GetProductArchived returns enabled state of the product before archive, false is returned for product
+// which isn't archived
+func GetProductArchived(product *grpc.Product) (bool, bool) {
+	val, ok := product.Metadata[ProductMetadataArchived]
+
+	if !ok {
+		return false, false
+	}
+
+	enabled, _ := strconv.ParseBool(val)
+
+	return enabled, true
+}
+
+// SetProductArchived marks product archived with its enabled state and disables it, or restores enabled state
+// of archived product and removes the mark
+func SetProductArchived(product *grpc.Product, archived bool) {
+	if archived {
+		if product.Metadata == nil {
+			product.Metadata = make(map[string]string)
+		}
+
+		product.Metadata[ProductMetadataArchived] = strconv.FormatBool(product.Enabled)
+		product.Enabled = false
+
+		return
+	}
+
+	if enabled, ok := GetProductArchived(product); ok {
+		product.Enabled = enabled
+		delete(product.Metadata, ProductMetadataArchived)
+	}
+}
+
+// ArchivedProducts is index of archived products of merchants in redis, billing server keeps the archive mark
+// with the product but can't filter products by it. The index is rebuilt from products of the merchant when
+// it's lost, the hash has marker field set by the rebuild to tell lost index apart from merchant without
+// archived products
+type ArchivedProducts struct {
+	redis redis.Cmdable
+}
+
+// NewArchivedProducts
+func NewArchivedProducts(redis redis.Cmdable) *ArchivedProducts {
+	return &ArchivedProducts{redis: redis}
+}
+
+// Add adds archived product into index of the merchant
+func (a *ArchivedProducts) Add(product *grpc.Product) error {
+	data, err := json.Marshal(&ArchivedProduct{ProjectId: product.ProjectId, Sku: product.Sku, Name: product.Name})
+
+	if err != nil {
+		return err
+	}
+
+	return a.redis.HSet(fmt.Sprintf(archivedProductsKeyMask, product.MerchantId), product.Id, data).Err()
+}
+
+// Remove removes product from index of the merchant
+func (a *ArchivedProducts) Remove(merchantId, productId string) error {
+	return a.redis.HDel(fmt.Sprintf(archivedProductsKeyMask, merchantId), productId).Err()
+}
+
+// List returns archived products of the merchant by identifier, false is returned when index of the merchant
+// is lost and must be rebuilt
+func (a *ArchivedProducts) List(merchantId string) (map[string]*ArchivedProduct, bool, error) {
+	fields, err := a.redis.HGetAll(fmt.Sprintf(archivedProductsKeyMask, merchantId)).Result()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, ok := fields[archivedProductsFieldInitialized]; !ok {
+		return nil, false, nil
+	}
+
+	res := make(map[string]*ArchivedProduct, len(fields)-1)
+
+	for id, data := range fields {
+		if id == archivedProductsFieldInitialized {
+			continue
+		}
+
+		product := &ArchivedProduct{}
+
+		if err = json.Unmarshal([]byte(data), product); err != nil {
+			return nil, false, err
+		}
+
+		res[id] = product
+	}
+
+	return res, true, nil
+}
+
+// Rebuild replaces index of the merchant by archived products of all products of the merchant
+func (a *ArchivedProducts) Rebuild(merchantId string, products []*grpc.Product) error {
+	fields := map[string]interface{}{archivedProductsFieldInitialized: 1}
+
+	for _, product := range products {
+		if _, ok := GetProductArchived(product); !ok {
+			continue
+		}
+
+		data, err := json.Marshal(&ArchivedProduct{ProjectId: product.ProjectId, Sku: product.Sku, Name: product.Name})
+
+		if err != nil {
+			return err
+		}
+
+		fields[product.Id] = data
+	}
+
+	key := fmt.Sprintf(archivedProductsKeyMask, merchantId)
+	_, err := a.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(key)
+		pipe.HMSet(key, fields)
+		return nil
+	})
+
+	return err
+}
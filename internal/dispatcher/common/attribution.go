@@ -0,0 +1,174 @@
+package common
+
+import (
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	orderAttributionKeyMask      = "order_attribution:%s"
+	orderAttributionIndexKeyMask = "order_attribution_index:%s:%s:%s"
+	orderAttributionQueryKeyMask = "order_attribution_query:%s"
+
+	// orderAttributionQueryTTL removes intersection of indexes left by instance died while listing orders
+	orderAttributionQueryTTL = time.Minute
+)
+
+// OrderAttributionDimensions are order parameters orders are indexed, filtered and grouped by: attribution
+// parameters and platform of key products
+var OrderAttributionDimensions = []string{
+	RequestParameterUtmSource,
+	RequestParameterUtmMedium,
+	RequestParameterUtmCampaign,
+	RequestParameterAdId,
+	RequestParameterPlatform,
+}
+
+// OrderAttributions keeps attribution parameters of orders in redis with index of orders of the project by each
+// parameter value, the index is ordered by order create time. Billing server only stores the parameters with
+// the order and can't filter or group orders by them
+type OrderAttributions struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewOrderAttributions
+func NewOrderAttributions(redis redis.Cmdable, ttl time.Duration) *OrderAttributions {
+	return &OrderAttributions{redis: redis, ttl: ttl}
+}
+
+// Record saves parameters of OrderAttributionDimensions of the created order, empty parameters are skipped
+func (a *OrderAttributions) Record(projectId, orderId string, attr map[string]string, createdAt time.Time) error {
+	fields := make(map[string]interface{})
+
+	for _, dimension := range OrderAttributionDimensions {
+		if val := attr[dimension]; val != "" {
+			fields[dimension] = val
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, err := a.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		key := fmt.Sprintf(orderAttributionKeyMask, orderId)
+		pipe.HMSet(key, fields)
+		pipe.Expire(key, a.ttl)
+
+		for dimension, val := range fields {
+			index := fmt.Sprintf(orderAttributionIndexKeyMask, projectId, dimension, val)
+			pipe.ZAdd(index, redis.Z{Score: float64(createdAt.Unix()), Member: orderId})
+			pipe.Expire(index, a.ttl)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// OrderAttributionPage is page of orders matching attribution filter, From and To are create time of the oldest
+// and the newest order of the page
+type OrderAttributionPage struct {
+	Ids   []string
+	Count int64
+	From  time.Time
+	To    time.Time
+}
+
+// Find returns identifiers of orders of the project matching all parameters of filter, newest first,
+// and the number of matched orders
+func (a *OrderAttributions) Find(projectId string, filter map[string]string, offset, limit int64) (*OrderAttributionPage, error) {
+	var keys []string
+
+	for _, dimension := range OrderAttributionDimensions {
+		if val := filter[dimension]; val != "" {
+			keys = append(keys, fmt.Sprintf(orderAttributionIndexKeyMask, projectId, dimension, val))
+		}
+	}
+
+	page := &OrderAttributionPage{Ids: []string{}}
+
+	if len(keys) == 0 || limit <= 0 {
+		return page, nil
+	}
+
+	key := keys[0]
+
+	if len(keys) > 1 {
+		key = fmt.Sprintf(orderAttributionQueryKeyMask, bson.NewObjectId().Hex())
+
+		// scores of order in all indexes are equal, max keeps the create time
+		_, err := a.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+			pipe.ZInterStore(key, redis.ZStore{Aggregate: "MAX"}, keys...)
+			pipe.Expire(key, orderAttributionQueryTTL)
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer a.redis.Del(key)
+	}
+
+	count, err := a.redis.ZCard(key).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := a.redis.ZRevRangeWithScores(key, offset, offset+limit-1).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	page.Count = count
+
+	for i, item := range items {
+		createdAt := time.Unix(int64(item.Score), 0)
+
+		if i == 0 {
+			page.To = createdAt
+		}
+
+		page.Ids = append(page.Ids, item.Member.(string))
+		page.From = createdAt
+	}
+
+	return page, nil
+}
+
+// Get returns attribution parameters of orders by order identifier, orders without parameters are omitted
+func (a *OrderAttributions) Get(orderIds []string) (map[string]map[string]string, error) {
+	res := make(map[string]map[string]string)
+
+	if len(orderIds) == 0 {
+		return res, nil
+	}
+
+	cmds := make([]*redis.StringStringMapCmd, len(orderIds))
+	_, err := a.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, id := range orderIds {
+			cmds[i] = pipe.HGetAll(fmt.Sprintf(orderAttributionKeyMask, id))
+		}
+
+		return nil
+	})
+
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		if attr := cmd.Val(); len(attr) > 0 {
+			res[orderIds[i]] = attr
+		}
+	}
+
+	return res, nil
+}
@@ -3,6 +3,9 @@ package common
 import (
 	"bytes"
 	"context"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
@@ -11,17 +14,55 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"io"
 	"io/ioutil"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+)
+
+const (
+	merchantListingQuickSearchMaxLength = 255
+	merchantListingSortMaxFields        = 5
+
+	rangeParamFromSuffix = "_from"
+	rangeParamToSuffix   = "_to"
+
+	// IdsFilterMaxLength is max count of identifiers in ids filter of listings
+	IdsFilterMaxLength = 100
+
+	orderUserPhoneField                 = "user.phone"
+	merchantContactAuthorizedPhoneField = "authorized.phone"
+	merchantContactTechnicalPhoneField  = "technical.phone"
+)
+
+var (
+	errJsonTrailingData = errors.New("unexpected data after json value")
+
+	merchantListingSortRegexp    = regexp.MustCompile("^-?[a-z_][a-z0-9_.]*$")
+	merchantListingCountryRegexp = regexp.MustCompile("^[A-Z]{2}$")
 )
 
 type OrderFormBinder struct{}
-type OrderJsonBinder struct{}
+type OrderJsonBinder struct {
+	// Extra is decoded from json body with fields of the order request the billing server doesn't know,
+	// so the body is decoded for them once
+	Extra interface{}
+}
 type OrderRevenueDynamicRequestBinder struct{}
 type OrderAccountingPaymentRequestBinder struct{}
-type PaymentCreateProcessBinder struct{}
+type PaymentCreateProcessBinder struct {
+	TokenizationMode           bool
+	TokenizationPaymentMethods []string
+}
 type OnboardingMerchantListingBinder struct {
 	LimitDefault, OffsetDefault int32
+	// Countries is filled on Bind from country[] query parameter
+	Countries []string
+	// LastActivityFrom and LastActivityTo are filled on Bind from last_activity_from and last_activity_to
+	// query parameters, zero value means the range is open
+	LastActivityFrom, LastActivityTo int64
 }
 type OnboardingChangeMerchantStatusBinder struct{}
 type OnboardingNotificationsListBinder struct {
@@ -30,8 +71,13 @@ type OnboardingNotificationsListBinder struct {
 type OnboardingGetPaymentMethodBinder struct{}
 type OnboardingChangePaymentMethodBinder struct{}
 type OnboardingCreateNotificationBinder struct{}
+type OnboardingChangeMerchantContactsBinder struct{}
 type ProductsGetProductsListBinder struct {
 	LimitDefault, OffsetDefault int32
+	// IncludeArchived is filled on Bind from include_archived query parameter
+	IncludeArchived bool
+	// Ids is filled on Bind from ids query parameter
+	Ids []string
 }
 type ProductsCreateProductBinder struct{}
 type ProductsUpdateProductBinder struct{}
@@ -97,35 +143,90 @@ func (cb *OrderFormBinder) Bind(i interface{}, ctx echo.Context) (err error) {
 	o.Other = addParams
 	o.RawParams = rawParams
 
-	return
+	// national payer phone is read in the payer region
+	return normalizePhoneField(&o.PayerPhone, rawParams[OrderFieldRegion], OrderFieldPayerPhone)
 }
 
-// Bind
+// Bind decodes json order while the request body is read. The body is hashed on the fly for check of the request
+// signature (see CheckProjectAuthRequestSignature) and is always kept as raw body of the order, billing server checks
+// signature of the order by it
 func (cb *OrderJsonBinder) Bind(i interface{}, ctx echo.Context) (err error) {
-	var buf []byte
+	structure := i.(*billing.OrderCreateRequest)
+	buf := ExtractRawBodyContext(ctx)
+	hash := sha512.New()
 
-	if ctx.Request().Body != nil {
-		buf, err = ioutil.ReadAll(ctx.Request().Body)
-		rdr := ioutil.NopCloser(bytes.NewBuffer(buf))
+	if !strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		if buf == nil && ctx.Request().Body != nil {
+			if buf, err = ioutil.ReadAll(ctx.Request().Body); err != nil {
+				return err
+			}
 
-		if err != nil {
+			SetRawBodyContext(ctx, buf)
+		}
+
+		hash.Write(buf)
+		ctx.Request().Body = ioutil.NopCloser(bytes.NewReader(buf))
+
+		if err = new(echo.DefaultBinder).Bind(i, ctx); err != nil {
 			return err
 		}
+	} else if ctx.Request().Body != nil {
+		body := io.TeeReader(ctx.Request().Body, hash)
+		var raw *bytes.Buffer
 
-		ctx.Request().Body = rdr
-	}
+		// raw body is read by RawBodyPreMiddleware into pooled buffer, it's kept here only without the middleware
+		if buf == nil {
+			raw = new(bytes.Buffer)
+			body = io.TeeReader(body, raw)
+		}
 
-	db := new(echo.DefaultBinder)
-	if err = db.Bind(i, ctx); err != nil {
-		return err
+		if err = decodeJson(body, structure); err != nil {
+			return err
+		}
+
+		if raw != nil {
+			buf = raw.Bytes()
+			SetRawBodyContext(ctx, buf)
+		}
+
+		if cb.Extra != nil && len(buf) > 0 {
+			if err = json.Unmarshal(buf, cb.Extra); err != nil {
+				return err
+			}
+		}
 	}
 
-	structure := i.(*billing.OrderCreateRequest)
+	SetRawBodyHashContext(ctx, hash)
 	structure.RawBody = string(buf)
 
+	if structure.User != nil {
+		// national user phone is read in the country of the user address
+		return normalizePhoneField(&structure.User.Phone, structure.User.GetAddress().GetCountry(), orderUserPhoneField)
+	}
+
 	return
 }
 
+// decodeJson decodes single json value of the reader, the reader is read to the end, so all of it passes through
+// readers it's teed to. Empty body leaves the value unchanged, data after the value is rejected as by json.Unmarshal
+func decodeJson(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+
+	if err := dec.Decode(v); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+
+		return err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return errJsonTrailingData
+	}
+
+	return nil
+}
+
 // Bind
 func (cb *PaymentCreateProcessBinder) Bind(i interface{}, ctx echo.Context) (err error) {
 	db := new(echo.DefaultBinder)
@@ -151,6 +252,22 @@ func (cb *PaymentCreateProcessBinder) Bind(i interface{}, ctx echo.Context) (err
 		}
 	}
 
+	if !cb.TokenizationMode {
+		return
+	}
+
+	for _, field := range PaymentCreateRawCardFields {
+		if _, ok := data[field]; ok {
+			return ErrorMessageRawCardDataNotAllowed
+		}
+	}
+
+	for _, methodId := range cb.TokenizationPaymentMethods {
+		if data[PaymentCreateFieldPaymentMethodId] == methodId && data[PaymentCreateFieldCardToken] == "" {
+			return ErrorMessageCardTokenRequired
+		}
+	}
+
 	return
 }
 
@@ -172,7 +289,7 @@ func (cb *OnboardingMerchantListingBinder) Bind(i interface{}, ctx echo.Context)
 
 	if v, ok := params[RequestParameterIsSigned]; ok {
 		if v[0] == "0" || v[0] == "false" {
-			structure.IsSigned = 2
+			structure.IsSigned = 1
 		} else {
 			if v[0] == "1" || v[0] == "true" {
 				structure.IsSigned = 2
@@ -182,7 +299,90 @@ func (cb *OnboardingMerchantListingBinder) Bind(i interface{}, ctx echo.Context)
 		}
 	}
 
-	return
+	if v, ok := params[RequestParameterQuickSearch]; ok && len(v[0]) > merchantListingQuickSearchMaxLength {
+		return ErrorRequestParamsIncorrect
+	}
+
+	if v, ok := params[RequestParameterStatuses]; ok {
+		structure.Statuses = make([]int32, 0, len(v))
+
+		for _, val := range v {
+			status, err := strconv.ParseInt(val, 10, 32)
+
+			if err != nil {
+				return ErrorRequestParamsIncorrect
+			}
+
+			structure.Statuses = append(structure.Statuses, int32(status))
+		}
+	}
+
+	for _, country := range params[RequestParameterCountries] {
+		if !merchantListingCountryRegexp.MatchString(country) {
+			return ErrorRequestParamsIncorrect
+		}
+
+		cb.Countries = append(cb.Countries, country)
+	}
+
+	if v, ok := params[QueryParameterNameSort]; ok {
+		if len(v) > merchantListingSortMaxFields {
+			return ErrorRequestParamsIncorrect
+		}
+
+		for _, field := range v {
+			if !merchantListingSortRegexp.MatchString(field) {
+				return ErrorRequestParamsIncorrect
+			}
+		}
+	}
+
+	if err = checkRangeParams(params); err != nil {
+		return err
+	}
+
+	if v, ok := params[RequestParameterLastActivityFrom]; ok {
+		cb.LastActivityFrom, _ = strconv.ParseInt(v[0], 10, 64)
+	}
+
+	if v, ok := params[RequestParameterLastActivityTo]; ok {
+		cb.LastActivityTo, err = strconv.ParseInt(v[0], 10, 64)
+
+		if err != nil {
+			return ErrorRequestParamsIncorrect
+		}
+	}
+
+	return nil
+}
+
+// checkRangeParams checks that all *_from and *_to query parameters are integers and range start isn't after its end
+func checkRangeParams(params url.Values) error {
+	for key, v := range params {
+		if !strings.HasSuffix(key, rangeParamFromSuffix) {
+			continue
+		}
+
+		from, err := strconv.ParseInt(v[0], 10, 64)
+
+		if err != nil {
+			return ErrorRequestParamsIncorrect
+		}
+
+		toV, ok := params[strings.TrimSuffix(key, rangeParamFromSuffix)+rangeParamToSuffix]
+
+		if !ok {
+			continue
+		}
+
+		to, err := strconv.ParseInt(toV[0], 10, 64)
+
+		if err != nil || from > to {
+			return ErrorRequestParamsIncorrect
+		}
+	}
+
+	return nil
 }
 
 // Bind
@@ -302,6 +502,32 @@ func (b *OnboardingCreateNotificationBinder) Bind(i interface{}, ctx echo.Contex
 	return nil
 }
 
+// Bind normalizes phones of the contacts to E.164 format, contacts have no country, so national numbers
+// are kept as entered
+func (b *OnboardingChangeMerchantContactsBinder) Bind(i interface{}, ctx echo.Context) error {
+	db := new(echo.DefaultBinder)
+
+	if err := db.Bind(i, ctx); err != nil {
+		return err
+	}
+
+	structure := i.(*billing.MerchantContact)
+
+	if structure.Authorized != nil {
+		err := normalizePhoneField(&structure.Authorized.Phone, "", merchantContactAuthorizedPhoneField)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if structure.Technical != nil {
+		return normalizePhoneField(&structure.Technical.Phone, "", merchantContactTechnicalPhoneField)
+	}
+
+	return nil
+}
+
 // Bind
 func (b *ProductsGetProductsListBinder) Bind(i interface{}, ctx echo.Context) error {
 	limit := int32(b.LimitDefault)
@@ -347,9 +573,48 @@ func (b *ProductsGetProductsListBinder) Bind(i interface{}, ctx echo.Context) er
 		}
 	}
 
+	if v, ok := params[RequestParameterIncludeArchived]; ok {
+		includeArchived, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return err
+		}
+		b.IncludeArchived = includeArchived
+	}
+
+	ids, err := ParseIdsParam(ctx.QueryParam(RequestParameterIds))
+
+	if err != nil {
+		return err
+	}
+
+	b.Ids = ids
+
 	return nil
 }
 
+// ParseIdsParam parses comma separated identifiers of ids filter of listings, empty value means no filter
+func ParseIdsParam(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	ids := strings.Split(value, ",")
+
+	if len(ids) > IdsFilterMaxLength {
+		return nil, ErrorMessageIdsFilterTooLong
+	}
+
+	for i, id := range ids {
+		ids[i] = strings.TrimSpace(id)
+
+		if !bson.IsObjectIdHex(ids[i]) {
+			return nil, ErrorMessageIdsFilterInvalid
+		}
+	}
+
+	return ids, nil
+}
+
 // Bind
 func (b *ProductsCreateProductBinder) Bind(i interface{}, ctx echo.Context) error {
 	db := new(echo.DefaultBinder)
@@ -670,12 +935,10 @@ func (b *ChangeProjectRequestBinder) Bind(i interface{}, ctx echo.Context) error
 		}
 	}
 
-	if v, ok := req[RequestParameterStatus]; ok {
-		if tv, ok := v.(float64); !ok {
-			return ErrorMessageStatusIncorrectType
-		} else {
-			structure.Status = int32(tv)
-		}
+	// status of the project is changed by review workflow only, so merchant can't move the project to production
+	// without approval of operator
+	if _, ok := req[RequestParameterStatus]; ok {
+		return ErrorMessageProjectStatusTransitionInvalid
 	}
 
 	if v, ok := req[RequestParameterUrlChargebackPayment]; ok {
@@ -0,0 +1,141 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	BulkRefundRowStatusPending          = "pending"
+	BulkRefundRowStatusProcessing       = "processing"
+	BulkRefundRowStatusCreated          = "created"
+	BulkRefundRowStatusApprovalRequired = "approval_required"
+	BulkRefundRowStatusFailed           = "failed"
+
+	// BulkRefundLockTTL releases processing of bulk refund when instance processing it has died, the lock is
+	// refreshed after each row, so the ttl must exceed processing time of single refund
+	BulkRefundLockTTL = 2 * RefundOrderLockTTL
+
+	bulkRefundKeyMask            = "bulk_refund:%s"
+	bulkRefundLockKeyMask        = "bulk_refund_lock:%s"
+	bulkRefundIdempotencyKeyMask = "bulk_refund_idempotency:%s:%s"
+)
+
+// BulkRefundRow is single refund of bulk refund with its processing state
+type BulkRefundRow struct {
+	Row      int     `json:"row"`
+	OrderId  string  `json:"order_id"`
+	Amount   float64 `json:"amount"`
+	Reason   string  `json:"reason"`
+	Status   string  `json:"status"`
+	RefundId string  `json:"refund_id,omitempty"`
+	// ApprovalId is approval of refund exceeding auto-approve threshold of the project, see RefundApproval
+	ApprovalId string      `json:"approval_id,omitempty"`
+	Error      interface{} `json:"error,omitempty"`
+}
+
+// BulkRefund is bulk refund job, rows are processed one by one and the job is saved after each of them,
+// so progress is visible while it's processed and the job is resumed from the first pending row
+type BulkRefund struct {
+	Id               string           `json:"id"`
+	CreatorId        string           `json:"creator_id"`
+	Total            int              `json:"total"`
+	Succeeded        int              `json:"succeeded"`
+	ApprovalRequired int              `json:"approval_required"`
+	Failed           int              `json:"failed"`
+	Completed        bool             `json:"completed"`
+	CreatedAt        time.Time        `json:"created_at"`
+	Rows             []*BulkRefundRow `json:"rows"`
+}
+
+// BulkRefunds keeps bulk refund jobs in redis for ttl, the job is found by idempotency key of its creator,
+// so retried request doesn't create refunds again
+type BulkRefunds struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewBulkRefunds
+func NewBulkRefunds(redis redis.Cmdable, ttl time.Duration) *BulkRefunds {
+	return &BulkRefunds{redis: redis, ttl: ttl}
+}
+
+// Create saves new job under idempotency key of the creator, job created with the key before is returned
+// with false instead
+func (b *BulkRefunds) Create(idempotencyKey string, job *BulkRefund) (*BulkRefund, bool, error) {
+	job.Id = bson.NewObjectId().Hex()
+	key := fmt.Sprintf(bulkRefundIdempotencyKeyMask, job.CreatorId, idempotencyKey)
+	created, err := b.redis.SetNX(key, job.Id, b.ttl).Result()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !created {
+		id, err := b.redis.Get(key).Result()
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		existing, err := b.Get(id)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		// job can be missing only when key is saved and the job isn't yet, it's saved right after the key
+		if existing == nil {
+			return nil, false, fmt.Errorf("bulk refund %s isn't saved yet", id)
+		}
+
+		return existing, false, nil
+	}
+
+	if err = b.Save(job); err != nil {
+		b.redis.Del(key)
+		return nil, false, err
+	}
+
+	return job, true, nil
+}
+
+// Lock serializes processing of the job across instances, nil is returned when it's locked already
+func (b *BulkRefunds) Lock(id string) (*Lock, error) {
+	return AcquireLock(b.redis, fmt.Sprintf(bulkRefundLockKeyMask, id), BulkRefundLockTTL)
+}
+
+// Get returns the job, nil is returned when it doesn't exist or is expired
+func (b *BulkRefunds) Get(id string) (*BulkRefund, error) {
+	data, err := b.redis.Get(fmt.Sprintf(bulkRefundKeyMask, id)).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	job := &BulkRefund{}
+
+	if err = json.Unmarshal(data, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Save
+func (b *BulkRefunds) Save(job *BulkRefund) error {
+	data, err := json.Marshal(job)
+
+	if err != nil {
+		return err
+	}
+
+	return b.redis.Set(fmt.Sprintf(bulkRefundKeyMask, job.Id), data, b.ttl).Err()
+}
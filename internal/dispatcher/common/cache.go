@@ -0,0 +1,332 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderCache tells whether response of cacheable route is served from cache
+	HeaderCache = "X-Cache"
+
+	RouteCacheHit  = "HIT"
+	RouteCacheMiss = "MISS"
+
+	// RouteCacheMaxBodySize is size of the largest cacheable response body
+	RouteCacheMaxBodySize = 1024 * 1024
+
+	routeCacheMetricsNamespace = "management_api"
+	routeCacheMetricsSubsystem = "route_cache"
+	routeCacheKeySeparator     = "|"
+	routeCacheKeyMask          = "route_cache:%s"
+	routeCacheEventKeyMask     = "route_cache_event:%s"
+)
+
+// Events invalidating cached responses, the same event may be declared by many routes
+const (
+	RouteCacheEventCountries      = "countries"
+	RouteCacheEventPriceGroups    = "price_groups"
+	RouteCacheEventPriceTables    = "price_tables"
+	RouteCacheEventPaymentCosts   = "payment_costs"
+	RouteCacheEventPaymentMethods = "payment_methods"
+)
+
+var routeCacheKeyPlaceholder = regexp.MustCompile(`{(param|query|header)(\.[^}]+)?}`)
+
+// RouteCachePolicy declares cacheability of GET route.
+// Key is template of cache key made unique across routes by route path and api version, placeholders {param.name},
+// {query.name} and {header.name} are replaced by values of the request, {query} by all query parameters
+// sorted by name. The cached response lives for TTL or until any of InvalidatedBy events, requests with body
+// are never cached
+type RouteCachePolicy struct {
+	Key           string
+	TTL           time.Duration
+	InvalidatedBy []string
+}
+
+type routeCacheEntry struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// RouteCache keeps successful responses of routes declared cacheable in their registration in redis, so all
+// instances share responses and invalidations. Every event has generation in redis which is a part of keys
+// of responses invalidated by the event, flush of the event increments generation and responses cached before
+// are never read again and expire by their TTL. When redis fails requests are served without cache
+type RouteCache struct {
+	redis redis.Cmdable
+
+	registry      *prometheus.Registry
+	requests      *prometheus.CounterVec
+	invalidations *prometheus.CounterVec
+	errors        *prometheus.CounterVec
+}
+
+// NewRouteCache
+func NewRouteCache(redis redis.Cmdable) *RouteCache {
+	c := &RouteCache{
+		redis:    redis,
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: routeCacheMetricsNamespace,
+			Subsystem: routeCacheMetricsSubsystem,
+			Name:      "requests_total",
+			Help:      "Requests to cacheable routes by result of cache lookup",
+		}, []string{"route", "result"}),
+		invalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: routeCacheMetricsNamespace,
+			Subsystem: routeCacheMetricsSubsystem,
+			Name:      "invalidations_total",
+			Help:      "Invalidation events of cached responses",
+		}, []string{"event"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: routeCacheMetricsNamespace,
+			Subsystem: routeCacheMetricsSubsystem,
+			Name:      "errors_total",
+			Help:      "Failed operations of cache storage",
+		}, []string{"operation"}),
+	}
+
+	c.registry.MustRegister(c.requests, c.invalidations, c.errors)
+
+	return c
+}
+
+// Cache returns route middleware serving successful responses of GET route from cache.
+// Usage: groups.AuthProject.GET(path, h.handler, groups.RouteCache.Cache(common.RouteCachePolicy{...}))
+func (c *RouteCache) Cache(policy RouteCachePolicy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			// parameters sent in body of GET request aren't part of the key, so such requests aren't cached
+			if ctx.Request().Method != http.MethodGet || ctx.Request().ContentLength != 0 {
+				return next(ctx)
+			}
+
+			// generations are read before the response is built, so response built from data changed
+			// by concurrent flush is stored under key which isn't read anymore
+			route := ctx.Path()
+			generations, err := c.generations(policy.InvalidatedBy)
+
+			if err != nil {
+				c.errors.WithLabelValues("get").Inc()
+				return next(ctx)
+			}
+
+			key := strings.Join([]string{
+				route,
+				strconv.Itoa(GetApiVersion(ctx)),
+				renderRouteCacheKey(ctx, policy.Key),
+				generations,
+			}, routeCacheKeySeparator)
+			entry, err := c.get(key)
+
+			if err != nil {
+				c.errors.WithLabelValues("get").Inc()
+				return next(ctx)
+			}
+
+			if entry != nil {
+				c.requests.WithLabelValues(route, RouteCacheHit).Inc()
+				ctx.Response().Header().Set(HeaderCache, RouteCacheHit)
+				return ctx.Blob(http.StatusOK, entry.ContentType, entry.Body)
+			}
+
+			c.requests.WithLabelValues(route, RouteCacheMiss).Inc()
+			ctx.Response().Header().Set(HeaderCache, RouteCacheMiss)
+
+			writer := ctx.Response().Writer
+			recorder := &routeCacheRecorder{ResponseWriter: writer}
+			ctx.Response().Writer = recorder
+			err = next(ctx)
+			ctx.Response().Writer = writer
+
+			if err != nil || ctx.Response().Status != http.StatusOK || recorder.overflow {
+				return err
+			}
+
+			entry = &routeCacheEntry{
+				ContentType: ctx.Response().Header().Get(echo.HeaderContentType),
+				Body:        recorder.body,
+			}
+
+			if err = c.set(key, entry, policy.TTL); err != nil {
+				c.errors.WithLabelValues("set").Inc()
+			}
+
+			return nil
+		}
+	}
+}
+
+// Invalidate returns route middleware dropping cached responses declared invalidated by the events
+// once the route responds successfully.
+// Usage: groups.AuthUser.POST(path, h.handler, groups.RouteCache.Invalidate(common.RouteCacheEventX))
+func (c *RouteCache) Invalidate(events ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			err := next(ctx)
+
+			if err == nil && ctx.Response().Status < http.StatusBadRequest {
+				_ = c.Flush(events...)
+			}
+
+			return err
+		}
+	}
+}
+
+// Flush drops cached responses declared invalidated by the events on all instances
+func (c *RouteCache) Flush(events ...string) error {
+	_, err := c.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, event := range events {
+			pipe.Incr(fmt.Sprintf(routeCacheEventKeyMask, event))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		c.errors.WithLabelValues("flush").Inc()
+		return err
+	}
+
+	for _, event := range events {
+		c.invalidations.WithLabelValues(event).Inc()
+	}
+
+	return nil
+}
+
+// Metrics returns handler exposing cache metrics of the instance in Prometheus format
+func (c *RouteCache) Metrics() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// generations returns generations of the events joined in order of the events, absent event has zero generation
+func (c *RouteCache) generations(events []string) (string, error) {
+	if len(events) == 0 {
+		return "", nil
+	}
+
+	keys := make([]string, 0, len(events))
+
+	for _, event := range events {
+		keys = append(keys, fmt.Sprintf(routeCacheEventKeyMask, event))
+	}
+
+	values, err := c.redis.MGet(keys...).Result()
+
+	if err != nil {
+		return "", err
+	}
+
+	generations := make([]string, 0, len(values))
+
+	for _, value := range values {
+		generation, ok := value.(string)
+
+		if !ok {
+			generation = "0"
+		}
+
+		generations = append(generations, generation)
+	}
+
+	return strings.Join(generations, ","), nil
+}
+
+func (c *RouteCache) get(key string) (*routeCacheEntry, error) {
+	data, err := c.redis.Get(fmt.Sprintf(routeCacheKeyMask, key)).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &routeCacheEntry{}
+
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (c *RouteCache) set(key string, entry *routeCacheEntry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	return c.redis.Set(fmt.Sprintf(routeCacheKeyMask, key), data, ttl).Err()
+}
+
+func renderRouteCacheKey(ctx echo.Context, template string) string {
+	return routeCacheKeyPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		match := routeCacheKeyPlaceholder.FindStringSubmatch(placeholder)
+		name := strings.TrimPrefix(match[2], ".")
+
+		switch match[1] {
+		case "param":
+			return ctx.Param(name)
+		case "header":
+			return ctx.Request().Header.Get(name)
+		}
+
+		if name != "" {
+			return ctx.QueryParam(name)
+		}
+
+		query := ctx.QueryParams()
+		names := make([]string, 0, len(query))
+
+		for k := range query {
+			names = append(names, k)
+		}
+
+		sort.Strings(names)
+		pairs := make([]string, 0, len(names))
+
+		for _, k := range names {
+			values := append([]string{}, query[k]...)
+			sort.Strings(values)
+			pairs = append(pairs, k+"="+strings.Join(values, ","))
+		}
+
+		return strings.Join(pairs, "&")
+	})
+}
+
+// routeCacheRecorder copies response body written by the handler, body above RouteCacheMaxBodySize isn't kept
+type routeCacheRecorder struct {
+	http.ResponseWriter
+	body     []byte
+	overflow bool
+}
+
+func (r *routeCacheRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if len(r.body)+len(b) > RouteCacheMaxBodySize {
+			r.overflow = true
+			r.body = nil
+		} else {
+			r.body = append(r.body, b...)
+		}
+	}
+
+	return r.ResponseWriter.Write(b)
+}
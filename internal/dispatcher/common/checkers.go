@@ -1,23 +1,56 @@
 package common
 
 import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding"
+	"encoding/hex"
 	"fmt"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/money"
 	"gopkg.in/go-playground/validator.v9"
+	"hash"
 	"net/http"
+	"strings"
 )
 
-// CheckProjectAuthRequestSignature
-func CheckProjectAuthRequestSignature(dispatch HandlerSet, ctx echo.Context, projectId string) error {
+// CheckProjectAuthRequestSignature checks signature of the request body: sha512 of the raw body followed by secret key
+// of the project. Hash of the body computed while it was decoded is reused, so matching signature is checked without
+// sending the body to billing server. Otherwise billing server checks the signature and its result is returned.
+// Handler which reads the project anyway passes getProject, so the project is read once, it's read here when
+// getProject is nil
+func CheckProjectAuthRequestSignature(
+	dispatch HandlerSet,
+	ctx echo.Context,
+	projectId string,
+	getProject func() *billing.Project,
+) error {
 
 	signature := ctx.Request().Header.Get(HeaderXApiSignatureHeader)
 	if signature == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, ErrorMessageSignatureHeaderIsEmpty)
 	}
 
+	if getProject == nil {
+		getProject = func() *billing.Project {
+			rsp, err := dispatch.Services.Billing.GetProject(ctx.Request().Context(), &grpc.GetProjectRequest{ProjectId: projectId})
+
+			if err != nil || rsp.Status != pkg.ResponseStatusOk {
+				return nil
+			}
+
+			return rsp.Item
+		}
+	}
+
+	if isProjectRequestSignatureValid(ctx, getProject(), signature) {
+		return nil
+	}
+
 	req := &grpc.CheckProjectRequestSignatureRequest{Body: string(ExtractRawBodyContext(ctx)), ProjectId: projectId, Signature: signature}
 
 	rsp, err := dispatch.Services.Billing.CheckProjectRequestSignature(ctx.Request().Context(), req)
@@ -32,6 +65,68 @@ func CheckProjectAuthRequestSignature(dispatch HandlerSet, ctx echo.Context, pro
 	return nil
 }
 
+// isProjectRequestSignatureValid checks signature by the project secret key, false is returned when it doesn't match
+// or the project isn't read. The secret is written into copy of the body hash, so the check can be repeated
+func isProjectRequestSignatureValid(ctx echo.Context, project *billing.Project, signature string) bool {
+	if project.GetSecretKey() == "" {
+		return false
+	}
+
+	h := cloneHash(ExtractRawBodyHashContext(ctx))
+
+	if h == nil {
+		h = sha512.New()
+		h.Write(ExtractRawBodyContext(ctx))
+	}
+
+	h.Write([]byte(project.GetSecretKey()))
+
+	return hmac.Equal([]byte(hex.EncodeToString(h.Sum(nil))), []byte(strings.ToLower(signature)))
+}
+
+// cloneHash copies state of sha512 hash, nil is returned when the hash is nil or isn't sha512
+func cloneHash(h hash.Hash) hash.Hash {
+	m, ok := h.(encoding.BinaryMarshaler)
+
+	if !ok {
+		return nil
+	}
+
+	state, err := m.MarshalBinary()
+
+	if err != nil {
+		return nil
+	}
+
+	c := sha512.New()
+
+	if err = c.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil
+	}
+
+	return c
+}
+
+// CheckAmountPrecision checks that amounts have no more decimal places than the currency exponent allows
+func CheckAmountPrecision(currency string, amounts ...float64) error {
+	if currency == "" {
+		return nil
+	}
+
+	for _, amount := range amounts {
+		if money.HasValidPrecision(amount, currency) {
+			continue
+		}
+
+		rspErr := *ErrorMessageAmountPrecisionInvalid
+		rspErr.Details = fmt.Sprintf("%s allows %d decimal places", currency, money.Exponent(currency))
+
+		return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	return nil
+}
+
 // GetValidationError
 func GetValidationError(err error) (rspErr *grpc.ResponseErrorMessage) {
 
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	cohortCustomersKeyMask = "cohort_customers:%s:%s"
+)
+
+// CohortCustomers keeps customers who purchased in a complete month by projects of the merchant in redis for ttl,
+// they're used to tell new customers of cohorts from returning ones
+type CohortCustomers struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewCohortCustomers
+func NewCohortCustomers(redis redis.Cmdable, ttl time.Duration) *CohortCustomers {
+	return &CohortCustomers{redis: redis, ttl: ttl}
+}
+
+// Get returns customer identifiers of the month by project identifier, nil is returned when the month isn't kept
+func (c *CohortCustomers) Get(merchantId, month string) (map[string][]string, error) {
+	data, err := c.redis.Get(fmt.Sprintf(cohortCustomersKeyMask, merchantId, month)).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	customers := make(map[string][]string)
+
+	if err = json.Unmarshal(data, &customers); err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+// Save
+func (c *CohortCustomers) Save(merchantId, month string, customers map[string][]string) error {
+	data, err := json.Marshal(customers)
+
+	if err != nil {
+		return err
+	}
+
+	return c.redis.Set(fmt.Sprintf(cohortCustomersKeyMask, merchantId, month), data, c.ttl).Err()
+}
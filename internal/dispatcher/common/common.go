@@ -3,12 +3,16 @@ package common
 import (
 	"github.com/ProtocolONE/geoip-service/pkg/proto"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/go-redis/redis"
 	"github.com/labstack/echo/v4"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-recurring-repository/pkg/proto/repository"
 	reporterProto "github.com/paysuper/paysuper-reporter/pkg/proto"
 	tax_service "github.com/paysuper/paysuper-tax-service/proto"
 	"gopkg.in/go-playground/validator.v9"
+	"hash"
+	"sort"
+	"strings"
 )
 
 const (
@@ -18,6 +22,10 @@ const (
 	AuthProjectGroupPath     = "/api/v1"
 	AuthUserGroupPath        = "/admin/api/v1"
 	WebHookGroupPath         = "/webhook"
+	SystemGroupPath          = "/system/api/v1"
+
+	// ProjectScopePrefix is prefix of access token scopes restricting the token to the project
+	ProjectScopePrefix = "project:"
 )
 
 // Cursor
@@ -42,6 +50,15 @@ func ExtractRawBodyContext(ctx echo.Context) []byte {
 	return nil
 }
 
+// ExtractRawBodyHashContext returns sha512 of raw body computed while the body was decoded, nil when the body
+// wasn't hashed
+func ExtractRawBodyHashContext(ctx echo.Context) hash.Hash {
+	if h, ok := ctx.Get("rawBodyHash").(hash.Hash); ok {
+		return h
+	}
+	return nil
+}
+
 // ExtractCursorContext
 func ExtractCursorContext(ctx echo.Context) *Cursor {
 	if cursor, ok := ctx.Get("cursor").(*Cursor); ok {
@@ -60,6 +77,11 @@ func SetRawBodyContext(ctx echo.Context, rawBody []byte) {
 	ctx.Set("rawBody", rawBody)
 }
 
+// SetRawBodyHashContext
+func SetRawBodyHashContext(ctx echo.Context, h hash.Hash) {
+	ctx.Set("rawBodyHash", h)
+}
+
 // SetCursorContext
 func SetCursorContext(ctx echo.Context, cursor *Cursor) {
 	ctx.Set("cursor", cursor)
@@ -71,7 +93,20 @@ type Groups struct {
 	Access      *echo.Group
 	AuthUser    *echo.Group
 	WebHooks    *echo.Group
+	System      *echo.Group
 	Common      *echo.Echo
+	// Deprecations provides middleware marking routes deprecated, see Deprecations.Deprecate
+	Deprecations *Deprecations
+	// RequestLog contains recent requests of authenticated users to admin api
+	RequestLog *RequestLog
+	// Redis is storage of state shared by all instances
+	Redis redis.Cmdable
+	// RateLimits creates rate limiters counting requests of all instances, see RateLimits.Limiter
+	RateLimits *RateLimits
+	// ProjectIssues detects configuration changes of projects followed by spike of failed order creations
+	ProjectIssues *ProjectIssues
+	// RouteCache provides middlewares declaring routes cacheable and invalidating them, see RouteCache.Cache
+	RouteCache *RouteCache
 }
 
 // Handler
@@ -91,6 +126,9 @@ type Services struct {
 	Billing    grpc.BillingService
 	Tax        tax_service.TaxService
 	Reporter   reporterProto.ReporterService
+
+	// Integrations tracks calls of the services above, it's nil when services aren't created by micro client
+	Integrations *Integrations
 }
 
 // Handlers
@@ -110,4 +148,61 @@ type AuthUser struct {
 	Email     string
 	Roles     map[string]bool
 	Merchants map[string]bool
+	// Projects restricts the token to a subset of the merchant projects granted by token scopes, empty means no restriction
+	Projects map[string]bool
+	// Operator is set for operator authenticated by personal token of SystemApiUsers, merchant tokens never set it
+	Operator bool
+}
+
+// IsProjectAllowed
+func (u *AuthUser) IsProjectAllowed(projectId string) bool {
+	if len(u.Projects) == 0 {
+		return true
+	}
+	return u.Projects[projectId]
+}
+
+// FilterProjects intersects requested projects with the token project scope.
+// Second value is false when none of the requested projects is allowed.
+func (u *AuthUser) FilterProjects(projects []string) ([]string, bool) {
+	if len(u.Projects) == 0 {
+		return projects, true
+	}
+
+	var filtered []string
+
+	if len(projects) == 0 {
+		for projectId := range u.Projects {
+			filtered = append(filtered, projectId)
+		}
+		sort.Strings(filtered)
+		return filtered, true
+	}
+
+	for _, projectId := range projects {
+		if u.Projects[projectId] {
+			filtered = append(filtered, projectId)
+		}
+	}
+
+	return filtered, len(filtered) > 0
+}
+
+// ParseProjectScope returns projects of "project:<id>" scopes of space separated token scope
+func ParseProjectScope(scope string) map[string]bool {
+	var projects map[string]bool
+
+	for _, item := range strings.Fields(scope) {
+		if !strings.HasPrefix(item, ProjectScopePrefix) {
+			continue
+		}
+
+		if projects == nil {
+			projects = make(map[string]bool)
+		}
+
+		projects[strings.TrimPrefix(item, ProjectScopePrefix)] = true
+	}
+
+	return projects
 }
@@ -33,4 +33,139 @@ type Config struct {
 	CustomerTokenCookiesLifetime time.Duration // CustomerTokenCookiesLifetime = 2592000
 
 	OrderInlineFormUrlMask string `envconfig:"ORDER_INLINE_FORM_URL_MASK" required:"true"`
+
+	// Redis keeps state shared by all instances: rate limits, caches, claims
+	RedisHost     string `envconfig:"REDIS_HOST" default:"127.0.0.1:6379"`
+	RedisPassword string `envconfig:"REDIS_PASSWORD" default:""`
+
+	// TrustedProxies lists addresses or CIDR networks of proxies whose X-Forwarded-For header is trusted by rate limits
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
+
+	// CardTokenizationMode forbids raw card requisites in payment create requests, only vault tokens are accepted
+	CardTokenizationMode           bool     `envconfig:"CARD_TOKENIZATION_MODE" default:"false"`
+	CardTokenizationPaymentMethods []string `envconfig:"CARD_TOKENIZATION_PAYMENT_METHODS"`
+
+	// Card vault of the cardholder data environment, the tokenization endpoint works on instances deployed in it only.
+	// CardTokenizationVaults maps payment method id to payment system of vault in format "5be2d0b4b0b30d0007383ce6:cardpay",
+	// a card token is required in payment create requests for these payment methods
+	CardTokenizationVaultUrl     string            `envconfig:"CARD_TOKENIZATION_VAULT_URL"`
+	CardTokenizationVaultApiKey  string            `envconfig:"CARD_TOKENIZATION_VAULT_API_KEY"`
+	CardTokenizationVaultTimeout time.Duration     `envconfig:"CARD_TOKENIZATION_VAULT_TIMEOUT" default:"10s"`
+	CardTokenizationVaults       map[string]string `envconfig:"CARD_TOKENIZATION_VAULTS"`
+
+	// PaymentEmailScreeningMode enables screening of payer email in payment create requests: "flag" logs rejected emails,
+	// "block" rejects the payment, empty value disables the check. Projects may override mode and mx lookup
+	PaymentEmailScreeningMode       string        `envconfig:"PAYMENT_EMAIL_SCREENING_MODE" default:""`
+	PaymentEmailScreeningMxLookup   bool          `envconfig:"PAYMENT_EMAIL_SCREENING_MX_LOOKUP" default:"false"`
+	PaymentEmailScreeningDnsTimeout time.Duration `envconfig:"PAYMENT_EMAIL_SCREENING_DNS_TIMEOUT" default:"2s"`
+	PaymentEmailScreeningVerdictTtl time.Duration `envconfig:"PAYMENT_EMAIL_SCREENING_VERDICT_TTL" default:"2160h"`
+	PaymentEmailDisposableDomains   []string      `envconfig:"PAYMENT_EMAIL_DISPOSABLE_DOMAINS"`
+
+	// VirtualCurrencyMaxAmount is the largest number of virtual currency units purchased by one order
+	VirtualCurrencyMaxAmount int64 `envconfig:"VIRTUAL_CURRENCY_MAX_AMOUNT" default:"1000000"`
+
+	// ReceiptRateLimit is the number of public receipt lookups allowed per client ip in ReceiptRateLimitWindow
+	ReceiptRateLimit       int           `envconfig:"RECEIPT_RATE_LIMIT" default:"60"`
+	ReceiptRateLimitWindow time.Duration `envconfig:"RECEIPT_RATE_LIMIT_WINDOW" default:"1m"`
+
+	// RefundMaxCount limits the number of refunds (full or partial) per order, zero disables the check
+	RefundMaxCount int32 `envconfig:"REFUND_MAX_COUNT" default:"0"`
+
+	// RefundBulkMaxRows limits the number of refunds created by one bulk refund request
+	RefundBulkMaxRows int `envconfig:"REFUND_BULK_MAX_ROWS" default:"500"`
+	// RefundBulkTtl is the time bulk refund progress is kept, request repeated with the same idempotency key
+	// within it returns the same bulk refund
+	RefundBulkTtl time.Duration `envconfig:"REFUND_BULK_TTL" default:"168h"`
+	// RefundApprovalTtl is the time refund exceeding auto-approve threshold of the project waits for approval of support
+	RefundApprovalTtl time.Duration `envconfig:"REFUND_APPROVAL_TTL" default:"720h"`
+
+	// OrderSearchMaxPeriod is the longest payment or project date period of order listing searching by account or text
+	// across all projects, zero disables the check
+	OrderSearchMaxPeriod time.Duration `envconfig:"ORDER_SEARCH_MAX_PERIOD" default:"2208h"`
+
+	// OrderAttributionTTL is how long attribution parameters of order are kept for filtering and reports by them
+	OrderAttributionTTL time.Duration `envconfig:"ORDER_ATTRIBUTION_TTL" default:"9480h"`
+
+	// AnalyticsDailyRevenueTtl is how long net revenue of a complete day is kept for forecast, refunds of the day made
+	// within it aren't reflected
+	AnalyticsDailyRevenueTtl time.Duration `envconfig:"ANALYTICS_DAILY_REVENUE_TTL" default:"6h"`
+	// AnalyticsCohortHistoryMonths is the number of months before cohorts period checked for purchases of customers,
+	// customers who purchased in them are returning and aren't counted in cohorts. Customers of the months are kept
+	// for AnalyticsCohortCustomersTtl
+	AnalyticsCohortHistoryMonths int           `envconfig:"ANALYTICS_COHORT_HISTORY_MONTHS" default:"24"`
+	AnalyticsCohortCustomersTtl  time.Duration `envconfig:"ANALYTICS_COHORT_CUSTOMERS_TTL" default:"24h"`
+	// AnalyticsProductsMaxPeriod is the longest period of products report, zero disables the check
+	AnalyticsProductsMaxPeriod time.Duration `envconfig:"ANALYTICS_PRODUCTS_MAX_PERIOD" default:"8784h"`
+
+	// ExportConcurrencyLimit is the number of exports a merchant may run at once: report files, orders export and
+	// products report, zero disables the check. ExportSlotTtl frees slot of export of instance died while exporting.
+	// Report files are generated asynchronously by reporter, so slot of report file is held for ReportFileExportSlotTtl
+	ExportConcurrencyLimit  int           `envconfig:"EXPORT_CONCURRENCY_LIMIT" default:"3"`
+	ExportSlotTtl           time.Duration `envconfig:"EXPORT_SLOT_TTL" default:"1h"`
+	ReportFileExportSlotTtl time.Duration `envconfig:"REPORT_FILE_EXPORT_SLOT_TTL" default:"10m"`
+
+	// Merchant risk monitoring, a merchant is moved to RiskReviewMerchantStatus when a ratio for RiskPeriod exceeds its threshold,
+	// zero status disables automatic flagging
+	RiskPeriod                   time.Duration `envconfig:"RISK_PERIOD" default:"720h"`
+	RiskChargebackRatioThreshold float64       `envconfig:"RISK_CHARGEBACK_RATIO_THRESHOLD" default:"0.01"`
+	RiskRefundRatioThreshold     float64       `envconfig:"RISK_REFUND_RATIO_THRESHOLD" default:"0.1"`
+	RiskReviewMerchantStatus     int32         `envconfig:"RISK_REVIEW_MERCHANT_STATUS" default:"0"`
+
+	// Country specific rounding rules for VAT and commissions in format "DE:half_even,FR:down", default is half_up
+	VatRoundingRules        map[string]string `envconfig:"VAT_ROUNDING_RULES"`
+	CommissionRoundingRules map[string]string `envconfig:"COMMISSION_ROUNDING_RULES"`
+	// Currency rounding rules of amounts in format "JPY:down,EUR:half_even", default is half_up
+	CurrencyRoundingRules map[string]string `envconfig:"CURRENCY_ROUNDING_RULES"`
+
+	// IntegrationTestTimeout limits each request to merchant urls made by project integration test
+	IntegrationTestTimeout time.Duration `envconfig:"INTEGRATION_TEST_TIMEOUT" default:"10s"`
+	// IntegrationTestAllowedNetworks lists private addresses or CIDR networks integration test may send requests to
+	// (staging environments), all other private and loopback addresses are rejected
+	IntegrationTestAllowedNetworks []string `envconfig:"INTEGRATION_TEST_ALLOWED_NETWORKS"`
+	// IntegrationTestResultTtl keeps results of the last integration test of projects for go-live readiness
+	IntegrationTestResultTtl time.Duration `envconfig:"INTEGRATION_TEST_RESULT_TTL" default:"2160h"`
+	// ProjectReviewMinReadinessScore is go-live readiness score the project must reach to be submitted for review
+	ProjectReviewMinReadinessScore int `envconfig:"PROJECT_REVIEW_MIN_READINESS_SCORE" default:"100"`
+
+	// KYB compliance provider, decisions are applied on behalf of KybSystemUserId, zero merchant status disables status change.
+	// KybCallbackBaseUrl is public url of the api the provider sends decisions to, e.g. https://api.paysuper.online
+	KybProviderUrl            string        `envconfig:"KYB_PROVIDER_URL"`
+	KybProviderApiKey         string        `envconfig:"KYB_PROVIDER_API_KEY"`
+	KybProviderTimeout        time.Duration `envconfig:"KYB_PROVIDER_TIMEOUT" default:"10s"`
+	KybCallbackSecret         string        `envconfig:"KYB_CALLBACK_SECRET"`
+	KybCallbackBaseUrl        string        `envconfig:"KYB_CALLBACK_BASE_URL"`
+	KybSystemUserId           string        `envconfig:"KYB_SYSTEM_USER_ID"`
+	KybApprovedMerchantStatus int32         `envconfig:"KYB_APPROVED_MERCHANT_STATUS" default:"0"`
+	KybRejectedMerchantStatus int32         `envconfig:"KYB_REJECTED_MERCHANT_STATUS" default:"0"`
+
+	// Company registry provider used to pre-fill merchant company on onboarding by registration number
+	CompanyRegistryUrl     string        `envconfig:"COMPANY_REGISTRY_URL"`
+	CompanyRegistryApiKey  string        `envconfig:"COMPANY_REGISTRY_API_KEY"`
+	CompanyRegistryTimeout time.Duration `envconfig:"COMPANY_REGISTRY_TIMEOUT" default:"10s"`
+
+	// SandboxSeedEnabled enables sandbox seeding, seeding creates entities in billing server the api is connected to,
+	// so it's enabled only on deployments serving test environment
+	SandboxSeedEnabled bool `envconfig:"SANDBOX_SEED_ENABLED" default:"false"`
+	// SandboxSeedOrdersCount is the number of sample orders created per project by sandbox seeding
+	SandboxSeedOrdersCount int `envconfig:"SANDBOX_SEED_ORDERS_COUNT" default:"20"`
+
+	// SystemApiToken is bearer token of internal system api, empty token closes system api
+	SystemApiToken string `envconfig:"SYSTEM_API_TOKEN"`
+	// SystemApiUsers are personal bearer tokens of operators by their user id, e.g. "5bdc39a9...:token,5be2d0b4...:token",
+	// actions of operator (merchant review, risk flagging, etc) are done on behalf of user of the token
+	SystemApiUsers map[string]string `envconfig:"SYSTEM_API_USERS"`
+
+	// ReviewQueueSla is the time given to operators to decide on merchant awaiting onboarding review
+	ReviewQueueSla time.Duration `envconfig:"REVIEW_QUEUE_SLA" default:"48h"`
+	// ReviewQueueClaimTtl is the time review stays assigned to operator, unfinished review returns to the queue after it
+	ReviewQueueClaimTtl time.Duration `envconfig:"REVIEW_QUEUE_CLAIM_TTL" default:"8h"`
+
+	// Embedded dashboard widgets, tokens are signed by EmbedTokenSecret and valid for EmbedTokenTtl,
+	// empty secret disables widgets
+	EmbedTokenSecret string        `envconfig:"EMBED_TOKEN_SECRET"`
+	EmbedTokenTtl    time.Duration `envconfig:"EMBED_TOKEN_TTL" default:"15m"`
+
+	// SubdivisionsCatalogPath is read-only json file with ISO 3166-2 subdivisions shipped with the api, relative path
+	// is resolved from working directory
+	SubdivisionsCatalogPath string `envconfig:"SUBDIVISIONS_CATALOG_PATH" default:"assets/data/subdivisions.json"`
 }
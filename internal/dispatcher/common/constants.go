@@ -19,7 +19,15 @@ const (
 	RequestParameterId                       = "id"
 	RequestParameterName                     = "name"
 	RequestParameterSku                      = "sku"
+	RequestParameterIncludeArchived          = "include_archived"
+	RequestParameterIds                      = "ids"
+	RequestParameterCode                     = "code"
 	RequestParameterIsSigned                 = "is_signed"
+	RequestParameterQuickSearch              = "quick_search"
+	RequestParameterStatuses                 = "status[]"
+	RequestParameterCountries                = "country[]"
+	RequestParameterLastActivityFrom         = "last_activity_from"
+	RequestParameterLastActivityTo           = "last_activity_to"
 	RequestParameterMerchantId               = "merchant_id"
 	RequestParameterProject                  = "project[]"
 	RequestParameterPaymentMethod            = "payment_method[]"
@@ -37,6 +45,8 @@ const (
 	RequestParameterUtmSource                = "utm_source"
 	RequestParameterUtmMedium                = "utm_medium"
 	RequestParameterUtmCampaign              = "utm_campaign"
+	RequestParameterAdId                     = "ad_id"
+	RequestParameterPlatform                 = "platform"
 	RequestParameterIsSystem                 = "is_system"
 	RequestParameterAgreementType            = "agreement_type"
 	RequestParameterHasMerchantSignature     = "has_merchant_signature"
@@ -107,6 +117,16 @@ const (
 	OrderFieldDescription   = "PP_DESCRIPTION"
 	OrderFieldRegion        = "PP_REGION"
 
+	PaymentCreateFieldPaymentMethodId = "payment_method_id"
+	PaymentCreateFieldPan             = "pan"
+	PaymentCreateFieldCvv             = "cvv"
+	PaymentCreateFieldMonth           = "month"
+	PaymentCreateFieldYear            = "year"
+	PaymentCreateFieldHolder          = "card_holder"
+	PaymentCreateFieldCardToken       = "card_token"
+	PaymentCreateFieldEmail           = "email"
+	PaymentCreateFieldOrderId         = "order_id"
+
 	QueryParameterNameLimit  = "limit"
 	QueryParameterNameOffset = "offset"
 	QueryParameterNameSort   = "sort[]"
@@ -121,6 +141,10 @@ const (
 	HeaderUserAgent           = "User-Agent"
 	HeaderXApiSignatureHeader = "X-API-SIGNATURE"
 	HeaderReferer             = "referer"
+	HeaderXApiVersion         = "X-API-VERSION"
+	HeaderIdempotencyKey      = "Idempotency-Key"
+
+	MIMETextCsv = "text/csv"
 
 	// EnvironmentProduction        = "prod"
 	CustomerTokenCookiesName = "_ps_ctkn"
@@ -150,6 +174,14 @@ const (
 var (
 	DefaultSort = []string{"_id"}
 
+	PaymentCreateRawCardFields = []string{
+		PaymentCreateFieldPan,
+		PaymentCreateFieldCvv,
+		PaymentCreateFieldMonth,
+		PaymentCreateFieldYear,
+		PaymentCreateFieldHolder,
+	}
+
 	OrderReservedWords = map[string]bool{
 		OrderFieldProjectId:     true,
 		OrderFieldSignature:     true,
@@ -176,7 +208,7 @@ var (
 		"AS": regexp.MustCompile("^\\d{5}(-{1}\\d{4,6})$"),
 		"AD": regexp.MustCompile("^[Aa][Dd]\\d{3}$"),
 		"AI": regexp.MustCompile("^[Aa][I][-][2][6][4][0]$"),
-		"AR": regexp.MustCompile("^\\d{4}|[A-Za-z]\\d{4}[a-zA-Z]{3}$"),
+		"AR": regexp.MustCompile("^(\\d{4}|[A-Za-z]\\d{4}[a-zA-Z]{3})$"),
 		"AM": regexp.MustCompile("^\\d{4}$"),
 		"AC": regexp.MustCompile("^[Aa][Ss][Cc][Nn]\\s{0,1}[1][Zz][Zz]$"),
 		"AU": regexp.MustCompile("^\\d{4}$"),
@@ -341,7 +373,7 @@ var (
 		"TM": regexp.MustCompile("^\\d{6}$"),
 		"TC": regexp.MustCompile("^[Tt][Kk][Cc][Aa]\\s{0,1}[1][Zz]{2}$"),
 		"UA": regexp.MustCompile("^\\d{5}$"),
-		"GB": regexp.MustCompile("^[A-Z]{1,2}[0-9R][0-9A-Z]?\\s*[0-9][A-Z-[CIKMOV]]{2}"),
+		"GB": regexp.MustCompile("^(?i)[A-Z]{1,2}[0-9R][0-9A-Z]?\\s*[0-9][ABD-HJLNP-UW-Z]{2}$"),
 		"US": regexp.MustCompile("^\\b\\d{5}\\b(?:[- ]{1}\\d{4})?$"),
 		"UY": regexp.MustCompile("^\\d{5}$"),
 		"VI": regexp.MustCompile("^\\d{5}$"),
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	dailyRevenueKeyMask = "daily_revenue:%s:%d"
+)
+
+// DailyRevenue is net revenue of processed payments of one project paid in one day
+type DailyRevenue struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// DailyRevenues keeps net revenue of complete days by projects of the merchant in redis for ttl, payments of past days
+// change only by refunds, so orders of each day are read from billing server once per ttl
+type DailyRevenues struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewDailyRevenues
+func NewDailyRevenues(redis redis.Cmdable, ttl time.Duration) *DailyRevenues {
+	return &DailyRevenues{redis: redis, ttl: ttl}
+}
+
+// Get returns revenue of the day by project identifier, nil is returned when the day isn't kept
+func (r *DailyRevenues) Get(merchantId string, day time.Time) (map[string]*DailyRevenue, error) {
+	data, err := r.redis.Get(fmt.Sprintf(dailyRevenueKeyMask, merchantId, day.Unix())).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	revenues := make(map[string]*DailyRevenue)
+
+	if err = json.Unmarshal(data, &revenues); err != nil {
+		return nil, err
+	}
+
+	return revenues, nil
+}
+
+// Save
+func (r *DailyRevenues) Save(merchantId string, day time.Time, revenues map[string]*DailyRevenue) error {
+	data, err := json.Marshal(revenues)
+
+	if err != nil {
+		return err
+	}
+
+	return r.redis.Set(fmt.Sprintf(dailyRevenueKeyMask, merchantId, day.Unix()), data, r.ttl).Err()
+}
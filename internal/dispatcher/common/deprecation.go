@@ -0,0 +1,192 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+
+	deprecationRoutesKey           = "deprecation_routes"
+	deprecationCallsKeyMask        = "deprecation_calls:%s"
+	deprecationLastCallKeyMask     = "deprecation_last_call:%s"
+	deprecationUserMerchantKeyMask = "deprecation_user_merchant:%s"
+
+	// deprecationUserMerchantTTL is lifetime of merchant of user resolved on deprecated route call,
+	// user calling deprecated routes resolves its merchant once a day
+	deprecationUserMerchantTTL = 24 * time.Hour
+)
+
+// DeprecationUsage is usage of deprecated route by single merchant, calls of requests without authenticated user
+// and of users without merchant are counted with empty merchant
+type DeprecationUsage struct {
+	MerchantId string    `json:"merchant_id"`
+	Calls      int64     `json:"calls"`
+	LastCallAt time.Time `json:"last_call_at"`
+}
+
+// DeprecatedRoute describes deprecated route and its usage
+type DeprecatedRoute struct {
+	Method    string              `json:"method"`
+	Path      string              `json:"path"`
+	Sunset    time.Time           `json:"sunset"`
+	Successor string              `json:"successor,omitempty"`
+	Usage     []*DeprecationUsage `json:"usage"`
+}
+
+// Deprecations tracks calls of routes marked deprecated in their registration. Usage is kept in redis per merchant,
+// so it's shared by all instances and its size is bound by the number of merchants. Tracking is best effort,
+// failures of redis or billing server never break the route
+type Deprecations struct {
+	redis   redis.Cmdable
+	billing grpc.BillingService
+}
+
+// NewDeprecations
+func NewDeprecations(redis redis.Cmdable, billing grpc.BillingService) *Deprecations {
+	return &Deprecations{redis: redis, billing: billing}
+}
+
+// Deprecate returns route middleware which emits Deprecation and Sunset headers and tracks callers of the route,
+// successor is url of replacement documentation or route and may be empty.
+// Usage: groups.AuthUser.GET(path, h.handler, groups.Deprecations.Deprecate(sunset, successor))
+func (d *Deprecations) Deprecate(sunset time.Time, successor string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			header := ctx.Response().Header()
+			header.Set(HeaderDeprecation, "true")
+			header.Set(HeaderSunset, sunset.UTC().Format(http.TimeFormat))
+
+			if successor != "" {
+				header.Set(HeaderLink, "<"+successor+`>; rel="successor-version"`)
+			}
+
+			d.track(ctx, &DeprecatedRoute{
+				Method:    ctx.Request().Method,
+				Path:      ctx.Path(),
+				Sunset:    sunset,
+				Successor: successor,
+			})
+
+			return next(ctx)
+		}
+	}
+}
+
+// List returns deprecated routes which were called at least once, sorted by sunset date
+func (d *Deprecations) List() ([]*DeprecatedRoute, error) {
+	routes, err := d.redis.HGetAll(deprecationRoutesKey).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*DeprecatedRoute, 0, len(routes))
+
+	for key, data := range routes {
+		route := &DeprecatedRoute{}
+
+		if err = json.Unmarshal([]byte(data), route); err != nil {
+			return nil, err
+		}
+
+		calls, err := d.redis.HGetAll(fmt.Sprintf(deprecationCallsKeyMask, key)).Result()
+
+		if err != nil {
+			return nil, err
+		}
+
+		lastCalls, err := d.redis.HGetAll(fmt.Sprintf(deprecationLastCallKeyMask, key)).Result()
+
+		if err != nil {
+			return nil, err
+		}
+
+		route.Usage = make([]*DeprecationUsage, 0, len(calls))
+
+		for merchantId, val := range calls {
+			usage := &DeprecationUsage{MerchantId: merchantId}
+			usage.Calls, _ = strconv.ParseInt(val, 10, 64)
+
+			if ts, err := strconv.ParseInt(lastCalls[merchantId], 10, 64); err == nil {
+				usage.LastCallAt = time.Unix(ts, 0).UTC()
+			}
+
+			route.Usage = append(route.Usage, usage)
+		}
+
+		sort.Slice(route.Usage, func(i, j int) bool { return route.Usage[i].Calls > route.Usage[j].Calls })
+		list = append(list, route)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Sunset.Equal(list[j].Sunset) {
+			return list[i].Path+list[i].Method < list[j].Path+list[j].Method
+		}
+
+		return list[i].Sunset.Before(list[j].Sunset)
+	})
+
+	return list, nil
+}
+
+func (d *Deprecations) track(ctx echo.Context, route *DeprecatedRoute) {
+	data, err := json.Marshal(route)
+
+	if err != nil {
+		return
+	}
+
+	key := route.Method + " " + route.Path
+	merchantId := d.merchantId(ctx.Request().Context(), ExtractUserContext(ctx).Id)
+
+	_, _ = d.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(deprecationRoutesKey, key, data)
+		pipe.HIncrBy(fmt.Sprintf(deprecationCallsKeyMask, key), merchantId, 1)
+		pipe.HSet(fmt.Sprintf(deprecationLastCallKeyMask, key), merchantId, time.Now().Unix())
+		return nil
+	})
+}
+
+// merchantId returns merchant of the user, the merchant is resolved by billing server on first call of the user
+// and kept in redis, empty string is returned for requests without user and users without merchant
+func (d *Deprecations) merchantId(ctx context.Context, userId string) string {
+	if userId == "" {
+		return ""
+	}
+
+	key := fmt.Sprintf(deprecationUserMerchantKeyMask, userId)
+
+	if merchantId, err := d.redis.Get(key).Result(); err == nil {
+		return merchantId
+	}
+
+	rsp, err := d.billing.GetMerchantBy(ctx, &grpc.GetMerchantByRequest{UserId: userId})
+
+	// merchant isn't remembered on failure, so it's resolved again on next call
+	if err != nil || (rsp.Status != pkg.ResponseStatusOk && rsp.Status != pkg.ResponseStatusNotFound) {
+		return ""
+	}
+
+	merchantId := ""
+
+	if rsp.Item != nil {
+		merchantId = rsp.Item.Id
+	}
+
+	d.redis.Set(key, merchantId, deprecationUserMerchantTTL)
+
+	return merchantId
+}
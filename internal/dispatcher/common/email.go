@@ -0,0 +1,123 @@
+package common
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	EmailScreeningModeOff   = "off"
+	EmailScreeningModeFlag  = "flag"
+	EmailScreeningModeBlock = "block"
+
+	EmailVerdictAccepted      = "accepted"
+	EmailVerdictInvalidSyntax = "invalid_syntax"
+	EmailVerdictNoMx          = "no_mx"
+	EmailVerdictDisposable    = "disposable"
+)
+
+// EmailResolver is dns resolver of email screening, *net.Resolver is used in production
+type EmailResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// EmailScreening checks payer email before payment, zero value checks syntax only
+type EmailScreening struct {
+	MxLookup          bool
+	DisposableDomains []string
+	// Resolver is used for mx lookup, net.DefaultResolver when it isn't set
+	Resolver EmailResolver
+	// Timeout limits dns lookups of the email, unfinished lookup accepts the email
+	Timeout time.Duration
+}
+
+// Screen returns verdict for the email or empty string when email is accepted.
+// Disposable domains match the email domain and all its subdomains
+func (s *EmailScreening) Screen(ctx context.Context, email string) string {
+	addr, err := mail.ParseAddress(email)
+
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return EmailVerdictInvalidSyntax
+	}
+
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+
+	if !strings.Contains(domain, ".") {
+		return EmailVerdictInvalidSyntax
+	}
+
+	for _, disposable := range s.DisposableDomains {
+		disposable = strings.ToLower(disposable)
+
+		if domain == disposable || strings.HasSuffix(domain, "."+disposable) {
+			return EmailVerdictDisposable
+		}
+	}
+
+	if !s.MxLookup {
+		return ""
+	}
+
+	return s.lookup(ctx, domain)
+}
+
+// lookup checks the domain accepts mail: it has mx records other than null mx (RFC 7505) or, without mx records,
+// address records used as implicit mx (RFC 5321). Temporary dns failures must not reject payments, only missing
+// records do
+func (s *EmailScreening) lookup(ctx context.Context, domain string) string {
+	var resolver EmailResolver = net.DefaultResolver
+
+	if s.Resolver != nil {
+		resolver = s.Resolver
+	}
+
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	records, err := resolver.LookupMX(ctx, domain)
+
+	if isTemporaryDnsError(ctx, err) {
+		return ""
+	}
+
+	if err == nil && len(records) > 0 {
+		if len(records) == 1 && (records[0].Host == "." || records[0].Host == "") {
+			return EmailVerdictNoMx
+		}
+
+		return ""
+	}
+
+	hosts, err := resolver.LookupHost(ctx, domain)
+
+	if isTemporaryDnsError(ctx, err) {
+		return ""
+	}
+
+	if err != nil || len(hosts) == 0 {
+		return EmailVerdictNoMx
+	}
+
+	return ""
+}
+
+func isTemporaryDnsError(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if ctx.Err() != nil {
+		return true
+	}
+
+	dnsErr, ok := err.(*net.DNSError)
+
+	return ok && (dnsErr.Temporary() || dnsErr.Timeout())
+}
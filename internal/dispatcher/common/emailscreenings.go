@@ -0,0 +1,95 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	emailScreeningPoliciesKey    = "email_screening_policies"
+	emailScreeningVerdictKeyMask = "email_screening_verdict:%s"
+)
+
+// EmailScreeningPolicy is payer email screening of the project, empty mode leaves screening configured
+// by PAYMENT_EMAIL_SCREENING_* environment variables
+type EmailScreeningPolicy struct {
+	Mode     string `json:"mode" validate:"omitempty,oneof=off flag block"`
+	MxLookup bool   `json:"mx_lookup"`
+}
+
+// EmailScreeningVerdict is result of screening of payer email of the order
+type EmailScreeningVerdict struct {
+	OrderId    string    `json:"order_id"`
+	Verdict    string    `json:"verdict"`
+	Mode       string    `json:"mode"`
+	ScreenedAt time.Time `json:"screened_at"`
+}
+
+// EmailScreenings keeps email screening policies of projects and screening verdicts of orders in redis,
+// verdicts are kept for ttl. Lost policies are reported with ErrPolicyStoreLost, so screening fails closed
+type EmailScreenings struct {
+	redis    redis.Cmdable
+	ttl      time.Duration
+	policies *policyStore
+}
+
+// NewEmailScreenings
+func NewEmailScreenings(redis redis.Cmdable, ttl time.Duration) *EmailScreenings {
+	return &EmailScreenings{redis: redis, ttl: ttl, policies: &policyStore{redis: redis, key: emailScreeningPoliciesKey}}
+}
+
+// InitPolicies marks the policies initialized on the first deploy or restored after they're lost
+func (s *EmailScreenings) InitPolicies() error {
+	return s.policies.init()
+}
+
+// GetPolicy returns email screening policy of the project, empty policy when it isn't set
+func (s *EmailScreenings) GetPolicy(projectId string) (*EmailScreeningPolicy, error) {
+	policy := &EmailScreeningPolicy{}
+
+	if _, err := s.policies.get(projectId, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// SetPolicy
+func (s *EmailScreenings) SetPolicy(projectId string, policy *EmailScreeningPolicy) error {
+	return s.policies.set(projectId, policy)
+}
+
+// GetVerdict returns the last screening verdict of the order, nil is returned when payer email of the order
+// wasn't screened
+func (s *EmailScreenings) GetVerdict(orderId string) (*EmailScreeningVerdict, error) {
+	data, err := s.redis.Get(fmt.Sprintf(emailScreeningVerdictKeyMask, orderId)).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &EmailScreeningVerdict{}
+
+	if err = json.Unmarshal(data, verdict); err != nil {
+		return nil, err
+	}
+
+	return verdict, nil
+}
+
+// SaveVerdict
+func (s *EmailScreenings) SaveVerdict(verdict *EmailScreeningVerdict) error {
+	data, err := json.Marshal(verdict)
+
+	if err != nil {
+		return err
+	}
+
+	return s.redis.Set(fmt.Sprintf(emailScreeningVerdictKeyMask, verdict.OrderId), data, s.ttl).Err()
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	EmbedWidgetRevenue      = "revenue"
+	EmbedWidgetRecentOrders = "recent_orders"
+)
+
+var (
+	ErrorEmbedTokenInvalid = errors.New("embed token is invalid")
+	ErrorEmbedTokenExpired = errors.New("embed token is expired")
+)
+
+// EmbedClaims is content of the token which allows to read data of listed widgets of the merchant until expiration,
+// data of token with projects is limited to these projects
+type EmbedClaims struct {
+	MerchantId string   `json:"merchant_id"`
+	Projects   []string `json:"projects,omitempty"`
+	Widgets    []string `json:"widgets"`
+	ExpiresAt  int64    `json:"exp"`
+}
+
+// Allows checks that widget is in token scope
+func (c *EmbedClaims) Allows(widget string) bool {
+	for _, w := range c.Widgets {
+		if w == widget {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SignEmbedToken returns token in format base64url(claims).base64url(hmac-sha256 of claims part)
+func SignEmbedToken(secret string, claims *EmbedClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + signEmbedPayload(secret, encoded), nil
+}
+
+// ParseEmbedToken checks signature and expiration of the token and returns its claims
+func ParseEmbedToken(secret, token string) (*EmbedClaims, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(signEmbedPayload(secret, parts[0]))) {
+		return nil, ErrorEmbedTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+
+	if err != nil {
+		return nil, ErrorEmbedTokenInvalid
+	}
+
+	claims := &EmbedClaims{}
+
+	if err = json.Unmarshal(payload, claims); err != nil || claims.MerchantId == "" {
+		return nil, ErrorEmbedTokenInvalid
+	}
+
+	if time.Now().Unix() > claims.ExpiresAt {
+		return nil, ErrorEmbedTokenExpired
+	}
+
+	return claims, nil
+}
+
+func signEmbedPayload(secret, payload string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
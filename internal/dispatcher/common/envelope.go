@@ -0,0 +1,163 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ApiVersionLegacy is served when client doesn't send version header, listings keep their own shapes
+	ApiVersionLegacy = 1
+	// ApiVersionEnvelope wraps successful json responses and errors into Envelope
+	ApiVersionEnvelope = 2
+	ApiVersionLatest   = ApiVersionEnvelope
+
+	envelopeWrittenContextKey = "envelopeWritten"
+)
+
+// Envelope is standard shape of response for clients negotiated ApiVersionEnvelope
+type Envelope struct {
+	Data   interface{}   `json:"data"`
+	Meta   *EnvelopeMeta `json:"meta,omitempty"`
+	Errors []interface{} `json:"errors"`
+}
+
+// EnvelopeMeta contains pagination of listing
+type EnvelopeMeta struct {
+	Count  int32  `json:"count"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// EnvelopeError is error item of envelope for errors having plain text message
+type EnvelopeError struct {
+	Message string `json:"message"`
+}
+
+// GetApiVersion returns api version requested by client in X-API-VERSION header,
+// unknown and absent versions fall back to legacy, versions above supported are downgraded to latest
+func GetApiVersion(ctx echo.Context) int {
+	version, err := strconv.Atoi(ctx.Request().Header.Get(HeaderXApiVersion))
+
+	if err != nil || version < ApiVersionLegacy {
+		return ApiVersionLegacy
+	}
+
+	if version > ApiVersionLatest {
+		return ApiVersionLatest
+	}
+
+	return version
+}
+
+// ListResponse writes listing in shape negotiated with client, legacy is the shape endpoint returned before envelope
+func ListResponse(ctx echo.Context, legacy interface{}, items interface{}, meta *EnvelopeMeta) error {
+	if GetApiVersion(ctx) < ApiVersionEnvelope {
+		return ctx.JSON(http.StatusOK, legacy)
+	}
+
+	ctx.Set(envelopeWrittenContextKey, true)
+	return ctx.JSON(http.StatusOK, &Envelope{Data: items, Meta: meta, Errors: []interface{}{}})
+}
+
+// ErrorResponse writes http error into envelope
+func ErrorResponse(ctx echo.Context, err *echo.HTTPError) error {
+	var item interface{}
+
+	switch msg := err.Message.(type) {
+	case *grpc.ResponseErrorMessage:
+		item = msg
+	case string:
+		item = &EnvelopeError{Message: msg}
+	case error:
+		item = &EnvelopeError{Message: msg.Error()}
+	default:
+		item = msg
+	}
+
+	return ctx.JSON(err.Code, &Envelope{Errors: []interface{}{item}})
+}
+
+// EnvelopeWriter buffers successful json response of handler to wrap it into Envelope, other responses
+// (errors, files, streams, html and redirects) are written through as they are
+type EnvelopeWriter struct {
+	http.ResponseWriter
+	status int
+	body   *bytes.Buffer
+}
+
+// NewEnvelopeWriter
+func NewEnvelopeWriter(w http.ResponseWriter) *EnvelopeWriter {
+	return &EnvelopeWriter{ResponseWriter: w}
+}
+
+// WriteHeader
+func (w *EnvelopeWriter) WriteHeader(code int) {
+	isJson := strings.HasPrefix(w.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+
+	if isJson && code >= http.StatusOK && code < http.StatusMultipleChoices && code != http.StatusNoContent {
+		w.status = code
+		w.body = &bytes.Buffer{}
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write
+func (w *EnvelopeWriter) Write(b []byte) (int, error) {
+	if w.body != nil {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush passes flush of streamed response through, buffered response is written on Close only
+func (w *EnvelopeWriter) Flush() {
+	if w.body != nil {
+		return
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes buffered response wrapped into envelope, response written by ListResponse is an envelope already
+func (w *EnvelopeWriter) Close(ctx echo.Context) error {
+	if w.body == nil {
+		return nil
+	}
+
+	body := bytes.TrimSpace(w.body.Bytes())
+	w.body = nil
+
+	if written, _ := ctx.Get(envelopeWrittenContextKey).(bool); !written {
+		envelope := &Envelope{Errors: []interface{}{}}
+
+		if len(body) > 0 {
+			envelope.Data = json.RawMessage(body)
+		}
+
+		b, err := json.Marshal(envelope)
+
+		if err != nil {
+			return err
+		}
+
+		body = b
+	}
+
+	w.Header().Del(echo.HeaderContentLength)
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(body)
+
+	return err
+}
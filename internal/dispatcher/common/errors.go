@@ -27,6 +27,7 @@ const (
 	ErrorNamespaceMerchantCompanyInfoCountry              = "OnboardingRequest.Company.Country"
 	ErrorNamespaceMerchantCompanyInfoState                = "OnboardingRequest.Company.State"
 	ErrorNamespaceMerchantCompanyInfoZip                  = "OnboardingRequest.Company.Zip"
+	ErrorNamespaceBillingAddressZip                       = "ProcessBillingAddressRequest.Zip"
 	ErrorNamespaceMerchantCompanyInfoCity                 = "OnboardingRequest.Company.City"
 	ErrorNamespaceMerchantCompanyInfoAddress              = "OnboardingRequest.Company.Address"
 	ErrorNamespaceMerchantContactAuthorized               = "OnboardingRequest.Contacts.Authorized"
@@ -153,8 +154,77 @@ var (
 	ErrorMessageMerchantNotFound                  = NewManagementApiResponseError("ma000100", "merchant not found")
 	ErrorMessageCreateReportFile                  = NewManagementApiResponseError("ma000101", "unable to create report file")
 	ErrorMessageDownloadReportFile                = NewManagementApiResponseError("ma000102", "unable to download report file")
-	ErrorMessageLocalizedFieldIncorrectType                = NewManagementApiResponseError("ma000103", "localized field has invalid type")
-	ErrorMessageCoverFieldIncorrectType                = NewManagementApiResponseError("ma000104", "cover field has invalid type")
+	ErrorMessageLocalizedFieldIncorrectType       = NewManagementApiResponseError("ma000103", "localized field has invalid type")
+	ErrorMessageCoverFieldIncorrectType           = NewManagementApiResponseError("ma000104", "cover field has invalid type")
+	ErrorMessageRawCardDataNotAllowed             = NewManagementApiResponseError("ma000105", "raw payment card data is not allowed, use card token instead")
+	ErrorMessageCardTokenRequired                 = NewManagementApiResponseError("ma000106", "payment card token is required")
+	ErrorMessageTooManyRequests                   = NewManagementApiResponseError("ma000107", "too many requests, try again later")
+	ErrorMessageRefundCountLimitExceeded          = NewManagementApiResponseError("ma000108", "refunds count limit for the order exceeded")
+	ErrorMessageAmountPrecisionInvalid            = NewManagementApiResponseError("ma000109", "amount has more decimal places than the currency allows")
+	ErrorMessageOrderCartWithProducts             = NewManagementApiResponseError("ma000110", "cart can't be combined with products list")
+	ErrorMessageOrderCartProductInvalid           = NewManagementApiResponseError("ma000111", "cart product not found or not available for the project")
+	ErrorMessageOrderCartPriceNotFound            = NewManagementApiResponseError("ma000112", "cart product has no price in the order currency")
+	ErrorMessageOrderCartAmountMismatch           = NewManagementApiResponseError("ma000113", "order amount doesn't match the cart total")
+	ErrorMessageVirtualCurrencyNotConfigured      = NewManagementApiResponseError("ma000114", "project has no virtual currency")
+	ErrorMessageVirtualCurrencyPriceNotFound      = NewManagementApiResponseError("ma000115", "virtual currency has no price in the order currency")
+	ErrorMessageVirtualCurrencyWithProducts       = NewManagementApiResponseError("ma000116", "virtual currency order can't contain products")
+	ErrorMessageVirtualCurrencyAmountMismatch     = NewManagementApiResponseError("ma000117", "order amount doesn't match virtual currency price")
+	ErrorMessageOrderPlatformNotAvailable         = NewManagementApiResponseError("ma000118", "platform isn't available for order products")
+	ErrorMessageProjectStatusTransitionInvalid    = NewManagementApiResponseError("ma000119", "project status doesn't allow this transition")
+	ErrorMessageProjectGoLiveChecklistFailed      = NewManagementApiResponseError("ma000120", "project doesn't meet go-live requirements")
+	ErrorMessageKybProviderNotConfigured          = NewManagementApiResponseError("ma000121", "kyb provider isn't configured")
+	ErrorMessageKybSubmitFailed                   = NewManagementApiResponseError("ma000122", "merchant data can't be sent to kyb provider")
+	ErrorMessageSandboxMerchantLive               = NewManagementApiResponseError("ma000124", "sandbox can't be seeded for merchant with projects in production")
+	ErrorMessageReviewMerchantNotPending          = NewManagementApiResponseError("ma000126", "merchant is not awaiting onboarding review")
+	ErrorMessageReviewClaimedByOther              = NewManagementApiResponseError("ma000127", "merchant review is claimed by another reviewer")
+	ErrorMessageReviewNotClaimed                  = NewManagementApiResponseError("ma000128", "merchant review is not claimed by the reviewer")
+	ErrorMessageOrderMetadataInvalid              = NewManagementApiResponseError("ma000129", "order metadata must be an object with string values")
+	ErrorMessageOffboardingBlocked                = NewManagementApiResponseError("ma000130", "account can't be closed while it has negative balance or open chargebacks")
+	ErrorMessageOffboardingAlreadyRequested       = NewManagementApiResponseError("ma000131", "account closure is already requested")
+	ErrorMessageEmbedNotConfigured                = NewManagementApiResponseError("ma000132", "embedded widgets are not configured")
+	ErrorMessageEmbedTokenInvalid                 = NewManagementApiResponseError("ma000133", "embed token is invalid or expired")
+	ErrorMessageEmbedWidgetNotAllowed             = NewManagementApiResponseError("ma000134", "widget is not allowed by embed token")
+	ErrorMessageBulkRefundRowsLimitExceeded       = NewManagementApiResponseError("ma000135", "rows count limit for bulk refund exceeded")
+	ErrorMessageBulkRefundCsvInvalid              = NewManagementApiResponseError("ma000136", "bulk refund csv is invalid, expected columns order_id, amount and optional reason")
+	ErrorMessagePaymentEmailRejected              = NewManagementApiResponseError("ma000137", "payer email is not accepted")
+	ErrorMessageOrderSearchTooBroad               = NewManagementApiResponseError("ma000138", "order search by account or text requires a limited period or project filter")
+	ErrorMessageIntegrationNotFound               = NewManagementApiResponseError("ma000139", "integration not found")
+	ErrorMessageIntegrationCheckNotSupported      = NewManagementApiResponseError("ma000140", "integration has no test call")
+	ErrorMessageIdsFilterInvalid                  = NewManagementApiResponseError("ma000141", "ids filter must be comma separated list of identifiers")
+	ErrorMessageIdsFilterTooLong                  = NewManagementApiResponseError("ma000142", "too many identifiers in ids filter")
+	ErrorMessageExportCursorInvalid               = NewManagementApiResponseError("ma000143", "cursor order of export isn't found")
+	ErrorMessageRequestsStatusInvalid             = NewManagementApiResponseError("ma000144", "status filter must be status code or class of status codes like 4xx")
+	ErrorMessageSubdivisionInvalid                = NewManagementApiResponseError("ma000145", "subdivision and parent codes must start with country code and subdivision must have name in en locale")
+	ErrorMessageCompanyRegistryNotConfigured      = NewManagementApiResponseError("ma000146", "company registry provider isn't configured")
+	ErrorMessageCompanyRegistryLookupFailed       = NewManagementApiResponseError("ma000147", "company can't be looked up in registry")
+	ErrorMessageCompanyRegistryNotFound           = NewManagementApiResponseError("ma000148", "company not found in registry")
+	ErrorMessageCardTokenizationNotConfigured     = NewManagementApiResponseError("ma000149", "card vault isn't configured")
+	ErrorMessageCardTokenizationNotSupported      = NewManagementApiResponseError("ma000150", "payment method doesn't support card tokenization")
+	ErrorMessageCardInvalid                       = NewManagementApiResponseError("ma000151", "payment card data is invalid")
+	ErrorMessageCardRejected                      = NewManagementApiResponseError("ma000152", "payment card is rejected by vault")
+	ErrorMessageCardTokenizationFailed            = NewManagementApiResponseError("ma000153", "payment card can't be tokenized")
+	ErrorMessageProjectScopeFilterRequired        = NewManagementApiResponseError("ma000154", "project filter is required for token restricted to several projects")
+	ErrorMessageRefundInProgress                  = NewManagementApiResponseError("ma000155", "another refund of the order is being created")
+	ErrorMessageRefundPeriodExpired               = NewManagementApiResponseError("ma000156", "refund period of the order is expired")
+	ErrorMessageRefundApprovalRequired            = NewManagementApiResponseError("ma000157", "refund amount exceeds auto-approve threshold of the project and must be approved by support")
+	ErrorMessageOrderAttributionFilterInvalid     = NewManagementApiResponseError("ma000158", "filter of orders by attribution requires exactly one project and can't be combined with other filters")
+	ErrorMessageKybDecisionExpired                = NewManagementApiResponseError("ma000159", "kyb decision is expired or replayed")
+	ErrorMessageSandboxSeedDisabled               = NewManagementApiResponseError("ma000160", "sandbox seeding is disabled on this environment")
+	ErrorMessageSandboxSeedInProgress             = NewManagementApiResponseError("ma000161", "sandbox of the merchant is being seeded")
+	ErrorMessageOffboardingInProgress             = NewManagementApiResponseError("ma000162", "account closure of the merchant is in progress")
+	ErrorMessageEmbedRevenueProjectScoped         = NewManagementApiResponseError("ma000163", "revenue widget shows whole merchant and can't be embedded with token scoped to projects")
+	ErrorMessageBulkRefundIdempotencyKeyRequired  = NewManagementApiResponseError("ma000164", "Idempotency-Key header is required for bulk refund")
+	ErrorMessageBulkRefundNotFound                = NewManagementApiResponseError("ma000165", "bulk refund not found")
+	ErrorMessageBulkRefundRowInterrupted          = NewManagementApiResponseError("ma000166", "refund of the row was interrupted, check refunds of the order before retrying it")
+	ErrorMessagePaymentEmailVerdictNotFound       = NewManagementApiResponseError("ma000167", "payer email of the order wasn't screened")
+	ErrorMessageAnalyticsPeriodTooLong            = NewManagementApiResponseError("ma000168", "period of the report is too long")
+	ErrorMessageRefundPolicyUnavailable           = NewManagementApiResponseError("ma000169", "refund policies are unavailable, refunds are suspended until they're restored")
+	ErrorMessageRefundApprovalNotFound            = NewManagementApiResponseError("ma000170", "refund approval not found")
+	ErrorMessageRefundApprovalResolved            = NewManagementApiResponseError("ma000171", "refund approval is already resolved")
+	ErrorMessageRefundApprovalInProgress          = NewManagementApiResponseError("ma000172", "refund approval is being resolved")
+	ErrorMessageVirtualCurrencyAmountTooLarge     = NewManagementApiResponseError("ma000173", "virtual currency amount of the order is too large")
+	ErrorMessageEmbedProjectsRequired             = NewManagementApiResponseError("ma000174", "projects are required for embed token issued with token restricted to projects")
+	ErrorMessageEmailScreeningPolicyUnavailable   = NewManagementApiResponseError("ma000175", "email screening policies are unavailable, payer emails are screened in block mode until they're restored")
 
 	ValidationErrors = map[string]*grpc.ResponseErrorMessage{
 		UserProfileFieldNumberOfEmployees: ErrorMessageIncorrectNumberOfEmployees,
@@ -176,6 +246,7 @@ var (
 		ErrorNamespaceMerchantCompanyInfoCountry:              ErrorIncorrectCountryIdentifier,
 		ErrorNamespaceMerchantCompanyInfoState:                ErrorMessageIncorrectState,
 		ErrorNamespaceMerchantCompanyInfoZip:                  ErrorMessageIncorrectZip,
+		ErrorNamespaceBillingAddressZip:                       ErrorMessageIncorrectZip,
 		ErrorNamespaceMerchantCompanyInfoCity:                 ErrorMessageIncorrectCity,
 		ErrorNamespaceMerchantCompanyInfoAddress:              ErrorMessageIncorrectAddress,
 		ErrorNamespaceMerchantContactAuthorized:               ErrorMessageRequiredContactAuthorized,
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"github.com/go-redis/redis"
+	"strconv"
+	"time"
+)
+
+const (
+	exportSlotsKeyMask = "export_slots:%s"
+
+	// exportSlotsKeyTTL removes slots of merchant which hasn't exported for a day, expired slots are removed
+	// on each acquire
+	exportSlotsKeyTTL = 24 * time.Hour
+)
+
+// ExportSlots caps the number of exports of a merchant running at once on all instances: report files, orders
+// export and reports built from orders. Slots are kept in redis, a slot is held until it's released or its ttl
+// passes, so slots of instances died while exporting are freed
+type ExportSlots struct {
+	redis redis.Cmdable
+	limit int
+}
+
+// NewExportSlots
+func NewExportSlots(redis redis.Cmdable, limit int) *ExportSlots {
+	return &ExportSlots{redis: redis, limit: limit}
+}
+
+// Acquire takes a slot of the merchant for ttl, false is returned when all slots of the merchant are taken.
+// Exports aren't capped while redis is unavailable or limit is zero, empty slot is returned then
+func (s *ExportSlots) Acquire(merchantId string, ttl time.Duration) (string, bool) {
+	if s.limit <= 0 {
+		return "", true
+	}
+
+	key := fmt.Sprintf(exportSlotsKeyMask, merchantId)
+	slot := bson.NewObjectId().Hex()
+	now := time.Now()
+
+	// slot is taken first and given back when it exceeds the limit, so concurrent requests never take more slots
+	var count *redis.IntCmd
+	_, err := s.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(key, "-inf", strconv.FormatInt(now.UnixNano(), 10))
+		pipe.ZAdd(key, redis.Z{Score: float64(now.Add(ttl).UnixNano()), Member: slot})
+		count = pipe.ZCard(key)
+		pipe.Expire(key, exportSlotsKeyTTL)
+		return nil
+	})
+
+	if err != nil {
+		return "", true
+	}
+
+	if count.Val() > int64(s.limit) {
+		s.redis.ZRem(key, slot)
+		return "", false
+	}
+
+	return slot, true
+}
+
+// Release frees the slot of the merchant
+func (s *ExportSlots) Release(merchantId, slot string) {
+	if slot == "" {
+		return
+	}
+
+	s.redis.ZRem(fmt.Sprintf(exportSlotsKeyMask, merchantId), slot)
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	integrationTestResultKeyMask = "integration_test_result:%s"
+	projectLiveAtKeyMask         = "project_live_at:%s"
+)
+
+// IntegrationTestResult is result of the last integration test of the project made with its test keys,
+// checks are passed state by check name
+type IntegrationTestResult struct {
+	ProjectId string          `json:"project_id"`
+	TestedAt  int64           `json:"tested_at"`
+	Checks    map[string]bool `json:"checks"`
+}
+
+// IntegrationReadiness keeps data of go-live readiness of projects in redis: results of integration tests for ttl
+// and time the project was approved for production, orders made before that time were made with test keys
+type IntegrationReadiness struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewIntegrationReadiness
+func NewIntegrationReadiness(redis redis.Cmdable, ttl time.Duration) *IntegrationReadiness {
+	return &IntegrationReadiness{redis: redis, ttl: ttl}
+}
+
+// GetTestResult returns result of the last integration test of the project, nil is returned when it wasn't tested
+func (r *IntegrationReadiness) GetTestResult(projectId string) (*IntegrationTestResult, error) {
+	data, err := r.redis.Get(fmt.Sprintf(integrationTestResultKeyMask, projectId)).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IntegrationTestResult{}
+
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SaveTestResult
+func (r *IntegrationReadiness) SaveTestResult(result *IntegrationTestResult) error {
+	data, err := json.Marshal(result)
+
+	if err != nil {
+		return err
+	}
+
+	return r.redis.Set(fmt.Sprintf(integrationTestResultKeyMask, result.ProjectId), data, r.ttl).Err()
+}
+
+// GetLiveAt returns time the project was approved for production, zero time is returned when the project wasn't
+// approved through the review
+func (r *IntegrationReadiness) GetLiveAt(projectId string) (time.Time, error) {
+	at, err := r.redis.Get(fmt.Sprintf(projectLiveAtKeyMask, projectId)).Int64()
+
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(at, 0), nil
+}
+
+// SetLiveAt
+func (r *IntegrationReadiness) SetLiveAt(projectId string, at time.Time) error {
+	return r.redis.Set(fmt.Sprintf(projectLiveAtKeyMask, projectId), at.Unix(), 0).Err()
+}
@@ -0,0 +1,313 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"github.com/micro/go-micro/client"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	IntegrationBilling    = "billing"
+	IntegrationReporter   = "reporter"
+	IntegrationTax        = "tax"
+	IntegrationGeo        = "geo"
+	IntegrationRepository = "repository"
+	IntegrationRates      = "rates"
+
+	IntegrationStorageAgreement = "storage_agreement"
+	IntegrationStorageReporter  = "storage_reporter"
+
+	IntegrationServiceS3 = "s3"
+
+	IntegrationStatusOk       = "ok"
+	IntegrationStatusDegraded = "degraded"
+	IntegrationStatusDown     = "down"
+	IntegrationStatusUnknown  = "unknown"
+
+	// IntegrationStatsWindow is period of calls statistics in integration status
+	IntegrationStatsWindow = 15 * time.Minute
+	// IntegrationDegradedErrorRate is share of failed calls in the window from which integration is degraded
+	IntegrationDegradedErrorRate = 0.05
+
+	integrationBucketSize = time.Minute
+)
+
+var (
+	ErrIntegrationNotFound          = errors.New("integration not found")
+	ErrIntegrationCheckNotSupported = errors.New("integration has no test call")
+)
+
+// IntegrationCheck is lightweight call of external dependency used by manual test of integration
+type IntegrationCheck func(ctx context.Context) error
+
+// IntegrationStatus is connectivity of external dependency and its calls statistics for the last window
+type IntegrationStatus struct {
+	Name        string     `json:"name"`
+	Service     string     `json:"service"`
+	Status      string     `json:"status"`
+	Calls       int64      `json:"calls"`
+	Errors      int64      `json:"errors"`
+	ErrorRate   float64    `json:"error_rate"`
+	LastCallAt  *time.Time `json:"last_call_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type integrationBucket struct {
+	start  time.Time
+	calls  int64
+	errors int64
+}
+
+type integration struct {
+	name        string
+	service     string
+	check       IntegrationCheck
+	direct      bool
+	buckets     []*integrationBucket
+	lastCallAt  time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Integrations tracks calls of external services made through the micro client and calls of storages
+// since application start
+type Integrations struct {
+	mx         sync.Mutex
+	items      map[string]*integration
+	byService  map[string]*integration
+	byEndpoint map[string]*integration
+}
+
+// NewIntegrations
+func NewIntegrations() *Integrations {
+	return &Integrations{
+		items:      make(map[string]*integration),
+		byService:  make(map[string]*integration),
+		byEndpoint: make(map[string]*integration),
+	}
+}
+
+// Register adds external dependency with name of its micro service, check may be nil
+// when dependency has no call which is safe to make from the gateway
+func (i *Integrations) Register(name, service string, check IntegrationCheck) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	item := &integration{name: name, service: service, check: check}
+	i.items[name] = item
+	i.byService[service] = item
+}
+
+// RegisterEndpoints adds external dependency reached through endpoints of the micro service, e.g. currency rates
+// provider used by billing server for price conversion. Calls of the endpoints are counted by both the dependency
+// and the service
+func (i *Integrations) RegisterEndpoints(name, service string, endpoints []string, check IntegrationCheck) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	item := &integration{name: name, service: service, check: check}
+	i.items[name] = item
+
+	for _, endpoint := range endpoints {
+		i.byEndpoint[service+" "+endpoint] = item
+	}
+}
+
+// RegisterDirect adds external dependency called without micro client, its calls are registered by RecordDirect
+// and the test call is registered by Check
+func (i *Integrations) RegisterDirect(name, service string, check IntegrationCheck) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	i.items[name] = &integration{name: name, service: service, check: check, direct: true}
+}
+
+// Record registers result of the call of micro service, calls of unknown services are ignored
+func (i *Integrations) Record(service string, err error) {
+	i.RecordEndpoint(service, "", err)
+}
+
+// RecordEndpoint registers result of the call of the micro service endpoint
+func (i *Integrations) RecordEndpoint(service, endpoint string, err error) {
+	now := time.Now()
+
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	if item, ok := i.byService[service]; ok {
+		item.record(now, err)
+	}
+
+	if item, ok := i.byEndpoint[service+" "+endpoint]; ok && endpoint != "" {
+		item.record(now, err)
+	}
+}
+
+// RecordDirect registers result of the call of dependency added by RegisterDirect
+func (i *Integrations) RecordDirect(name string, err error) {
+	now := time.Now()
+
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	if item, ok := i.items[name]; ok && item.direct {
+		item.record(now, err)
+	}
+}
+
+// List returns status of all registered integrations sorted by name
+func (i *Integrations) List() []*IntegrationStatus {
+	now := time.Now()
+
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	list := make([]*IntegrationStatus, 0, len(i.items))
+
+	for _, item := range i.items {
+		list = append(list, item.status(now))
+	}
+
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Name < list[b].Name
+	})
+
+	return list
+}
+
+// Get returns status of the integration
+func (i *Integrations) Get(name string) (*IntegrationStatus, error) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	item, ok := i.items[name]
+
+	if !ok {
+		return nil, ErrIntegrationNotFound
+	}
+
+	return item.status(time.Now()), nil
+}
+
+// Check makes the test call of the integration, the call is recorded by the wrapped client as any other,
+// test calls of direct dependencies are recorded here
+func (i *Integrations) Check(ctx context.Context, name string) error {
+	i.mx.Lock()
+	item, ok := i.items[name]
+	i.mx.Unlock()
+
+	if !ok {
+		return ErrIntegrationNotFound
+	}
+
+	if item.check == nil {
+		return ErrIntegrationCheckNotSupported
+	}
+
+	err := item.check(ctx)
+
+	if item.direct && !isCallCanceled(ctx) {
+		i.RecordDirect(name, err)
+	}
+
+	return err
+}
+
+// Wrap returns micro client recording results of all unary calls made through it
+func (i *Integrations) Wrap(c client.Client) client.Client {
+	return &integrationsClient{Client: c, integrations: i}
+}
+
+func (item *integration) cleanup(now time.Time) {
+	from := now.Add(-IntegrationStatsWindow)
+	n := 0
+
+	for n < len(item.buckets) && !item.buckets[n].start.After(from) {
+		n++
+	}
+
+	item.buckets = item.buckets[n:]
+}
+
+func (item *integration) record(now time.Time, err error) {
+	item.cleanup(now)
+	start := now.Truncate(integrationBucketSize)
+
+	if len(item.buckets) == 0 || !item.buckets[len(item.buckets)-1].start.Equal(start) {
+		item.buckets = append(item.buckets, &integrationBucket{start: start})
+	}
+
+	bucket := item.buckets[len(item.buckets)-1]
+	bucket.calls++
+	item.lastCallAt = now
+
+	if err != nil {
+		bucket.errors++
+		item.lastError = err.Error()
+		item.lastErrorAt = now
+	}
+}
+
+func (item *integration) status(now time.Time) *IntegrationStatus {
+	item.cleanup(now)
+
+	status := &IntegrationStatus{Name: item.name, Service: item.service, Status: IntegrationStatusUnknown}
+
+	for _, bucket := range item.buckets {
+		status.Calls += bucket.calls
+		status.Errors += bucket.errors
+	}
+
+	if !item.lastCallAt.IsZero() {
+		lastCallAt := item.lastCallAt
+		status.LastCallAt = &lastCallAt
+	}
+
+	if !item.lastErrorAt.IsZero() {
+		lastErrorAt := item.lastErrorAt
+		status.LastError = item.lastError
+		status.LastErrorAt = &lastErrorAt
+	}
+
+	if status.Calls == 0 {
+		return status
+	}
+
+	status.ErrorRate = float64(status.Errors) / float64(status.Calls)
+
+	switch {
+	case status.Errors == status.Calls:
+		status.Status = IntegrationStatusDown
+	case status.ErrorRate >= IntegrationDegradedErrorRate:
+		status.Status = IntegrationStatusDegraded
+	default:
+		status.Status = IntegrationStatusOk
+	}
+
+	return status
+}
+
+type integrationsClient struct {
+	client.Client
+	integrations *Integrations
+}
+
+func (c *integrationsClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	err := c.Client.Call(ctx, req, rsp, opts...)
+
+	if !isCallCanceled(ctx) {
+		c.integrations.RecordEndpoint(req.Service(), req.Endpoint(), err)
+	}
+
+	return err
+}
+
+// isCallCanceled reports the call was interrupted by its caller, e.g. http client closed connection, such calls
+// tell nothing about the dependency. Exceeded deadline is counted as failure of the dependency
+func isCallCanceled(ctx context.Context) bool {
+	return ctx.Err() == context.Canceled
+}
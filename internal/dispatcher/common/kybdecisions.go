@@ -0,0 +1,75 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	kybDecisionsKeyMask     = "kyb_decisions:%s"
+	kybDecisionNonceKeyMask = "kyb_decision_nonce:%s"
+)
+
+// KybDecision is entry of decision log of the KYB compliance provider for the merchant
+type KybDecision struct {
+	Decision  string `json:"decision"`
+	Message   string `json:"message"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// KybDecisions keeps decision log of merchants in redis, the log is written by signed provider callbacks only,
+// so it can't be forged by merchant notifications
+type KybDecisions struct {
+	redis redis.Cmdable
+}
+
+// NewKybDecisions
+func NewKybDecisions(redis redis.Cmdable) *KybDecisions {
+	return &KybDecisions{redis: redis}
+}
+
+// AcceptNonce remembers nonce of the callback for ttl, false is returned when the nonce was accepted already
+func (d *KybDecisions) AcceptNonce(nonce string, ttl time.Duration) (bool, error) {
+	return d.redis.SetNX(fmt.Sprintf(kybDecisionNonceKeyMask, nonce), 1, ttl).Result()
+}
+
+// ReleaseNonce forgets nonce of the callback which wasn't applied, so the provider can retry it
+func (d *KybDecisions) ReleaseNonce(nonce string) {
+	d.redis.Del(fmt.Sprintf(kybDecisionNonceKeyMask, nonce))
+}
+
+// Add appends decision to the log of the merchant
+func (d *KybDecisions) Add(merchantId string, decision *KybDecision) error {
+	data, err := json.Marshal(decision)
+
+	if err != nil {
+		return err
+	}
+
+	return d.redis.LPush(fmt.Sprintf(kybDecisionsKeyMask, merchantId), data).Err()
+}
+
+// List returns the decision log of the merchant, newest first
+func (d *KybDecisions) List(merchantId string) ([]*KybDecision, error) {
+	items, err := d.redis.LRange(fmt.Sprintf(kybDecisionsKeyMask, merchantId), 0, -1).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*KybDecision, 0, len(items))
+
+	for _, item := range items {
+		decision := &KybDecision{}
+
+		if err = json.Unmarshal([]byte(item), decision); err != nil {
+			return nil, err
+		}
+
+		res = append(res, decision)
+	}
+
+	return res, nil
+}
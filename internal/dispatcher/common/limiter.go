@@ -0,0 +1,143 @@
+package common
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"github.com/labstack/echo/v4"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	rateLimiterKeyMask = "rate_limit:%s:%s:%d"
+)
+
+// RateLimits creates rate limiters of routes, counters are kept in redis and shared by all instances
+type RateLimits struct {
+	redis          redis.Cmdable
+	trustedProxies []*net.IPNet
+}
+
+// NewRateLimits
+func NewRateLimits(redis redis.Cmdable, trustedProxies []*net.IPNet) *RateLimits {
+	return &RateLimits{redis: redis, trustedProxies: trustedProxies}
+}
+
+// Limiter returns limiter allowing limit requests of key in window, name separates counters of limiters
+func (r *RateLimits) Limiter(name string, limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limits: r,
+		name:   name,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// ClientIp returns address of the client connected to the instance, forwarded headers are trusted only when the
+// connection comes from trusted proxy, so the address can't be spoofed by the client
+func (r *RateLimits) ClientIp(ctx echo.Context) string {
+	remote, _, err := net.SplitHostPort(ctx.Request().RemoteAddr)
+
+	if err != nil {
+		remote = ctx.Request().RemoteAddr
+	}
+
+	if !r.isTrustedProxy(remote) {
+		return remote
+	}
+
+	forwarded := strings.Split(ctx.Request().Header.Get(echo.HeaderXForwardedFor), ",")
+
+	// the right-most address not added by trusted proxy is the client, the left ones are set by the client itself
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+
+		if ip == "" {
+			continue
+		}
+
+		if !r.isTrustedProxy(ip) {
+			return ip
+		}
+
+		remote = ip
+	}
+
+	return remote
+}
+
+func (r *RateLimits) isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range r.trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RateLimiter counts requests per key in a fixed time window
+type RateLimiter struct {
+	limits *RateLimits
+	name   string
+	limit  int
+	window time.Duration
+}
+
+// Allow counts the request of key, requests are allowed while redis is unavailable, so limits never break the route
+func (l *RateLimiter) Allow(key string) bool {
+	if l.limit <= 0 || l.window <= 0 {
+		return true
+	}
+
+	// counter of each window has own key, so the counter and its expiration are set in one transaction
+	bucket := time.Now().UnixNano() / int64(l.window)
+	redisKey := fmt.Sprintf(rateLimiterKeyMask, l.name, key, bucket)
+
+	var hits *redis.IntCmd
+	_, err := l.limits.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		hits = pipe.Incr(redisKey)
+		pipe.Expire(redisKey, l.window)
+		return nil
+	})
+
+	if err != nil {
+		return true
+	}
+
+	return hits.Val() <= int64(l.limit)
+}
+
+// ParseTrustedProxies parses list of proxy addresses or networks in CIDR notation
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
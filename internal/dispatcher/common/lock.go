@@ -0,0 +1,57 @@
+package common
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	lockRefreshScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+	lockReleaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+)
+
+// Lock is lock of redis key held by one owner, the key holds random token of the owner, so the lock expired
+// and taken by another owner is neither refreshed nor released by the previous one
+type Lock struct {
+	redis redis.Cmdable
+	key   string
+	token string
+	ttl   time.Duration
+}
+
+// AcquireLock locks the key for ttl, nil is returned when the key is locked by another owner
+func AcquireLock(redis redis.Cmdable, key string, ttl time.Duration) (*Lock, error) {
+	l := &Lock{redis: redis, key: key, token: uuid.New().String(), ttl: ttl}
+	ok, err := redis.SetNX(key, l.token, ttl).Result()
+
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Refresh extends the lock for ttl, false is returned when the lock is expired and the key isn't held anymore
+func (l *Lock) Refresh() (bool, error) {
+	n, err := lockRefreshScript.Run(l.redis, []string{l.key}, l.token, l.ttl.Nanoseconds()/int64(time.Millisecond)).Int64()
+
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// Release removes the lock when it's still held by the owner
+func (l *Lock) Release() error {
+	return lockReleaseScript.Run(l.redis, []string{l.key}, l.token).Err()
+}
@@ -1,5 +1,16 @@
 package common
 
+import (
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// BodyDumpRedacted replaces bodies of requests and responses marked by RedactBodyDump in body dump logs
+	BodyDumpRedacted = "[redacted]"
+
+	bodyDumpRedactedContextKey = "bodyDumpRedacted"
+)
+
 // RequestResponseHeadersToString
 func RequestResponseHeadersToString(headers map[string][]string) string {
 	var out string
@@ -8,3 +19,29 @@ func RequestResponseHeadersToString(headers map[string][]string) string {
 	}
 	return out
 }
+
+// RedactBodyDump is route middleware which keeps bodies of the route out of body dump logs,
+// it's used by routes receiving or returning card data and other secrets
+func RedactBodyDump(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		ctx.Set(bodyDumpRedactedContextKey, true)
+		return next(ctx)
+	}
+}
+
+// BodyDumpFields returns fields of body dump log record of the request, bodies of routes marked by RedactBodyDump
+// are replaced with BodyDumpRedacted
+func BodyDumpFields(ctx echo.Context, reqBody, resBody []byte) map[string]interface{} {
+	reqDump, resDump := string(reqBody), string(resBody)
+
+	if redacted, _ := ctx.Get(bodyDumpRedactedContextKey).(bool); redacted {
+		reqDump, resDump = BodyDumpRedacted, BodyDumpRedacted
+	}
+
+	return map[string]interface{}{
+		"request_headers":  RequestResponseHeadersToString(ctx.Request().Header),
+		"request_body":     reqDump,
+		"response_headers": RequestResponseHeadersToString(ctx.Response().Header()),
+		"response_body":    resDump,
+	}
+}
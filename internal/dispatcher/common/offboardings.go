@@ -0,0 +1,83 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	// OffboardingLockTTL releases account closure of the merchant when instance processing it has died
+	OffboardingLockTTL = 5 * time.Minute
+
+	offboardingKeyMask     = "offboarding:%s"
+	offboardingLockKeyMask = "offboarding_lock:%s"
+)
+
+// Offboarding is progress of account closure of the merchant, every step is saved as soon as billing server
+// has done it, so repeated request resumes the closure without repeating done steps
+type Offboarding struct {
+	RequestedAt int64  `json:"requested_at"`
+	RequestedBy string `json:"requested_by"`
+	// DisabledProjects are statuses of disabled projects before the closure by project identifier
+	DisabledProjects map[string]int32 `json:"disabled_projects"`
+	PayoutId         string           `json:"payout_id"`
+	Completed        bool             `json:"completed"`
+}
+
+// Offboardings keeps account closures of merchants in redis
+type Offboardings struct {
+	redis redis.Cmdable
+}
+
+// NewOffboardings
+func NewOffboardings(redis redis.Cmdable) *Offboardings {
+	return &Offboardings{redis: redis}
+}
+
+// Lock serializes processing of account closure of the merchant, false is returned when it's locked already
+func (o *Offboardings) Lock(merchantId string) (bool, error) {
+	return o.redis.SetNX(fmt.Sprintf(offboardingLockKeyMask, merchantId), 1, OffboardingLockTTL).Result()
+}
+
+// Unlock
+func (o *Offboardings) Unlock(merchantId string) {
+	o.redis.Del(fmt.Sprintf(offboardingLockKeyMask, merchantId))
+}
+
+// Get returns account closure of the merchant, nil is returned when closure wasn't requested
+func (o *Offboardings) Get(merchantId string) (*Offboarding, error) {
+	data, err := o.redis.Get(fmt.Sprintf(offboardingKeyMask, merchantId)).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	offboarding := &Offboarding{}
+
+	if err = json.Unmarshal(data, offboarding); err != nil {
+		return nil, err
+	}
+
+	if offboarding.DisabledProjects == nil {
+		offboarding.DisabledProjects = make(map[string]int32)
+	}
+
+	return offboarding, nil
+}
+
+// Save
+func (o *Offboardings) Save(merchantId string, offboarding *Offboarding) error {
+	data, err := json.Marshal(offboarding)
+
+	if err != nil {
+		return err
+	}
+
+	return o.redis.Set(fmt.Sprintf(offboardingKeyMask, merchantId), data, 0).Err()
+}
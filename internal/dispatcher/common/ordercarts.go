@@ -0,0 +1,90 @@
+package common
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"strconv"
+	"time"
+)
+
+const (
+	orderCartKeyMask = "order_cart:%s"
+)
+
+// OrderCarts keeps quantities of products of orders created with cart in redis. Billing server lists each product
+// of the order once, so quantities are known only from the cart the order was created with
+type OrderCarts struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewOrderCarts
+func NewOrderCarts(redis redis.Cmdable, ttl time.Duration) *OrderCarts {
+	return &OrderCarts{redis: redis, ttl: ttl}
+}
+
+// Record saves quantities of products of the order by product identifier
+func (c *OrderCarts) Record(orderId string, quantities map[string]int32) error {
+	if len(quantities) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(quantities))
+
+	for productId, quantity := range quantities {
+		fields[productId] = quantity
+	}
+
+	_, err := c.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		key := fmt.Sprintf(orderCartKeyMask, orderId)
+		pipe.HMSet(key, fields)
+		pipe.Expire(key, c.ttl)
+		return nil
+	})
+
+	return err
+}
+
+// Get returns quantities of products by order identifier, orders created without cart are omitted
+func (c *OrderCarts) Get(orderIds []string) (map[string]map[string]int32, error) {
+	res := make(map[string]map[string]int32)
+
+	if len(orderIds) == 0 {
+		return res, nil
+	}
+
+	cmds := make([]*redis.StringStringMapCmd, len(orderIds))
+	_, err := c.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, id := range orderIds {
+			cmds[i] = pipe.HGetAll(fmt.Sprintf(orderCartKeyMask, id))
+		}
+
+		return nil
+	})
+
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			continue
+		}
+
+		quantities := make(map[string]int32, len(cmd.Val()))
+
+		for productId, val := range cmd.Val() {
+			quantity, err := strconv.ParseInt(val, 10, 32)
+
+			if err != nil {
+				return nil, err
+			}
+
+			quantities[productId] = int32(quantity)
+		}
+
+		res[orderIds[i]] = quantities
+	}
+
+	return res, nil
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"errors"
+	"github.com/ttacon/libphonenumber"
+	"strings"
+)
+
+const (
+	// phoneLegacyRegion is region of phone validation used before phones were normalized, the validation only
+	// checked that the number could be parsed
+	phoneLegacyRegion = "US"
+)
+
+var ErrPhoneInvalid = errors.New("phone number is invalid")
+
+// NormalizePhone returns phone number in E.164 format, so the same number is stored and searched in one form
+// regardless of spaces, dashes and national prefixes in the input. Number without international prefix is read
+// as national number of the region (ISO 3166-1 alpha-2 country of the payer or the address), region may be empty.
+// Number which isn't valid for the region is returned as is when it was accepted by phone validation before,
+// only input which isn't a phone number at all is rejected
+func NormalizePhone(phone, region string) (string, error) {
+	num, err := libphonenumber.Parse(phone, strings.ToUpper(region))
+
+	if err == nil && libphonenumber.IsValidNumber(num) {
+		return libphonenumber.Format(num, libphonenumber.E164), nil
+	}
+
+	if _, err = libphonenumber.Parse(phone, phoneLegacyRegion); err != nil {
+		return "", ErrPhoneInvalid
+	}
+
+	return phone, nil
+}
+
+// normalizePhoneField normalizes phone in place, empty phone is left as is.
+// Invalid phone is reported with the field name in details of the error
+func normalizePhoneField(phone *string, region, field string) error {
+	if *phone == "" {
+		return nil
+	}
+
+	normalized, err := NormalizePhone(*phone, region)
+
+	if err != nil {
+		rspErr := *ErrorMessageIncorrectPhone
+		rspErr.Details = field
+		return &rspErr
+	}
+
+	*phone = normalized
+
+	return nil
+}
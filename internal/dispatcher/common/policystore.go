@@ -0,0 +1,65 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis"
+)
+
+const (
+	policyStoreFieldInitialized = "_initialized"
+)
+
+// ErrPolicyStoreLost is returned by policy stores whose redis hash is missing, e.g. after it's evicted or redis
+// is restarted without persistence. Policies of all projects are unknown then and features enforcing them fail
+// closed until the store is initialized again by operator through system api
+var ErrPolicyStoreLost = errors.New("policies of projects are lost")
+
+// policyStore keeps policies of projects in single redis hash with field per project, so the policies can be lost
+// all together only. The hash has marker field set by initialization of the store, missing marker means the hash
+// is lost and tells it apart from project which never set the policy
+type policyStore struct {
+	redis redis.Cmdable
+	key   string
+}
+
+// init marks the store initialized, policies set before are kept
+func (s *policyStore) init() error {
+	return s.redis.HSet(s.key, policyStoreFieldInitialized, 1).Err()
+}
+
+// get reads policy of the project into value, false is returned when the project has no policy
+func (s *policyStore) get(projectId string, value interface{}) (bool, error) {
+	fields, err := s.redis.HMGet(s.key, policyStoreFieldInitialized, projectId).Result()
+
+	if err != nil {
+		return false, err
+	}
+
+	if fields[0] == nil {
+		return false, ErrPolicyStoreLost
+	}
+
+	data, ok := fields[1].(string)
+
+	if !ok {
+		return false, nil
+	}
+
+	if err = json.Unmarshal([]byte(data), value); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// set saves policy of the project, policy saved into lost store stays unknown until the store is initialized again
+func (s *policyStore) set(projectId string, value interface{}) error {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	return s.redis.HSet(s.key, projectId, data).Err()
+}
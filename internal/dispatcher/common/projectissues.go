@@ -0,0 +1,284 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"github.com/go-redis/redis"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ProjectIssuesWindow is period after configuration change in which failures of order creation are attributed to it
+	ProjectIssuesWindow = 24 * time.Hour
+	// ProjectIssuesMinFailures is number of failed order creations after the change required to raise an issue
+	ProjectIssuesMinFailures = 10
+	// ProjectIssuesFailureRate is share of failed order creations after the change required to raise an issue,
+	// the share must also be at least twice as much as before the change
+	ProjectIssuesFailureRate = 0.5
+	// ProjectIssuesMaxEntries is number of the latest issues kept for the project
+	ProjectIssuesMaxEntries = 20
+
+	projectIssuesStateKeyMask = "project_issues_state:%s"
+	projectIssuesListKeyMask  = "project_issues:%s"
+
+	projectIssuesFieldChange   = "change"
+	projectIssuesFieldIssue    = "issue"
+	projectIssuesFieldAttempts = "attempts"
+	projectIssuesFieldFailures = "failures"
+	projectIssuesFieldError    = "error:"
+)
+
+// ProjectIssue is spike of failed order creations after change of project configuration
+type ProjectIssue struct {
+	ProjectId    string         `json:"project_id"`
+	MerchantId   string         `json:"merchant_id"`
+	UserId       string         `json:"user_id"`
+	Settings     []string       `json:"settings"`
+	ChangedAt    time.Time      `json:"changed_at"`
+	DetectedAt   time.Time      `json:"detected_at"`
+	Attempts     int            `json:"attempts"`
+	Failures     int            `json:"failures"`
+	Errors       map[string]int `json:"errors"`
+	BaselineRate float64        `json:"baseline_failure_rate"`
+}
+
+type projectIssuesChange struct {
+	MerchantId       string    `json:"merchant_id"`
+	UserId           string    `json:"user_id"`
+	Settings         []string  `json:"settings"`
+	ChangedAt        time.Time `json:"changed_at"`
+	BaselineAttempts int       `json:"baseline_attempts"`
+	BaselineFailures int       `json:"baseline_failures"`
+}
+
+// ProjectIssues detects projects whose configuration change breaks payments by comparing share of order creations
+// rejected by billing server before and after the change. Counters of the project are kept in redis hash
+// which expires ProjectIssuesWindow after the last change or order creation, the hash is created by the change
+// or by successful order creation only, so failures of unknown projects sent by clients aren't kept
+type ProjectIssues struct {
+	redis redis.Cmdable
+}
+
+// NewProjectIssues
+func NewProjectIssues(redis redis.Cmdable) *ProjectIssues {
+	return &ProjectIssues{redis: redis}
+}
+
+// RecordChange starts new period of order creations attributed to the changed settings of the project,
+// counters of the previous period become the baseline
+func (p *ProjectIssues) RecordChange(projectId, merchantId, userId string, settings []string) error {
+	if !bson.IsObjectIdHex(projectId) || len(settings) == 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf(projectIssuesStateKeyMask, projectId)
+	counters, err := p.redis.HMGet(key, projectIssuesFieldAttempts, projectIssuesFieldFailures).Result()
+
+	if err != nil {
+		return err
+	}
+
+	change := &projectIssuesChange{
+		MerchantId:       merchantId,
+		UserId:           userId,
+		Settings:         append([]string{}, settings...),
+		ChangedAt:        time.Now(),
+		BaselineAttempts: getProjectIssuesCounter(counters[0]),
+		BaselineFailures: getProjectIssuesCounter(counters[1]),
+	}
+	sort.Strings(change.Settings)
+	data, err := json.Marshal(change)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = p.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(key)
+		pipe.HSet(key, projectIssuesFieldChange, data)
+		pipe.Expire(key, ProjectIssuesWindow)
+		return nil
+	})
+
+	return err
+}
+
+// RecordOrderCreate counts result of order creation, empty error code is success.
+// The issue is returned once, when it is detected by any instance, so the caller can notify the merchant
+func (p *ProjectIssues) RecordOrderCreate(projectId, errorCode string) (*ProjectIssue, error) {
+	if !bson.IsObjectIdHex(projectId) {
+		return nil, nil
+	}
+
+	key := fmt.Sprintf(projectIssuesStateKeyMask, projectId)
+
+	if errorCode != "" {
+		n, err := p.redis.Exists(key).Result()
+
+		if err != nil || n == 0 {
+			return nil, err
+		}
+	}
+
+	var state *redis.StringStringMapCmd
+
+	_, err := p.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(key, projectIssuesFieldAttempts, 1)
+
+		if errorCode != "" {
+			pipe.HIncrBy(key, projectIssuesFieldFailures, 1)
+			pipe.HIncrBy(key, projectIssuesFieldError+errorCode, 1)
+		}
+
+		pipe.Expire(key, ProjectIssuesWindow)
+		state = pipe.HGetAll(key)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields := state.Val()
+	data, ok := fields[projectIssuesFieldChange]
+
+	if !ok || fields[projectIssuesFieldIssue] != "" {
+		return nil, nil
+	}
+
+	change := &projectIssuesChange{}
+
+	if err = json.Unmarshal([]byte(data), change); err != nil {
+		return nil, err
+	}
+
+	if time.Since(change.ChangedAt) > ProjectIssuesWindow {
+		return nil, nil
+	}
+
+	attempts, failures, errors := getProjectIssuesCounters(fields)
+	rate := float64(failures) / float64(attempts)
+	baselineRate := 0.0
+
+	if change.BaselineAttempts > 0 {
+		baselineRate = float64(change.BaselineFailures) / float64(change.BaselineAttempts)
+	}
+
+	if failures < ProjectIssuesMinFailures || rate < ProjectIssuesFailureRate || rate < 2*baselineRate {
+		return nil, nil
+	}
+
+	issue := &ProjectIssue{
+		ProjectId:    projectId,
+		MerchantId:   change.MerchantId,
+		UserId:       change.UserId,
+		Settings:     change.Settings,
+		ChangedAt:    change.ChangedAt,
+		DetectedAt:   time.Now(),
+		Attempts:     attempts,
+		Failures:     failures,
+		Errors:       errors,
+		BaselineRate: baselineRate,
+	}
+
+	issueData, err := json.Marshal(issue)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// only the instance which stores the issue first returns it, so the merchant is notified once
+	if ok, err = p.redis.HSetNX(key, projectIssuesFieldIssue, issueData).Result(); err != nil || !ok {
+		return nil, err
+	}
+
+	listKey := fmt.Sprintf(projectIssuesListKeyMask, projectId)
+	_, err = p.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.LPush(listKey, issueData)
+		pipe.LTrim(listKey, 0, ProjectIssuesMaxEntries-1)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// List returns issues of the project, the latest first, counters of the issue of the current change
+// include order creations recorded after the issue is detected
+func (p *ProjectIssues) List(projectId string) ([]*ProjectIssue, error) {
+	list := []*ProjectIssue{}
+	items, err := p.redis.LRange(fmt.Sprintf(projectIssuesListKeyMask, projectId), 0, -1).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		issue := &ProjectIssue{}
+
+		if err = json.Unmarshal([]byte(item), issue); err != nil {
+			return nil, err
+		}
+
+		list = append(list, issue)
+	}
+
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	fields, err := p.redis.HGetAll(fmt.Sprintf(projectIssuesStateKeyMask, projectId)).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fields[projectIssuesFieldIssue] == "" {
+		return list, nil
+	}
+
+	current := &ProjectIssue{}
+
+	if err = json.Unmarshal([]byte(fields[projectIssuesFieldIssue]), current); err != nil {
+		return nil, err
+	}
+
+	if current.ChangedAt.Equal(list[0].ChangedAt) {
+		list[0].Attempts, list[0].Failures, list[0].Errors = getProjectIssuesCounters(fields)
+	}
+
+	return list, nil
+}
+
+func getProjectIssuesCounters(fields map[string]string) (int, int, map[string]int) {
+	errors := make(map[string]int)
+
+	for field, value := range fields {
+		if strings.HasPrefix(field, projectIssuesFieldError) {
+			errors[strings.TrimPrefix(field, projectIssuesFieldError)] = getProjectIssuesCounter(value)
+		}
+	}
+
+	return getProjectIssuesCounter(fields[projectIssuesFieldAttempts]),
+		getProjectIssuesCounter(fields[projectIssuesFieldFailures]),
+		errors
+}
+
+func getProjectIssuesCounter(value interface{}) int {
+	s, ok := value.(string)
+
+	if !ok {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(s)
+
+	return n
+}
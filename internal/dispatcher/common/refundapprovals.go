@@ -0,0 +1,153 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	RefundApprovalStatusPending  = "pending"
+	RefundApprovalStatusApproved = "approved"
+	RefundApprovalStatusRejected = "rejected"
+
+	// RefundApprovalLockTTL releases lock of approval being resolved when instance resolving it has died
+	RefundApprovalLockTTL = time.Minute
+
+	refundApprovalKeyMask     = "refund_approval:%s"
+	refundApprovalLockKeyMask = "refund_approval_lock:%s"
+	refundApprovalsPendingKey = "refund_approvals_pending"
+)
+
+// RefundApproval is refund exceeding auto-approve threshold of the project, the refund is created when support
+// approves it
+type RefundApproval struct {
+	Id         string     `json:"id"`
+	OrderId    string     `json:"order_id"`
+	ProjectId  string     `json:"project_id"`
+	Amount     float64    `json:"amount"`
+	Reason     string     `json:"reason"`
+	CreatorId  string     `json:"creator_id"`
+	Status     string     `json:"status"`
+	RefundId   string     `json:"refund_id,omitempty"`
+	ResolverId string     `json:"resolver_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// RefundApprovals keeps refund approvals in redis for ttl, pending approvals are listed in order of creation
+type RefundApprovals struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewRefundApprovals
+func NewRefundApprovals(redis redis.Cmdable, ttl time.Duration) *RefundApprovals {
+	return &RefundApprovals{redis: redis, ttl: ttl}
+}
+
+// Create saves new pending approval
+func (a *RefundApprovals) Create(approval *RefundApproval) error {
+	approval.Id = bson.NewObjectId().Hex()
+	approval.Status = RefundApprovalStatusPending
+	approval.CreatedAt = time.Now().UTC()
+	data, err := json.Marshal(approval)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = a.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(fmt.Sprintf(refundApprovalKeyMask, approval.Id), data, a.ttl)
+		pipe.ZAdd(refundApprovalsPendingKey, redis.Z{Score: float64(approval.CreatedAt.UnixNano()), Member: approval.Id})
+		return nil
+	})
+
+	return err
+}
+
+// Get returns the approval, nil is returned when it doesn't exist or is expired
+func (a *RefundApprovals) Get(id string) (*RefundApproval, error) {
+	data, err := a.redis.Get(fmt.Sprintf(refundApprovalKeyMask, id)).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	approval := &RefundApproval{}
+
+	if err = json.Unmarshal(data, approval); err != nil {
+		return nil, err
+	}
+
+	return approval, nil
+}
+
+// Save saves the approval, resolved approval is removed from pending list
+func (a *RefundApprovals) Save(approval *RefundApproval) error {
+	data, err := json.Marshal(approval)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = a.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(fmt.Sprintf(refundApprovalKeyMask, approval.Id), data, a.ttl)
+
+		if approval.Status == RefundApprovalStatusPending {
+			pipe.ZAdd(refundApprovalsPendingKey, redis.Z{Score: float64(approval.CreatedAt.UnixNano()), Member: approval.Id})
+		} else {
+			pipe.ZRem(refundApprovalsPendingKey, approval.Id)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// ListPending returns page of pending approvals, the oldest first, and count of them.
+// Expired approvals are dropped from the list
+func (a *RefundApprovals) ListPending(limit, offset int64) ([]*RefundApproval, int64, error) {
+	ids, err := a.redis.ZRange(refundApprovalsPendingKey, offset, offset+limit-1).Result()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]*RefundApproval, 0, len(ids))
+
+	for _, id := range ids {
+		approval, err := a.Get(id)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if approval == nil {
+			a.redis.ZRem(refundApprovalsPendingKey, id)
+			continue
+		}
+
+		list = append(list, approval)
+	}
+
+	count, err := a.redis.ZCard(refundApprovalsPendingKey).Result()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, count, nil
+}
+
+// Lock serializes resolution of the approval across instances, nil is returned when it's locked already
+func (a *RefundApprovals) Lock(id string) (*Lock, error) {
+	return AcquireLock(a.redis, fmt.Sprintf(refundApprovalLockKeyMask, id), RefundApprovalLockTTL)
+}
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	// RefundOrderLockTTL releases lock of the order refund when instance creating the refund has died
+	RefundOrderLockTTL = 30 * time.Second
+
+	refundPoliciesKey      = "refund_policies"
+	refundOrderLockKeyMask = "refund_lock:%s"
+)
+
+// RefundPolicy is refund rules of the project enforced on refund create, zero value disables the rule
+type RefundPolicy struct {
+	// MaxDaysAfterPurchase is the number of days after payment the order may be refunded
+	MaxDaysAfterPurchase int32 `json:"max_days_after_purchase" validate:"min=0,max=3650"`
+	// MaxRefundsPerOrder limits the number of refunds (full or partial) of the order, the global limit
+	// REFUND_MAX_COUNT isn't exceeded by the project
+	MaxRefundsPerOrder int32 `json:"max_refunds_per_order" validate:"min=0,max=1000"`
+	// AutoApproveThreshold is the largest refund amount created without approval of support
+	AutoApproveThreshold float64 `json:"auto_approve_threshold" validate:"min=0"`
+}
+
+// RefundPolicies keeps refund policies of projects in redis, refunds of all projects fail closed when the policies
+// are lost, see ErrPolicyStoreLost
+type RefundPolicies struct {
+	redis redis.Cmdable
+	store *policyStore
+}
+
+// NewRefundPolicies
+func NewRefundPolicies(redis redis.Cmdable) *RefundPolicies {
+	return &RefundPolicies{redis: redis, store: &policyStore{redis: redis, key: refundPoliciesKey}}
+}
+
+// Init marks the policies initialized on the first deploy or restored after they're lost
+func (p *RefundPolicies) Init() error {
+	return p.store.init()
+}
+
+// Get returns refund policy of the project, empty policy when it isn't set
+func (p *RefundPolicies) Get(projectId string) (*RefundPolicy, error) {
+	policy := &RefundPolicy{}
+
+	if _, err := p.store.get(projectId, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Set
+func (p *RefundPolicies) Set(projectId string, policy *RefundPolicy) error {
+	return p.store.set(projectId, policy)
+}
+
+// LockOrder serializes refund creation of the order across instances, so policy checks and refund create
+// aren't interleaved with another refund of the order. Nil is returned when the order is locked already
+func (p *RefundPolicies) LockOrder(orderId string) (*Lock, error) {
+	return AcquireLock(p.redis, fmt.Sprintf(refundOrderLockKeyMask, orderId), RefundOrderLockTTL)
+}
@@ -0,0 +1,350 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/globalsign/mgo/bson"
+	"github.com/go-redis/redis"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// RequestLogRetention is period for which requests of the merchant are kept
+	RequestLogRetention = 24 * time.Hour
+	// RequestLogMaxEntries is number of the latest requests kept for the merchant
+	RequestLogMaxEntries = 1000
+
+	RequestLogRedacted = "[redacted]"
+
+	requestLogKeyMask                = "request_log:%s"
+	requestLogUserMerchantKeyMask    = "request_log_user_merchant:%s"
+	requestLogProjectMerchantKeyMask = "request_log_project_merchant:%s"
+	requestLogOrderProjectKeyMask    = "request_log_order_project:%s"
+	requestLogProjectContextKey      = "requestLogProject"
+	requestLogOrderContextKey        = "requestLogOrder"
+
+	// requestLogResolvedTTL is lifetime of merchant of user or project and of project of order resolved on request,
+	// so each of them is resolved once a day
+	requestLogResolvedTTL = 24 * time.Hour
+)
+
+// requestLogQueryAllowed are query parameters which never carry personal data of customers,
+// values of all other parameters are redacted in the log
+var requestLogQueryAllowed = map[string]bool{
+	QueryParameterNameLimit:  true,
+	QueryParameterNameOffset: true,
+	QueryParameterNameSort:   true,
+	"sort[]":                 true,
+	"project":                true,
+	"project[]":              true,
+	"merchant_id":            true,
+	"status":                 true,
+	"status[]":               true,
+	"type":                   true,
+	"format":                 true,
+	"ids":                    true,
+	"currency":               true,
+	"date_from":              true,
+	"date_to":                true,
+	"pm_date_from":           true,
+	"pm_date_to":             true,
+	"project_date_from":      true,
+	"project_date_to":        true,
+}
+
+// RequestLogEntry is single request of the merchant, it contains neither bodies nor headers of the request,
+// values of query parameters except listed in requestLogQueryAllowed and error details are redacted
+type RequestLogEntry struct {
+	RequestId    string    `json:"request_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Method       string    `json:"method"`
+	Endpoint     string    `json:"endpoint"`
+	Query        string    `json:"query,omitempty"`
+	Status       int       `json:"status"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	Latency      int64     `json:"latency_ms"`
+}
+
+// RequestLogFilter selects entries of the merchant, empty fields match any entry.
+// Status below 100 is status class, e.g. 4 matches all 4xx responses
+type RequestLogFilter struct {
+	From      time.Time
+	To        time.Time
+	Method    string
+	Endpoint  string
+	Status    int
+	ErrorCode string
+	RequestId string
+	Limit     int
+}
+
+// RequestLog keeps recent requests of merchants in redis, so requests handled by all instances are found.
+// Requests to admin api are attributed to merchant of the authenticated user, requests of integration api
+// to merchant of the project set by handler with SetRequestLogProject or SetRequestLogOrder. Logging is best effort, failures of redis
+// or billing server never break the request
+type RequestLog struct {
+	redis   redis.Cmdable
+	billing grpc.BillingService
+}
+
+// NewRequestLog
+func NewRequestLog(redis redis.Cmdable, billing grpc.BillingService) *RequestLog {
+	return &RequestLog{redis: redis, billing: billing}
+}
+
+// SetRequestLogProject attributes the request of integration api to merchant of the project
+func SetRequestLogProject(ctx echo.Context, projectId string) {
+	ctx.Set(requestLogProjectContextKey, projectId)
+}
+
+// SetRequestLogOrder attributes the request of integration api to merchant of project of the order
+func SetRequestLogOrder(ctx echo.Context, orderId string) {
+	ctx.Set(requestLogOrderContextKey, orderId)
+}
+
+// Middleware records requests of merchants, in admin api it must be used after middlewares authenticating the user.
+// Request id is taken from X-Request-ID header or generated and returned in the same response header
+func (l *RequestLog) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+			requestId := ctx.Request().Header.Get(echo.HeaderXRequestID)
+
+			if requestId == "" {
+				requestId = uuid.New().String()
+			}
+
+			ctx.Response().Header().Set(echo.HeaderXRequestID, requestId)
+
+			err := next(ctx)
+			merchantId := l.merchantId(ctx)
+
+			if merchantId == "" {
+				return err
+			}
+
+			entry := &RequestLogEntry{
+				RequestId: requestId,
+				Timestamp: start,
+				Method:    ctx.Request().Method,
+				Endpoint:  ctx.Path(),
+				Query:     redactRequestLogQuery(ctx.QueryParams()),
+				Status:    ctx.Response().Status,
+				Latency:   int64(time.Since(start) / time.Millisecond),
+			}
+
+			if err != nil {
+				entry.Status, entry.ErrorCode, entry.ErrorMessage = requestLogError(err)
+			}
+
+			_ = l.Record(merchantId, entry)
+
+			return err
+		}
+	}
+}
+
+// Record adds the entry to requests of the merchant, entries beyond the limit are dropped and requests
+// of the merchant expire in retention period after the last one
+func (l *RequestLog) Record(merchantId string, entry *RequestLogEntry) error {
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf(requestLogKeyMask, merchantId)
+
+	_, err = l.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.LPush(key, data)
+		pipe.LTrim(key, 0, RequestLogMaxEntries-1)
+		pipe.Expire(key, RequestLogRetention)
+		return nil
+	})
+
+	return err
+}
+
+// Search returns requests of the merchant matching the filter, the latest first
+func (l *RequestLog) Search(merchantId string, filter *RequestLogFilter) ([]*RequestLogEntry, error) {
+	items, err := l.redis.LRange(fmt.Sprintf(requestLogKeyMask, merchantId), 0, -1).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Now().Add(-RequestLogRetention)
+	list := make([]*RequestLogEntry, 0)
+
+	for _, item := range items {
+		entry := &RequestLogEntry{}
+
+		if err = json.Unmarshal([]byte(item), entry); err != nil {
+			return nil, err
+		}
+
+		if entry.Timestamp.After(from) && filter.match(entry) {
+			list = append(list, entry)
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Timestamp.After(list[j].Timestamp)
+	})
+
+	if filter.Limit > 0 && len(list) > filter.Limit {
+		list = list[:filter.Limit]
+	}
+
+	return list, nil
+}
+
+// merchantId returns merchant of the authenticated user or of the project of integration api request,
+// the merchant is resolved by billing server once and kept in redis. Empty string is returned for requests
+// which can't be attributed to a merchant
+func (l *RequestLog) merchantId(ctx echo.Context) string {
+	if userId := ExtractUserContext(ctx).Id; userId != "" {
+		return l.resolve(fmt.Sprintf(requestLogUserMerchantKeyMask, userId), func() (string, bool) {
+			rsp, err := l.billing.GetMerchantBy(ctx.Request().Context(), &grpc.GetMerchantByRequest{UserId: userId})
+
+			if err != nil || (rsp.Status != pkg.ResponseStatusOk && rsp.Status != pkg.ResponseStatusNotFound) {
+				return "", false
+			}
+
+			return rsp.GetItem().GetId(), true
+		})
+	}
+
+	projectId, _ := ctx.Get(requestLogProjectContextKey).(string)
+
+	if orderId, ok := ctx.Get(requestLogOrderContextKey).(string); ok && projectId == "" {
+		projectId = l.orderProjectId(ctx, orderId)
+	}
+
+	// project identifier of integration api request isn't authenticated yet, so malformed ones aren't resolved
+	if !bson.IsObjectIdHex(projectId) {
+		return ""
+	}
+
+	return l.resolve(fmt.Sprintf(requestLogProjectMerchantKeyMask, projectId), func() (string, bool) {
+		rsp, err := l.billing.GetProject(ctx.Request().Context(), &grpc.GetProjectRequest{ProjectId: projectId})
+
+		if err != nil || (rsp.Status != pkg.ResponseStatusOk && rsp.Status != pkg.ResponseStatusNotFound) {
+			return "", false
+		}
+
+		return rsp.GetItem().GetMerchantId(), true
+	})
+}
+
+// orderProjectId returns project of the order kept in redis or resolved by billing server
+func (l *RequestLog) orderProjectId(ctx echo.Context, orderId string) string {
+	if _, err := uuid.Parse(orderId); err != nil {
+		return ""
+	}
+
+	return l.resolve(fmt.Sprintf(requestLogOrderProjectKeyMask, orderId), func() (string, bool) {
+		rsp, err := l.billing.GetOrderPublic(ctx.Request().Context(), &grpc.GetOrderRequest{Id: orderId})
+
+		if err != nil || (rsp.Status != pkg.ResponseStatusOk && rsp.Status != pkg.ResponseStatusNotFound) {
+			return "", false
+		}
+
+		return rsp.GetItem().GetProject().GetId(), true
+	})
+}
+
+// resolve returns value kept by the key or resolved by the function, the value isn't remembered on failure,
+// so it's resolved again on next request
+func (l *RequestLog) resolve(key string, fn func() (string, bool)) string {
+	if val, err := l.redis.Get(key).Result(); err == nil {
+		return val
+	}
+
+	val, ok := fn()
+
+	if ok {
+		l.redis.Set(key, val, requestLogResolvedTTL)
+	}
+
+	return val
+}
+
+func (f *RequestLogFilter) match(entry *RequestLogEntry) bool {
+	if !f.From.IsZero() && entry.Timestamp.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && entry.Timestamp.After(f.To) {
+		return false
+	}
+
+	if f.Method != "" && !strings.EqualFold(f.Method, entry.Method) {
+		return false
+	}
+
+	if f.Endpoint != "" && !strings.Contains(entry.Endpoint, f.Endpoint) {
+		return false
+	}
+
+	if f.Status >= 100 && entry.Status != f.Status || f.Status > 0 && f.Status < 100 && entry.Status/100 != f.Status {
+		return false
+	}
+
+	if f.ErrorCode != "" && entry.ErrorCode != f.ErrorCode {
+		return false
+	}
+
+	return f.RequestId == "" || entry.RequestId == f.RequestId
+}
+
+func redactRequestLogQuery(params url.Values) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	redacted := make(url.Values, len(params))
+
+	for key, values := range params {
+		if requestLogQueryAllowed[key] {
+			redacted[key] = values
+			continue
+		}
+
+		redacted[key] = []string{RequestLogRedacted}
+	}
+
+	query, _ := url.QueryUnescape(redacted.Encode())
+
+	return query
+}
+
+// requestLogError returns status and error of the failed request, details of errors are omitted
+// since they may repeat values of the request
+func requestLogError(err error) (int, string, string) {
+	status := http.StatusInternalServerError
+	var msg interface{} = err
+
+	if httpErr, ok := err.(*echo.HTTPError); ok {
+		status = httpErr.Code
+		msg = httpErr.Message
+	}
+
+	switch m := msg.(type) {
+	case *grpc.ResponseErrorMessage:
+		return status, m.Code, m.Message
+	case string:
+		return status, "", m
+	}
+
+	return status, "", http.StatusText(status)
+}
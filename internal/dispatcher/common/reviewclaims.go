@@ -0,0 +1,137 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	reviewClaimKeyMask = "review_claim:%s"
+)
+
+// reviewClaimReleaseScript removes the claim only when it's still the claim read by the caller, so release
+// can't remove claim taken by other reviewer after expiration of the released one
+var reviewClaimReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ReviewClaim is assignment of merchant onboarding review to operator
+type ReviewClaim struct {
+	Reviewer  string `json:"reviewer"`
+	ClaimedAt int64  `json:"claimed_at"`
+}
+
+// ReviewClaims keeps claims of merchant reviews in redis. Claim is taken atomically and expires after ttl,
+// so review claimed by operator who left it returns to the queue
+type ReviewClaims struct {
+	redis redis.Cmdable
+	ttl   time.Duration
+}
+
+// NewReviewClaims
+func NewReviewClaims(redis redis.Cmdable, ttl time.Duration) *ReviewClaims {
+	return &ReviewClaims{redis: redis, ttl: ttl}
+}
+
+// Claim assigns review of the merchant to the reviewer unless it's claimed already,
+// the current claim of the review is returned in both cases
+func (c *ReviewClaims) Claim(merchantId, reviewer string) (*ReviewClaim, error) {
+	claim := &ReviewClaim{Reviewer: reviewer, ClaimedAt: time.Now().Unix()}
+	data, err := json.Marshal(claim)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := c.redis.SetNX(fmt.Sprintf(reviewClaimKeyMask, merchantId), data, c.ttl).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return claim, nil
+	}
+
+	current, err := c.Get(merchantId)
+
+	// claim expired right after the attempt, so the next attempt takes it
+	if err == nil && current == nil {
+		return c.Claim(merchantId, reviewer)
+	}
+
+	return current, err
+}
+
+// Get returns claim of the merchant review, nil is returned for unassigned review
+func (c *ReviewClaims) Get(merchantId string) (*ReviewClaim, error) {
+	claims, err := c.GetMany([]string{merchantId})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return claims[merchantId], nil
+}
+
+// GetMany returns claims of reviews of the merchants by merchant identifier, unassigned reviews are omitted
+func (c *ReviewClaims) GetMany(merchantIds []string) (map[string]*ReviewClaim, error) {
+	res := make(map[string]*ReviewClaim)
+
+	if len(merchantIds) == 0 {
+		return res, nil
+	}
+
+	keys := make([]string, len(merchantIds))
+
+	for i, id := range merchantIds {
+		keys[i] = fmt.Sprintf(reviewClaimKeyMask, id)
+	}
+
+	values, err := c.redis.MGet(keys...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, val := range values {
+		data, ok := val.(string)
+
+		if !ok {
+			continue
+		}
+
+		claim := &ReviewClaim{}
+
+		if err = json.Unmarshal([]byte(data), claim); err != nil {
+			return nil, err
+		}
+
+		res[merchantIds[i]] = claim
+	}
+
+	return res, nil
+}
+
+// Release returns review of the merchant to the queue when it's still assigned by the claim,
+// false is returned when the claim was expired or replaced
+func (c *ReviewClaims) Release(merchantId string, claim *ReviewClaim) (bool, error) {
+	data, err := json.Marshal(claim)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := reviewClaimReleaseScript.Run(c.redis, []string{fmt.Sprintf(reviewClaimKeyMask, merchantId)}, string(data)).Int64()
+
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
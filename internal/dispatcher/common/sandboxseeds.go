@@ -0,0 +1,79 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+const (
+	// SandboxSeedLockTTL releases seeding of the merchant sandbox when instance seeding it has died
+	SandboxSeedLockTTL = 5 * time.Minute
+
+	sandboxSeedKeyMask     = "sandbox_seed:%s"
+	sandboxSeedLockKeyMask = "sandbox_seed_lock:%s"
+)
+
+// SandboxSeed is progress of seeding of the merchant sandbox, entities are added as soon as billing server
+// creates them, so failed seeding is resumed by retry without creating them again
+type SandboxSeed struct {
+	Projects []string `json:"projects"`
+	Products []string `json:"products"`
+	// Orders are identifiers of sample orders by project
+	Orders    map[string][]string `json:"orders"`
+	Completed bool                `json:"completed"`
+}
+
+// SandboxSeeds keeps progress of sandbox seeding of merchants in redis
+type SandboxSeeds struct {
+	redis redis.Cmdable
+}
+
+// NewSandboxSeeds
+func NewSandboxSeeds(redis redis.Cmdable) *SandboxSeeds {
+	return &SandboxSeeds{redis: redis}
+}
+
+// Lock serializes seeding of the merchant sandbox across instances, false is returned when it's locked already
+func (s *SandboxSeeds) Lock(merchantId string) (bool, error) {
+	return s.redis.SetNX(fmt.Sprintf(sandboxSeedLockKeyMask, merchantId), 1, SandboxSeedLockTTL).Result()
+}
+
+// Unlock
+func (s *SandboxSeeds) Unlock(merchantId string) {
+	s.redis.Del(fmt.Sprintf(sandboxSeedLockKeyMask, merchantId))
+}
+
+// Get returns seeding progress of the merchant sandbox, empty progress when seeding wasn't started
+func (s *SandboxSeeds) Get(merchantId string) (*SandboxSeed, error) {
+	seed := &SandboxSeed{}
+	data, err := s.redis.Get(fmt.Sprintf(sandboxSeedKeyMask, merchantId)).Bytes()
+
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	if err == nil {
+		if err = json.Unmarshal(data, seed); err != nil {
+			return nil, err
+		}
+	}
+
+	if seed.Orders == nil {
+		seed.Orders = make(map[string][]string)
+	}
+
+	return seed, nil
+}
+
+// Save
+func (s *SandboxSeeds) Save(merchantId string, seed *SandboxSeed) error {
+	data, err := json.Marshal(seed)
+
+	if err != nil {
+		return err
+	}
+
+	return s.redis.Set(fmt.Sprintf(sandboxSeedKeyMask, merchantId), data, 0).Err()
+}
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	awsWrapper "github.com/paysuper/paysuper-aws-manager"
+)
+
+// StorageOptions are credentials and bucket of s3 storage
+type StorageOptions struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	Region          string
+	Bucket          string
+}
+
+// NewStorageCheck returns test call of the storage, it checks the bucket exists and is accessible
+// with the credentials without reading or writing any file
+func NewStorageCheck(opts StorageOptions) (IntegrationCheck, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(opts.Region),
+		Credentials: credentials.NewStaticCredentials(opts.AccessKeyId, opts.SecretAccessKey, ""),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	svc := s3.New(sess)
+
+	return func(ctx context.Context) error {
+		_, err := svc.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(opts.Bucket)})
+		return err
+	}, nil
+}
+
+// WrapStorage returns storage manager recording results of uploads and downloads as calls of the integration
+// added by RegisterDirect
+func (i *Integrations) WrapStorage(name string, m awsWrapper.AwsManagerInterface) awsWrapper.AwsManagerInterface {
+	return &integrationsStorage{AwsManagerInterface: m, integrations: i, name: name}
+}
+
+type integrationsStorage struct {
+	awsWrapper.AwsManagerInterface
+	integrations *Integrations
+	name         string
+}
+
+func (s *integrationsStorage) Upload(
+	ctx context.Context,
+	in *awsWrapper.UploadInput,
+	opts ...func(*s3manager.Uploader),
+) (*s3manager.UploadOutput, error) {
+	out, err := s.AwsManagerInterface.Upload(ctx, in, opts...)
+
+	if !isCallCanceled(ctx) {
+		s.integrations.RecordDirect(s.name, err)
+	}
+
+	return out, err
+}
+
+func (s *integrationsStorage) Download(
+	ctx context.Context,
+	filePath string,
+	in *awsWrapper.DownloadInput,
+	opts ...func(*s3manager.Downloader),
+) (int64, error) {
+	n, err := s.AwsManagerInterface.Download(ctx, filePath, in, opts...)
+
+	if !isCallCanceled(ctx) {
+		s.integrations.RecordDirect(s.name, err)
+	}
+
+	return n, err
+}
@@ -0,0 +1,129 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// SubdivisionsDefaultLocale is the locale every subdivision of the catalog must have a name in
+	SubdivisionsDefaultLocale = "en"
+
+	subdivisionsOverrideKeyMask = "subdivisions_override:%s"
+)
+
+// Subdivision is ISO 3166-2 subdivision (state, province, region) of the country with names by locale,
+// parent is code of the subdivision this one belongs to, e.g. region of the department
+type Subdivision struct {
+	Code   string            `json:"code" validate:"required,min=4,max=6"`
+	Type   string            `json:"type" validate:"omitempty,max=64"`
+	Parent string            `json:"parent,omitempty" validate:"omitempty,min=4,max=6"`
+	Name   map[string]string `json:"name" validate:"required,dive,keys,len=2,endkeys,required,max=255"`
+}
+
+// Subdivisions is read-only catalog of country subdivisions shipped with the api in json file
+// of format {"US": [subdivision, ...], ...}, the file is read once on start
+type Subdivisions struct {
+	countries map[string][]*Subdivision
+}
+
+// NewSubdivisions loads catalog from the file, missing file leaves the catalog empty, so the api starts
+// and serves subdivisions set through system api only
+func NewSubdivisions(path string) (*Subdivisions, error) {
+	s := &Subdivisions{countries: make(map[string][]*Subdivision)}
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make(map[string][]*Subdivision)
+
+	if err = json.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+
+	for country, items := range catalog {
+		sortSubdivisions(items)
+		s.countries[strings.ToUpper(country)] = items
+	}
+
+	return s, nil
+}
+
+// Get returns subdivisions of the country sorted by code, the result is empty for countries absent in catalog
+func (s *Subdivisions) Get(country string) []*Subdivision {
+	items := s.countries[strings.ToUpper(country)]
+	list := make([]*Subdivision, 0, len(items))
+
+	return append(list, items...)
+}
+
+// SubdivisionOverrides keeps subdivisions of countries set by operators through system api in redis,
+// they replace subdivisions of the country in catalog on every instance of the api
+type SubdivisionOverrides struct {
+	redis redis.Cmdable
+}
+
+// NewSubdivisionOverrides
+func NewSubdivisionOverrides(redis redis.Cmdable) *SubdivisionOverrides {
+	return &SubdivisionOverrides{redis: redis}
+}
+
+// Get returns subdivisions of the country sorted by code, nil is returned when subdivisions of the country
+// aren't overridden
+func (o *SubdivisionOverrides) Get(country string) ([]*Subdivision, error) {
+	data, err := o.redis.Get(fmt.Sprintf(subdivisionsOverrideKeyMask, strings.ToUpper(country))).Bytes()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*Subdivision, 0)
+
+	if err = json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Set replaces subdivisions of the country, empty list leaves the country without subdivisions
+func (o *SubdivisionOverrides) Set(country string, items []*Subdivision) error {
+	if items == nil {
+		items = make([]*Subdivision, 0)
+	}
+
+	sortSubdivisions(items)
+	data, err := json.Marshal(items)
+
+	if err != nil {
+		return err
+	}
+
+	return o.redis.Set(fmt.Sprintf(subdivisionsOverrideKeyMask, strings.ToUpper(country)), data, 0).Err()
+}
+
+// Delete removes override of the country, so subdivisions of the country are served from catalog again
+func (o *SubdivisionOverrides) Delete(country string) error {
+	return o.redis.Del(fmt.Sprintf(subdivisionsOverrideKeyMask, strings.ToUpper(country))).Err()
+}
+
+func sortSubdivisions(items []*Subdivision) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Code < items[j].Code
+	})
+}
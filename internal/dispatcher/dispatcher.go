@@ -3,16 +3,17 @@ package dispatcher
 import (
 	"context"
 	jwtverifier "github.com/ProtocolONE/authone-jwt-verifier-golang"
-	jwtMiddleware "github.com/ProtocolONE/authone-jwt-verifier-golang/middleware/echo"
 	"github.com/ProtocolONE/go-core/v2/pkg/invoker"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
 	"github.com/alexeyco/simpletable"
+	"github.com/go-redis/redis"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"html/template"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"sort"
 	"strings"
@@ -24,7 +25,13 @@ type Dispatcher struct {
 	cfg    Config
 	appSet AppSet
 	provider.LMT
-	globalCfg *common.Config
+	globalCfg     *common.Config
+	redis         redis.Cmdable
+	rateLimits    *common.RateLimits
+	deprecations  *common.Deprecations
+	requestLog    *common.RequestLog
+	projectIssues *common.ProjectIssues
+	routeCache    *common.RouteCache
 }
 
 // dispatch
@@ -46,21 +53,31 @@ func (d *Dispatcher) Dispatch(echoHttp *echo.Echo) error {
 	}))                                 // 3
 	echoHttp.Use(d.RecoverMiddleware()) // 2
 	echoHttp.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowHeaders: []string{"authorization", "content-type"},
+		AllowHeaders:  []string{"authorization", "content-type", "x-api-version"},
+		ExposeHeaders: []string{"x-api-version"},
 	}))                                 // 1
 	// Called before routes
+	echoHttp.Use(d.ApiVersionMiddleware)         // 3
 	echoHttp.Use(d.RawBodyPreMiddleware)         // 2
 	echoHttp.Use(d.LimitOffsetSortPreMiddleware) // 1
 	// init group routes
 	grp := &common.Groups{
-		AuthProject: echoHttp.Group(common.AuthProjectGroupPath),
-		AuthUser:    echoHttp.Group(common.AuthUserGroupPath),
-		WebHooks:    echoHttp.Group(common.WebHookGroupPath),
-		Common:      echoHttp,
+		AuthProject:   echoHttp.Group(common.AuthProjectGroupPath),
+		AuthUser:      echoHttp.Group(common.AuthUserGroupPath),
+		WebHooks:      echoHttp.Group(common.WebHookGroupPath),
+		System:        echoHttp.Group(common.SystemGroupPath),
+		Common:        echoHttp,
+		Deprecations:  d.deprecations,
+		RequestLog:    d.requestLog,
+		Redis:         d.redis,
+		RateLimits:    d.rateLimits,
+		ProjectIssues: d.projectIssues,
+		RouteCache:    d.routeCache,
 	}
 	d.authProjectGroup(grp.AuthProject)
 	d.authUserGroup(grp.AuthUser)
 	d.webHookGroup(grp.WebHooks)
+	d.systemGroup(grp.System)
 	// init routes
 	for _, handler := range d.appSet.Handlers {
 		handler.Route(grp)
@@ -127,7 +144,8 @@ func (d *Dispatcher) commonRoutes(echoHttp *echo.Echo) {
 
 func (d *Dispatcher) authProjectGroup(grp *echo.Group) {
 	// Called after routes
-	grp.Use(d.BodyDumpMiddleware()) // 1
+	grp.Use(d.BodyDumpMiddleware())    // 1
+	grp.Use(d.requestLog.Middleware()) // 2
 }
 
 func (d *Dispatcher) accessGroup(grp *echo.Group) {
@@ -138,25 +156,11 @@ func (d *Dispatcher) accessGroup(grp *echo.Group) {
 func (d *Dispatcher) authUserGroup(grp *echo.Group) {
 	// Called after routes
 	if !d.globalCfg.DisableAuthMiddleware {
-		grp.Use(
-			common.ContextWrapperCallback(func(c echo.Context, next echo.HandlerFunc) error {
-				handleFn := jwtMiddleware.AuthOneJwtCallableWithConfig(
-					d.appSet.JwtVerifier,
-					func(ui *jwtverifier.UserInfo) {
-						user := common.ExtractUserContext(c)
-						user.Id = ui.UserID
-						user.Name = "System User"
-						user.Merchants = make(map[string]bool)
-						user.Roles = make(map[string]bool)
-						common.SetUserContext(c, user)
-					},
-				)(next)
-				return handleFn(c)
-			}),
-		) // 1
 		// Called before routes
-		grp.Use(d.GetUserDetailsMiddleware) // 1
+		grp.Use(d.AuthUserMiddleware)       // 1
+		grp.Use(d.GetUserDetailsMiddleware) // 2
 	}
+	grp.Use(d.requestLog.Middleware()) // 3
 }
 
 func (d *Dispatcher) webHookGroup(grp *echo.Group) {
@@ -164,6 +168,13 @@ func (d *Dispatcher) webHookGroup(grp *echo.Group) {
 	grp.Use(d.BodyDumpMiddleware()) // 1
 }
 
+func (d *Dispatcher) systemGroup(grp *echo.Group) {
+	// Called before routes
+	if !d.globalCfg.DisableAuthMiddleware {
+		grp.Use(d.SystemTokenMiddleware) // 1
+	}
+}
+
 // Config
 type Config struct {
 	Debug         bool `fallback:"shared.debug"`
@@ -190,13 +201,27 @@ type AppSet struct {
 }
 
 // New
-func New(ctx context.Context, set provider.AwareSet, appSet AppSet, cfg *Config, globalCfg *common.Config) *Dispatcher {
+func New(
+	ctx context.Context,
+	set provider.AwareSet,
+	appSet AppSet,
+	cfg *Config,
+	globalCfg *common.Config,
+	redis redis.Cmdable,
+	trustedProxies []*net.IPNet,
+) *Dispatcher {
 	set.Logger = set.Logger.WithFields(logger.Fields{"service": common.Prefix})
 	return &Dispatcher{
-		ctx:       ctx,
-		cfg:       *cfg,
-		appSet:    appSet,
-		LMT:       &set,
-		globalCfg: globalCfg,
+		ctx:           ctx,
+		cfg:           *cfg,
+		appSet:        appSet,
+		LMT:           &set,
+		globalCfg:     globalCfg,
+		redis:         redis,
+		rateLimits:    common.NewRateLimits(redis, trustedProxies),
+		deprecations:  common.NewDeprecations(redis, appSet.Services.Billing),
+		requestLog:    common.NewRequestLog(redis, appSet.Services.Billing),
+		projectIssues: common.NewProjectIssues(redis),
+		routeCache:    common.NewRouteCache(redis),
 	}
 }
@@ -2,6 +2,7 @@ package dispatcher
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"fmt"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/labstack/echo/v4"
@@ -10,6 +11,21 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// rawBodyPoolMaxCap keeps buffers of rare large requests (e.g. bulk refunds) out of the pool
+	rawBodyPoolMaxCap = 1 << 20
+)
+
+var (
+	rawBodyPool = sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
 )
 
 // RecoverMiddleware
@@ -60,6 +76,35 @@ func (d *Dispatcher) GetUserDetailsMiddleware(next echo.HandlerFunc) echo.Handle
 	}
 }
 
+// AuthUserMiddleware authenticates user by access token introspected by authorization server, the token is
+// introspected once per request and the user is restricted to projects granted to the token as "project:<id>" scopes,
+// token without project scopes keeps access to all projects of the merchant
+func (d *Dispatcher) AuthUserMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		match := common.TokenRegex.FindStringSubmatch(ctx.Request().Header.Get(echo.HeaderAuthorization))
+
+		if len(match) < 2 {
+			return echo.NewHTTPError(http.StatusUnauthorized, common.ErrorMessageAuthorizationTokenNotFound)
+		}
+
+		token, err := d.appSet.JwtVerifier.Introspect(ctx.Request().Context(), match[1])
+
+		if err != nil || !token.Active {
+			return echo.NewHTTPError(http.StatusUnauthorized, common.ErrorMessageAuthorizedUserNotFound)
+		}
+
+		user := common.ExtractUserContext(ctx)
+		user.Id = token.Sub
+		user.Name = "System User"
+		user.Merchants = make(map[string]bool)
+		user.Roles = make(map[string]bool)
+		user.Projects = common.ParseProjectScope(token.Scope)
+		common.SetUserContext(ctx, user)
+
+		return next(ctx)
+	}
+}
+
 // LimitOffsetSortPreMiddleware
 func (d *Dispatcher) LimitOffsetSortPreMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -92,13 +137,79 @@ func (d *Dispatcher) LimitOffsetSortPreMiddleware(next echo.HandlerFunc) echo.Ha
 	}
 }
 
-// RawBodyPreMiddleware
+// ApiVersionMiddleware negotiates response shape with client, successful json responses and errors are wrapped
+// into envelope for clients requested ApiVersionEnvelope
+func (d *Dispatcher) ApiVersionMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		version := common.GetApiVersion(c)
+		c.Response().Header().Set(common.HeaderXApiVersion, strconv.Itoa(version))
+
+		if version < common.ApiVersionEnvelope {
+			return next(c)
+		}
+
+		w := common.NewEnvelopeWriter(c.Response().Writer)
+		c.Response().Writer = w
+		err := next(c)
+		c.Response().Writer = w.ResponseWriter
+
+		if err == nil || c.Response().Committed {
+			if closeErr := w.Close(c); closeErr != nil && err == nil {
+				err = closeErr
+			}
+
+			return err
+		}
+
+		httpErr, ok := err.(*echo.HTTPError)
+
+		if !ok {
+			d.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error()))
+			httpErr = echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		return common.ErrorResponse(c, httpErr)
+	}
+}
+
+// SystemTokenMiddleware allows requests with bearer token equal to SystemApiToken or to personal token of operator
+// from SystemApiUsers only, the operator is set as user of the request
+func (d *Dispatcher) SystemTokenMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+
+		for userId, userToken := range d.globalCfg.SystemApiUsers {
+			if userToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(userToken)) == 1 {
+				common.SetUserContext(c, &common.AuthUser{Id: userId, Name: "System User", Operator: true})
+				return next(c)
+			}
+		}
+
+		if d.globalCfg.SystemApiToken == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(d.globalCfg.SystemApiToken)) != 1 {
+			return echo.NewHTTPError(http.StatusUnauthorized, common.ErrorMessageAccessDenied)
+		}
+
+		return next(c)
+	}
+}
+
+// RawBodyPreMiddleware reads request body into pooled buffer, the buffer is returned to pool when the request
+// is handled, so handlers must not keep raw body after they return
 func (d *Dispatcher) RawBodyPreMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		buf, _ := ioutil.ReadAll(c.Request().Body)
-		rdr := ioutil.NopCloser(bytes.NewBuffer(buf))
-		c.Request().Body = rdr
-		common.SetRawBodyContext(c, buf)
+		buf := rawBodyPool.Get().(*bytes.Buffer)
+		buf.Reset()
+
+		defer func() {
+			if buf.Cap() <= rawBodyPoolMaxCap {
+				rawBodyPool.Put(buf)
+			}
+		}()
+
+		_, _ = buf.ReadFrom(c.Request().Body)
+		c.Request().Body = ioutil.NopCloser(bytes.NewReader(buf.Bytes()))
+		common.SetRawBodyContext(c, buf.Bytes())
 		return next(c)
 	}
 }
@@ -106,12 +217,6 @@ func (d *Dispatcher) RawBodyPreMiddleware(next echo.HandlerFunc) echo.HandlerFun
 // BodyDumpMiddleware
 func (d *Dispatcher) BodyDumpMiddleware() echo.MiddlewareFunc {
 	return middleware.BodyDump(func(ctx echo.Context, reqBody, resBody []byte) {
-		data := map[string]interface{}{
-			"request_headers":  common.RequestResponseHeadersToString(ctx.Request().Header),
-			"request_body":     string(reqBody),
-			"response_headers": common.RequestResponseHeadersToString(ctx.Response().Header()),
-			"response_body":    string(resBody),
-		}
-		d.L().Info(ctx.Path(), logger.WithFields(data))
+		d.L().Info(ctx.Path(), logger.WithFields(common.BodyDumpFields(ctx, reqBody, resBody)))
 	})
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/ProtocolONE/go-core/v2/pkg/config"
 	"github.com/ProtocolONE/go-core/v2/pkg/invoker"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/go-redis/redis"
 	"github.com/google/wire"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
@@ -24,6 +25,13 @@ import (
 	"gopkg.in/go-playground/validator.v9"
 )
 
+const (
+	integrationsGeoCheckIp = "8.8.8.8"
+
+	integrationsRatesCheckAmount   = 1
+	integrationsRatesCheckCurrency = "USD"
+)
+
 // ProviderCfg
 func ProviderCfg(cfg config.Configurator) (*Config, func(), error) {
 	c := &Config{
@@ -52,15 +60,49 @@ func ProviderJwtVerifier(cfg *common.Config) *jwtverifier.JwtVerifier {
 	})
 }
 
+var integrationsRatesEndpoints = []string{
+	"BillingService.GetRecommendedPriceByConversion",
+	"BillingService.GetRecommendedPriceTable",
+}
+
 // ProviderServices
 func ProviderServices(srv *micro.Micro) common.Services {
-	return common.Services{
-		Repository: repository.NewRepositoryService(constant.PayOneRepositoryServiceName, srv.Client()),
-		Geo:        proto.NewGeoIpService(geoip.ServiceName, srv.Client()),
-		Billing:    grpc.NewBillingService(pkg.ServiceName, srv.Client()),
-		Tax:        tax_service.NewTaxService(taxServiceConst.ServiceName, srv.Client()),
-		Reporter:   reporterProto.NewReporterService(reporterPkg.ServiceName, srv.Client()),
+	integrations := common.NewIntegrations()
+	client := integrations.Wrap(srv.Client())
+	services := common.Services{
+		Repository:   repository.NewRepositoryService(constant.PayOneRepositoryServiceName, client),
+		Geo:          proto.NewGeoIpService(geoip.ServiceName, client),
+		Billing:      grpc.NewBillingService(pkg.ServiceName, client),
+		Tax:          tax_service.NewTaxService(taxServiceConst.ServiceName, client),
+		Reporter:     reporterProto.NewReporterService(reporterPkg.ServiceName, client),
+		Integrations: integrations,
 	}
+
+	// reporter and repository have no read calls which are safe to make for test, only their statistics is shown
+	integrations.Register(common.IntegrationBilling, pkg.ServiceName, func(ctx context.Context) error {
+		_, err := services.Billing.GetCountriesList(ctx, &grpc.EmptyRequest{})
+		return err
+	})
+	integrations.Register(common.IntegrationTax, taxServiceConst.ServiceName, func(ctx context.Context) error {
+		_, err := services.Tax.GetRates(ctx, &tax_service.GetRatesRequest{Limit: 1})
+		return err
+	})
+	integrations.Register(common.IntegrationGeo, geoip.ServiceName, func(ctx context.Context) error {
+		_, err := services.Geo.GetIpData(ctx, &proto.GeoIpDataRequest{IP: integrationsGeoCheckIp})
+		return err
+	})
+	// currency rates provider is reached by gateway only through price conversion of billing server
+	integrations.RegisterEndpoints(common.IntegrationRates, pkg.ServiceName, integrationsRatesEndpoints, func(ctx context.Context) error {
+		_, err := services.Billing.GetRecommendedPriceByConversion(ctx, &grpc.RecommendedPriceRequest{
+			Amount:   integrationsRatesCheckAmount,
+			Currency: integrationsRatesCheckCurrency,
+		})
+		return err
+	})
+	integrations.Register(common.IntegrationReporter, reporterPkg.ServiceName, nil)
+	integrations.Register(common.IntegrationRepository, constant.PayOneRepositoryServiceName, nil)
+
+	return services
 }
 
 // ProviderValidators
@@ -86,6 +128,7 @@ func ProviderValidators(v *validators.ValidatorSet) (validate *validator.Validat
 	}
 	validate.RegisterStructValidation(v.CompanyValidator, grpc.UserProfileCompany{})
 	validate.RegisterStructValidation(v.MerchantCompanyValidator, billing.MerchantCompanyInfo{})
+	validate.RegisterStructValidation(v.BillingAddressValidator, grpc.ProcessBillingAddressRequest{})
 	if err = validate.RegisterValidation("company_name", v.CompanyNameValidator); err != nil {
 		return
 	}
@@ -109,8 +152,19 @@ func ProviderValidators(v *validators.ValidatorSet) (validate *validator.Validat
 
 // ProviderDispatcher
 func ProviderDispatcher(ctx context.Context, set provider.AwareSet, appSet AppSet, cfg *Config, globalCfg *common.Config) (*Dispatcher, func(), error) {
-	d := New(ctx, set, appSet, cfg, globalCfg)
-	return d, func() {}, nil
+	trustedProxies, err := common.ParseTrustedProxies(globalCfg.TrustedProxies)
+
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     globalCfg.RedisHost,
+		Password: globalCfg.RedisPassword,
+	})
+	d := New(ctx, set, appSet, cfg, globalCfg, redisClient, trustedProxies)
+
+	return d, func() { _ = redisClient.Close() }, nil
 }
 
 var (
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"context"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"sort"
+)
+
+const (
+	adjustmentsReportPath = "/reports/adjustments"
+)
+
+const (
+	adjustmentTypeRefund        = "refund"
+	adjustmentTypeRefundFee     = "refund_fee"
+	adjustmentTypeChargeback    = "chargeback"
+	adjustmentTypeChargebackFee = "chargeback_fee"
+	adjustmentTypeCorrection    = "correction"
+
+	// adjustmentOrderTypeRefund is type of order billing server creates for every refund and chargeback,
+	// including partial ones, transaction date of the order is date of the refund
+	adjustmentOrderTypeRefund = "refund"
+)
+
+// adjustmentTypes maps statuses of refund orders to types of adjustment and of its fee
+var adjustmentTypes = map[string][2]string{
+	riskOrderStatusRefunded:   {adjustmentTypeRefund, adjustmentTypeRefundFee},
+	riskOrderStatusChargeback: {adjustmentTypeChargeback, adjustmentTypeChargebackFee},
+}
+
+type adjustmentsRequest struct {
+	DateFrom int64 `query:"date_from" validate:"required,gt=0"`
+	DateTo   int64 `query:"date_to" validate:"required,gtfield=DateFrom"`
+	Limit    int32 `query:"limit" validate:"omitempty,gte=0"`
+	Offset   int32 `query:"offset" validate:"omitempty,gte=0"`
+}
+
+// adjustment is single post-settlement entry: refund or chargeback of the order, fee charged for it,
+// or correction of royalty report. Date is date of the entry, not date of payment of originating order
+type adjustment struct {
+	Type              string                   `json:"type"`
+	Date              int64                    `json:"date"`
+	Amount            float64                  `json:"amount"`
+	Currency          string                   `json:"currency"`
+	OrderId           string                   `json:"order_id,omitempty"`
+	OrderUrl          string                   `json:"order_url,omitempty"`
+	RefundOrderId     string                   `json:"refund_order_id,omitempty"`
+	RoyaltyReportId   string                   `json:"royalty_report_id,omitempty"`
+	AccountingEntryId string                   `json:"accounting_entry_id,omitempty"`
+	Reason            string                   `json:"reason,omitempty"`
+	Order             *billing.OrderViewPublic `json:"order,omitempty"`
+}
+
+type adjustmentsResponse struct {
+	Count int32         `json:"count"`
+	Items []*adjustment `json:"items"`
+}
+
+type AdjustmentsRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+}
+
+// NewAdjustmentsRoute
+func NewAdjustmentsRoute(set common.HandlerSet, cfg *common.Config) *AdjustmentsRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "AdjustmentsRoute"})
+	return &AdjustmentsRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *AdjustmentsRoute) Route(groups *common.Groups) {
+	groups.AuthUser.GET(adjustmentsReportPath, h.getAdjustments)
+}
+
+// @Description List of post-settlement adjustments (refunds, chargebacks, their fees and royalty report corrections)
+// @Description of authenticated merchant dated in the period with links to originating orders and royalty reports
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/reports/adjustments?date_from=1569888000&date_to=1572566399
+func (h *AdjustmentsRoute) getAdjustments(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+	reqCtx := ctx.Request().Context()
+
+	req := &adjustmentsRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = h.cfg.LimitDefault
+	}
+
+	if req.Limit > h.cfg.LimitMax {
+		req.Limit = h.cfg.LimitMax
+	}
+
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	reports, err := h.listRoyaltyReports(reqCtx, merchant.Item.Id, req)
+
+	if err != nil {
+		return err
+	}
+
+	items, err := h.listRefundAdjustments(reqCtx, merchant.Item.Id, req, reports)
+
+	if err != nil {
+		return err
+	}
+
+	items = append(items, getCorrectionAdjustments(reports, req)...)
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Date < items[j].Date })
+
+	res := &adjustmentsResponse{Count: int32(len(items)), Items: []*adjustment{}}
+
+	if int(req.Offset) < len(items) {
+		items = items[req.Offset:]
+
+		if len(items) > int(req.Limit) {
+			items = items[:req.Limit]
+		}
+
+		res.Items = items
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// listRefundAdjustments returns refunds and chargebacks dated in the period with their fees. Payment date of refund
+// order is date of the refund, so the period is applied to it. Billing server doesn't filter orders by type,
+// so all pages of refunded and charged back orders are read and refund orders are taken from them
+func (h *AdjustmentsRoute) listRefundAdjustments(
+	ctx context.Context,
+	merchantId string,
+	adjReq *adjustmentsRequest,
+	reports []*billing.RoyaltyReport,
+) ([]*adjustment, error) {
+	req := &grpc.ListOrdersRequest{
+		Merchant:   []string{merchantId},
+		Status:     []string{riskOrderStatusRefunded, riskOrderStatusChargeback},
+		PmDateFrom: adjReq.DateFrom,
+		PmDateTo:   adjReq.DateTo,
+		Limit:      h.cfg.LimitMax,
+	}
+	items := make([]*adjustment, 0)
+
+	for {
+		res, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx, req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "FindAllOrdersPublic", req)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if res.Status != pkg.ResponseStatusOk {
+			return nil, echo.NewHTTPError(int(res.Status), res.Message)
+		}
+
+		for _, order := range res.GetItem().GetItems() {
+			types, ok := adjustmentTypes[order.Status]
+
+			if !ok || order.GetType() != adjustmentOrderTypeRefund {
+				continue
+			}
+
+			orderId := order.GetParentOrder().GetUuid()
+
+			if orderId == "" {
+				orderId = order.Uuid
+			}
+
+			entry := &adjustment{
+				Type:          types[0],
+				Date:          order.GetTransactionDate().GetSeconds(),
+				Amount:        order.GetRefundReverseRevenue().GetAmount(),
+				Currency:      order.GetRefundReverseRevenue().GetCurrency(),
+				OrderId:       orderId,
+				OrderUrl:      common.AuthUserGroupPath + orderPath + "/" + orderId,
+				RefundOrderId: order.Uuid,
+				Order:         order,
+			}
+			entry.RoyaltyReportId = getRoyaltyReportByDate(reports, entry.Date)
+			items = append(items, entry)
+
+			if fee := order.GetRefundFeesTotal(); fee.GetAmount() != 0 {
+				items = append(items, &adjustment{
+					Type:            types[1],
+					Date:            entry.Date,
+					Amount:          fee.GetAmount(),
+					Currency:        fee.GetCurrency(),
+					OrderId:         entry.OrderId,
+					OrderUrl:        entry.OrderUrl,
+					RefundOrderId:   entry.RefundOrderId,
+					RoyaltyReportId: entry.RoyaltyReportId,
+				})
+			}
+		}
+
+		req.Offset += int32(len(res.GetItem().GetItems()))
+
+		if len(res.GetItem().GetItems()) == 0 || req.Offset >= res.GetItem().GetCount() {
+			return items, nil
+		}
+	}
+}
+
+// listRoyaltyReports returns all royalty reports of the merchant for the period
+func (h *AdjustmentsRoute) listRoyaltyReports(
+	ctx context.Context,
+	merchantId string,
+	adjReq *adjustmentsRequest,
+) ([]*billing.RoyaltyReport, error) {
+	req := &grpc.ListRoyaltyReportsRequest{
+		MerchantId: merchantId,
+		PeriodFrom: adjReq.DateFrom,
+		PeriodTo:   adjReq.DateTo,
+		Limit:      h.cfg.LimitMax,
+	}
+	reports := make([]*billing.RoyaltyReport, 0)
+
+	for {
+		res, err := h.dispatch.Services.Billing.ListRoyaltyReports(ctx, req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListRoyaltyReports", req)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if res.Status != pkg.ResponseStatusOk {
+			return nil, echo.NewHTTPError(int(res.Status), res.Message)
+		}
+
+		reports = append(reports, res.GetData().GetItems()...)
+		req.Offset += int32(len(res.GetData().GetItems()))
+
+		if len(res.GetData().GetItems()) == 0 || req.Offset >= res.GetData().GetCount() {
+			return reports, nil
+		}
+	}
+}
+
+// getRoyaltyReportByDate returns royalty report including entries of the date, royalty report includes all
+// transactions closed in its period, so adjustment gets into report by its date and not by date of the order.
+// Empty string is returned when report of the date isn't generated yet
+func getRoyaltyReportByDate(reports []*billing.RoyaltyReport, date int64) string {
+	for _, report := range reports {
+		if date >= report.GetPeriodFrom().GetSeconds() && date <= report.GetPeriodTo().GetSeconds() {
+			return report.Id
+		}
+	}
+
+	return ""
+}
+
+// getCorrectionAdjustments returns corrections of royalty reports dated in the period
+func getCorrectionAdjustments(reports []*billing.RoyaltyReport, req *adjustmentsRequest) []*adjustment {
+	items := make([]*adjustment, 0)
+
+	for _, report := range reports {
+		for _, correction := range report.GetSummary().GetCorrections() {
+			date := correction.GetEntryDate().GetSeconds()
+
+			if date < req.DateFrom || date > req.DateTo {
+				continue
+			}
+
+			items = append(items, &adjustment{
+				Type:              adjustmentTypeCorrection,
+				Date:              date,
+				Amount:            correction.Amount,
+				Currency:          correction.Currency,
+				RoyaltyReportId:   report.Id,
+				AccountingEntryId: correction.AccountingEntryId,
+				Reason:            correction.Reason,
+			})
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/globalsign/mgo/bson"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type AdjustmentsTestSuite struct {
+	suite.Suite
+	router *AdjustmentsRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Adjustments(t *testing.T) {
+	suite.Run(t, new(AdjustmentsTestSuite))
+}
+
+func (suite *AdjustmentsTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewAdjustmentsRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *AdjustmentsTestSuite) TearDownTest() {}
+
+func (suite *AdjustmentsTestSuite) getBillingMock(
+	orders []*billing.OrderViewPublic,
+	reports []*billing.RoyaltyReport,
+) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListOrdersPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &grpc.ListOrdersPublicResponseItem{Count: int32(len(orders)), Items: orders},
+		}, nil)
+	bill.On("ListRoyaltyReports", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListRoyaltyReportsResponse{
+			Status: pkg.ResponseStatusOk,
+			Data:   &grpc.RoyaltyReportsPaginate{Count: int32(len(reports)), Items: reports},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	return bill
+}
+
+func (suite *AdjustmentsTestSuite) getRefundOrder(parentId, status string, date int64, fee float64) *billing.OrderViewPublic {
+	return &billing.OrderViewPublic{
+		Uuid:                 bson.NewObjectId().Hex(),
+		Type:                 adjustmentOrderTypeRefund,
+		Status:               status,
+		ParentOrder:          &billing.ParentOrder{Uuid: parentId},
+		TransactionDate:      &timestamp.Timestamp{Seconds: date},
+		RefundReverseRevenue: &billing.OrderViewMoney{Amount: 10, Currency: "USD"},
+		RefundFeesTotal:      &billing.OrderViewMoney{Amount: fee, Currency: "USD"},
+	}
+}
+
+func (suite *AdjustmentsTestSuite) getAdjustments(query map[string]string) (*adjustmentsResponse, error) {
+	builder := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + adjustmentsReportPath).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399")
+
+	for key, val := range query {
+		builder = builder.SetQueryParam(key, val)
+	}
+
+	res, err := builder.Init(test.ReqInitJSON()).Exec(suite.T())
+
+	if err != nil {
+		return nil, err
+	}
+
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	adjustments := &adjustmentsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), adjustments)
+
+	return adjustments, err
+}
+
+func (suite *AdjustmentsTestSuite) TestAdjustments_GetAdjustments_Ok() {
+	orderId := bson.NewObjectId().Hex()
+	chargebackOrderId := bson.NewObjectId().Hex()
+	report := &billing.RoyaltyReport{
+		Id:         bson.NewObjectId().Hex(),
+		PeriodFrom: &timestamp.Timestamp{Seconds: 1569888000},
+		PeriodTo:   &timestamp.Timestamp{Seconds: 1570492799},
+		Summary: &billing.RoyaltyReportSummary{
+			Corrections: []*billing.RoyaltyReportCorrectionItem{
+				{
+					AccountingEntryId: bson.NewObjectId().Hex(),
+					Amount:            -5,
+					Currency:          "USD",
+					Reason:            "some reason",
+					EntryDate:         &timestamp.Timestamp{Seconds: 1570000000},
+				},
+				{Amount: 1, Currency: "USD", EntryDate: &timestamp.Timestamp{Seconds: 1560000000}},
+			},
+		},
+	}
+	// two partial refunds of the order and chargeback with fee, refunded order itself isn't adjustment
+	firstRefund := suite.getRefundOrder(orderId, riskOrderStatusRefunded, 1569900000, 0)
+	secondRefund := suite.getRefundOrder(orderId, riskOrderStatusRefunded, 1571000000, 0)
+	chargeback := suite.getRefundOrder(chargebackOrderId, riskOrderStatusChargeback, 1572000000, 15)
+	original := &billing.OrderViewPublic{Uuid: orderId, Type: "order", Status: riskOrderStatusRefunded}
+	bill := suite.getBillingMock([]*billing.OrderViewPublic{secondRefund, original, chargeback, firstRefund}, []*billing.RoyaltyReport{report})
+
+	adjustments, err := suite.getAdjustments(nil)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 5, adjustments.Count)
+	assert.Len(suite.T(), adjustments.Items, 5)
+
+	assert.Equal(suite.T(), adjustmentTypeRefund, adjustments.Items[0].Type)
+	assert.Equal(suite.T(), orderId, adjustments.Items[0].OrderId)
+	assert.Equal(suite.T(), common.AuthUserGroupPath+orderPath+"/"+orderId, adjustments.Items[0].OrderUrl)
+	assert.Equal(suite.T(), firstRefund.Uuid, adjustments.Items[0].RefundOrderId)
+	assert.EqualValues(suite.T(), 1569900000, adjustments.Items[0].Date)
+	assert.EqualValues(suite.T(), 10, adjustments.Items[0].Amount)
+	assert.Equal(suite.T(), report.Id, adjustments.Items[0].RoyaltyReportId)
+
+	assert.Equal(suite.T(), adjustmentTypeCorrection, adjustments.Items[1].Type)
+	assert.Equal(suite.T(), report.Summary.Corrections[0].AccountingEntryId, adjustments.Items[1].AccountingEntryId)
+	assert.EqualValues(suite.T(), -5, adjustments.Items[1].Amount)
+	assert.Equal(suite.T(), report.Id, adjustments.Items[1].RoyaltyReportId)
+
+	assert.Equal(suite.T(), adjustmentTypeRefund, adjustments.Items[2].Type)
+	assert.Equal(suite.T(), secondRefund.Uuid, adjustments.Items[2].RefundOrderId)
+	assert.Empty(suite.T(), adjustments.Items[2].RoyaltyReportId)
+
+	assert.Equal(suite.T(), adjustmentTypeChargeback, adjustments.Items[3].Type)
+	assert.Equal(suite.T(), chargebackOrderId, adjustments.Items[3].OrderId)
+	assert.Equal(suite.T(), adjustmentTypeChargebackFee, adjustments.Items[4].Type)
+	assert.EqualValues(suite.T(), 15, adjustments.Items[4].Amount)
+	assert.Equal(suite.T(), chargeback.Uuid, adjustments.Items[4].RefundOrderId)
+
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.Merchant[0] == mock.OnboardingMerchantMock.Id && len(req.Status) == 2 &&
+			req.PmDateFrom == 1569888000 && req.PmDateTo == 1572566399 && req.Limit == suite.router.cfg.LimitMax
+	}))
+	bill.AssertNotCalled(suite.T(), "ListRoyaltyReportOrders", mock2.Anything, mock2.Anything)
+}
+
+func (suite *AdjustmentsTestSuite) TestAdjustments_GetAdjustments_Paging_Ok() {
+	orders := make([]*billing.OrderViewPublic, 0)
+
+	for i := int64(0); i < 5; i++ {
+		orders = append(orders, suite.getRefundOrder(bson.NewObjectId().Hex(), riskOrderStatusRefunded, 1569900000+i, 0))
+	}
+
+	suite.getBillingMock(orders, []*billing.RoyaltyReport{})
+
+	adjustments, err := suite.getAdjustments(map[string]string{"limit": "2", "offset": "3"})
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 5, adjustments.Count)
+	assert.Len(suite.T(), adjustments.Items, 2)
+	assert.Equal(suite.T(), orders[3].Uuid, adjustments.Items[0].RefundOrderId)
+	assert.Equal(suite.T(), orders[4].Uuid, adjustments.Items[1].RefundOrderId)
+}
+
+func (suite *AdjustmentsTestSuite) TestAdjustments_GetAdjustments_Empty() {
+	suite.getBillingMock([]*billing.OrderViewPublic{}, []*billing.RoyaltyReport{})
+
+	adjustments, err := suite.getAdjustments(nil)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, adjustments.Count)
+	assert.Empty(suite.T(), adjustments.Items)
+}
+
+func (suite *AdjustmentsTestSuite) TestAdjustments_GetAdjustments_ValidationError() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + adjustmentsReportPath).
+		SetQueryParam("date_from", "1572566399").
+		SetQueryParam("date_to", "1569888000").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *AdjustmentsTestSuite) TestAdjustments_GetAdjustments_BillingServerError() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("ListRoyaltyReports", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListRoyaltyReportsResponse{Status: pkg.ResponseStatusOk, Data: &grpc.RoyaltyReportsPaginate{}}, nil)
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(nil, errors.New("some error"))
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + adjustmentsReportPath).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
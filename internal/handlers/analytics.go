@@ -0,0 +1,986 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/money"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	analyticsForecastPath    = "/analytics/forecast"
+	analyticsCohortsPath     = "/analytics/cohorts"
+	analyticsProductsPath    = "/analytics/products"
+	analyticsAttributionPath = "/analytics/attribution"
+)
+
+const (
+	forecastMethodMovingAverage = "moving_average"
+	forecastMethodSeasonalNaive = "seasonal_naive"
+
+	// forecastHistoryDays is four full weeks, so each weekday has the same number of samples for seasonal naive method
+	forecastHistoryDays = 28
+	forecastHorizonDays = 30
+	forecastDaysInWeek  = 7
+
+	// forecastConfidenceZ is z-score of 95% confidence interval
+	forecastConfidenceZ = 1.96
+
+	cohortsMonthsDefault = 6
+	cohortMonthLayout    = "2006-01"
+
+	productsSortRevenue    = "revenue"
+	productsSortUnits      = "units"
+	productsSortRefundRate = "refund_rate"
+	productsFormatCsv      = "csv"
+	productsCsvFileName    = "products.csv"
+)
+
+type forecastRequest struct {
+	ProjectId string `query:"project_id" validate:"omitempty,hexadecimal,len=24"`
+	Method    string `query:"method" validate:"omitempty,oneof=moving_average seasonal_naive"`
+}
+
+type forecastDay struct {
+	Date   int64   `json:"date"`
+	Amount float64 `json:"amount"`
+	Lower  float64 `json:"lower"`
+	Upper  float64 `json:"upper"`
+}
+
+type forecastProject struct {
+	ProjectId  string         `json:"project_id"`
+	Currency   string         `json:"currency"`
+	Total      float64        `json:"total"`
+	TotalLower float64        `json:"total_lower"`
+	TotalUpper float64        `json:"total_upper"`
+	Days       []*forecastDay `json:"days"`
+}
+
+type forecastResponse struct {
+	Method      string             `json:"method"`
+	HistoryFrom int64              `json:"history_from"`
+	HistoryTo   int64              `json:"history_to"`
+	Projects    []*forecastProject `json:"projects"`
+}
+
+type cohortsRequest struct {
+	Months int `query:"months" validate:"omitempty,min=1,max=12"`
+}
+
+type cohort struct {
+	Month           string    `json:"month"`
+	Customers       int       `json:"customers"`
+	RepeatCustomers int       `json:"repeat_customers"`
+	RepeatRate      float64   `json:"repeat_rate"`
+	Revenue         []float64 `json:"revenue"`
+}
+
+type cohortsResponse struct {
+	From               int64     `json:"from"`
+	To                 int64     `json:"to"`
+	Currency           string    `json:"currency"`
+	ReturningCustomers int       `json:"returning_customers"`
+	Cohorts            []*cohort `json:"cohorts"`
+}
+
+type productsRequest struct {
+	DateFrom int64  `query:"date_from" validate:"required,gt=0"`
+	DateTo   int64  `query:"date_to" validate:"required,gtfield=DateFrom"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=revenue units refund_rate"`
+	Format   string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type attributionRequest struct {
+	ProjectId string `query:"project_id" validate:"required,hexadecimal,len=24"`
+	Dimension string `query:"dimension" validate:"required,oneof=utm_source utm_medium utm_campaign ad_id platform"`
+	DateFrom  int64  `query:"date_from" validate:"required,gt=0"`
+	DateTo    int64  `query:"date_to" validate:"required,gtfield=DateFrom"`
+}
+
+// attributionStat is processed orders of one value of attribution dimension, orders without the parameter
+// have empty value
+type attributionStat struct {
+	Value   string  `json:"value"`
+	Orders  int     `json:"orders"`
+	Revenue float64 `json:"revenue"`
+}
+
+type attributionResponse struct {
+	Dimension string             `json:"dimension"`
+	DateFrom  int64              `json:"date_from"`
+	DateTo    int64              `json:"date_to"`
+	Currency  string             `json:"currency"`
+	Items     []*attributionStat `json:"items"`
+}
+
+type productStat struct {
+	Id          string  `json:"id"`
+	Sku         string  `json:"sku"`
+	Name        string  `json:"name"`
+	Revenue     float64 `json:"revenue"`
+	Units       int     `json:"units"`
+	RefundUnits int     `json:"refund_units"`
+	RefundRate  float64 `json:"refund_rate"`
+}
+
+type productsResponse struct {
+	DateFrom int64          `json:"date_from"`
+	DateTo   int64          `json:"date_to"`
+	Currency string         `json:"currency"`
+	Items    []*productStat `json:"items"`
+}
+
+// cohortCustomer is first purchase month and revenue by months of the period of one customer
+type cohortCustomer struct {
+	first   int
+	orders  int
+	revenue []float64
+}
+
+// forecastHistory is daily net revenue of the project for forecastHistoryDays, oldest day first
+type forecastHistory struct {
+	currency string
+	daily    []float64
+}
+
+type AnalyticsRoute struct {
+	dispatch        common.HandlerSet
+	cfg             common.Config
+	attributions    *common.OrderAttributions
+	dailyRevenues   *common.DailyRevenues
+	cohortCustomers *common.CohortCustomers
+	carts           *common.OrderCarts
+	exportSlots     *common.ExportSlots
+	provider.LMT
+}
+
+// NewAnalyticsRoute
+func NewAnalyticsRoute(set common.HandlerSet, cfg *common.Config) *AnalyticsRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "AnalyticsRoute"})
+	return &AnalyticsRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *AnalyticsRoute) Route(groups *common.Groups) {
+	h.attributions = common.NewOrderAttributions(groups.Redis, h.cfg.OrderAttributionTTL)
+	h.dailyRevenues = common.NewDailyRevenues(groups.Redis, h.cfg.AnalyticsDailyRevenueTtl)
+	h.cohortCustomers = common.NewCohortCustomers(groups.Redis, h.cfg.AnalyticsCohortCustomersTtl)
+	h.carts = common.NewOrderCarts(groups.Redis, h.cfg.OrderAttributionTTL)
+	h.exportSlots = common.NewExportSlots(groups.Redis, h.cfg.ExportConcurrencyLimit)
+
+	groups.AuthUser.GET(analyticsForecastPath, h.getForecast)
+	groups.AuthUser.GET(analyticsCohortsPath, h.getCohorts)
+	groups.AuthUser.GET(analyticsProductsPath, h.getProducts)
+	groups.AuthUser.GET(analyticsAttributionPath, h.getAttribution)
+}
+
+// @Description Net revenue forecast per project for the next 30 days based on processed payments of the last 4 weeks.
+// @Description Seasonal naive method (default) repeats weekly pattern, moving average method uses mean of all days.
+// @Description Days are taken by payment date. Bounds are 95% confidence interval of the daily values and of their total
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  'https://api.paysuper.online/admin/api/v1/analytics/forecast?method=seasonal_naive&project_id=%project_id_here%'
+func (h *AnalyticsRoute) getForecast(ctx echo.Context) error {
+	req := &forecastRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	authUser := common.ExtractUserContext(ctx)
+
+	if req.ProjectId != "" && !authUser.IsProjectAllowed(req.ProjectId) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	if req.Method == "" {
+		req.Method = forecastMethodSeasonalNaive
+	}
+
+	reqCtx := ctx.Request().Context()
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	// today isn't complete yet, so history ends at the start of the current day
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -forecastHistoryDays)
+
+	histories, err := h.getHistories(reqCtx, merchant.Item.Id, req.ProjectId, from)
+
+	if err != nil {
+		return err
+	}
+
+	res := &forecastResponse{
+		Method:      req.Method,
+		HistoryFrom: from.Unix(),
+		HistoryTo:   to.Unix(),
+		Projects:    []*forecastProject{},
+	}
+
+	for projectId, history := range histories {
+		if !authUser.IsProjectAllowed(projectId) {
+			continue
+		}
+
+		res.Projects = append(res.Projects, forecast(projectId, history, req.Method, to))
+	}
+
+	sort.Slice(res.Projects, func(i, j int) bool {
+		return res.Projects[i].ProjectId < res.Projects[j].ProjectId
+	})
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// @Description Customers grouped by month of their first purchase in the period (6 months by default, up to 12) with
+// @Description share of customers who purchased again and cumulative net revenue of the cohort by months since its start.
+// @Description Customers who purchased in the months before the period (24 by default) are returning, they are counted
+// @Description apart from cohorts
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  'https://api.paysuper.online/admin/api/v1/analytics/cohorts?months=3'
+func (h *AnalyticsRoute) getCohorts(ctx echo.Context) error {
+	req := &cohortsRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	if req.Months == 0 {
+		req.Months = cohortsMonthsDefault
+	}
+
+	authUser := common.ExtractUserContext(ctx)
+	reqCtx := ctx.Request().Context()
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	to := time.Now().UTC()
+	from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-req.Months, 0)
+	res := &cohortsResponse{From: from.Unix(), To: to.Unix(), Cohorts: []*cohort{}}
+	customers := make(map[string]*cohortCustomer)
+
+	oReq := &grpc.ListOrdersRequest{
+		Merchant:   []string{merchant.Item.Id},
+		Status:     []string{riskOrderStatusProcessed},
+		PmDateFrom: from.Unix(),
+		PmDateTo:   to.Unix(),
+	}
+	err = h.eachOrder(reqCtx, oReq, func(order *billing.OrderViewPublic) {
+		customerId := order.GetUser().GetId()
+
+		if customerId == "" || !authUser.IsProjectAllowed(order.GetProject().GetId()) {
+			return
+		}
+
+		date := time.Unix(order.GetTransactionDate().GetSeconds(), 0).UTC()
+		month := (date.Year()-from.Year())*12 + int(date.Month()) - int(from.Month())
+
+		if month < 0 || month >= req.Months {
+			return
+		}
+
+		customer, ok := customers[customerId]
+
+		if !ok {
+			customer = &cohortCustomer{first: month, revenue: make([]float64, req.Months)}
+			customers[customerId] = customer
+		}
+
+		if month < customer.first {
+			customer.first = month
+		}
+
+		customer.orders++
+		customer.revenue[month] += order.GetNetRevenue().GetAmount()
+		res.Currency = order.GetNetRevenue().GetCurrency()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	previous, err := h.getPreviousCustomers(reqCtx, authUser, merchant.Item.Id, from)
+
+	if err != nil {
+		return err
+	}
+
+	cohorts := make([]*cohort, req.Months)
+
+	for i := range cohorts {
+		cohorts[i] = &cohort{
+			Month:   from.AddDate(0, i, 0).Format(cohortMonthLayout),
+			Revenue: make([]float64, req.Months-i),
+		}
+	}
+
+	for customerId, customer := range customers {
+		if previous[customerId] {
+			res.ReturningCustomers++
+			continue
+		}
+
+		c := cohorts[customer.first]
+		c.Customers++
+
+		if customer.orders > 1 {
+			c.RepeatCustomers++
+		}
+
+		for month := customer.first; month < req.Months; month++ {
+			c.Revenue[month-customer.first] += customer.revenue[month]
+		}
+	}
+
+	for _, c := range cohorts {
+		if c.Customers > 0 {
+			c.RepeatRate = forecastRound(float64(c.RepeatCustomers) / float64(c.Customers))
+		}
+
+		for i := range c.Revenue {
+			if i > 0 {
+				c.Revenue[i] += c.Revenue[i-1]
+			}
+
+			c.Revenue[i] = money.Round(c.Revenue[i], res.Currency)
+		}
+
+		res.Cohorts = append(res.Cohorts, c)
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// @Description Products sold in the period (up to 366 days) with net revenue in merchant currency, units and refund rate.
+// @Description Units of orders created with cart are counted by quantity. Net revenue of order is split between its
+// @Description products proportionally to their prices multiplied by quantity.
+// @Description Sorted by revenue (default), units or refund_rate descending, format=csv returns csv file
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  'https://api.paysuper.online/admin/api/v1/analytics/products?date_from=1569888000&date_to=1572566399&sort=units'
+func (h *AnalyticsRoute) getProducts(ctx echo.Context) error {
+	req := &productsRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	maxPeriod := int64(h.cfg.AnalyticsProductsMaxPeriod / time.Second)
+
+	if maxPeriod > 0 && req.DateTo-req.DateFrom > maxPeriod {
+		rspErr := *common.ErrorMessageAnalyticsPeriodTooLong
+		rspErr.Details = fmt.Sprintf(
+			"set date_from and date_to not more than %d days apart",
+			int64(h.cfg.AnalyticsProductsMaxPeriod/(24*time.Hour)),
+		)
+
+		return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	authUser := common.ExtractUserContext(ctx)
+	reqCtx := ctx.Request().Context()
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	// the report reads all orders of the period, so it takes export slot of the merchant
+	slot, ok := h.exportSlots.Acquire(merchant.Item.Id, h.cfg.ExportSlotTtl)
+
+	if !ok {
+		return echo.NewHTTPError(http.StatusTooManyRequests, common.ErrorMessageTooManyRequests)
+	}
+
+	defer h.exportSlots.Release(merchant.Item.Id, slot)
+
+	res := &productsResponse{DateFrom: req.DateFrom, DateTo: req.DateTo, Items: []*productStat{}}
+	products := make(map[string]*productStat)
+
+	oReq := &grpc.ListOrdersRequest{
+		Merchant:   []string{merchant.Item.Id},
+		Status:     []string{riskOrderStatusProcessed, riskOrderStatusRefunded},
+		PmDateFrom: req.DateFrom,
+		PmDateTo:   req.DateTo,
+	}
+
+	// quantities of orders created with cart are requested for each page at once
+	err = h.eachOrdersPage(reqCtx, oReq, func(orders []*billing.OrderViewPublic) error {
+		ids := make([]string, len(orders))
+
+		for i, order := range orders {
+			ids[i] = order.GetUuid()
+		}
+
+		carts, err := h.carts.Get(ids)
+
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Item.Id))
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		for _, order := range orders {
+			if len(order.GetItems()) == 0 || !authUser.IsProjectAllowed(order.GetProject().GetId()) {
+				continue
+			}
+
+			items, quantities := getOrderItemQuantities(order, carts[order.GetUuid()])
+			total := float64(0)
+
+			for _, item := range items {
+				total += item.Amount * float64(quantities[item.Id])
+			}
+
+			for _, item := range items {
+				product, ok := products[item.Id]
+
+				if !ok {
+					product = &productStat{Id: item.Id, Sku: item.Sku, Name: item.Name}
+					products[item.Id] = product
+				}
+
+				if order.GetStatus() == riskOrderStatusRefunded {
+					product.RefundUnits += int(quantities[item.Id])
+					continue
+				}
+
+				product.Units += int(quantities[item.Id])
+
+				if total > 0 {
+					product.Revenue += order.GetNetRevenue().GetAmount() * item.Amount * float64(quantities[item.Id]) / total
+				}
+			}
+
+			if order.GetStatus() != riskOrderStatusRefunded {
+				res.Currency = order.GetNetRevenue().GetCurrency()
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		product.Revenue = money.Round(product.Revenue, res.Currency)
+
+		if sold := product.Units + product.RefundUnits; sold > 0 {
+			product.RefundRate = forecastRound(float64(product.RefundUnits) / float64(sold))
+		}
+
+		res.Items = append(res.Items, product)
+	}
+
+	sort.Slice(res.Items, func(i, j int) bool {
+		a, b := res.Items[i], res.Items[j]
+
+		switch req.Sort {
+		case productsSortUnits:
+			if a.Units != b.Units {
+				return a.Units > b.Units
+			}
+		case productsSortRefundRate:
+			if a.RefundRate != b.RefundRate {
+				return a.RefundRate > b.RefundRate
+			}
+		default:
+			if a.Revenue != b.Revenue {
+				return a.Revenue > b.Revenue
+			}
+		}
+
+		return a.Id < b.Id
+	})
+
+	if req.Format == productsFormatCsv {
+		return h.writeProductsCsv(ctx, res)
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// @Description Processed orders of the project in the period and their net revenue grouped by value of attribution
+// @Description parameter (dimension) passed on order create: utm_source, utm_medium, utm_campaign, ad_id or platform
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  'https://api.paysuper.online/admin/api/v1/analytics/attribution?project_id=%project_id_here%&dimension=utm_source&date_from=1569888000&date_to=1572566399'
+func (h *AnalyticsRoute) getAttribution(ctx echo.Context) error {
+	req := &attributionRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	project, err := getUserProject(ctx, h.dispatch, h.L(), req.ProjectId)
+
+	if err != nil {
+		return err
+	}
+
+	res := &attributionResponse{
+		Dimension: req.Dimension,
+		DateFrom:  req.DateFrom,
+		DateTo:    req.DateTo,
+		Items:     []*attributionStat{},
+	}
+	stats := make(map[string]*attributionStat)
+
+	oReq := &grpc.ListOrdersRequest{
+		Merchant:   []string{project.MerchantId},
+		Project:    []string{project.Id},
+		Status:     []string{riskOrderStatusProcessed},
+		PmDateFrom: req.DateFrom,
+		PmDateTo:   req.DateTo,
+	}
+
+	// attribution of orders is requested for each page at once
+	err = h.eachOrdersPage(ctx.Request().Context(), oReq, func(orders []*billing.OrderViewPublic) error {
+		ids := make([]string, len(orders))
+
+		for i, order := range orders {
+			ids[i] = order.GetUuid()
+		}
+
+		attrs, err := h.attributions.Get(ids)
+
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		for _, order := range orders {
+			value := attrs[order.GetUuid()][req.Dimension]
+			stat, ok := stats[value]
+
+			if !ok {
+				stat = &attributionStat{Value: value}
+				stats[value] = stat
+			}
+
+			stat.Orders++
+			stat.Revenue += order.GetNetRevenue().GetAmount()
+			res.Currency = order.GetNetRevenue().GetCurrency()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range stats {
+		stat.Revenue = money.Round(stat.Revenue, res.Currency)
+		res.Items = append(res.Items, stat)
+	}
+
+	sort.Slice(res.Items, func(i, j int) bool {
+		a, b := res.Items[i], res.Items[j]
+
+		if a.Revenue != b.Revenue {
+			return a.Revenue > b.Revenue
+		}
+
+		return a.Value < b.Value
+	})
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *AnalyticsRoute) writeProductsCsv(ctx echo.Context, res *productsResponse) error {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	rows := [][]string{{"id", "sku", "name", "revenue", "currency", "units", "refund_units", "refund_rate"}}
+
+	for _, item := range res.Items {
+		rows = append(rows, []string{
+			item.Id,
+			item.Sku,
+			item.Name,
+			money.Format(item.Revenue, res.Currency),
+			res.Currency,
+			strconv.Itoa(item.Units),
+			strconv.Itoa(item.RefundUnits),
+			strconv.FormatFloat(item.RefundRate, 'f', 2, 64),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename="+productsCsvFileName)
+
+	return ctx.Blob(http.StatusOK, common.MIMETextCsv, buf.Bytes())
+}
+
+// getOrderItemQuantities returns distinct products of the order and their quantities, quantity is the number
+// of the product occurrences in the order or its quantity in cart the order was created with when it's greater
+func getOrderItemQuantities(
+	order *billing.OrderViewPublic,
+	cart map[string]int32,
+) ([]*billing.OrderItem, map[string]int32) {
+	items := make([]*billing.OrderItem, 0, len(order.GetItems()))
+	quantities := make(map[string]int32, len(order.GetItems()))
+
+	for _, item := range order.GetItems() {
+		if _, ok := quantities[item.Id]; !ok {
+			items = append(items, item)
+		}
+
+		quantities[item.Id]++
+	}
+
+	for productId, quantity := range cart {
+		if val, ok := quantities[productId]; ok && quantity > val {
+			quantities[productId] = quantity
+		}
+	}
+
+	return items, quantities
+}
+
+// getHistories aggregates net revenue of processed payments by project and day. Orders are requested day by day,
+// so each of them is filtered and bucketed by the same payment date and is read with short offsets
+func (h *AnalyticsRoute) getHistories(
+	ctx context.Context,
+	merchantId, projectId string,
+	from time.Time,
+) (map[string]*forecastHistory, error) {
+	histories := make(map[string]*forecastHistory)
+
+	for day := 0; day < forecastHistoryDays; day++ {
+		revenues, err := h.getDailyRevenues(ctx, merchantId, from.AddDate(0, 0, day))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for id, revenue := range revenues {
+			if projectId != "" && id != projectId {
+				continue
+			}
+
+			history, ok := histories[id]
+
+			if !ok {
+				history = &forecastHistory{daily: make([]float64, forecastHistoryDays)}
+				histories[id] = history
+			}
+
+			history.daily[day] = revenue.Amount
+			history.currency = revenue.Currency
+		}
+	}
+
+	return histories, nil
+}
+
+// getDailyRevenues returns net revenue of processed payments paid in the day by projects of the merchant, revenue
+// of the day is kept in redis, failure of redis only makes orders of the day read again
+func (h *AnalyticsRoute) getDailyRevenues(
+	ctx context.Context,
+	merchantId string,
+	day time.Time,
+) (map[string]*common.DailyRevenue, error) {
+	revenues, err := h.dailyRevenues.Get(merchantId, day)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+	}
+
+	if revenues != nil {
+		return revenues, nil
+	}
+
+	revenues = make(map[string]*common.DailyRevenue)
+	req := &grpc.ListOrdersRequest{
+		Merchant:   []string{merchantId},
+		Status:     []string{riskOrderStatusProcessed},
+		PmDateFrom: day.Unix(),
+		PmDateTo:   day.AddDate(0, 0, 1).Unix() - 1,
+	}
+	err = h.eachOrder(ctx, req, func(order *billing.OrderViewPublic) {
+		id := order.GetProject().GetId()
+		revenue, ok := revenues[id]
+
+		if !ok {
+			revenue = &common.DailyRevenue{}
+			revenues[id] = revenue
+		}
+
+		revenue.Amount += order.GetNetRevenue().GetAmount()
+		revenue.Currency = order.GetNetRevenue().GetCurrency()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.dailyRevenues.Save(merchantId, day, revenues); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+	}
+
+	return revenues, nil
+}
+
+// getPreviousCustomers returns customers of projects allowed to the user who purchased in the months before
+// the month from, they aren't new customers of cohorts
+func (h *AnalyticsRoute) getPreviousCustomers(
+	ctx context.Context,
+	authUser *common.AuthUser,
+	merchantId string,
+	from time.Time,
+) (map[string]bool, error) {
+	previous := make(map[string]bool)
+
+	for i := 1; i <= h.cfg.AnalyticsCohortHistoryMonths; i++ {
+		customers, err := h.getMonthCustomers(ctx, merchantId, from.AddDate(0, -i, 0))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for projectId, ids := range customers {
+			if !authUser.IsProjectAllowed(projectId) {
+				continue
+			}
+
+			for _, id := range ids {
+				previous[id] = true
+			}
+		}
+	}
+
+	return previous, nil
+}
+
+// getMonthCustomers returns customers who purchased in the complete month by projects of the merchant, customers
+// of the month are kept in redis, failure of redis only makes orders of the month read again
+func (h *AnalyticsRoute) getMonthCustomers(
+	ctx context.Context,
+	merchantId string,
+	month time.Time,
+) (map[string][]string, error) {
+	key := month.Format(cohortMonthLayout)
+	customers, err := h.cohortCustomers.Get(merchantId, key)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+	}
+
+	if customers != nil {
+		return customers, nil
+	}
+
+	customers = make(map[string][]string)
+	seen := make(map[string]bool)
+	req := &grpc.ListOrdersRequest{
+		Merchant:   []string{merchantId},
+		Status:     []string{riskOrderStatusProcessed},
+		PmDateFrom: month.Unix(),
+		PmDateTo:   month.AddDate(0, 1, 0).Unix() - 1,
+	}
+	err = h.eachOrder(ctx, req, func(order *billing.OrderViewPublic) {
+		projectId := order.GetProject().GetId()
+		customerId := order.GetUser().GetId()
+
+		if customerId == "" || seen[projectId+customerId] {
+			return
+		}
+
+		seen[projectId+customerId] = true
+		customers[projectId] = append(customers[projectId], customerId)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.cohortCustomers.Save(merchantId, key, customers); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+	}
+
+	return customers, nil
+}
+
+// eachOrder calls fn for every order matched by request, reading all pages of orders by LimitMax
+func (h *AnalyticsRoute) eachOrder(
+	ctx context.Context,
+	req *grpc.ListOrdersRequest,
+	fn func(order *billing.OrderViewPublic),
+) error {
+	return h.eachOrdersPage(ctx, req, func(orders []*billing.OrderViewPublic) error {
+		for _, order := range orders {
+			fn(order)
+		}
+
+		return nil
+	})
+}
+
+// eachOrdersPage calls fn for every page of orders matched by request, error of fn stops reading
+func (h *AnalyticsRoute) eachOrdersPage(
+	ctx context.Context,
+	req *grpc.ListOrdersRequest,
+	fn func(orders []*billing.OrderViewPublic) error,
+) error {
+	req.Limit = h.cfg.LimitMax
+	req.Offset = 0
+
+	for {
+		res, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx, req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "FindAllOrdersPublic", req)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if res.Status != pkg.ResponseStatusOk {
+			return echo.NewHTTPError(int(res.Status), res.Message)
+		}
+
+		if err = fn(res.GetItem().GetItems()); err != nil {
+			return err
+		}
+
+		req.Offset += int32(len(res.GetItem().GetItems()))
+
+		if len(res.GetItem().GetItems()) == 0 || req.Offset >= res.GetItem().GetCount() {
+			return nil
+		}
+	}
+}
+
+// forecast predicts forecastHorizonDays starting from the day history ends at (the current day)
+func forecast(projectId string, history *forecastHistory, method string, start time.Time) *forecastProject {
+	res := &forecastProject{
+		ProjectId: projectId,
+		Currency:  history.currency,
+		Days:      make([]*forecastDay, 0, forecastHorizonDays),
+	}
+	variance := float64(0)
+
+	for i := 0; i < forecastHorizonDays; i++ {
+		samples := history.daily
+
+		// for seasonal naive method the samples are the same weekdays of history, history length is multiple of week
+		// so day i of horizon has the same weekday as days i, i+7, ... of history
+		if method == forecastMethodSeasonalNaive {
+			samples = make([]float64, 0, forecastHistoryDays/forecastDaysInWeek)
+
+			for j := i % forecastDaysInWeek; j < forecastHistoryDays; j += forecastDaysInWeek {
+				samples = append(samples, history.daily[j])
+			}
+		}
+
+		mean, deviation := forecastStats(samples)
+		day := &forecastDay{
+			Date:   start.AddDate(0, 0, i).Unix(),
+			Amount: forecastRound(mean),
+			Lower:  forecastRound(math.Max(0, mean-forecastConfidenceZ*deviation)),
+			Upper:  forecastRound(mean + forecastConfidenceZ*deviation),
+		}
+
+		res.Days = append(res.Days, day)
+		res.Total += mean
+		variance += deviation * deviation
+	}
+
+	// days are summed as independent values, so deviation of the total is square root of the sum of their variances
+	deviation := math.Sqrt(variance)
+	res.TotalLower = forecastRound(math.Max(0, res.Total-forecastConfidenceZ*deviation))
+	res.TotalUpper = forecastRound(res.Total + forecastConfidenceZ*deviation)
+	res.Total = forecastRound(res.Total)
+
+	return res
+}
+
+// forecastStats returns mean and sample standard deviation
+func forecastStats(samples []float64) (float64, float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+
+	sum := float64(0)
+
+	for _, v := range samples {
+		sum += v
+	}
+
+	mean := sum / float64(len(samples))
+
+	if len(samples) < 2 {
+		return mean, 0
+	}
+
+	variance := float64(0)
+
+	for _, v := range samples {
+		variance += (v - mean) * (v - mean)
+	}
+
+	return mean, math.Sqrt(variance / float64(len(samples)-1))
+}
+
+func forecastRound(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,582 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/globalsign/mgo/bson"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/micro/go-micro/client"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type AnalyticsTestSuite struct {
+	suite.Suite
+	router *AnalyticsRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Analytics(t *testing.T) {
+	suite.Run(t, new(AnalyticsTestSuite))
+}
+
+func (suite *AnalyticsTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewAnalyticsRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *AnalyticsTestSuite) TearDownTest() {}
+
+// getOrders returns order with amount 70 on every first weekday of the forecast history
+func (suite *AnalyticsTestSuite) getOrders(projectId string) []*billing.OrderViewPublic {
+	from := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -forecastHistoryDays)
+	orders := []*billing.OrderViewPublic{}
+
+	for day := 0; day < forecastHistoryDays; day += forecastDaysInWeek {
+		date, err := ptypes.TimestampProto(from.AddDate(0, 0, day).Add(time.Hour))
+		assert.NoError(suite.T(), err)
+
+		orders = append(orders, &billing.OrderViewPublic{
+			Uuid:            bson.NewObjectId().Hex(),
+			Project:         &billing.ProjectOrder{Id: projectId},
+			TransactionDate: date,
+			NetRevenue:      &billing.OrderViewMoney{Amount: 70, Currency: "USD"},
+		})
+	}
+
+	return orders
+}
+
+// getBillingMock returns billing server listing the orders, orders with transaction date are listed only when it's
+// in the requested payment date period
+func (suite *AnalyticsTestSuite) getBillingMock(orders []*billing.OrderViewPublic) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(func(_ context.Context, req *grpc.ListOrdersRequest, _ ...client.CallOption) *grpc.ListOrdersPublicResponse {
+			items := []*billing.OrderViewPublic{}
+
+			for _, order := range orders {
+				date := order.GetTransactionDate().GetSeconds()
+
+				if order.GetTransactionDate() == nil || (date >= req.PmDateFrom && date <= req.PmDateTo) {
+					items = append(items, order)
+				}
+			}
+
+			return &grpc.ListOrdersPublicResponse{
+				Status: pkg.ResponseStatusOk,
+				Item:   &grpc.ListOrdersPublicResponseItem{Count: int32(len(items)), Items: items},
+			}
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	return bill
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Forecast_SeasonalNaive_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getBillingMock(suite.getOrders(projectId))
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + analyticsForecastPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &forecastResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), forecastMethodSeasonalNaive, rsp.Method)
+	assert.Len(suite.T(), rsp.Projects, 1)
+
+	project := rsp.Projects[0]
+	assert.Equal(suite.T(), projectId, project.ProjectId)
+	assert.Equal(suite.T(), "USD", project.Currency)
+	assert.Len(suite.T(), project.Days, forecastHorizonDays)
+	assert.Equal(suite.T(), float64(70), project.Days[0].Amount)
+	assert.Equal(suite.T(), float64(70), project.Days[0].Upper)
+	assert.Equal(suite.T(), float64(0), project.Days[1].Amount)
+	assert.Equal(suite.T(), rsp.HistoryTo, project.Days[0].Date)
+	// horizon days 0, 7, 14, 21 and 28 repeat the first weekday of history
+	assert.Equal(suite.T(), float64(350), project.Total)
+	assert.Equal(suite.T(), float64(350), project.TotalUpper)
+
+	// orders are requested by days of history
+	bill.AssertNumberOfCalls(suite.T(), "FindAllOrdersPublic", forecastHistoryDays)
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.Merchant[0] == mock.OnboardingMerchantMock.Id && req.Status[0] == riskOrderStatusProcessed &&
+			req.PmDateFrom == rsp.HistoryFrom && req.PmDateTo == rsp.HistoryFrom+86399
+	}))
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Forecast_DailyRevenueKept_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getBillingMock(suite.getOrders(projectId))
+
+	for i := 0; i < 2; i++ {
+		res, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Path(common.AuthUserGroupPath + analyticsForecastPath).
+			Init(test.ReqInitJSON()).
+			Exec(suite.T())
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusOK, res.Code)
+	}
+
+	bill.AssertNumberOfCalls(suite.T(), "FindAllOrdersPublic", forecastHistoryDays)
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Forecast_MovingAverage_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	suite.getBillingMock(suite.getOrders(projectId))
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsForecastPath).
+		SetQueryParam("method", forecastMethodMovingAverage).
+		SetQueryParam("project_id", projectId).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &forecastResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), rsp.Projects, 1)
+
+	project := rsp.Projects[0]
+	assert.Equal(suite.T(), float64(10), project.Days[0].Amount)
+	assert.Equal(suite.T(), float64(10), project.Days[1].Amount)
+	assert.Equal(suite.T(), float64(0), project.Days[1].Lower)
+	assert.True(suite.T(), project.Days[1].Upper > project.Days[1].Amount)
+	assert.Equal(suite.T(), float64(300), project.Total)
+
+	// deviation of the total is square root of 30 daily variances, sum of daily upper bounds would be 1766.7
+	assert.Equal(suite.T(), 567.79, project.TotalUpper)
+	assert.Equal(suite.T(), 32.21, project.TotalLower)
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Forecast_NoOrders_Ok() {
+	suite.getBillingMock([]*billing.OrderViewPublic{})
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + analyticsForecastPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &forecastResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), rsp.Projects)
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Forecast_UnknownMethod() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsForecastPath).
+		SetQueryParam("method", "arima").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *AnalyticsTestSuite) getCohortOrder(customerId string, date time.Time, amount float64) *billing.OrderViewPublic {
+	ts, err := ptypes.TimestampProto(date)
+	assert.NoError(suite.T(), err)
+
+	return &billing.OrderViewPublic{
+		Uuid:            bson.NewObjectId().Hex(),
+		Project:         &billing.ProjectOrder{Id: bson.NewObjectId().Hex()},
+		User:            &billing.OrderUser{Id: customerId},
+		TransactionDate: ts,
+		NetRevenue:      &billing.OrderViewMoney{Amount: amount, Currency: "USD"},
+	}
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Cohorts_Ok() {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	previous := current.AddDate(0, -1, 0)
+	repeating := bson.NewObjectId().Hex()
+	single := bson.NewObjectId().Hex()
+
+	bill := suite.getBillingMock([]*billing.OrderViewPublic{
+		suite.getCohortOrder(repeating, current.Add(time.Minute), 5),
+		suite.getCohortOrder(repeating, previous.Add(time.Hour), 10),
+		suite.getCohortOrder(single, previous.Add(2*time.Hour), 20),
+		suite.getCohortOrder(bson.NewObjectId().Hex(), current.Add(time.Minute), 7),
+	})
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsCohortsPath).
+		SetQueryParam("months", "2").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &cohortsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), previous.Unix(), rsp.From)
+	assert.Equal(suite.T(), "USD", rsp.Currency)
+	assert.Len(suite.T(), rsp.Cohorts, 2)
+
+	first := rsp.Cohorts[0]
+	assert.Equal(suite.T(), previous.Format(cohortMonthLayout), first.Month)
+	assert.Equal(suite.T(), 2, first.Customers)
+	assert.Equal(suite.T(), 1, first.RepeatCustomers)
+	assert.Equal(suite.T(), 0.5, first.RepeatRate)
+	assert.Equal(suite.T(), []float64{30, 35}, first.Revenue)
+
+	second := rsp.Cohorts[1]
+	assert.Equal(suite.T(), 1, second.Customers)
+	assert.Equal(suite.T(), 0, second.RepeatCustomers)
+	assert.Equal(suite.T(), []float64{7}, second.Revenue)
+
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.PmDateFrom == previous.Unix() && req.Status[0] == riskOrderStatusProcessed
+	}))
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Cohorts_ReturningCustomer_Ok() {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	returning := bson.NewObjectId().Hex()
+	suite.router.cfg.AnalyticsCohortHistoryMonths = 3
+
+	bill := suite.getBillingMock([]*billing.OrderViewPublic{
+		suite.getCohortOrder(returning, current.AddDate(0, -3, 0).Add(time.Hour), 50),
+		suite.getCohortOrder(returning, current.Add(time.Minute), 5),
+		suite.getCohortOrder(bson.NewObjectId().Hex(), current.Add(time.Minute), 7),
+	})
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsCohortsPath).
+		SetQueryParam("months", "1").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &cohortsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, rsp.ReturningCustomers)
+	assert.Len(suite.T(), rsp.Cohorts, 1)
+	assert.Equal(suite.T(), 1, rsp.Cohorts[0].Customers)
+	assert.Equal(suite.T(), []float64{7}, rsp.Cohorts[0].Revenue)
+
+	// purchases are checked in complete months before the period
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		month := current.AddDate(0, -3, 0)
+		return req.PmDateFrom == month.Unix() && req.PmDateTo == month.AddDate(0, 1, 0).Unix()-1
+	}))
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Cohorts_MonthsLimitExceeded() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsCohortsPath).
+		SetQueryParam("months", "13").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *AnalyticsTestSuite) getProductsOrders() []*billing.OrderViewPublic {
+	sword := &billing.OrderItem{Id: "sword", Sku: "SWORD", Name: "Sword", Amount: 30}
+	shield := &billing.OrderItem{Id: "shield", Sku: "SHIELD", Name: "Shield", Amount: 10}
+
+	return []*billing.OrderViewPublic{
+		{
+			Status:     riskOrderStatusProcessed,
+			Items:      []*billing.OrderItem{sword, shield, shield},
+			NetRevenue: &billing.OrderViewMoney{Amount: 25, Currency: "USD"},
+		},
+		{
+			Status:     riskOrderStatusProcessed,
+			Items:      []*billing.OrderItem{sword},
+			NetRevenue: &billing.OrderViewMoney{Amount: 15, Currency: "USD"},
+		},
+		{
+			Status:     riskOrderStatusRefunded,
+			Items:      []*billing.OrderItem{shield},
+			NetRevenue: &billing.OrderViewMoney{Amount: 5, Currency: "USD"},
+		},
+	}
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Products_Ok() {
+	bill := suite.getBillingMock(suite.getProductsOrders())
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsProductsPath).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &productsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "USD", rsp.Currency)
+	assert.Len(suite.T(), rsp.Items, 2)
+
+	// revenue 25 of the first order is split 30:10:10 between sword and two shields
+	assert.Equal(suite.T(), "sword", rsp.Items[0].Id)
+	assert.Equal(suite.T(), float64(30), rsp.Items[0].Revenue)
+	assert.Equal(suite.T(), 2, rsp.Items[0].Units)
+	assert.Equal(suite.T(), float64(0), rsp.Items[0].RefundRate)
+
+	assert.Equal(suite.T(), "shield", rsp.Items[1].Id)
+	assert.Equal(suite.T(), float64(10), rsp.Items[1].Revenue)
+	assert.Equal(suite.T(), 2, rsp.Items[1].Units)
+	assert.Equal(suite.T(), 1, rsp.Items[1].RefundUnits)
+	assert.Equal(suite.T(), 0.33, rsp.Items[1].RefundRate)
+
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.PmDateFrom == 1569888000 && req.PmDateTo == 1572566399 && len(req.Status) == 2
+	}))
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Products_SortByRefundRate_Csv_Ok() {
+	suite.getBillingMock(suite.getProductsOrders())
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsProductsPath).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399").
+		SetQueryParam("sort", productsSortRefundRate).
+		SetQueryParam("format", productsFormatCsv).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), common.MIMETextCsv, res.Header().Get(echo.HeaderContentType))
+
+	expected := "id,sku,name,revenue,currency,units,refund_units,refund_rate\n" +
+		"shield,SHIELD,Shield,10.00,USD,2,1,0.33\n" +
+		"sword,SWORD,Sword,30.00,USD,2,0,0.00\n"
+	assert.Equal(suite.T(), expected, res.Body.String())
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Products_CartQuantity_Ok() {
+	sword := &billing.OrderItem{Id: "sword", Sku: "SWORD", Name: "Sword", Amount: 30}
+	shield := &billing.OrderItem{Id: "shield", Sku: "SHIELD", Name: "Shield", Amount: 10}
+	order := &billing.OrderViewPublic{
+		Uuid:       uuid.New().String(),
+		Status:     riskOrderStatusProcessed,
+		Items:      []*billing.OrderItem{sword, shield},
+		NetRevenue: &billing.OrderViewMoney{Amount: 50, Currency: "USD"},
+	}
+	suite.getBillingMock([]*billing.OrderViewPublic{order})
+
+	err := suite.router.carts.Record(order.Uuid, map[string]int32{"sword": 1, "shield": 2})
+	assert.NoError(suite.T(), err)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsProductsPath).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &productsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), rsp.Items, 2)
+
+	// revenue 50 is split 30:20 between sword and two shields
+	assert.Equal(suite.T(), "sword", rsp.Items[0].Id)
+	assert.Equal(suite.T(), float64(30), rsp.Items[0].Revenue)
+	assert.Equal(suite.T(), 1, rsp.Items[0].Units)
+	assert.Equal(suite.T(), "shield", rsp.Items[1].Id)
+	assert.Equal(suite.T(), float64(20), rsp.Items[1].Revenue)
+	assert.Equal(suite.T(), 2, rsp.Items[1].Units)
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Products_ConcurrencyLimit_Error() {
+	bill := suite.getBillingMock(suite.getProductsOrders())
+	suite.router.exportSlots = common.NewExportSlots(test.Redis(), 1)
+
+	_, ok := suite.router.exportSlots.Acquire(mock.OnboardingMerchantMock.Id, time.Minute)
+	assert.True(suite.T(), ok)
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsProductsPath).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, httpErr.Code)
+	bill.AssertNotCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.Anything)
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Products_PeriodTooLong() {
+	suite.router.cfg.AnalyticsProductsMaxPeriod = 366 * 24 * time.Hour
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsProductsPath).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1640995200").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageAnalyticsPeriodTooLong.Code, msg.Code)
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Products_PeriodRequired() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + analyticsProductsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Attribution_Ok() {
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), MerchantId: mock.OnboardingMerchantMock.Id}
+	orders := suite.getOrders(project.Id)
+	attributions := common.NewOrderAttributions(test.Redis(), time.Hour)
+
+	for _, order := range orders[:3] {
+		attr := map[string]string{common.RequestParameterUtmSource: "google"}
+		assert.NoError(suite.T(), attributions.Record(project.Id, order.Uuid, attr, time.Now()))
+	}
+
+	bill := suite.getBillingMock(orders)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}, nil)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsAttributionPath).
+		SetQueryParam("project_id", project.Id).
+		SetQueryParam("dimension", common.RequestParameterUtmSource).
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &attributionResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), rsp))
+	assert.Equal(suite.T(), "USD", rsp.Currency)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), "google", rsp.Items[0].Value)
+	assert.Equal(suite.T(), 3, rsp.Items[0].Orders)
+	assert.Equal(suite.T(), float64(210), rsp.Items[0].Revenue)
+	assert.Equal(suite.T(), "", rsp.Items[1].Value)
+	assert.Equal(suite.T(), 1, rsp.Items[1].Orders)
+
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return len(req.Project) == 1 && req.Project[0] == project.Id
+	}))
+}
+
+func (suite *AnalyticsTestSuite) TestAnalytics_Attribution_UnknownDimension() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+analyticsAttributionPath).
+		SetQueryParam("project_id", bson.NewObjectId().Hex()).
+		SetQueryParam("dimension", "country").
+		SetQueryParam("date_from", "1569888000").
+		SetQueryParam("date_to", "1572566399").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
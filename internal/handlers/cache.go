@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+)
+
+const (
+	cacheMetricsPath    = "/cache/metrics"
+	cacheInvalidatePath = "/cache/invalidate"
+)
+
+type cacheInvalidateRequest struct {
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=countries price_groups price_tables payment_costs payment_methods"`
+}
+
+type CacheRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	cache    *common.RouteCache
+	provider.LMT
+}
+
+// NewCacheRoute
+func NewCacheRoute(set common.HandlerSet, cfg *common.Config) *CacheRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "CacheRoute"})
+	return &CacheRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *CacheRoute) Route(groups *common.Groups) {
+	h.cache = groups.RouteCache
+	groups.System.GET(cacheMetricsPath, echo.WrapHandler(h.cache.Metrics()))
+	groups.System.POST(cacheInvalidatePath, h.invalidate)
+}
+
+// @Description Drop cached responses of routes invalidated by the events on all instances, e.g. after countries
+// @Description or price groups are changed in billing server directly
+// @Example curl -X POST -H 'Authorization: Bearer %system_api_token_here%' -H 'Content-Type: application/json' \
+//  -d '{"events": ["countries", "price_groups"]}' \
+//  https://api.paysuper.online/system/api/v1/cache/invalidate
+func (h *CacheRoute) invalidate(ctx echo.Context) error {
+	req := &cacheInvalidateRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	if err := h.cache.Flush(req.Events...); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "events", req.Events))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
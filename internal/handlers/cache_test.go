@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type CacheTestSuite struct {
+	suite.Suite
+	router  *CacheRoute
+	country *CountryApiV1
+	caller  *test.EchoReqResCaller
+}
+
+func Test_Cache(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
+
+func (suite *CacheTestSuite) SetupTest() {
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		suite.router = NewCacheRoute(set.HandlerSet, set.GlobalConfig)
+		suite.country = NewCountryApiV1(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+			suite.country,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *CacheTestSuite) TearDownTest() {}
+
+func (suite *CacheTestSuite) getCountries() string {
+	return suite.getCountriesOfVersion("")
+}
+
+func (suite *CacheTestSuite) getCountriesOfVersion(version string) string {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath + "/country").
+		Init(func(request *http.Request, middleware test.Middleware) {
+			if version != "" {
+				request.Header.Set(common.HeaderXApiVersion, version)
+			}
+		}).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	return res.Header().Get(common.HeaderCache)
+}
+
+func (suite *CacheTestSuite) TestCache_Countries_Ok() {
+	bill := mock.NewBillingServerBuilder().
+		Method("GetCountriesList").Always(&billing.CountriesList{}).
+		Build()
+	suite.country.dispatch.Services.Billing = bill
+
+	assert.Equal(suite.T(), common.RouteCacheMiss, suite.getCountries())
+	assert.Equal(suite.T(), common.RouteCacheHit, suite.getCountries())
+	bill.AssertNumberOfCalls(suite.T(), "GetCountriesList", 1)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + cacheInvalidatePath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"events": ["countries"]}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusNoContent, res.Code)
+
+	assert.Equal(suite.T(), common.RouteCacheMiss, suite.getCountries())
+	bill.AssertNumberOfCalls(suite.T(), "GetCountriesList", 2)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.SystemGroupPath + cacheMetricsPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Contains(suite.T(), res.Body.String(), `management_api_route_cache_requests_total{result="HIT",route="/api/v1/country"} 1`)
+	assert.Contains(suite.T(), res.Body.String(), `management_api_route_cache_invalidations_total{event="countries"} 1`)
+}
+
+func (suite *CacheTestSuite) TestCache_Countries_FlushedByOtherInstance() {
+	bill := mock.NewBillingServerBuilder().
+		Method("GetCountriesList").Always(&billing.CountriesList{}).
+		Build()
+	suite.country.dispatch.Services.Billing = bill
+
+	assert.Equal(suite.T(), common.RouteCacheMiss, suite.getCountries())
+	assert.Equal(suite.T(), common.RouteCacheHit, suite.getCountries())
+
+	// responses and invalidations are shared by instances through redis
+	assert.NoError(suite.T(), common.NewRouteCache(test.Redis()).Flush(common.RouteCacheEventCountries))
+
+	assert.Equal(suite.T(), common.RouteCacheMiss, suite.getCountries())
+	bill.AssertNumberOfCalls(suite.T(), "GetCountriesList", 2)
+}
+
+func (suite *CacheTestSuite) TestCache_Countries_ApiVersion() {
+	bill := mock.NewBillingServerBuilder().
+		Method("GetCountriesList").Always(&billing.CountriesList{}).
+		Build()
+	suite.country.dispatch.Services.Billing = bill
+
+	assert.Equal(suite.T(), common.RouteCacheMiss, suite.getCountries())
+	assert.Equal(suite.T(), common.RouteCacheHit, suite.getCountries())
+	assert.Equal(suite.T(), common.RouteCacheMiss, suite.getCountriesOfVersion("2"))
+	assert.Equal(suite.T(), common.RouteCacheHit, suite.getCountriesOfVersion("2"))
+	bill.AssertNumberOfCalls(suite.T(), "GetCountriesList", 2)
+}
+
+func (suite *CacheTestSuite) TestCache_Invalidate_EventUnknown() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + cacheInvalidatePath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"events": ["orders"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
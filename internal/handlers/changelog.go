@@ -0,0 +1,608 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+)
+
+const (
+	changelogPath = "/changelog"
+)
+
+const (
+	changelogTypeAdded      = "added"
+	changelogTypeChanged    = "changed"
+	changelogTypeDeprecated = "deprecated"
+	changelogTypeRemoved    = "removed"
+
+	changelogUnreleased = "unreleased"
+)
+
+type changelogRequest struct {
+	Type string `query:"type" validate:"omitempty,oneof=added changed deprecated removed"`
+}
+
+type changelogEntry struct {
+	Type        string `json:"type"`
+	Method      string `json:"method,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Description string `json:"description"`
+}
+
+type changelogRelease struct {
+	Version string            `json:"version"`
+	Date    string            `json:"date,omitempty"`
+	Changes []*changelogEntry `json:"changes"`
+}
+
+// changelog is maintained together with the code, newest release first. Changes go to the unreleased section
+// in the same commit as the change itself, the section gets version and date when the release is tagged
+var changelog = []*changelogRelease{
+	{
+		Version: changelogUnreleased,
+		Changes: []*changelogEntry{
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + projectsEmailScreeningPath,
+				Description: "Email screening policy is returned with 503 while the policies are unavailable, payer emails are screened in block mode until they're restored",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + embedTokensPath,
+				Description: "Token restricted to projects issues embed token of its projects only, projects are required and revenue widget isn't allowed",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPatch,
+				Path:        common.AuthUserGroupPath + projectsIdPath,
+				Description: "Project status can't be changed by update, it's changed by submit for review and operator approval only",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPut,
+				Path:        common.AuthUserGroupPath + productsIdPath,
+				Description: "Archive mark is kept in _archived metadata field of the product, the field is reserved and can't be changed by update",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + orderPath,
+				Description: "Virtual currency amount of the order is limited, the limit is returned by limits endpoint",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + orderRefundsPath,
+				Description: "Refund exceeding auto-approve threshold of the project is held for approval of support and returned with 202, refunds are rejected with 503 while refund policies are unavailable",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + refundsApprovalsIdPath,
+				Description: "Refund held for approval of support, available for creator of the refund",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.SystemGroupPath + refundsApprovalsPath,
+				Description: "Refunds waiting for approval of support, the oldest first",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.SystemGroupPath + refundsApprovePath,
+				Description: "Approve refund held for approval, the refund is created, requires personal operator token",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.SystemGroupPath + refundsRejectPath,
+				Description: "Reject refund held for approval, requires personal operator token",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.SystemGroupPath + projectsPoliciesInitPath,
+				Description: "Initialize policies of projects on the first deploy or after they're lost, features enforcing them are suspended until then",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.SystemGroupPath + projectsApprovePath,
+				Description: "Approve project on go-live review for production, requires personal operator token",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.SystemGroupPath + projectsRejectPath,
+				Description: "Reject project on go-live review, the project returns to test failed status, requires personal operator token",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPut,
+				Path:        common.AuthUserGroupPath + paymentCostsChannelMerchantIdsPath,
+				Description: "Updates payment channel merchant cost, before the request was handled by money back merchant cost registered on the same path",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + merchantsPath,
+				Description: "Sort accepts _id and up to 5 sort[] fields, is_signed=false lists unsigned merchants, status[], country[], last_activity_from and last_activity_to filters are added, malformed ranges and quick_search longer than 255 characters are rejected",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + orderPath,
+				Description: "Form encoded order create is accepted again, products cart, metadata, virtual currency amount and platform are read from json requests only, utm attribution of form requests is read from form fields",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPut,
+				Path:        common.SystemGroupPath + subdivisionsPath,
+				Description: "Replace subdivisions of the country on all instances of the api",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodDelete,
+				Path:        common.SystemGroupPath + subdivisionsPath,
+				Description: "Serve subdivisions of the country from ISO 3166-2 catalog again",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + orderEmailScreeningPath,
+				Description: "Payer email screening verdict of the order",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + projectsEmailScreeningPath,
+				Description: "Payer email screening policy of the project",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPut,
+				Path:        common.AuthUserGroupPath + projectsEmailScreeningPath,
+				Description: "Set payer email screening mode and mx lookup of the project",
+			},
+			{
+				Type:        changelogTypeDeprecated,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + balanceMerchantPath,
+				Description: "Use GET /admin/api/v1/balance, balance of merchant of authenticated user is returned",
+			},
+			{
+				Type:        changelogTypeDeprecated,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + productsMerchantPath,
+				Description: "Use GET /admin/api/v1/products, products of merchant of authenticated user are listed",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPut,
+				Path:        common.AuthUserGroupPath + paymentCostsMoneyBackMerchantIdsPath,
+				Description: "Money back merchant cost is updated by its own path, before it was registered on PUT /payment_costs/channel/merchant/:merchant_id/:rate_id and shadowed by payment channel merchant cost",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.SystemGroupPath + kybMerchantDecisionsPath,
+				Description: "KYB decision log of the merchant for operators",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + productsUnarchivePath,
+				Description: "Product is enabled on unarchive only if it was enabled before archive",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + projectsIntegrationTestPath,
+				Description: "Checks contain pass or fail result only, status_code, latency_ms and errors are removed. Urls resolving to private or loopback addresses fail, redirects are not followed",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + royaltyReportsTransactionsPath,
+				Description: "Transactions contain platform of key products selected on order create",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + analyticsAttributionPath,
+				Description: "Processed orders and net revenue of the project grouped by attribution parameter or platform",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + orderPath,
+				Description: "Orders of one project can be filtered by utm_source, utm_medium, utm_campaign, ad_id and platform",
+			},
+			{
+				Type:        changelogTypeRemoved,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + riskOverviewMerchantPath,
+				Description: "Risk overview of other merchants is available in system api only",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.SystemGroupPath + riskOverviewMerchantPath,
+				Description: "Get risk overview of any merchant, the overview never changes merchant status",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.SystemGroupPath + riskReviewMerchantPath,
+				Description: "Move merchant exceeding chargeback or refund ratio threshold to risk review status, requires personal operator token",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + projectsRefundPolicyPath,
+				Description: "Get refund policy of the project",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPut,
+				Path:        common.AuthUserGroupPath + projectsRefundPolicyPath,
+				Description: "Set refund policy of the project: refund period, refunds count per order and auto-approve threshold of refund amount",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + orderRefundsPath,
+				Description: "Refund is checked by refund policy of the order project, concurrent refund of the same order is rejected with 409",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + paymentTokenizePath,
+				Description: "Exchange payment card data for token of the card vault configured for payment method, available in the cardholder data environment deployment only",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthProjectGroupPath + pricingRecommendedTablePath,
+				Description: "Countries, price groups, price tables, payment method settings and payment costs matrices are cached up to 1 hour per api version, X-Cache header tells whether response is served from cache",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + projectsIssuesPath,
+				Description: "Spikes of failed order creations after project configuration changes, merchant is notified on detection",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + merchantsCompanyLookupPath,
+				Description: "Look up company in registry by registration number to pre-fill merchant company on onboarding",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + orderBillingAddressPath,
+				Description: "Zip is validated by postal code format of the country, error ma000073 is returned for invalid zip",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthProjectGroupPath + countrySubdivisionsPath,
+				Description: "ISO 3166-2 subdivisions of the country with names by locale",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + requestsPath,
+				Description: "Search requests of the merchant to admin api and to create orders, payments and tokens of its projects for the last 24 hours by endpoint, status, error code and request id",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + orderExportPath,
+				Description: "Streamed csv or ndjson export of orders with total in header X-Total-Count and trailer X-Export-Status, resumable after the last received order with parameter after",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + projectsIntegrationReadinessPath,
+				Description: "Project go-live readiness score by flows exercised with test keys: by the last integration test and orders made before approval of the project for production",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + projectsIntegrationTestPath,
+				Description: "Integration test report includes tested_at time",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + countriesBatchPath,
+				Description: "Countries by list of codes in one request",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + currenciesBatchPath,
+				Description: "Currencies by list of codes in one request with minor unit, rounding rule and countries of each currency",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + productsPath,
+				Description: "Listing accepts ids filter with comma separated product identifiers",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + projectsPath,
+				Description: "Listing accepts ids filter with comma separated project identifiers",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + orderPath,
+				Description: "User phone is normalized to E.164 format, national number is read in the country of user address, input which isn't a phone number is rejected with field name in error details",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        orderCreatePath,
+				Description: "PP_PAYER_PHONE is normalized to E.164 format, national number is read in PP_REGION, input which isn't a phone number is rejected with field name in error details",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPut,
+				Path:        common.AuthUserGroupPath + merchantsContactsPath,
+				Description: "Contact phones with international prefix are normalized to E.164 format, input which isn't a phone number is rejected with field name in error details",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + orderPath,
+				Description: "Search by account or text requires a limited payment or project date period, also with project filter",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + reportFilePath,
+				Description: "Number of exports a merchant runs at once is limited, a report file holds its slot while it is generated",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + analyticsProductsPath,
+				Description: "Revenue, units and refund rate per product for the period, json or csv",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + analyticsCohortsPath,
+				Description: "Customer cohorts by month of first purchase with repeat rate and cumulative revenue",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + analyticsForecastPath,
+				Description: "Net revenue forecast per project for the next 30 days with confidence bounds",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + paymentPath,
+				Description: "Payer email may be rejected by screening of the project with verdict in error details",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthProjectGroupPath + changelogPath,
+				Description: "Machine-readable list of API changes",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + refundsBulkPath,
+				Description: "Bulk refund by json items or csv body processed in background, requires Idempotency-Key header",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + refundsBulkIdPath,
+				Description: "Progress of bulk refund with per-row results",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + embedTokensPath,
+				Description: "Short-lived tokens for embedded read-only dashboard widgets",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthProjectGroupPath + embedWidgetRevenuePath,
+				Description: "Revenue widget data, accepts embed token only",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthProjectGroupPath + embedWidgetRecentOrdersPath,
+				Description: "Recent orders widget data, accepts embed token only",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + offboardingPath,
+				Description: "Merchant account closure request",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + offboardingPath,
+				Description: "Merchant account closure status and blockers",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + orderPath,
+				Description: "Json order create accepts metadata object with string values",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + adjustmentsReportPath,
+				Description: "Refunds, chargebacks, their fees and royalty report corrections of the period with royalty report references",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + sandboxSeedPath,
+				Description: "Sample projects, products and orders for merchant test environment",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Description: "Deprecated routes respond with Deprecation, Sunset and Link headers",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Description: "X-API-VERSION request header, version 2 wraps listings and errors into data, meta and errors envelope",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + limitsPath,
+				Description: "Effective payment limits of the merchant projects",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + kybMerchantPath,
+				Description: "Submit merchant onboarding data to KYB compliance provider",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + kybMerchantDecisionsPath,
+				Description: "KYB decision log of the merchant",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + projectsGoLiveChecklistPath,
+				Description: "Project go-live checklist",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + projectsSubmitForReviewPath,
+				Description: "Submit project for go-live review, integration readiness score of the project is a checklist item",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + productsDuplicatePath,
+				Description: "Duplicate product",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + productsArchivePath,
+				Description: "Archive product, archived products are hidden from listing by default",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + projectsIntegrationTestPath,
+				Description: "Test calls of project urls from the integration console",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthProjectGroupPath + orderPath,
+				Description: "Json order create accepts products cart, virtual currency amount, platform and utm attribution",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + feeCalculatorPath,
+				Description: "Preview of VAT, payment method and payment system fees for the payment",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthUserGroupPath + riskOverviewPath,
+				Description: "Chargeback and refund ratios of the merchant for the rolling period",
+			},
+			{
+				Type:        changelogTypeChanged,
+				Method:      http.MethodPost,
+				Path:        common.AuthUserGroupPath + orderRefundsPath,
+				Description: "Refunds count per order may be limited",
+			},
+			{
+				Type:        changelogTypeAdded,
+				Method:      http.MethodGet,
+				Path:        common.AuthProjectGroupPath + receiptPath,
+				Description: "Public receipt lookup, rate limited per client ip",
+			},
+		},
+	},
+}
+
+type ChangelogRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+}
+
+// NewChangelogRoute
+func NewChangelogRoute(set common.HandlerSet, cfg *common.Config) *ChangelogRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "ChangelogRoute"})
+	return &ChangelogRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *ChangelogRoute) Route(groups *common.Groups) {
+	groups.AuthProject.GET(changelogPath, h.getChangelog)
+}
+
+// @Description List of API changes by releases, newest first, optionally filtered by type of change
+// @Example curl -X GET 'https://api.paysuper.online/api/v1/changelog?type=deprecated'
+func (h *ChangelogRoute) getChangelog(ctx echo.Context) error {
+	req := &changelogRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	releases := make([]*changelogRelease, 0, len(changelog))
+
+	for _, release := range changelog {
+		item := &changelogRelease{Version: release.Version, Date: release.Date, Changes: []*changelogEntry{}}
+
+		for _, change := range release.Changes {
+			if req.Type == "" || change.Type == req.Type {
+				item.Changes = append(item.Changes, change)
+			}
+		}
+
+		if len(item.Changes) > 0 {
+			releases = append(releases, item)
+		}
+	}
+
+	meta := &common.EnvelopeMeta{Count: int32(len(releases))}
+	return common.ListResponse(ctx, releases, releases, meta)
+}
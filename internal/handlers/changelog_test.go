@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type ChangelogTestSuite struct {
+	suite.Suite
+	router *ChangelogRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Changelog(t *testing.T) {
+	suite.Run(t, new(ChangelogTestSuite))
+}
+
+func (suite *ChangelogTestSuite) SetupTest() {
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		suite.router = NewChangelogRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *ChangelogTestSuite) TearDownTest() {}
+
+func (suite *ChangelogTestSuite) TestChangelog_Ok() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath + changelogPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	var releases []*changelogRelease
+	err = json.Unmarshal(res.Body.Bytes(), &releases)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), releases, len(changelog))
+	assert.Equal(suite.T(), changelogUnreleased, releases[0].Version)
+	assert.NotEmpty(suite.T(), releases[0].Changes)
+}
+
+func (suite *ChangelogTestSuite) TestChangelog_FilterByType_Ok() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath + changelogPath).
+		SetQueryParam("type", changelogTypeChanged).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	var releases []*changelogRelease
+	err = json.Unmarshal(res.Body.Bytes(), &releases)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), releases)
+
+	for _, release := range releases {
+		for _, change := range release.Changes {
+			assert.Equal(suite.T(), changelogTypeChanged, change.Type)
+		}
+	}
+}
+
+func (suite *ChangelogTestSuite) TestChangelog_UnknownType() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath + changelogPath).
+		SetQueryParam("type", "fixed").
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
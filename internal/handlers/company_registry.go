@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/registry"
+	"net/http"
+	"strings"
+)
+
+const (
+	merchantsCompanyLookupPath = "/merchants/company/lookup"
+)
+
+type companyLookupRequest struct {
+	Country            string `query:"country" validate:"required,len=2"`
+	RegistrationNumber string `query:"registration_number" validate:"required,max=255"`
+}
+
+type CompanyRegistryRoute struct {
+	dispatch        common.HandlerSet
+	cfg             common.Config
+	companyRegistry registry.Provider
+	provider.LMT
+}
+
+// NewCompanyRegistryRoute
+func NewCompanyRegistryRoute(set common.HandlerSet, companyRegistry registry.Provider, cfg *common.Config) *CompanyRegistryRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "CompanyRegistryRoute"})
+	return &CompanyRegistryRoute{
+		dispatch:        set,
+		LMT:             &set.AwareSet,
+		cfg:             *cfg,
+		companyRegistry: companyRegistry,
+	}
+}
+
+func (h *CompanyRegistryRoute) Route(groups *common.Groups) {
+	groups.AuthUser.GET(merchantsCompanyLookupPath, h.lookupCompany)
+}
+
+// @Description Look up the company in registry by registration number to pre-fill merchant company on onboarding,
+// @Description the result has format of company info accepted by PUT /admin/api/v1/merchants/company
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/merchants/company/lookup?country=GB&registration_number=01234567
+func (h *CompanyRegistryRoute) lookupCompany(ctx echo.Context) error {
+	req := &companyLookupRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	req.Country = strings.ToUpper(req.Country)
+	req.RegistrationNumber = strings.TrimSpace(req.RegistrationNumber)
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	company, err := h.companyRegistry.Lookup(ctx.Request().Context(), req.Country, req.RegistrationNumber)
+
+	if err == registry.ErrorProviderNotConfigured {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageCompanyRegistryNotConfigured)
+	}
+
+	if err == registry.ErrorCompanyNotFound {
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageCompanyRegistryNotFound)
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return echo.NewHTTPError(http.StatusBadGateway, common.ErrorMessageCompanyRegistryLookupFailed)
+	}
+
+	res := &billing.MerchantCompanyInfo{
+		Name:               company.Name,
+		AlternativeName:    company.AlternativeName,
+		Website:            company.Website,
+		Country:            req.Country,
+		State:              company.State,
+		Zip:                company.Zip,
+		City:               company.City,
+		Address:            company.Address,
+		RegistrationNumber: req.RegistrationNumber,
+	}
+
+	if company.RegistrationNumber != "" {
+		res.RegistrationNumber = company.RegistrationNumber
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/registry"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type companyRegistryMock struct {
+	country            string
+	registrationNumber string
+	company            *registry.Company
+	err                error
+}
+
+func (p *companyRegistryMock) Lookup(ctx context.Context, country, registrationNumber string) (*registry.Company, error) {
+	p.country = country
+	p.registrationNumber = registrationNumber
+	return p.company, p.err
+}
+
+type CompanyRegistryTestSuite struct {
+	suite.Suite
+	router   *CompanyRegistryRoute
+	caller   *test.EchoReqResCaller
+	provider *companyRegistryMock
+}
+
+func Test_CompanyRegistry(t *testing.T) {
+	suite.Run(t, new(CompanyRegistryTestSuite))
+}
+
+func (suite *CompanyRegistryTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.provider = &companyRegistryMock{}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewCompanyRegistryRoute(set.HandlerSet, suite.provider, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *CompanyRegistryTestSuite) TearDownTest() {}
+
+func (suite *CompanyRegistryTestSuite) lookup(country, number string) (*echo.HTTPError, []byte) {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+merchantsCompanyLookupPath).
+		SetQueryParam("country", country).
+		SetQueryParam("registration_number", number).
+		Exec(suite.T())
+
+	if err != nil {
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		return httpErr, nil
+	}
+
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	return nil, res.Body.Bytes()
+}
+
+func (suite *CompanyRegistryTestSuite) TestCompanyRegistry_Lookup_Ok() {
+	suite.provider.company = &registry.Company{
+		Name:    "Company Ltd",
+		City:    "London",
+		Zip:     "EC1A 1BB",
+		Address: "1 Street",
+	}
+
+	httpErr, body := suite.lookup("gb", " 01234567 ")
+	assert.Nil(suite.T(), httpErr)
+	assert.Equal(suite.T(), "GB", suite.provider.country)
+	assert.Equal(suite.T(), "01234567", suite.provider.registrationNumber)
+
+	company := &billing.MerchantCompanyInfo{}
+	err := json.Unmarshal(body, company)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Company Ltd", company.Name)
+	assert.Equal(suite.T(), "GB", company.Country)
+	assert.Equal(suite.T(), "London", company.City)
+	assert.Equal(suite.T(), "EC1A 1BB", company.Zip)
+	assert.Equal(suite.T(), "01234567", company.RegistrationNumber)
+}
+
+func (suite *CompanyRegistryTestSuite) TestCompanyRegistry_Lookup_ValidationError() {
+	httpErr, _ := suite.lookup("GBR", "01234567")
+	assert.NotNil(suite.T(), httpErr)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	httpErr, _ = suite.lookup("GB", "")
+	assert.NotNil(suite.T(), httpErr)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *CompanyRegistryTestSuite) TestCompanyRegistry_Lookup_NotFound() {
+	suite.provider.err = registry.ErrorCompanyNotFound
+
+	httpErr, _ := suite.lookup("GB", "01234567")
+	assert.NotNil(suite.T(), httpErr)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCompanyRegistryNotFound, httpErr.Message)
+}
+
+func (suite *CompanyRegistryTestSuite) TestCompanyRegistry_Lookup_ProviderError() {
+	suite.provider.err = errors.New("timeout")
+
+	httpErr, _ := suite.lookup("GB", "01234567")
+	assert.NotNil(suite.T(), httpErr)
+	assert.Equal(suite.T(), http.StatusBadGateway, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCompanyRegistryLookupFailed, httpErr.Message)
+
+	suite.provider.err = registry.ErrorProviderNotConfigured
+
+	httpErr, _ = suite.lookup("GB", "01234567")
+	assert.NotNil(suite.T(), httpErr)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCompanyRegistryNotConfigured, httpErr.Message)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+const (
+	billingContractsFile = "test/contracts/billing.json"
+	billingModulePath    = "github.com/paysuper/paysuper-billing-server"
+
+	billingContractUserId     = "ffffffffffffffffffffffff"
+	billingContractMerchantId = "5dbc0a6468add44c7aed8c20"
+	billingContractProjectId  = "5bdc39a95d1e1100019fb7df"
+	billingContractProductId  = "5c99288068add43f74be9c1d"
+)
+
+// billingContractCall is expected request of billing server rpc, the request is written in protobuf json mapping
+// with field names of proto files and parsed into message of the rpc, so unknown fields are rejected
+type billingContractCall struct {
+	Rpc     string          `json:"rpc"`
+	Request json.RawMessage `json:"request"`
+}
+
+type billingContract struct {
+	Name   string                 `json:"name"`
+	Method string                 `json:"method"`
+	Path   string                 `json:"path"`
+	Body   map[string]interface{} `json:"body"`
+	Status int                    `json:"status"`
+	Calls  []*billingContractCall `json:"calls"`
+}
+
+type billingContracts struct {
+	BillingVersion string             `json:"billing_version"`
+	Contracts      []*billingContract `json:"contracts"`
+}
+
+type billingRecordedCall struct {
+	rpc     string
+	request proto.Message
+}
+
+// BillingContractTestSuite checks requests sent to billing server against recorded contracts. Each contract lists
+// all rpc calls of the request in order and every request is compared with the expected message field by field as
+// protobuf does, so a field added, renamed or dropped on either side breaks the contract. Contracts are pinned to
+// billing server version and must be reviewed on every upgrade of the dependency
+type BillingContractTestSuite struct {
+	suite.Suite
+	caller    *test.EchoReqResCaller
+	billing   *billMock.BillingService
+	recorded  []*billingRecordedCall
+	contracts *billingContracts
+	workDir   string
+}
+
+func Test_BillingContract(t *testing.T) {
+	suite.Run(t, new(BillingContractTestSuite))
+}
+
+func (suite *BillingContractTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    billingContractUserId,
+		Email: "test@unit.test",
+	}
+
+	suite.workDir = os.Getenv("WD")
+
+	if suite.workDir == "" {
+		suite.workDir = "./../../"
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(suite.workDir, billingContractsFile))
+
+	if err != nil {
+		panic(err)
+	}
+
+	suite.contracts = &billingContracts{}
+
+	if err = json.Unmarshal(b, suite.contracts); err != nil {
+		panic(err)
+	}
+
+	// responses are fixed, so requests built from them are the same on every run
+	project := &billing.Project{Id: billingContractProjectId, MerchantId: billingContractMerchantId}
+	responses := map[string]interface{}{
+		"GetMerchantBy": &grpc.GetMerchantResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Merchant{Id: billingContractMerchantId, User: &billing.MerchantUser{Id: user.Id}},
+		},
+		"GetProject":   &grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project},
+		"ListProducts": &grpc.ListProductsResponse{},
+		"GetProduct": &grpc.GetProductResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &grpc.Product{Id: billingContractProductId, ProjectId: billingContractProjectId},
+		},
+		"OrderCreateProcess": &grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: "5dbc0a6468add44c7aed8c21"},
+		},
+		"PaymentCreateProcess": &grpc.PaymentCreateResponse{Status: pkg.ResponseStatusOk},
+	}
+
+	suite.billing = &billMock.BillingService{}
+
+	for rpc, rsp := range responses {
+		name := rpc
+		suite.billing.On(name, mock2.Anything, mock2.Anything).
+			Run(func(args mock2.Arguments) {
+				suite.recorded = append(suite.recorded, &billingRecordedCall{rpc: name, request: args.Get(1).(proto.Message)})
+			}).
+			Return(rsp, nil)
+	}
+
+	// request log of the dispatcher resolves merchants of requests with its own billing server,
+	// so contracts contain calls of handlers only
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, err = test.SetUp(test.DefaultSettings(), srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		set.HandlerSet.Services.Billing = suite.billing
+		return common.Handlers{
+			NewOrderRoute(set.HandlerSet, set.GlobalConfig),
+			NewProductRoute(set.HandlerSet, set.GlobalConfig),
+			NewProjectRoute(set.HandlerSet, set.GlobalConfig),
+		}
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (suite *BillingContractTestSuite) TearDownTest() {}
+
+func (suite *BillingContractTestSuite) TestBillingContract_Version() {
+	b, err := ioutil.ReadFile(filepath.Join(suite.workDir, "go.mod"))
+	assert.NoError(suite.T(), err)
+
+	match := regexp.MustCompile(regexp.QuoteMeta(billingModulePath) + `\s+(\S+)`).FindSubmatch(b)
+	assert.NotNil(suite.T(), match)
+	assert.Equal(
+		suite.T(),
+		suite.contracts.BillingVersion,
+		string(match[1]),
+		"billing server dependency is changed, check contracts in %s and update billing_version",
+		billingContractsFile,
+	)
+}
+
+func (suite *BillingContractTestSuite) TestBillingContract_Requests() {
+	assert.NotEmpty(suite.T(), suite.contracts.Contracts)
+
+	for _, contract := range suite.contracts.Contracts {
+		suite.recorded = nil
+
+		u, err := url.Parse(contract.Path)
+		assert.NoError(suite.T(), err)
+
+		builder := suite.caller.Builder().
+			Method(contract.Method).
+			Path(u.Path).
+			SetQueryParams(u.Query()).
+			Init(test.ReqInitJSON())
+
+		// body is sent as compact json with sorted keys, so raw body forwarded to billing server is stable
+		if contract.Body != nil {
+			body, err := json.Marshal(contract.Body)
+			assert.NoError(suite.T(), err, contract.Name)
+			builder.BodyBytes(body)
+		}
+
+		res, err := builder.Exec(suite.T())
+
+		if !assert.NoError(suite.T(), err, contract.Name) {
+			continue
+		}
+
+		assert.Equal(suite.T(), contract.Status, res.Code, "%s: %s", contract.Name, res.Body.String())
+
+		expectedRpc := make([]string, len(contract.Calls))
+		recordedRpc := make([]string, len(suite.recorded))
+
+		for i, call := range contract.Calls {
+			expectedRpc[i] = call.Rpc
+		}
+
+		for i, call := range suite.recorded {
+			recordedRpc[i] = call.rpc
+		}
+
+		if !assert.Equal(suite.T(), expectedRpc, recordedRpc, "%s: sequence of billing server calls", contract.Name) {
+			continue
+		}
+
+		for i, call := range contract.Calls {
+			actual := suite.recorded[i].request
+			expected := reflect.New(reflect.TypeOf(actual).Elem()).Interface().(proto.Message)
+			err = jsonpb.Unmarshal(bytes.NewReader(call.Request), expected)
+
+			if !assert.NoError(suite.T(), err, "%s: call %d %s", contract.Name, i, call.Rpc) {
+				continue
+			}
+
+			if !proto.Equal(expected, actual) {
+				b, _ := (&jsonpb.Marshaler{OrigName: true}).MarshalToString(actual)
+				assert.Fail(suite.T(), "request doesn't match contract", "%s: call %d %s\nexpected: %s\nactual: %s",
+					contract.Name, i, call.Rpc, string(call.Request), b)
+			}
+		}
+	}
+}
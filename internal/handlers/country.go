@@ -1,15 +1,52 @@
 package handlers
 
 import (
+	"context"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
 	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/money"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
+const (
+	countriesBatchPath  = "/countries/batch"
+	currenciesBatchPath = "/currencies/batch"
+)
+
+type countriesBatchRequest struct {
+	Codes []string `json:"codes" validate:"required,min=1,max=300,dive,len=2"`
+}
+
+type countriesBatchResponse struct {
+	Items    []*billing.Country `json:"items"`
+	NotFound []string           `json:"not_found"`
+}
+
+type currenciesBatchRequest struct {
+	Codes []string `json:"codes" validate:"required,min=1,max=300,dive,len=3"`
+}
+
+// currenciesBatchItem is currency with its minor unit, rounding rule and countries using it
+type currenciesBatchItem struct {
+	Code      string             `json:"code"`
+	Exponent  int                `json:"exponent"`
+	Rounding  money.RoundingMode `json:"rounding"`
+	Countries []string           `json:"countries"`
+}
+
+type currenciesBatchResponse struct {
+	Items    []*currenciesBatchItem `json:"items"`
+	NotFound []string               `json:"not_found"`
+}
+
 type CountryApiV1 struct {
 	dispatch common.HandlerSet
 	cfg      common.Config
@@ -26,8 +63,12 @@ func NewCountryApiV1(set common.HandlerSet, cfg *common.Config) *CountryApiV1 {
 }
 
 func (h *CountryApiV1) Route(groups *common.Groups) {
-	groups.AuthProject.GET("/country", h.get)
-	groups.AuthProject.GET("/country/:code", h.getById)
+	countries := common.RouteCachePolicy{Key: "{param.code}", TTL: time.Hour, InvalidatedBy: []string{common.RouteCacheEventCountries}}
+
+	groups.AuthProject.GET("/country", h.get, groups.RouteCache.Cache(countries))
+	groups.AuthProject.GET("/country/:code", h.getById, groups.RouteCache.Cache(countries))
+	groups.AuthProject.POST(countriesBatchPath, h.getBatch)
+	groups.AuthProject.POST(currenciesBatchPath, h.getCurrenciesBatch)
 }
 
 // Get full list of currencies
@@ -66,3 +107,123 @@ func (h *CountryApiV1) getById(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, res)
 }
+
+// Get countries by list of ISO 3166-1 alpha 2 country codes in one request, unknown codes are listed in not_found
+// POST /api/v1/countries/batch
+func (h *CountryApiV1) getBatch(ctx echo.Context) error {
+	req := &countriesBatchRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	countries, err := h.listCountries(ctx.Request().Context())
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	byCode := make(map[string]*billing.Country, len(countries))
+
+	for _, country := range countries {
+		byCode[country.IsoCodeA2] = country
+	}
+
+	res := &countriesBatchResponse{Items: []*billing.Country{}, NotFound: []string{}}
+
+	for _, code := range uniqueCodes(req.Codes) {
+		if country, ok := byCode[code]; ok {
+			res.Items = append(res.Items, country)
+			continue
+		}
+
+		res.NotFound = append(res.NotFound, code)
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// Get currencies by list of ISO 4217 currency codes in one request. Known currencies are currencies of countries
+// supported by billing, unknown codes are listed in not_found
+// POST /api/v1/currencies/batch
+func (h *CountryApiV1) getCurrenciesBatch(ctx echo.Context) error {
+	req := &currenciesBatchRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	countries, err := h.listCountries(ctx.Request().Context())
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	byCurrency := make(map[string][]string)
+
+	for _, country := range countries {
+		if country.Currency != "" {
+			byCurrency[country.Currency] = append(byCurrency[country.Currency], country.IsoCodeA2)
+		}
+	}
+
+	res := &currenciesBatchResponse{Items: []*currenciesBatchItem{}, NotFound: []string{}}
+
+	for _, code := range uniqueCodes(req.Codes) {
+		codes, ok := byCurrency[code]
+
+		if !ok {
+			res.NotFound = append(res.NotFound, code)
+			continue
+		}
+
+		sort.Strings(codes)
+		info := money.Info(code)
+		res.Items = append(res.Items, &currenciesBatchItem{
+			Code:      info.Code,
+			Exponent:  info.Exponent,
+			Rounding:  info.Rounding,
+			Countries: codes,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// listCountries reads all countries in one call of billing server, batches are matched against the list
+func (h *CountryApiV1) listCountries(ctx context.Context) ([]*billing.Country, error) {
+	req := &grpc.EmptyRequest{}
+	res, err := h.dispatch.Services.Billing.GetCountriesList(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetCountriesList", req)
+		return nil, err
+	}
+
+	return res.Countries, nil
+}
+
+// uniqueCodes returns upper cased codes without duplicates in order of the request
+func uniqueCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	unique := make([]string, 0, len(codes))
+
+	for _, code := range codes {
+		code = strings.ToUpper(code)
+
+		if !seen[code] {
+			seen[code] = true
+			unique = append(unique, code)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/money"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type CountryTestSuite struct {
+	suite.Suite
+	router *CountryApiV1
+	caller *test.EchoReqResCaller
+}
+
+func Test_Country(t *testing.T) {
+	suite.Run(t, new(CountryTestSuite))
+}
+
+func (suite *CountryTestSuite) SetupTest() {
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		suite.router = NewCountryApiV1(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	bill := &billMock.BillingService{}
+	bill.On("GetCountriesList", mock2.Anything, mock2.Anything).
+		Return(&billing.CountriesList{
+			Countries: []*billing.Country{
+				{IsoCodeA2: "DE", Currency: "EUR"},
+				{IsoCodeA2: "FR", Currency: "EUR"},
+				{IsoCodeA2: "JP", Currency: "JPY"},
+			},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+}
+
+func (suite *CountryTestSuite) TearDownTest() {}
+
+func (suite *CountryTestSuite) TestCountry_GetBatch_Ok() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + countriesBatchPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"codes": ["de", "JP", "DE", "XX"]}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	batch := &countriesBatchResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), batch))
+	assert.Len(suite.T(), batch.Items, 2)
+	assert.Equal(suite.T(), "DE", batch.Items[0].IsoCodeA2)
+	assert.Equal(suite.T(), "JP", batch.Items[1].IsoCodeA2)
+	assert.Equal(suite.T(), []string{"XX"}, batch.NotFound)
+
+	bill := suite.router.dispatch.Services.Billing.(*billMock.BillingService)
+	bill.AssertNumberOfCalls(suite.T(), "GetCountriesList", 1)
+}
+
+func (suite *CountryTestSuite) TestCountry_GetBatch_BillingServerError() {
+	bill := &billMock.BillingService{}
+	bill.On("GetCountriesList", mock2.Anything, mock2.Anything).Return(nil, errors.New("some error"))
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + countriesBatchPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"codes": ["DE"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
+
+func (suite *CountryTestSuite) TestCountry_GetCurrenciesBatch_Ok() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + currenciesBatchPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"codes": ["eur", "JPY", "XXX"]}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	batch := &currenciesBatchResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), batch))
+	assert.Len(suite.T(), batch.Items, 2)
+	assert.Equal(suite.T(), &currenciesBatchItem{
+		Code:      "EUR",
+		Exponent:  2,
+		Rounding:  money.RoundHalfUp,
+		Countries: []string{"DE", "FR"},
+	}, batch.Items[0])
+	assert.Equal(suite.T(), "JPY", batch.Items[1].Code)
+	assert.Zero(suite.T(), batch.Items[1].Exponent)
+	assert.Equal(suite.T(), []string{"XXX"}, batch.NotFound)
+}
+
+func (suite *CountryTestSuite) TestCountry_GetCurrenciesBatch_ValidationError() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + currenciesBatchPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"codes": ["EURO"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"time"
+)
+
+const (
+	deprecationsPath = "/deprecations"
+)
+
+// merchantIdRoutesSunset is sunset of routes taking merchant identifier in path, successors of the routes
+// take the merchant of authenticated user
+var merchantIdRoutesSunset = time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+type DeprecationsRoute struct {
+	dispatch     common.HandlerSet
+	cfg          common.Config
+	deprecations *common.Deprecations
+	provider.LMT
+}
+
+// NewDeprecationsRoute
+func NewDeprecationsRoute(set common.HandlerSet, cfg *common.Config) *DeprecationsRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "DeprecationsRoute"})
+	return &DeprecationsRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *DeprecationsRoute) Route(groups *common.Groups) {
+	h.deprecations = groups.Deprecations
+	groups.System.GET(deprecationsPath, h.listDeprecations)
+}
+
+// @Description List of deprecated routes called since tracking start with calls of each merchant
+// @Example curl -X GET -H 'Authorization: Bearer %system_api_token_here%' \
+//  https://api.paysuper.online/system/api/v1/deprecations
+func (h *DeprecationsRoute) listDeprecations(ctx echo.Context) error {
+	routes, err := h.deprecations.List()
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.JSON(http.StatusOK, routes)
+}
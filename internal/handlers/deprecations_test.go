@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	deprecatedStubPath      = "/deprecated_stub"
+	deprecatedStubSuccessor = "https://docs.paysuper.online/api/v2"
+)
+
+var deprecatedStubSunset = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type deprecatedRouteStub struct{}
+
+func (r *deprecatedRouteStub) Route(groups *common.Groups) {
+	groups.AuthUser.GET(deprecatedStubPath, func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}, groups.Deprecations.Deprecate(deprecatedStubSunset, deprecatedStubSuccessor))
+}
+
+type DeprecationsTestSuite struct {
+	suite.Suite
+	router  *DeprecationsRoute
+	caller  *test.EchoReqResCaller
+	billing *billMock.BillingService
+}
+
+func Test_Deprecations(t *testing.T) {
+	suite.Run(t, new(DeprecationsTestSuite))
+}
+
+func (suite *DeprecationsTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	suite.billing = &billMock.BillingService{}
+	suite.billing.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	srv := common.Services{
+		Billing: suite.billing,
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewDeprecationsRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+			&deprecatedRouteStub{},
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *DeprecationsTestSuite) TearDownTest() {}
+
+func (suite *DeprecationsTestSuite) TestDeprecations_DeprecatedRoute_Headers() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + deprecatedStubPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), "true", res.Header().Get(common.HeaderDeprecation))
+	assert.Equal(suite.T(), "Tue, 01 Jan 2030 00:00:00 GMT", res.Header().Get(common.HeaderSunset))
+	assert.Equal(suite.T(), "<"+deprecatedStubSuccessor+`>; rel="successor-version"`, res.Header().Get(common.HeaderLink))
+}
+
+func (suite *DeprecationsTestSuite) TestDeprecations_ListDeprecations_Ok() {
+	for i := 0; i < 2; i++ {
+		_, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Path(common.AuthUserGroupPath + deprecatedStubPath).
+			Init(test.ReqInitJSON()).
+			Exec(suite.T())
+		assert.NoError(suite.T(), err)
+	}
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.SystemGroupPath + deprecationsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	var routes []*common.DeprecatedRoute
+	err = json.Unmarshal(res.Body.Bytes(), &routes)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), routes, 1)
+	assert.Equal(suite.T(), common.AuthUserGroupPath+deprecatedStubPath, routes[0].Path)
+	assert.Equal(suite.T(), http.MethodGet, routes[0].Method)
+	assert.True(suite.T(), deprecatedStubSunset.Equal(routes[0].Sunset))
+	assert.Equal(suite.T(), deprecatedStubSuccessor, routes[0].Successor)
+	assert.Len(suite.T(), routes[0].Usage, 1)
+	assert.Equal(suite.T(), mock.OnboardingMerchantMock.Id, routes[0].Usage[0].MerchantId)
+	assert.EqualValues(suite.T(), 2, routes[0].Usage[0].Calls)
+	assert.False(suite.T(), routes[0].Usage[0].LastCallAt.IsZero())
+
+	// merchant of the user is resolved once for deprecations and once for request log,
+	// listing doesn't call billing server
+	suite.billing.AssertNumberOfCalls(suite.T(), "GetMerchantBy", 2)
+}
+
+func (suite *DeprecationsTestSuite) TestDeprecations_ListDeprecations_SharedByInstances() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + deprecatedStubPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+	assert.NoError(suite.T(), err)
+
+	routes, err := common.NewDeprecations(test.Redis(), suite.billing).List()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), routes, 1)
+	assert.Len(suite.T(), routes[0].Usage, 1)
+	assert.EqualValues(suite.T(), 1, routes[0].Usage[0].Calls)
+}
+
+func (suite *DeprecationsTestSuite) TestDeprecations_UserWithoutMerchant_CountedAnonymously() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusNotFound, Message: mock.SomeError}, nil)
+	deprecations := common.NewDeprecations(test.Redis(), bill)
+
+	e := echo.New()
+	ctx := e.NewContext(httptest.NewRequest(http.MethodGet, deprecatedStubPath, nil), httptest.NewRecorder())
+	ctx.SetPath(deprecatedStubPath)
+	common.SetUserContext(ctx, &common.AuthUser{Id: "eeeeeeeeeeeeeeeeeeeeeeee"})
+
+	handler := deprecations.Deprecate(deprecatedStubSunset, "")(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	assert.NoError(suite.T(), handler(ctx))
+	assert.NoError(suite.T(), handler(ctx))
+
+	routes, err := deprecations.List()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), routes, 1)
+	assert.Len(suite.T(), routes[0].Usage, 1)
+	assert.Empty(suite.T(), routes[0].Usage[0].MerchantId)
+	assert.EqualValues(suite.T(), 2, routes[0].Usage[0].Calls)
+	bill.AssertNumberOfCalls(suite.T(), "GetMerchantBy", 1)
+}
+
+func (suite *DeprecationsTestSuite) TestDeprecations_ListDeprecations_Empty() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.SystemGroupPath + deprecationsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), "[]", strings.TrimSpace(res.Body.String()))
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	embedTokensPath             = "/embed/tokens"
+	embedWidgetRevenuePath      = "/embed/widgets/revenue"
+	embedWidgetRecentOrdersPath = "/embed/widgets/recent_orders"
+)
+
+const (
+	embedPeriodQueryParameter = "period"
+	embedRecentOrdersLimit    = 10
+	embedRevenueDefaultPeriod = "current_month"
+)
+
+type embedTokenRequest struct {
+	Widgets  []string `json:"widgets" validate:"required,min=1,dive,oneof=revenue recent_orders"`
+	Projects []string `json:"projects" validate:"omitempty,max=10,dive,hexadecimal,len=24"`
+}
+
+type embedTokenResponse struct {
+	Token     string   `json:"token"`
+	Widgets   []string `json:"widgets"`
+	Projects  []string `json:"projects,omitempty"`
+	ExpiresAt int64    `json:"expires_at"`
+}
+
+// embedRecentOrder is order of recent orders widget, widget is shown to backoffice users of the merchant,
+// so customer data of the order is left out
+type embedRecentOrder struct {
+	Id        string  `json:"id"`
+	ProjectId string  `json:"project_id"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+	Status    string  `json:"status"`
+	Country   string  `json:"country"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+type EmbedRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+}
+
+// NewEmbedRoute
+func NewEmbedRoute(set common.HandlerSet, cfg *common.Config) *EmbedRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "EmbedRoute"})
+	return &EmbedRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *EmbedRoute) Route(groups *common.Groups) {
+	groups.AuthUser.POST(embedTokensPath, h.createToken)
+	groups.AuthProject.GET(embedWidgetRevenuePath, h.getRevenueWidget)
+	groups.AuthProject.GET(embedWidgetRecentOrdersPath, h.getRecentOrdersWidget)
+}
+
+// @Description Issue short-lived token allowing to read data of the listed widgets of authenticated merchant,
+// @Description token with projects allows to read data of these projects only
+// @Example curl -X POST -H 'Authorization: Bearer %access_token_here%' -H 'Content-Type: application/json' \
+//  -d '{"widgets": ["recent_orders"], "projects": ["5bdc39a95d1e1100019fb7df"]}' \
+//  https://api.paysuper.online/admin/api/v1/embed/tokens
+func (h *EmbedRoute) createToken(ctx echo.Context) error {
+	if h.cfg.EmbedTokenSecret == "" {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageEmbedNotConfigured)
+	}
+
+	req := &embedTokenRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	authUser := common.ExtractUserContext(ctx)
+
+	// embed token can't be wider than the token it's issued with, so token restricted to projects issues
+	// embed token of some of its projects and without revenue widget showing whole merchant
+	if len(req.Projects) > 0 || len(authUser.Projects) > 0 {
+		for _, widget := range req.Widgets {
+			if widget == common.EmbedWidgetRevenue {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageEmbedRevenueProjectScoped)
+			}
+		}
+	}
+
+	if len(req.Projects) == 0 && len(authUser.Projects) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageEmbedProjectsRequired)
+	}
+
+	// projects outside of scope of the token are forbidden by getUserProject
+	for _, projectId := range req.Projects {
+		if _, err := getUserProject(ctx, h.dispatch, h.L(), projectId); err != nil {
+			return err
+		}
+	}
+
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(ctx.Request().Context(), mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	claims := &common.EmbedClaims{
+		MerchantId: merchant.Item.Id,
+		Projects:   req.Projects,
+		Widgets:    req.Widgets,
+		ExpiresAt:  time.Now().Add(h.cfg.EmbedTokenTtl).Unix(),
+	}
+	token, err := common.SignEmbedToken(h.cfg.EmbedTokenSecret, claims)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	res := &embedTokenResponse{
+		Token:     token,
+		Widgets:   claims.Widgets,
+		Projects:  claims.Projects,
+		ExpiresAt: claims.ExpiresAt,
+	}
+
+	return ctx.JSON(http.StatusCreated, res)
+}
+
+// @Description Revenue dynamics of the merchant for revenue widget, accepts embed token only
+// @Example curl -X GET -H 'Authorization: Bearer %embed_token_here%' \
+//  https://api.paysuper.online/api/v1/embed/widgets/revenue?period=previous_month
+func (h *EmbedRoute) getRevenueWidget(ctx echo.Context) error {
+	claims, err := h.authorize(ctx, common.EmbedWidgetRevenue)
+
+	if err != nil {
+		return err
+	}
+
+	req := &grpc.GetDashboardMainRequest{
+		MerchantId: claims.MerchantId,
+		Period:     ctx.QueryParam(embedPeriodQueryParameter),
+	}
+
+	if req.Period == "" {
+		req.Period = embedRevenueDefaultPeriod
+	}
+
+	if err = h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	res, err := h.dispatch.Services.Billing.GetDashboardRevenueDynamicsReport(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetDashboardRevenueDynamicsReport", req)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return ctx.JSON(http.StatusOK, res.Item)
+}
+
+// @Description Last orders of the merchant or of projects of the token for recent orders widget without customer data,
+// @Description accepts embed token only
+// @Example curl -X GET -H 'Authorization: Bearer %embed_token_here%' \
+//  https://api.paysuper.online/api/v1/embed/widgets/recent_orders
+func (h *EmbedRoute) getRecentOrdersWidget(ctx echo.Context) error {
+	claims, err := h.authorize(ctx, common.EmbedWidgetRecentOrders)
+
+	if err != nil {
+		return err
+	}
+
+	req := &grpc.ListOrdersRequest{
+		Merchant: []string{claims.MerchantId},
+		Project:  claims.Projects,
+		Limit:    embedRecentOrdersLimit,
+	}
+	res, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "FindAllOrdersPublic", req)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	orders := make([]*embedRecentOrder, 0, len(res.GetItem().GetItems()))
+
+	for _, order := range res.GetItem().GetItems() {
+		orders = append(orders, &embedRecentOrder{
+			Id:        order.Uuid,
+			ProjectId: order.GetProject().GetId(),
+			Amount:    order.TotalPaymentAmount,
+			Currency:  order.Currency,
+			Status:    order.Status,
+			Country:   order.CountryCode,
+			CreatedAt: order.GetCreatedAt().GetSeconds(),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, orders)
+}
+
+// authorize takes embed token from bearer authorization header and checks that it's valid and includes the widget,
+// token isn't accepted from query to keep it out of access logs and referer headers
+func (h *EmbedRoute) authorize(ctx echo.Context, widget string) (*common.EmbedClaims, error) {
+	if h.cfg.EmbedTokenSecret == "" {
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageEmbedNotConfigured)
+	}
+
+	token := strings.TrimPrefix(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+
+	claims, err := common.ParseEmbedToken(h.cfg.EmbedTokenSecret, token)
+
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, common.ErrorMessageEmbedTokenInvalid)
+	}
+
+	if !claims.Allows(widget) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageEmbedWidgetNotAllowed)
+	}
+
+	return claims, nil
+}
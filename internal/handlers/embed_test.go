@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	embedTestSecret = "embed_test_secret"
+)
+
+type EmbedTestSuite struct {
+	suite.Suite
+	router *EmbedRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Embed(t *testing.T) {
+	suite.Run(t, new(EmbedTestSuite))
+}
+
+func (suite *EmbedTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewEmbedRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	suite.router.cfg.EmbedTokenSecret = embedTestSecret
+	suite.router.cfg.EmbedTokenTtl = 15 * time.Minute
+}
+
+func (suite *EmbedTestSuite) TearDownTest() {}
+
+func (suite *EmbedTestSuite) getToken(widgets []string, expiresAt time.Time, projects ...string) string {
+	token, err := common.SignEmbedToken(embedTestSecret, &common.EmbedClaims{
+		MerchantId: mock.OnboardingMerchantMock.Id,
+		Projects:   projects,
+		Widgets:    widgets,
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	assert.NoError(suite.T(), err)
+
+	return token
+}
+
+func (suite *EmbedTestSuite) getWidget(path, token string) (*httptest.ResponseRecorder, error) {
+	return suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath + path).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}).
+		Exec(suite.T())
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + embedTokensPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"widgets": ["revenue"]}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusCreated, res.Code)
+
+	token := &embedTokenResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), token)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), token.ExpiresAt > time.Now().Unix())
+
+	claims, err := common.ParseEmbedToken(embedTestSecret, token.Token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), mock.OnboardingMerchantMock.Id, claims.MerchantId)
+	assert.True(suite.T(), claims.Allows(common.EmbedWidgetRevenue))
+	assert.False(suite.T(), claims.Allows(common.EmbedWidgetRecentOrders))
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_UnknownWidget() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + embedTokensPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"widgets": ["payouts"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_NotConfigured() {
+	suite.router.cfg.EmbedTokenSecret = ""
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + embedTokensPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"widgets": ["revenue"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageEmbedNotConfigured, httpErr.Message)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_Projects_Ok() {
+	projectId := "5bdc39a95d1e1100019fb7df"
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, MerchantId: mock.OnboardingMerchantMock.Id},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + embedTokensPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"widgets": ["recent_orders"], "projects": ["` + projectId + `"]}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusCreated, res.Code)
+
+	token := &embedTokenResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), token)
+	assert.NoError(suite.T(), err)
+
+	claims, err := common.ParseEmbedToken(embedTestSecret, token.Token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{projectId}, claims.Projects)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_ForeignProject() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: "5bdc39a95d1e1100019fb7df", MerchantId: "5bdc39a95d1e1100019fb7e0"},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + embedTokensPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"widgets": ["recent_orders"], "projects": ["5bdc39a95d1e1100019fb7df"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageAccessDenied, httpErr.Message)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_RevenueProjectScoped() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + embedTokensPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"widgets": ["revenue"], "projects": ["5bdc39a95d1e1100019fb7df"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageEmbedRevenueProjectScoped, httpErr.Message)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_TokenProjectScope_ProjectsRequired() {
+	bill := &billMock.BillingService{}
+	suite.router.dispatch.Services.Billing = bill
+
+	for _, body := range []string{
+		`{"widgets": ["recent_orders"], "projects": []}`,
+		`{"widgets": ["revenue"]}`,
+	} {
+		_, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Path(common.AuthUserGroupPath + embedTokensPath).
+			Init(test.ReqInitJSON()).
+			Init(func(request *http.Request, middleware test.Middleware) {
+				middleware.Pre(test.PreAuthUserMiddleware(&common.AuthUser{
+					Id:       "ffffffffffffffffffffffff",
+					Projects: map[string]bool{"5bdc39a95d1e1100019fb7df": true},
+				}))
+			}).
+			BodyString(body).
+			Exec(suite.T())
+
+		assert.Error(suite.T(), err)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	}
+
+	bill.AssertNotCalled(suite.T(), "GetMerchantBy", mock2.Anything, mock2.Anything)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_CreateToken_TokenProjectScope_OtherProject() {
+	bill := &billMock.BillingService{}
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + embedTokensPath).
+		Init(test.ReqInitJSON()).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			middleware.Pre(test.PreAuthUserMiddleware(&common.AuthUser{
+				Id:       "ffffffffffffffffffffffff",
+				Projects: map[string]bool{"5bdc39a95d1e1100019fb7df": true},
+			}))
+		}).
+		BodyString(`{"widgets": ["recent_orders"], "projects": ["5bdc39a95d1e1100019fb7e0"]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageAccessDenied, httpErr.Message)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_RecentOrdersWidget_Ok() {
+	order := &billing.OrderViewPublic{
+		Uuid:               "order1",
+		Project:            &billing.ProjectOrder{Id: "5bdc39a95d1e1100019fb7df"},
+		TotalPaymentAmount: 9.99,
+		Currency:           "USD",
+		Status:             riskOrderStatusProcessed,
+		CountryCode:        "US",
+		User:               &billing.OrderUser{Email: "customer@unit.test"},
+	}
+	bill := &billMock.BillingService{}
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListOrdersPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &grpc.ListOrdersPublicResponseItem{Count: 1, Items: []*billing.OrderViewPublic{order}},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.getWidget(embedWidgetRecentOrdersPath, suite.getToken([]string{common.EmbedWidgetRecentOrders}, time.Now().Add(time.Minute)))
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotContains(suite.T(), res.Body.String(), "customer@unit.test")
+
+	var orders []*embedRecentOrder
+	err = json.Unmarshal(res.Body.Bytes(), &orders)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []*embedRecentOrder{{
+		Id:        "order1",
+		ProjectId: "5bdc39a95d1e1100019fb7df",
+		Amount:    9.99,
+		Currency:  "USD",
+		Status:    riskOrderStatusProcessed,
+		Country:   "US",
+	}}, orders)
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.Merchant[0] == mock.OnboardingMerchantMock.Id && req.Limit == embedRecentOrdersLimit && len(req.Project) == 0
+	}))
+}
+
+func (suite *EmbedTestSuite) TestEmbed_RecentOrdersWidget_ProjectScoped_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListOrdersPublicResponse{Status: pkg.ResponseStatusOk, Item: &grpc.ListOrdersPublicResponseItem{}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	token := suite.getToken([]string{common.EmbedWidgetRecentOrders}, time.Now().Add(time.Minute), "5bdc39a95d1e1100019fb7df")
+	res, err := suite.getWidget(embedWidgetRecentOrdersPath, token)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return len(req.Project) == 1 && req.Project[0] == "5bdc39a95d1e1100019fb7df"
+	}))
+}
+
+func (suite *EmbedTestSuite) TestEmbed_Widget_QueryTokenRejected() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath + embedWidgetRecentOrdersPath).
+		SetQueryParam("token", suite.getToken([]string{common.EmbedWidgetRecentOrders}, time.Now().Add(time.Minute))).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusUnauthorized, httpErr.Code)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_RevenueWidget_BearerToken_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetDashboardRevenueDynamicsReport", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetDashboardRevenueDynamicsReportResponse{Status: pkg.ResponseStatusOk}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	token := suite.getToken([]string{common.EmbedWidgetRevenue}, time.Now().Add(time.Minute))
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath + embedWidgetRevenuePath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertCalled(suite.T(), "GetDashboardRevenueDynamicsReport", mock2.Anything, mock2.MatchedBy(func(req *grpc.GetDashboardMainRequest) bool {
+		return req.MerchantId == mock.OnboardingMerchantMock.Id && req.Period == embedRevenueDefaultPeriod
+	}))
+}
+
+func (suite *EmbedTestSuite) TestEmbed_Widget_WidgetNotAllowed() {
+	_, err := suite.getWidget(embedWidgetRevenuePath, suite.getToken([]string{common.EmbedWidgetRecentOrders}, time.Now().Add(time.Minute)))
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageEmbedWidgetNotAllowed, httpErr.Message)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_Widget_TokenExpired() {
+	_, err := suite.getWidget(embedWidgetRecentOrdersPath, suite.getToken([]string{common.EmbedWidgetRecentOrders}, time.Now().Add(-time.Minute)))
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusUnauthorized, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageEmbedTokenInvalid, httpErr.Message)
+}
+
+func (suite *EmbedTestSuite) TestEmbed_Widget_TokenForged() {
+	token := suite.getToken([]string{common.EmbedWidgetRecentOrders}, time.Now().Add(time.Minute))
+
+	_, err := suite.getWidget(embedWidgetRecentOrdersPath, token+"x")
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusUnauthorized, httpErr.Code)
+}
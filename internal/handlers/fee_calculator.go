@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/money"
+	"github.com/paysuper/paysuper-tax-service/proto"
+	"net/http"
+)
+
+const (
+	feeCalculatorPath = "/calculator/fees"
+
+	feeCalculatorSalesTaxCountry = "US"
+)
+
+type feeCalculatorRequest struct {
+	Amount   float64 `query:"amount" validate:"required,gt=0"`
+	Currency string  `query:"currency" validate:"required,len=3"`
+	Country  string  `query:"country" validate:"required,len=2"`
+	Method   string  `query:"method" validate:"required"`
+	Region   string  `query:"region"`
+	City     string  `query:"city"`
+	Zip      string  `query:"zip"`
+}
+
+type feeCalculatorFee struct {
+	Percent           float64 `json:"percent"`
+	PercentAmount     float64 `json:"percent_amount"`
+	FixAmount         float64 `json:"fix_amount"`
+	FixAmountCurrency string  `json:"fix_amount_currency"`
+}
+
+type feeCalculatorResponse struct {
+	Amount             float64               `json:"amount"`
+	Currency           string                `json:"currency"`
+	Country            string                `json:"country"`
+	Method             string                `json:"method"`
+	VatRate            float64               `json:"vat_rate"`
+	VatAmount          float64               `json:"vat_amount"`
+	TotalPaymentAmount float64               `json:"total_payment_amount"`
+	MethodFee          *feeCalculatorFee     `json:"method_fee"`
+	PsFee              *feeCalculatorFee     `json:"ps_fee"`
+	PayoutAmount       float64               `json:"payout_amount"`
+	Rounding           *money.RoundingPolicy `json:"rounding"`
+}
+
+type FeeCalculatorRoute struct {
+	dispatch         common.HandlerSet
+	cfg              common.Config
+	roundingPolicies *money.RoundingPolicies
+	provider.LMT
+}
+
+// NewFeeCalculatorRoute
+func NewFeeCalculatorRoute(
+	set common.HandlerSet,
+	roundingPolicies *money.RoundingPolicies,
+	cfg *common.Config,
+) *FeeCalculatorRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "FeeCalculatorRoute"})
+	return &FeeCalculatorRoute{
+		dispatch:         set,
+		LMT:              &set.AwareSet,
+		cfg:              *cfg,
+		roundingPolicies: roundingPolicies,
+	}
+}
+
+func (h *FeeCalculatorRoute) Route(groups *common.Groups) {
+	groups.AuthUser.GET(feeCalculatorPath, h.calculateFees)
+}
+
+// @Description Preview of the fees (VAT, payment method and payment system commissions) for the payment
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/calculator/fees?amount=100&currency=USD&country=US&zip=98001&method=VISA
+func (h *FeeCalculatorRoute) calculateFees(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+	reqCtx := ctx.Request().Context()
+
+	req := &feeCalculatorRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	vatRate, err := h.getVatRate(reqCtx, req)
+
+	if err != nil {
+		return err
+	}
+
+	cReq := &billing.PaymentChannelCostMerchantRequest{
+		MerchantId:     merchant.Item.Id,
+		Name:           req.Method,
+		PayoutCurrency: req.Currency,
+		Amount:         req.Amount,
+		Region:         req.Region,
+		Country:        req.Country,
+	}
+	cost, err := h.dispatch.Services.Billing.GetPaymentChannelCostMerchant(reqCtx, cReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetPaymentChannelCostMerchant", cReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	if cost.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(cost.Status), cost.Message)
+	}
+
+	res, err := calculateFeeBreakdown(req, vatRate, cost.Item, h.roundingPolicies.Get(req.Country))
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestDataInvalid)
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// getVatRate resolves tax rate of the payer location by the same tax service call billing server makes on order
+// processing, zip code is significant for US sales tax only
+func (h *FeeCalculatorRoute) getVatRate(ctx context.Context, calcReq *feeCalculatorRequest) (float64, error) {
+	geo := &tax_service.GeoIdentity{Country: calcReq.Country, City: calcReq.City}
+
+	if calcReq.Country == feeCalculatorSalesTaxCountry {
+		geo.Zip = calcReq.Zip
+	}
+
+	req := &tax_service.GetRateRequest{IpData: geo, UserData: &tax_service.GeoIdentity{}}
+	res, err := h.dispatch.Services.Tax.GetRate(ctx, req)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return 0, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	if res.Rate == nil {
+		return 0, nil
+	}
+
+	return float64(res.Rate.Rate), nil
+}
+
+func calculateFeeBreakdown(
+	req *feeCalculatorRequest,
+	vatRate float64,
+	cost *billing.PaymentChannelCostMerchant,
+	rounding *money.RoundingPolicy,
+) (*feeCalculatorResponse, error) {
+	res := &feeCalculatorResponse{
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Country:  req.Country,
+		Method:   req.Method,
+		VatRate:  vatRate,
+		Rounding: rounding,
+	}
+
+	amount, err := money.FromFloat(req.Amount, req.Currency, money.RoundHalfUp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vat := amount.MulRate(vatRate, rounding.Vat)
+	total, _ := amount.Add(vat)
+
+	res.VatAmount = vat.Float64()
+	res.TotalPaymentAmount = total.Float64()
+	res.PayoutAmount = amount.Float64()
+
+	if cost == nil {
+		return res, nil
+	}
+
+	methodFee := amount.MulRate(cost.MethodPercent, rounding.Commission)
+	psFee := amount.MulRate(cost.PsPercent, rounding.Commission)
+
+	res.MethodFee = &feeCalculatorFee{
+		Percent:           cost.MethodPercent,
+		PercentAmount:     methodFee.Float64(),
+		FixAmount:         cost.MethodFixAmount,
+		FixAmountCurrency: cost.MethodFixAmountCurrency,
+	}
+	res.PsFee = &feeCalculatorFee{
+		Percent:           cost.PsPercent,
+		PercentAmount:     psFee.Float64(),
+		FixAmount:         cost.PsFixedFee,
+		FixAmountCurrency: cost.PsFixedFeeCurrency,
+	}
+
+	payout, _ := amount.Sub(methodFee)
+	payout, _ = payout.Sub(psFee)
+
+	// fixed fees in other currencies are converted by billing server on processing and can't be previewed here
+	for _, fee := range []*feeCalculatorFee{res.MethodFee, res.PsFee} {
+		if fee.FixAmountCurrency != "" && fee.FixAmountCurrency != req.Currency {
+			continue
+		}
+
+		fixFee, err := money.FromFloat(fee.FixAmount, req.Currency, money.RoundHalfUp)
+
+		if err != nil {
+			return nil, err
+		}
+
+		payout, _ = payout.Sub(fixFee)
+	}
+
+	res.PayoutAmount = payout.Float64()
+
+	return res, nil
+}
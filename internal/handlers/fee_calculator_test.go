@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/money"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type FeeCalculatorTestSuite struct {
+	suite.Suite
+	router *FeeCalculatorRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_FeeCalculator(t *testing.T) {
+	suite.Run(t, new(FeeCalculatorTestSuite))
+}
+
+func (suite *FeeCalculatorTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+		Tax:     createNewTaxServiceMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewFeeCalculatorRoute(set.HandlerSet, nil, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *FeeCalculatorTestSuite) TearDownTest() {}
+
+func (suite *FeeCalculatorTestSuite) TestFeeCalculator_CalculateFees_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetPaymentChannelCostMerchant", mock2.Anything, mock2.Anything).
+		Return(&grpc.PaymentChannelCostMerchantResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &billing.PaymentChannelCostMerchant{
+				MethodPercent:           0.02,
+				MethodFixAmount:         1,
+				MethodFixAmountCurrency: "USD",
+				PsPercent:               0.05,
+				PsFixedFee:              0.5,
+				PsFixedFeeCurrency:      "EUR",
+			},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+feeCalculatorPath).
+		SetQueryParam("amount", "100").
+		SetQueryParam("currency", "USD").
+		SetQueryParam("country", "AZ").
+		SetQueryParam("method", "VISA").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	breakdown := &feeCalculatorResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), breakdown)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), float64(10), breakdown.VatAmount)
+	assert.Equal(suite.T(), float64(110), breakdown.TotalPaymentAmount)
+	assert.Equal(suite.T(), float64(2), breakdown.MethodFee.PercentAmount)
+	assert.Equal(suite.T(), float64(5), breakdown.PsFee.PercentAmount)
+	assert.Equal(suite.T(), float64(92), breakdown.PayoutAmount)
+	assert.Equal(suite.T(), money.RoundHalfUp, breakdown.Rounding.Vat)
+}
+
+func (suite *FeeCalculatorTestSuite) TestFeeCalculator_CalculateFees_ValidationError() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+feeCalculatorPath).
+		SetQueryParam("currency", "USD").
+		SetQueryParam("country", "AZ").
+		SetQueryParam("method", "VISA").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *FeeCalculatorTestSuite) TestFeeCalculator_CalculateFees_BillingServerError() {
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+feeCalculatorPath).
+		SetQueryParam("amount", "100").
+		SetQueryParam("currency", "USD").
+		SetQueryParam("country", "AZ").
+		SetQueryParam("method", "VISA").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+}
+
+func (suite *FeeCalculatorTestSuite) TestFeeCalculator_CalculateFees_SalesTaxByZip() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetPaymentChannelCostMerchant", mock2.Anything, mock2.Anything).
+		Return(&grpc.PaymentChannelCostMerchantResponse{Status: pkg.ResponseStatusOk}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+feeCalculatorPath).
+		SetQueryParam("amount", "100").
+		SetQueryParam("currency", "USD").
+		SetQueryParam("country", "US").
+		SetQueryParam("zip", "98001").
+		SetQueryParam("method", "VISA").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+
+	breakdown := &feeCalculatorResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), breakdown)
+	assert.NoError(suite.T(), err)
+	assert.InDelta(suite.T(), 0.08, breakdown.VatRate, 0.000001)
+	assert.Equal(suite.T(), float64(8), breakdown.VatAmount)
+}
+
+func (suite *FeeCalculatorTestSuite) TestFeeCalculator_CalculateFees_TaxServiceError() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+feeCalculatorPath).
+		SetQueryParam("amount", "100").
+		SetQueryParam("currency", "USD").
+		SetQueryParam("country", "AZ").
+		SetQueryParam("city", "fail").
+		SetQueryParam("method", "VISA").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+}
@@ -0,0 +1,253 @@
+// +build integration
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/micro/go-micro"
+	"github.com/micro/go-plugins/client/selector/static"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	// integrationRedisHostEnv is address of redis container, see deployments/docker-compose/docker-compose-integration.yml
+	integrationRedisHostEnv = "INTEGRATION_REDIS_HOST"
+	// integrationBillingStubUrlEnv is url of admin api of billing server stub container, requests of billing server
+	// client are sent to the stub by static selector, so the stub container has the name of billing server service
+	integrationBillingStubUrlEnv = "INTEGRATION_BILLING_STUB_URL"
+)
+
+// IntegrationTestSuite runs all application handlers behind real http server with real redis, billing server is
+// replaced by the stub service in container, so requests and responses are serialized as in production
+type IntegrationTestSuite struct {
+	suite.Suite
+	billingStubUrl string
+	server         *httptest.Server
+	echo           *echo.Echo
+}
+
+func Test_Integration(t *testing.T) {
+	suite.Run(t, new(IntegrationTestSuite))
+}
+
+func (suite *IntegrationTestSuite) SetupTest() {
+	redisHost := os.Getenv(integrationRedisHostEnv)
+	suite.billingStubUrl = os.Getenv(integrationBillingStubUrlEnv)
+
+	if redisHost == "" || suite.billingStubUrl == "" {
+		suite.T().Fatalf("%s and %s must be set, run integration tests by make integration-test",
+			integrationRedisHostEnv, integrationBillingStubUrlEnv)
+	}
+
+	suite.stubRequest(http.MethodDelete, "/requests", nil).Body.Close()
+
+	service := micro.NewService(micro.Name("p1payapi.integration"), micro.Selector(static.NewSelector()))
+	srv := common.Services{
+		Billing: grpc.NewBillingService(pkg.ServiceName, service.Client()),
+		Tax:     createNewTaxServiceMock(),
+	}
+
+	settings := test.DefaultSettings()
+	global := settings["dispatcher"].(map[string]interface{})["global"].(map[string]interface{})
+	global["redisHost"] = redisHost
+
+	var handlersErr error
+	caller, err := test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		handlers, _, err := ProviderHandlers(set.Initial, srv, set.HandlerSet.Validate, set.AwareSet, set.GlobalConfig)
+		handlersErr = err
+		return handlers
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	if handlersErr != nil {
+		panic(handlersErr)
+	}
+
+	suite.server, suite.echo, err = caller.Server()
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (suite *IntegrationTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *IntegrationTestSuite) post(path string, body []byte) *http.Response {
+	rsp, err := http.Post(suite.server.URL+path, echo.MIMEApplicationJSON, bytes.NewReader(body))
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *IntegrationTestSuite) stubRequest(method, path string, body interface{}) *http.Response {
+	var reader io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		suite.Require().NoError(err)
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, suite.billingStubUrl+path, reader)
+	suite.Require().NoError(err)
+
+	rsp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+
+	return rsp
+}
+
+// stubRespond sets response of billing server method
+func (suite *IntegrationTestSuite) stubRespond(method string, response interface{}) {
+	rsp := suite.stubRequest(http.MethodPut, "/responses/"+method, response)
+	defer rsp.Body.Close()
+
+	suite.Require().Equal(http.StatusNoContent, rsp.StatusCode)
+}
+
+// stubRequests decodes requests received by billing server method into slice pointed by requests
+func (suite *IntegrationTestSuite) stubRequests(method string, requests interface{}) {
+	rsp := suite.stubRequest(http.MethodGet, "/requests/"+method, nil)
+	defer rsp.Body.Close()
+
+	suite.Require().Equal(http.StatusOK, rsp.StatusCode)
+	suite.Require().NoError(json.NewDecoder(rsp.Body).Decode(requests))
+}
+
+func (suite *IntegrationTestSuite) TestIntegration_Routes_Unique() {
+	routes := make(map[string]string)
+
+	for _, route := range suite.echo.Routes() {
+		// group middlewares register catch-all routes for every method, they are not application routes
+		if strings.HasSuffix(route.Path, "*") || route.Path == common.AuthProjectGroupPath ||
+			route.Path == common.WebHookGroupPath {
+			continue
+		}
+
+		key := route.Method + " " + route.Path
+
+		if handler, ok := routes[key]; ok {
+			assert.Fail(suite.T(), "route registered twice", "%s: %s and %s", key, handler, route.Name)
+		}
+
+		routes[key] = route.Name
+	}
+}
+
+func (suite *IntegrationTestSuite) TestIntegration_OrderPaymentCallback_Flow() {
+	projectId := bson.NewObjectId().Hex()
+	orderId := bson.NewObjectId().Hex()
+
+	suite.stubRespond("OrderCreateProcess", &grpc.OrderCreateProcessResponse{
+		Status: pkg.ResponseStatusOk,
+		Item:   &billing.Order{Id: orderId, Uuid: orderId},
+	})
+	suite.stubRespond("PaymentCreateProcess", &grpc.PaymentCreateResponse{
+		Status:       pkg.ResponseStatusOk,
+		RedirectUrl:  "https://pay.example.com/3ds",
+		NeedRedirect: true,
+	})
+	suite.stubRespond("PaymentCallbackProcess", &grpc.PaymentNotifyResponse{})
+
+	// order
+	rsp := suite.post(common.AuthProjectGroupPath+orderPath, []byte(
+		`{"project_id": "`+projectId+`", "amount": 10.5, "currency": "USD", "utm_source": "newsletter"}`,
+	))
+	defer rsp.Body.Close()
+	assert.Equal(suite.T(), http.StatusOK, rsp.StatusCode)
+
+	order := &CreateOrderJsonProjectResponse{}
+	assert.NoError(suite.T(), json.NewDecoder(rsp.Body).Decode(order))
+	assert.Equal(suite.T(), orderId, order.Id)
+
+	var orderRequests []*billing.OrderCreateRequest
+	suite.stubRequests("OrderCreateProcess", &orderRequests)
+	suite.Require().Len(orderRequests, 1)
+	assert.Equal(suite.T(), projectId, orderRequests[0].ProjectId)
+	assert.Equal(suite.T(), 10.5, orderRequests[0].Amount)
+	assert.Equal(suite.T(), "USD", orderRequests[0].Currency)
+	assert.Equal(suite.T(), "newsletter", orderRequests[0].Other[common.RequestParameterUtmSource])
+
+	// payment
+	rsp = suite.post(common.AuthProjectGroupPath+paymentPath, []byte(
+		`{"order_id": "`+order.Id+`", "payment_method_id": "`+bson.NewObjectId().Hex()+`", "store_data": true}`,
+	))
+	defer rsp.Body.Close()
+	assert.Equal(suite.T(), http.StatusOK, rsp.StatusCode)
+
+	payment := make(map[string]interface{})
+	assert.NoError(suite.T(), json.NewDecoder(rsp.Body).Decode(&payment))
+	assert.Equal(suite.T(), "https://pay.example.com/3ds", payment["redirect_url"])
+	assert.Equal(suite.T(), true, payment["need_redirect"])
+
+	var paymentRequests []*grpc.PaymentCreateRequest
+	suite.stubRequests("PaymentCreateProcess", &paymentRequests)
+	suite.Require().Len(paymentRequests, 1)
+	assert.Equal(suite.T(), order.Id, paymentRequests[0].Data["order_id"])
+	assert.Equal(suite.T(), "1", paymentRequests[0].Data["store_data"])
+
+	// payment system callback, billing server checks signature of the raw body, so it must reach it untouched
+	callback := []byte(`{
+		"callback_time": "2019-11-01T10:00:00Z",
+		"payment_method": "BANKCARD",
+		"merchant_order": {"id": "` + orderId + `", "description": "test order"},
+		"payment_data": {
+			"id": "` + bson.NewObjectId().Hex() + `",
+			"amount": 10.5,
+			"currency": "USD",
+			"status": "COMPLETED",
+			"created": "2019-11-01T09:59:00Z",
+			"auth_code": "123456",
+			"rrn": "000000000001",
+			"is_3d": true,
+			"description": "test order"
+		},
+		"card_account": {
+			"holder": "UNIT TEST",
+			"issuing_country_code": "RU",
+			"masked_pan": "400000...0002",
+			"token": "` + bson.NewObjectId().Hex() + `",
+			"expiration": "12/2030"
+		},
+		"customer": {"email": "test@unit.test", "id": "test@unit.test", "ip": "127.0.0.1", "locale": "en"}
+	}`)
+	rsp = suite.post(common.WebHookGroupPath+cardPayWebHookPaymentNotifyPath, callback)
+	defer rsp.Body.Close()
+
+	b, _ := ioutil.ReadAll(rsp.Body)
+	assert.Equal(suite.T(), http.StatusOK, rsp.StatusCode, string(b))
+
+	var callbackRequests []*grpc.PaymentNotifyRequest
+	suite.stubRequests("PaymentCallbackProcess", &callbackRequests)
+	suite.Require().Len(callbackRequests, 1)
+	assert.Equal(suite.T(), orderId, callbackRequests[0].OrderId)
+	assert.Equal(suite.T(), callback, callbackRequests[0].Request)
+}
+
+func (suite *IntegrationTestSuite) TestIntegration_UnknownRoute_NotFound() {
+	rsp := suite.post(common.AuthProjectGroupPath+"/unknown", []byte(`{}`))
+	defer rsp.Body.Close()
+
+	assert.Equal(suite.T(), http.StatusNotFound, rsp.StatusCode)
+}
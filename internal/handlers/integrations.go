@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"time"
+)
+
+const (
+	integrationsStatusPath = "/integrations/status"
+	integrationsTestPath   = "/integrations/:name/test"
+)
+
+type integrationTestResponse struct {
+	Ok          bool                      `json:"ok"`
+	Error       string                    `json:"error,omitempty"`
+	Duration    int64                     `json:"duration_ms"`
+	Integration *common.IntegrationStatus `json:"integration"`
+}
+
+type IntegrationsRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+}
+
+// NewIntegrationsRoute
+func NewIntegrationsRoute(set common.HandlerSet, cfg *common.Config) *IntegrationsRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "IntegrationsRoute"})
+	return &IntegrationsRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *IntegrationsRoute) Route(groups *common.Groups) {
+	groups.System.GET(integrationsStatusPath, h.getStatus)
+	groups.System.POST(integrationsTestPath, h.testIntegration)
+}
+
+// @Description Connectivity and error rate of calls of external services for the last 15 minutes
+// @Example curl -X GET -H 'Authorization: Bearer %system_api_token_here%' \
+//  https://api.paysuper.online/system/api/v1/integrations/status
+func (h *IntegrationsRoute) getStatus(ctx echo.Context) error {
+	integrations := h.dispatch.Services.Integrations
+
+	if integrations == nil {
+		return ctx.JSON(http.StatusOK, []*common.IntegrationStatus{})
+	}
+
+	return ctx.JSON(http.StatusOK, integrations.List())
+}
+
+// @Description Make test call of external service, failed call is reported in response body and counted in status
+// @Example curl -X POST -H 'Authorization: Bearer %system_api_token_here%' \
+//  https://api.paysuper.online/system/api/v1/integrations/billing/test
+func (h *IntegrationsRoute) testIntegration(ctx echo.Context) error {
+	integrations := h.dispatch.Services.Integrations
+
+	if integrations == nil {
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageIntegrationNotFound)
+	}
+
+	name := ctx.Param(common.RequestParameterName)
+	start := time.Now()
+	err := integrations.Check(ctx.Request().Context(), name)
+
+	switch err {
+	case common.ErrIntegrationNotFound:
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageIntegrationNotFound)
+	case common.ErrIntegrationCheckNotSupported:
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageIntegrationCheckNotSupported)
+	}
+
+	res := &integrationTestResponse{Ok: err == nil, Duration: int64(time.Since(start) / time.Millisecond)}
+
+	if err != nil {
+		h.L().Error("integration test call failed", logger.PairArgs("integration", name, "err", err.Error()))
+		res.Error = err.Error()
+	}
+
+	if res.Integration, err = integrations.Get(name); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageIntegrationNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+const (
+	integrationsTestBillingService  = "p1paybilling"
+	integrationsTestTaxService      = "p1paytaxservice"
+	integrationsTestReporterService = "p1payreporter"
+	integrationsTestRatesEndpoint   = "BillingService.GetRecommendedPriceByConversion"
+)
+
+type IntegrationsTestSuite struct {
+	suite.Suite
+	router       *IntegrationsRoute
+	caller       *test.EchoReqResCaller
+	integrations *common.Integrations
+	checkErr     error
+}
+
+func Test_Integrations(t *testing.T) {
+	suite.Run(t, new(IntegrationsTestSuite))
+}
+
+func (suite *IntegrationsTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewIntegrationsRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	suite.checkErr = nil
+	suite.integrations = common.NewIntegrations()
+	suite.integrations.Register(common.IntegrationBilling, integrationsTestBillingService, func(ctx context.Context) error {
+		return suite.checkErr
+	})
+	suite.integrations.Register(common.IntegrationTax, integrationsTestTaxService, nil)
+	suite.integrations.Register(common.IntegrationReporter, integrationsTestReporterService, nil)
+	suite.integrations.RegisterEndpoints(
+		common.IntegrationRates,
+		integrationsTestBillingService,
+		[]string{integrationsTestRatesEndpoint},
+		nil,
+	)
+	suite.integrations.RegisterDirect(common.IntegrationStorageReporter, common.IntegrationServiceS3, func(ctx context.Context) error {
+		return suite.checkErr
+	})
+	suite.router.dispatch.Services.Integrations = suite.integrations
+}
+
+func (suite *IntegrationsTestSuite) TearDownTest() {}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Status_Ok() {
+	for i := 0; i < 3; i++ {
+		suite.integrations.Record(integrationsTestBillingService, nil)
+	}
+	suite.integrations.Record(integrationsTestTaxService, errors.New("connection refused"))
+	suite.integrations.Record("unknown_service", nil)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.SystemGroupPath + integrationsStatusPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	var statuses []*common.IntegrationStatus
+	err = json.Unmarshal(res.Body.Bytes(), &statuses)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), statuses, 5)
+
+	assert.Equal(suite.T(), common.IntegrationBilling, statuses[0].Name)
+	assert.Equal(suite.T(), common.IntegrationStatusOk, statuses[0].Status)
+	assert.EqualValues(suite.T(), 3, statuses[0].Calls)
+	assert.Zero(suite.T(), statuses[0].ErrorRate)
+
+	assert.Equal(suite.T(), common.IntegrationRates, statuses[1].Name)
+	assert.Equal(suite.T(), common.IntegrationStatusUnknown, statuses[1].Status)
+
+	assert.Equal(suite.T(), common.IntegrationReporter, statuses[2].Name)
+	assert.Equal(suite.T(), common.IntegrationStatusUnknown, statuses[2].Status)
+	assert.Nil(suite.T(), statuses[2].LastCallAt)
+
+	assert.Equal(suite.T(), common.IntegrationStorageReporter, statuses[3].Name)
+	assert.Equal(suite.T(), common.IntegrationServiceS3, statuses[3].Service)
+	assert.Equal(suite.T(), common.IntegrationStatusUnknown, statuses[3].Status)
+
+	assert.Equal(suite.T(), common.IntegrationTax, statuses[4].Name)
+	assert.Equal(suite.T(), common.IntegrationStatusDown, statuses[4].Status)
+	assert.EqualValues(suite.T(), 1, statuses[4].ErrorRate)
+	assert.Equal(suite.T(), "connection refused", statuses[4].LastError)
+	assert.NotNil(suite.T(), statuses[4].LastErrorAt)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Status_Endpoints() {
+	suite.integrations.RecordEndpoint(integrationsTestBillingService, "BillingService.GetCountriesList", nil)
+	suite.integrations.RecordEndpoint(integrationsTestBillingService, integrationsTestRatesEndpoint, errors.New("rates not found"))
+
+	status, err := suite.integrations.Get(common.IntegrationBilling)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 2, status.Calls)
+	assert.EqualValues(suite.T(), 1, status.Errors)
+
+	status, err = suite.integrations.Get(common.IntegrationRates)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.IntegrationStatusDown, status.Status)
+	assert.EqualValues(suite.T(), 1, status.Calls)
+	assert.Equal(suite.T(), "rates not found", status.LastError)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Status_Direct() {
+	suite.integrations.RecordDirect(common.IntegrationStorageReporter, nil)
+	suite.integrations.RecordDirect(common.IntegrationBilling, errors.New("not direct"))
+
+	status, err := suite.integrations.Get(common.IntegrationStorageReporter)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.IntegrationStatusOk, status.Status)
+	assert.EqualValues(suite.T(), 1, status.Calls)
+
+	status, err = suite.integrations.Get(common.IntegrationBilling)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), status.Calls)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Status_Degraded() {
+	for i := 0; i < 9; i++ {
+		suite.integrations.Record(integrationsTestBillingService, nil)
+	}
+	suite.integrations.Record(integrationsTestBillingService, errors.New("timeout"))
+
+	status, err := suite.integrations.Get(common.IntegrationBilling)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.IntegrationStatusDegraded, status.Status)
+	assert.EqualValues(suite.T(), 10, status.Calls)
+	assert.EqualValues(suite.T(), 1, status.Errors)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Test_Ok() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + integrationsTestPath).
+		Params(":"+common.RequestParameterName, common.IntegrationBilling).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	result := &integrationTestResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), result)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), result.Ok)
+	assert.Empty(suite.T(), result.Error)
+	assert.Equal(suite.T(), common.IntegrationBilling, result.Integration.Name)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Test_CallFailed() {
+	suite.checkErr = errors.New("service not found")
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + integrationsTestPath).
+		Params(":"+common.RequestParameterName, common.IntegrationBilling).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	result := &integrationTestResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), result)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), result.Ok)
+	assert.Equal(suite.T(), "service not found", result.Error)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Test_DirectRecorded() {
+	suite.checkErr = errors.New("access denied")
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + integrationsTestPath).
+		Params(":"+common.RequestParameterName, common.IntegrationStorageReporter).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	result := &integrationTestResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), result)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), result.Ok)
+	assert.Equal(suite.T(), common.IntegrationStatusDown, result.Integration.Status)
+	assert.EqualValues(suite.T(), 1, result.Integration.Errors)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Test_CanceledNotRecorded() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := suite.integrations.Check(ctx, common.IntegrationStorageReporter)
+	assert.NoError(suite.T(), err)
+
+	status, err := suite.integrations.Get(common.IntegrationStorageReporter)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), status.Calls)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Test_NotSupported() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + integrationsTestPath).
+		Params(":"+common.RequestParameterName, common.IntegrationReporter).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageIntegrationCheckNotSupported, httpErr.Message)
+}
+
+func (suite *IntegrationsTestSuite) TestIntegrations_Test_NotFound() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + integrationsTestPath).
+		Params(":"+common.RequestParameterName, "unknown").
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageIntegrationNotFound, httpErr.Message)
+}
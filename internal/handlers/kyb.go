@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/kyb"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	kybMerchantPath          = "/merchants/:id/kyb"
+	kybMerchantDecisionsPath = "/merchants/:id/kyb/decisions"
+	kybDecisionCallbackPath  = "/kyb/decision"
+)
+
+const (
+	kybNotificationTitle = "KYB decision: %s"
+	kybDecisionNoReason  = "no reason provided"
+)
+
+type KybRoute struct {
+	dispatch    common.HandlerSet
+	cfg         common.Config
+	kybProvider kyb.Provider
+	decisions   *common.KybDecisions
+	provider.LMT
+}
+
+// NewKybRoute
+func NewKybRoute(set common.HandlerSet, kybProvider kyb.Provider, cfg *common.Config) *KybRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "KybRoute"})
+	return &KybRoute{
+		dispatch:    set,
+		LMT:         &set.AwareSet,
+		cfg:         *cfg,
+		kybProvider: kybProvider,
+	}
+}
+
+func (h *KybRoute) Route(groups *common.Groups) {
+	h.decisions = common.NewKybDecisions(groups.Redis)
+
+	groups.AuthUser.POST(kybMerchantPath, h.submitMerchant)
+	groups.AuthUser.GET(kybMerchantDecisionsPath, h.listDecisions)
+	groups.System.GET(kybMerchantDecisionsPath, h.listMerchantDecisions)
+	groups.WebHooks.POST(kybDecisionCallbackPath, h.decisionCallback)
+}
+
+// @Description Send onboarding data of merchant of current user to KYB compliance provider, decision comes to
+// @Description the webhook later
+// @Example curl -X POST -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/merchants/%merchant_id_here%/kyb
+func (h *KybRoute) submitMerchant(ctx echo.Context) error {
+	merchant, err := getUserMerchant(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	// callback url is never taken from the request, so it can't be pointed to other host by Host header
+	if h.cfg.KybCallbackBaseUrl == "" {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageKybProviderNotConfigured)
+	}
+
+	submission := &kyb.Submission{
+		ReferenceId: merchant.Id,
+		Company:     merchant.Company,
+		Contacts:    merchant.Contacts,
+		CallbackUrl: strings.TrimRight(h.cfg.KybCallbackBaseUrl, "/") + common.WebHookGroupPath + kybDecisionCallbackPath,
+	}
+	err = h.kybProvider.Submit(ctx.Request().Context(), submission)
+
+	if err == kyb.ErrorProviderNotConfigured {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageKybProviderNotConfigured)
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return echo.NewHTTPError(http.StatusBadGateway, common.ErrorMessageKybSubmitFailed)
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// @Description Decision log of the KYB compliance provider for merchant of current user
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/merchants/%merchant_id_here%/kyb/decisions
+func (h *KybRoute) listDecisions(ctx echo.Context) error {
+	merchant, err := getUserMerchant(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	return h.writeDecisions(ctx, merchant.Id)
+}
+
+// @Description Decision log of the KYB compliance provider for the merchant, used by operators
+// @Example curl -X GET -H 'Authorization: Bearer %system_token_here%' \
+//  https://api.paysuper.online/system/api/v1/merchants/%merchant_id_here%/kyb/decisions
+func (h *KybRoute) listMerchantDecisions(ctx echo.Context) error {
+	merchant, err := h.getMerchant(ctx.Request().Context(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	return h.writeDecisions(ctx, merchant.Id)
+}
+
+func (h *KybRoute) writeDecisions(ctx echo.Context, merchantId string) error {
+	decisions, err := h.decisions.List(merchantId)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.JSON(http.StatusOK, decisions)
+}
+
+// @Description Decision callback of the KYB compliance provider, changes merchant status and writes decision log.
+// @Description Decision is signed with nonce and timestamp, expired decisions and repeated nonces are rejected
+// @Example POST /webhook/kyb/decision
+func (h *KybRoute) decisionCallback(ctx echo.Context) error {
+	body := common.ExtractRawBodyContext(ctx)
+	decision, err := h.kybProvider.ParseDecision(body, ctx.Request().Header.Get(kyb.SignatureHeader))
+
+	if err == kyb.ErrorProviderNotConfigured {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageKybProviderNotConfigured)
+	}
+
+	if err == kyb.ErrorSignatureInvalid {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	if err == kyb.ErrorDecisionExpired {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageKybDecisionExpired)
+	}
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestDataInvalid)
+	}
+
+	// nonce is remembered while timestamp of the decision is valid, so signed callback can't be replayed
+	accepted, err := h.decisions.AcceptNonce(decision.Nonce, 2*kyb.DecisionMaxAge)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "nonce", decision.Nonce))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if !accepted {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageKybDecisionExpired)
+	}
+
+	if err = h.applyDecision(ctx, decision); err != nil {
+		h.decisions.ReleaseNonce(decision.Nonce)
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h *KybRoute) applyDecision(ctx echo.Context, decision *kyb.Decision) error {
+	reqCtx := ctx.Request().Context()
+	merchant, err := h.getMerchant(reqCtx, decision.ReferenceId)
+
+	if err != nil {
+		return err
+	}
+
+	reason := decision.Reason
+
+	if reason == "" {
+		reason = kybDecisionNoReason
+	}
+
+	status := int32(0)
+
+	switch decision.Decision {
+	case kyb.DecisionApproved:
+		status = h.cfg.KybApprovedMerchantStatus
+	case kyb.DecisionRejected:
+		status = h.cfg.KybRejectedMerchantStatus
+	}
+
+	if status > 0 && merchant.Status != status {
+		sReq := &grpc.MerchantChangeStatusRequest{
+			MerchantId: merchant.Id,
+			UserId:     h.cfg.KybSystemUserId,
+			Status:     status,
+			Message:    reason,
+		}
+		sRes, err := h.dispatch.Services.Billing.ChangeMerchantStatus(reqCtx, sReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ChangeMerchantStatus", sReq)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if sRes.Status != pkg.ResponseStatusOk {
+			return echo.NewHTTPError(int(sRes.Status), sRes.Message)
+		}
+	}
+
+	nReq := &grpc.NotificationRequest{
+		MerchantId: merchant.Id,
+		UserId:     h.cfg.KybSystemUserId,
+		Title:      fmt.Sprintf(kybNotificationTitle, decision.Decision),
+		Message:    reason,
+	}
+	nRes, err := h.dispatch.Services.Billing.CreateNotification(reqCtx, nReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateNotification", nReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if nRes.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(nRes.Status), nRes.Message)
+	}
+
+	entry := &common.KybDecision{Decision: decision.Decision, Message: reason, CreatedAt: time.Now().Unix()}
+
+	if err = h.decisions.Add(merchant.Id, entry); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return nil
+}
+
+func (h *KybRoute) getMerchant(ctx context.Context, merchantId string) (*billing.Merchant, error) {
+	if !bson.IsObjectIdHex(merchantId) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectMerchantId)
+	}
+
+	req := &grpc.GetMerchantByRequest{MerchantId: merchantId}
+	res, err := h.dispatch.Services.Billing.GetMerchantBy(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item, nil
+}
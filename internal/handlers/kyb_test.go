@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/kyb"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type kybProviderMock struct {
+	submitted   *kyb.Submission
+	submitErr   error
+	decision    *kyb.Decision
+	decisionErr error
+}
+
+func (p *kybProviderMock) Submit(ctx context.Context, submission *kyb.Submission) error {
+	p.submitted = submission
+	return p.submitErr
+}
+
+func (p *kybProviderMock) ParseDecision(body []byte, signature string) (*kyb.Decision, error) {
+	return p.decision, p.decisionErr
+}
+
+type KybTestSuite struct {
+	suite.Suite
+	router   *KybRoute
+	caller   *test.EchoReqResCaller
+	provider *kybProviderMock
+}
+
+func Test_Kyb(t *testing.T) {
+	suite.Run(t, new(KybTestSuite))
+}
+
+func (suite *KybTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.provider = &kybProviderMock{}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewKybRoute(set.HandlerSet, suite.provider, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	suite.router.cfg.KybSystemUserId = "kyb_system"
+	suite.router.cfg.KybApprovedMerchantStatus = pkg.MerchantStatusAgreementSigning
+	suite.router.cfg.KybCallbackBaseUrl = "https://api.paysuper.online/"
+}
+
+func (suite *KybTestSuite) TearDownTest() {}
+
+func (suite *KybTestSuite) getBillingMock() *billMock.BillingService {
+	merchant := &billing.Merchant{
+		Id:      mock.OnboardingMerchantMock.Id,
+		Status:  pkg.MerchantStatusDraft,
+		Company: mock.OnboardingMerchantMock.Company,
+	}
+
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: merchant}, nil)
+	bill.On("ChangeMerchantStatus", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeMerchantStatusResponse{Status: pkg.ResponseStatusOk, Item: merchant}, nil)
+	bill.On("CreateNotification", mock2.Anything, mock2.Anything).
+		Return(&grpc.CreateNotificationResponse{Status: pkg.ResponseStatusOk, Item: &billing.Notification{}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	return bill
+}
+
+func (suite *KybTestSuite) TestKyb_SubmitMerchant_Ok() {
+	suite.getBillingMock()
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, mock.OnboardingMerchantMock.Id).
+		Path(common.AuthUserGroupPath + kybMerchantPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusAccepted, res.Code)
+	assert.Equal(suite.T(), mock.OnboardingMerchantMock.Id, suite.provider.submitted.ReferenceId)
+	assert.Equal(suite.T(), mock.OnboardingMerchantMock.Company.Name, suite.provider.submitted.Company.Name)
+	assert.Equal(suite.T(), "https://api.paysuper.online/webhook/kyb/decision", suite.provider.submitted.CallbackUrl)
+}
+
+func (suite *KybTestSuite) TestKyb_SubmitMerchant_OtherMerchant_Forbidden() {
+	suite.getBillingMock()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + kybMerchantPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.Nil(suite.T(), suite.provider.submitted)
+}
+
+func (suite *KybTestSuite) TestKyb_SubmitMerchant_CallbackUrlNotConfigured() {
+	suite.getBillingMock()
+	suite.router.cfg.KybCallbackBaseUrl = ""
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, mock.OnboardingMerchantMock.Id).
+		Path(common.AuthUserGroupPath + kybMerchantPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, httpErr.Code)
+	assert.Nil(suite.T(), suite.provider.submitted)
+}
+
+func (suite *KybTestSuite) TestKyb_SubmitMerchant_ProviderError() {
+	suite.getBillingMock()
+	suite.provider.submitErr = errors.New("connection refused")
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, mock.OnboardingMerchantMock.Id).
+		Path(common.AuthUserGroupPath + kybMerchantPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadGateway, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageKybSubmitFailed, httpErr.Message)
+}
+
+func (suite *KybTestSuite) TestKyb_DecisionCallback_Approved() {
+	bill := suite.getBillingMock()
+	suite.provider.decision = &kyb.Decision{
+		ReferenceId: mock.OnboardingMerchantMock.Id,
+		Decision:    kyb.DecisionApproved,
+		Nonce:       bson.NewObjectId().Hex(),
+	}
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.WebHookGroupPath + kybDecisionCallbackPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	bill.AssertCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.MatchedBy(func(req *grpc.MerchantChangeStatusRequest) bool {
+		return req.Status == pkg.MerchantStatusAgreementSigning && req.UserId == "kyb_system"
+	}))
+	bill.AssertCalled(suite.T(), "CreateNotification", mock2.Anything, mock2.MatchedBy(func(req *grpc.NotificationRequest) bool {
+		return req.Title == "KYB decision: approved" && req.Message == kybDecisionNoReason
+	}))
+}
+
+func (suite *KybTestSuite) TestKyb_DecisionCallback_Review() {
+	bill := suite.getBillingMock()
+	suite.provider.decision = &kyb.Decision{
+		ReferenceId: mock.OnboardingMerchantMock.Id,
+		Decision:    kyb.DecisionReview,
+		Reason:      "documents requested",
+		Nonce:       bson.NewObjectId().Hex(),
+	}
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.WebHookGroupPath + kybDecisionCallbackPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	bill.AssertNotCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.Anything)
+	bill.AssertCalled(suite.T(), "CreateNotification", mock2.Anything, mock2.Anything)
+}
+
+func (suite *KybTestSuite) TestKyb_DecisionCallback_SignatureInvalid() {
+	suite.getBillingMock()
+	suite.provider.decisionErr = kyb.ErrorSignatureInvalid
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.WebHookGroupPath + kybDecisionCallbackPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+}
+
+func (suite *KybTestSuite) TestKyb_DecisionCallback_Replayed() {
+	bill := suite.getBillingMock()
+	suite.provider.decision = &kyb.Decision{
+		ReferenceId: mock.OnboardingMerchantMock.Id,
+		Decision:    kyb.DecisionApproved,
+		Nonce:       bson.NewObjectId().Hex(),
+	}
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.WebHookGroupPath + kybDecisionCallbackPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.WebHookGroupPath + kybDecisionCallbackPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageKybDecisionExpired, httpErr.Message)
+	bill.AssertNumberOfCalls(suite.T(), "ChangeMerchantStatus", 1)
+}
+
+func (suite *KybTestSuite) TestKyb_DecisionCallback_Expired() {
+	suite.getBillingMock()
+	suite.provider.decisionErr = kyb.ErrorDecisionExpired
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.WebHookGroupPath + kybDecisionCallbackPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageKybDecisionExpired, httpErr.Message)
+}
+
+func (suite *KybTestSuite) TestKyb_ListDecisions_Ok() {
+	suite.getBillingMock()
+	suite.provider.decision = &kyb.Decision{
+		ReferenceId: mock.OnboardingMerchantMock.Id,
+		Decision:    kyb.DecisionRejected,
+		Reason:      "sanctions list match",
+		Nonce:       bson.NewObjectId().Hex(),
+	}
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.WebHookGroupPath + kybDecisionCallbackPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, mock.OnboardingMerchantMock.Id).
+		Path(common.AuthUserGroupPath + kybMerchantDecisionsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	var decisions []*common.KybDecision
+	err = json.Unmarshal(res.Body.Bytes(), &decisions)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), decisions, 1)
+	assert.Equal(suite.T(), kyb.DecisionRejected, decisions[0].Decision)
+	assert.Equal(suite.T(), "sanctions list match", decisions[0].Message)
+}
+
+func (suite *KybTestSuite) TestKyb_ListDecisions_OtherMerchant_Forbidden() {
+	suite.getBillingMock()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + kybMerchantDecisionsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+}
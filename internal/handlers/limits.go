@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"time"
+)
+
+const (
+	limitsPath = "/limits"
+)
+
+type limitsProject struct {
+	Id               string            `json:"id"`
+	Name             map[string]string `json:"name"`
+	LimitsCurrency   string            `json:"limits_currency"`
+	MinPaymentAmount float64           `json:"min_payment_amount"`
+	MaxPaymentAmount float64           `json:"max_payment_amount"`
+}
+
+// payout is created when merchant balance reaches MinAmount in Currency, manual payouts are requested by merchant
+type limitsPayout struct {
+	Currency             string  `json:"currency"`
+	MinAmount            float64 `json:"min_amount"`
+	ManualPayoutsEnabled bool    `json:"manual_payouts_enabled"`
+}
+
+type limitsRate struct {
+	Limit         int   `json:"limit"`
+	WindowSeconds int64 `json:"window_seconds"`
+}
+
+// zero values of count limits mean there is no limit
+type limitsResponse struct {
+	MerchantId             string           `json:"merchant_id"`
+	Projects               []*limitsProject `json:"projects"`
+	Payout                 *limitsPayout    `json:"payout"`
+	ReceiptLookupRateLimit *limitsRate      `json:"receipt_lookup_rate_limit"`
+	ListLimitDefault       int32            `json:"list_limit_default"`
+	ListLimitMax           int32            `json:"list_limit_max"`
+	RefundsPerOrderMax     int32            `json:"refunds_per_order_max"`
+	AgreementUploadMaxSize int64            `json:"agreement_upload_max_size"`
+	VirtualCurrencyMax     int64            `json:"virtual_currency_max_amount"`
+}
+
+type LimitsRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+}
+
+// NewLimitsRoute
+func NewLimitsRoute(set common.HandlerSet, cfg *common.Config) *LimitsRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "LimitsRoute"})
+	return &LimitsRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *LimitsRoute) Route(groups *common.Groups) {
+	groups.AuthUser.GET(limitsPath, h.getLimits)
+}
+
+// @Description Effective limits for authenticated merchant: payment amounts per project, payout threshold, rate limits
+// @Description and count caps
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/limits
+func (h *LimitsRoute) getLimits(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+	reqCtx := ctx.Request().Context()
+
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	res := &limitsResponse{
+		MerchantId: merchant.Item.Id,
+		Projects:   []*limitsProject{},
+		Payout: &limitsPayout{
+			Currency:             merchant.Item.GetBanking().GetCurrency(),
+			MinAmount:            merchant.Item.MinPayoutAmount,
+			ManualPayoutsEnabled: merchant.Item.ManualPayoutsEnabled,
+		},
+		ReceiptLookupRateLimit: &limitsRate{
+			Limit:         h.cfg.ReceiptRateLimit,
+			WindowSeconds: int64(h.cfg.ReceiptRateLimitWindow / time.Second),
+		},
+		ListLimitDefault:       h.cfg.LimitDefault,
+		ListLimitMax:           h.cfg.LimitMax,
+		RefundsPerOrderMax:     h.cfg.RefundMaxCount,
+		AgreementUploadMaxSize: agreementUploadMaxSize,
+		VirtualCurrencyMax:     h.cfg.VirtualCurrencyMaxAmount,
+	}
+
+	pReq := &grpc.ListProjectsRequest{MerchantId: merchant.Item.Id, Limit: h.cfg.LimitMax}
+
+	// all projects of the merchant are listed, merchant may have more projects than fit the page
+	for {
+		projects, err := h.dispatch.Services.Billing.ListProjects(reqCtx, pReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProjects", pReq)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		for _, project := range projects.Items {
+			if !authUser.IsProjectAllowed(project.Id) {
+				continue
+			}
+
+			res.Projects = append(res.Projects, &limitsProject{
+				Id:               project.Id,
+				Name:             project.Name,
+				LimitsCurrency:   project.LimitsCurrency,
+				MinPaymentAmount: project.MinPaymentAmount,
+				MaxPaymentAmount: project.MaxPaymentAmount,
+			})
+		}
+
+		pReq.Offset += int32(len(projects.Items))
+
+		if len(projects.Items) == 0 || pReq.Offset >= projects.Count {
+			break
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type LimitsTestSuite struct {
+	suite.Suite
+	router *LimitsRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Limits(t *testing.T) {
+	suite.Run(t, new(LimitsTestSuite))
+}
+
+func (suite *LimitsTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewLimitsRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *LimitsTestSuite) TearDownTest() {}
+
+func (suite *LimitsTestSuite) TestLimits_GetLimits_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("ListProjects", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListProjectsResponse{
+			Count: 1,
+			Items: []*billing.Project{
+				{
+					Id:               "5bdc39a95d1e1100019fb7df",
+					LimitsCurrency:   "USD",
+					MinPaymentAmount: 1,
+					MaxPaymentAmount: 1000,
+				},
+			},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.cfg.RefundMaxCount = 3
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + limitsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	limits := &limitsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), limits)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), mock.OnboardingMerchantMock.Id, limits.MerchantId)
+	assert.Len(suite.T(), limits.Projects, 1)
+	assert.Equal(suite.T(), float64(1000), limits.Projects[0].MaxPaymentAmount)
+	assert.Equal(suite.T(), int32(3), limits.RefundsPerOrderMax)
+	assert.NotNil(suite.T(), limits.ReceiptLookupRateLimit)
+	assert.Equal(suite.T(), mock.OnboardingMerchantMock.Banking.Currency, limits.Payout.Currency)
+
+	bill.AssertCalled(suite.T(), "ListProjects", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListProjectsRequest) bool {
+		return req.MerchantId == mock.OnboardingMerchantMock.Id
+	}))
+}
+
+func (suite *LimitsTestSuite) TestLimits_GetLimits_AllProjectPages_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("ListProjects", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListProjectsRequest) bool {
+		return req.Offset == 0
+	})).Return(&grpc.ListProjectsResponse{Count: 2, Items: []*billing.Project{{Id: "5bdc39a95d1e1100019fb7df"}}}, nil)
+	bill.On("ListProjects", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListProjectsRequest) bool {
+		return req.Offset == 1
+	})).Return(&grpc.ListProjectsResponse{Count: 2, Items: []*billing.Project{{Id: "5bdc39a95d1e1100019fb7e0"}}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + limitsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	limits := &limitsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), limits)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), limits.Projects, 2)
+	assert.Equal(suite.T(), "5bdc39a95d1e1100019fb7e0", limits.Projects[1].Id)
+}
+
+func (suite *LimitsTestSuite) TestLimits_GetLimits_BillingServerError() {
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + limitsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+}
+
+func (suite *LimitsTestSuite) TestLimits_GetLimits_PartialFailure() {
+	bill := mock.NewBillingServerBuilder().
+		Method("GetMerchantBy").Always(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}).
+		Method("ListProjects").Fail(mock.SomeError).Always(&grpc.ListProjectsResponse{}).
+		Build()
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + limitsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + limitsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertNumberOfCalls(suite.T(), "ListProjects", 2)
+}
+
+func (suite *LimitsTestSuite) TestLimits_GetLimits_BillingServerTimeout() {
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerBuilder().
+		Method("GetMerchantBy").Latency(time.Second).Always(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk}).
+		Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + limitsPath).
+		Init(test.ReqInitJSON()).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			*request = *request.WithContext(ctx)
+		}).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"time"
+)
+
+const (
+	offboardingPath = "/offboarding"
+)
+
+const (
+	offboardingPayoutDescription      = "final payout on account closure"
+	offboardingBlockerNegativeBalance = "negative_balance"
+	offboardingBlockerOpenChargebacks = "open_chargebacks"
+)
+
+type offboardingStatus struct {
+	MerchantId     string   `json:"merchant_id"`
+	Requested      bool     `json:"requested"`
+	RequestedAt    int64    `json:"requested_at,omitempty"`
+	Completed      bool     `json:"completed"`
+	Balance        float64  `json:"balance"`
+	Currency       string   `json:"currency"`
+	ActiveProjects int      `json:"active_projects"`
+	Blockers       []string `json:"blockers"`
+	PayoutId       string   `json:"payout_id,omitempty"`
+}
+
+type OffboardingRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+	offboardings *common.Offboardings
+}
+
+// NewOffboardingRoute
+func NewOffboardingRoute(set common.HandlerSet, cfg *common.Config) *OffboardingRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "OffboardingRoute"})
+	return &OffboardingRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *OffboardingRoute) Route(groups *common.Groups) {
+	h.offboardings = common.NewOffboardings(groups.Redis)
+	groups.AuthUser.GET(offboardingPath, h.getStatus)
+	groups.AuthUser.POST(offboardingPath, h.requestClosure)
+}
+
+// @Description Account closure status of authenticated merchant with reasons which block the closure
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/offboarding
+func (h *OffboardingRoute) getStatus(ctx echo.Context) error {
+	merchant, err := h.getMerchant(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	offboarding, err := h.offboardings.Get(merchant.Id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	_, status, err := h.getOffboardingStatus(ctx.Request().Context(), merchant, offboarding)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, status)
+}
+
+// @Description Request closure of authenticated merchant account. Projects are disabled by moving them back to draft
+// @Description and positive balance is paid out by final payout document, merchant records are kept in billing
+// @Description server for retention period. Repeated request resumes closure which failed halfway
+// @Example curl -X POST -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/offboarding
+func (h *OffboardingRoute) requestClosure(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+	reqCtx := ctx.Request().Context()
+
+	merchant, err := h.getMerchant(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	locked, err := h.offboardings.Lock(merchant.Id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if !locked {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageOffboardingInProgress)
+	}
+
+	defer h.offboardings.Unlock(merchant.Id)
+
+	offboarding, err := h.offboardings.Get(merchant.Id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if offboarding != nil && offboarding.Completed {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageOffboardingAlreadyRequested)
+	}
+
+	projects, status, err := h.getOffboardingStatus(reqCtx, merchant, offboarding)
+
+	if err != nil {
+		return err
+	}
+
+	// blockers are checked when closure starts, closure which failed halfway is resumed as is
+	if offboarding == nil {
+		if len(status.Blockers) > 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageOffboardingBlocked)
+		}
+
+		offboarding = &common.Offboarding{
+			RequestedAt:      time.Now().Unix(),
+			RequestedBy:      authUser.Id,
+			DisabledProjects: make(map[string]int32),
+		}
+
+		if err = h.saveOffboarding(merchant.Id, offboarding); err != nil {
+			return err
+		}
+	}
+
+	for _, project := range projects {
+		previous := project.Status
+		project.Status = pkg.ProjectStatusDraft
+		res, err := h.dispatch.Services.Billing.ChangeProject(reqCtx, project)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ChangeProject", project)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if res.Status != pkg.ResponseStatusOk {
+			return echo.NewHTTPError(int(res.Status), res.Message)
+		}
+
+		offboarding.DisabledProjects[project.Id] = previous
+
+		if err = h.saveOffboarding(merchant.Id, offboarding); err != nil {
+			return err
+		}
+	}
+
+	// payout is the last step, so failure of any other step can't lead to second payout on retry
+	if offboarding.PayoutId == "" {
+		if offboarding.PayoutId, err = h.createPayout(ctx, merchant, status.Balance); err != nil {
+			return err
+		}
+	}
+
+	offboarding.Completed = true
+
+	if err = h.saveOffboarding(merchant.Id, offboarding); err != nil {
+		return err
+	}
+
+	status.Requested = true
+	status.RequestedAt = offboarding.RequestedAt
+	status.Completed = true
+	status.PayoutId = offboarding.PayoutId
+	status.ActiveProjects = 0
+
+	return ctx.JSON(http.StatusAccepted, status)
+}
+
+// createPayout creates final payout of positive balance of the merchant, payout created by previous attempt
+// which failed to save it is reused. Empty identifier is returned when there is nothing to pay out
+func (h *OffboardingRoute) createPayout(ctx echo.Context, merchant *billing.Merchant, balance float64) (string, error) {
+	reqCtx := ctx.Request().Context()
+	lReq := &grpc.GetPayoutDocumentsRequest{MerchantId: merchant.Id, Limit: h.cfg.LimitMax}
+
+	for {
+		lRes, err := h.dispatch.Services.Billing.GetPayoutDocuments(reqCtx, lReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetPayoutDocuments", lReq)
+			return "", echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if lRes.Status != pkg.ResponseStatusOk {
+			return "", echo.NewHTTPError(int(lRes.Status), lRes.Message)
+		}
+
+		for _, payout := range lRes.GetData().GetItems() {
+			if payout.Description == offboardingPayoutDescription {
+				return payout.Id, nil
+			}
+		}
+
+		lReq.Offset += int32(len(lRes.GetData().GetItems()))
+
+		if len(lRes.GetData().GetItems()) == 0 || lReq.Offset >= lRes.GetData().GetCount() {
+			break
+		}
+	}
+
+	if balance <= 0 {
+		return "", nil
+	}
+
+	req := &grpc.CreatePayoutDocumentRequest{
+		MerchantId:  merchant.Id,
+		Description: offboardingPayoutDescription,
+		Ip:          ctx.RealIP(),
+		Initiator:   pkg.RoyaltyReportChangeSourceAuto,
+	}
+	res, err := h.dispatch.Services.Billing.CreatePayoutDocument(reqCtx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreatePayoutDocument", req)
+		return "", echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return "", echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item.Id, nil
+}
+
+func (h *OffboardingRoute) saveOffboarding(merchantId string, offboarding *common.Offboarding) error {
+	if err := h.offboardings.Save(merchantId, offboarding); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return nil
+}
+
+func (h *OffboardingRoute) getMerchant(ctx echo.Context) (*billing.Merchant, error) {
+	req := &grpc.GetMerchantByRequest{UserId: common.ExtractUserContext(ctx).Id}
+	res, err := h.dispatch.Services.Billing.GetMerchantBy(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item, nil
+}
+
+// getOffboardingStatus collects closure status of the merchant and returns projects which are still active,
+// offboarding is requested closure of the merchant or nil
+func (h *OffboardingRoute) getOffboardingStatus(
+	ctx context.Context,
+	merchant *billing.Merchant,
+	offboarding *common.Offboarding,
+) ([]*billing.Project, *offboardingStatus, error) {
+	status := &offboardingStatus{MerchantId: merchant.Id, Blockers: []string{}}
+
+	if offboarding != nil {
+		status.Requested = true
+		status.RequestedAt = offboarding.RequestedAt
+		status.Completed = offboarding.Completed
+		status.PayoutId = offboarding.PayoutId
+	}
+
+	bReq := &grpc.GetMerchantBalanceRequest{MerchantId: merchant.Id}
+	balance, err := h.dispatch.Services.Billing.GetMerchantBalance(ctx, bReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBalance", bReq)
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if balance.Status != pkg.ResponseStatusOk {
+		return nil, nil, echo.NewHTTPError(int(balance.Status), balance.Message)
+	}
+
+	status.Balance = balance.Item.Total
+	status.Currency = balance.Item.Currency
+
+	if status.Balance < 0 {
+		status.Blockers = append(status.Blockers, offboardingBlockerNegativeBalance)
+	}
+
+	// chargebacks of the risk period may still be disputed, the account must stay until they are resolved
+	to := time.Now()
+	oReq := &grpc.ListOrdersRequest{
+		Merchant:   []string{merchant.Id},
+		Status:     []string{riskOrderStatusChargeback},
+		PmDateFrom: to.Add(-h.cfg.RiskPeriod).Unix(),
+		PmDateTo:   to.Unix(),
+		Limit:      1,
+	}
+	orders, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx, oReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "FindAllOrdersPublic", oReq)
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if orders.Status != pkg.ResponseStatusOk {
+		return nil, nil, echo.NewHTTPError(int(orders.Status), orders.Message)
+	}
+
+	if orders.GetItem().GetCount() > 0 {
+		status.Blockers = append(status.Blockers, offboardingBlockerOpenChargebacks)
+	}
+
+	active := make([]*billing.Project, 0)
+	pReq := &grpc.ListProjectsRequest{MerchantId: merchant.Id, Limit: h.cfg.LimitMax}
+
+	for {
+		projects, err := h.dispatch.Services.Billing.ListProjects(ctx, pReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProjects", pReq)
+			return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		// draft projects don't accept live payments, closure moves active projects to draft
+		for _, project := range projects.Items {
+			if project.Status != pkg.ProjectStatusDraft && project.Status != pkg.ProjectStatusDeleted {
+				active = append(active, project)
+			}
+		}
+
+		pReq.Offset += int32(len(projects.Items))
+
+		if len(projects.Items) == 0 || pReq.Offset >= projects.Count {
+			break
+		}
+	}
+
+	status.ActiveProjects = len(active)
+
+	return active, status, nil
+}
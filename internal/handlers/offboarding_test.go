@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type OffboardingTestSuite struct {
+	suite.Suite
+	router *OffboardingRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Offboarding(t *testing.T) {
+	suite.Run(t, new(OffboardingTestSuite))
+}
+
+func (suite *OffboardingTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewOffboardingRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *OffboardingTestSuite) TearDownTest() {}
+
+func (suite *OffboardingTestSuite) getBillingMock(
+	balance float64,
+	chargebacks int32,
+	payouts []*billing.PayoutDocument,
+) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetMerchantBalance", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantBalanceResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.MerchantBalance{Currency: "USD", Total: balance},
+		}, nil)
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListOrdersPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &grpc.ListOrdersPublicResponseItem{Count: chargebacks},
+		}, nil)
+	bill.On("ListProjects", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListProjectsResponse{
+			Count: 2,
+			Items: []*billing.Project{
+				{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusInProduction},
+				{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusDeleted},
+			},
+		}, nil)
+	bill.On("ChangeProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk}, nil)
+	bill.On("GetPayoutDocuments", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetPayoutDocumentsResponse{
+			Status: pkg.ResponseStatusOk,
+			Data:   &grpc.PayoutDocumentsPaginate{Count: int32(len(payouts)), Items: payouts},
+		}, nil)
+	bill.On("CreatePayoutDocument", mock2.Anything, mock2.Anything).
+		Return(&grpc.PayoutDocumentResponse{Status: pkg.ResponseStatusOk, Item: &billing.PayoutDocument{Id: bson.NewObjectId().Hex()}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	return bill
+}
+
+func (suite *OffboardingTestSuite) requestClosure() (*offboardingStatus, error) {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + offboardingPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	if err != nil {
+		return nil, err
+	}
+
+	assert.Equal(suite.T(), http.StatusAccepted, res.Code)
+
+	status := &offboardingStatus{}
+	err = json.Unmarshal(res.Body.Bytes(), status)
+
+	return status, err
+}
+
+func (suite *OffboardingTestSuite) assertHTTPError(err error, code int, msg interface{}) {
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), code, httpErr.Code)
+	assert.Equal(suite.T(), msg, httpErr.Message)
+}
+
+func (suite *OffboardingTestSuite) TestOffboarding_GetStatus_Ok() {
+	suite.getBillingMock(-10, 1, nil)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + offboardingPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	status := &offboardingStatus{}
+	err = json.Unmarshal(res.Body.Bytes(), status)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), status.Requested)
+	assert.Equal(suite.T(), 1, status.ActiveProjects)
+	assert.Equal(suite.T(), []string{offboardingBlockerNegativeBalance, offboardingBlockerOpenChargebacks}, status.Blockers)
+}
+
+func (suite *OffboardingTestSuite) TestOffboarding_RequestClosure_Ok() {
+	bill := suite.getBillingMock(150, 0, nil)
+
+	status, err := suite.requestClosure()
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), status.Requested)
+	assert.True(suite.T(), status.Completed)
+	assert.NotEmpty(suite.T(), status.PayoutId)
+
+	bill.AssertCalled(suite.T(), "CreatePayoutDocument", mock2.Anything, mock2.MatchedBy(func(req *grpc.CreatePayoutDocumentRequest) bool {
+		return req.MerchantId == mock.OnboardingMerchantMock.Id && req.Description == offboardingPayoutDescription &&
+			req.Initiator == pkg.RoyaltyReportChangeSourceAuto
+	}))
+	bill.AssertNumberOfCalls(suite.T(), "ChangeProject", 1)
+	bill.AssertCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.MatchedBy(func(req *billing.Project) bool {
+		return req.Status == pkg.ProjectStatusDraft
+	}))
+	bill.AssertNotCalled(suite.T(), "DeleteProject", mock2.Anything, mock2.Anything)
+
+	offboarding, err := common.NewOffboardings(test.Redis()).Get(mock.OnboardingMerchantMock.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "ffffffffffffffffffffffff", offboarding.RequestedBy)
+	assert.Equal(suite.T(), status.PayoutId, offboarding.PayoutId)
+	assert.Len(suite.T(), offboarding.DisabledProjects, 1)
+}
+
+func (suite *OffboardingTestSuite) TestOffboarding_RequestClosure_ResumeReusesPayout_Ok() {
+	payoutId := bson.NewObjectId().Hex()
+	offboardings := common.NewOffboardings(test.Redis())
+	err := offboardings.Save(mock.OnboardingMerchantMock.Id, &common.Offboarding{RequestedAt: 1, RequestedBy: "ffffffffffffffffffffffff"})
+	assert.NoError(suite.T(), err)
+
+	// payout was created by failed attempt, blockers appeared after closure start don't stop it
+	bill := suite.getBillingMock(150, 1, []*billing.PayoutDocument{
+		{Id: bson.NewObjectId().Hex(), Description: "royalty for june-july 2019"},
+		{Id: payoutId, Description: offboardingPayoutDescription},
+	})
+
+	status, err := suite.requestClosure()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), payoutId, status.PayoutId)
+	assert.EqualValues(suite.T(), 1, status.RequestedAt)
+	bill.AssertNotCalled(suite.T(), "CreatePayoutDocument", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OffboardingTestSuite) TestOffboarding_RequestClosure_Blocked() {
+	bill := suite.getBillingMock(0, 3, nil)
+
+	_, err := suite.requestClosure()
+	suite.assertHTTPError(err, http.StatusBadRequest, common.ErrorMessageOffboardingBlocked)
+	bill.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OffboardingTestSuite) TestOffboarding_RequestClosure_AlreadyRequested() {
+	err := common.NewOffboardings(test.Redis()).
+		Save(mock.OnboardingMerchantMock.Id, &common.Offboarding{RequestedAt: 1, Completed: true})
+	assert.NoError(suite.T(), err)
+	bill := suite.getBillingMock(0, 0, nil)
+
+	_, err = suite.requestClosure()
+	suite.assertHTTPError(err, http.StatusConflict, common.ErrorMessageOffboardingAlreadyRequested)
+	bill.AssertNotCalled(suite.T(), "CreatePayoutDocument", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OffboardingTestSuite) TestOffboarding_RequestClosure_InProgress() {
+	locked, err := common.NewOffboardings(test.Redis()).Lock(mock.OnboardingMerchantMock.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), locked)
+	bill := suite.getBillingMock(0, 0, nil)
+
+	_, err = suite.requestClosure()
+	suite.assertHTTPError(err, http.StatusConflict, common.ErrorMessageOffboardingInProgress)
+	bill.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
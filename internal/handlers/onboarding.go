@@ -157,15 +157,19 @@ func (h *OnboardingRoute) getMerchantByUser(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, res.Item)
 }
 
-// @Description List merchants by conditions
+// @Description List merchants by conditions: registration date range (received_date_from, received_date_to), set of
+// @Description statuses (status[]), company countries (country[]), last activity range (last_activity_from,
+// @Description last_activity_to), signed or unsigned agreement (is_signed) and company name or email (quick_search).
+// @Description Merchants are sorted by several fields of sort[], "-" prefix sorts the field in descending order
 // @Example curl -X GET 'Authorization: Bearer %access_token_here%' \
-//  'https://api.paysuper.online/admin/api/v1/merchants?received_date_from=1568332800'
+//  'https://api.paysuper.online/admin/api/v1/merchants?received_date_from=1568332800&country[]=DE&sort[]=-_id'
 func (h *OnboardingRoute) listMerchants(ctx echo.Context) error {
 	req := &grpc.MerchantListingRequest{}
-	err := (&common.OnboardingMerchantListingBinder{
+	binder := &common.OnboardingMerchantListingBinder{
 		LimitDefault:  h.cfg.LimitDefault,
 		OffsetDefault: h.cfg.OffsetDefault,
-	}).Bind(req, ctx)
+	}
+	err := binder.Bind(req, ctx)
 
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
@@ -177,14 +181,83 @@ func (h *OnboardingRoute) listMerchants(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
-	res, err := h.dispatch.Services.Billing.ListMerchants(ctx.Request().Context(), req)
+	var res *grpc.MerchantListingResponse
+
+	// billing server can't filter merchants by country and last activity, so merchants are filtered here
+	if len(binder.Countries) > 0 || binder.LastActivityFrom > 0 || binder.LastActivityTo > 0 {
+		countries := make(map[string]bool, len(binder.Countries))
+
+		for _, country := range binder.Countries {
+			countries[country] = true
+		}
+
+		res, err = h.listFilteredMerchants(ctx, req, func(merchant *billing.Merchant) bool {
+			if len(countries) > 0 && !countries[merchant.GetCompany().GetCountry()] {
+				return false
+			}
+
+			activity := merchant.GetUpdatedAt().GetSeconds()
+
+			return (binder.LastActivityFrom <= 0 || activity >= binder.LastActivityFrom) &&
+				(binder.LastActivityTo <= 0 || activity <= binder.LastActivityTo)
+		})
+	} else {
+		res, err = h.dispatch.Services.Billing.ListMerchants(ctx.Request().Context(), req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListMerchants", req)
+			err = echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+	}
 
 	if err != nil {
-		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListMerchants", req)
-		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		return err
 	}
 
-	return ctx.JSON(http.StatusOK, res)
+	meta := &common.EnvelopeMeta{Count: res.Count, Limit: req.Limit, Offset: req.Offset}
+	return common.ListResponse(ctx, res, res.Items, meta)
+}
+
+// listFilteredMerchants pages through all merchants matching billing server filters of the request and returns
+// page of the request of merchants kept by keep
+func (h *OnboardingRoute) listFilteredMerchants(
+	ctx echo.Context,
+	req *grpc.MerchantListingRequest,
+	keep func(merchant *billing.Merchant) bool,
+) (*grpc.MerchantListingResponse, error) {
+	limit, offset := req.Limit, req.Offset
+	res := &grpc.MerchantListingResponse{Items: []*billing.Merchant{}}
+
+	mReq := *req
+	mReq.Limit = h.cfg.LimitMax
+	mReq.Offset = 0
+
+	for {
+		page, err := h.dispatch.Services.Billing.ListMerchants(ctx.Request().Context(), &mReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListMerchants", &mReq)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		for _, merchant := range page.Items {
+			if !keep(merchant) {
+				continue
+			}
+
+			if res.Count >= offset && res.Count < offset+limit {
+				res.Items = append(res.Items, merchant)
+			}
+
+			res.Count++
+		}
+
+		mReq.Offset += int32(len(page.Items))
+
+		if len(page.Items) == 0 || mReq.Offset >= page.Count {
+			return res, nil
+		}
+	}
 }
 
 func (h *OnboardingRoute) changeMerchantStatus(ctx echo.Context) error {
@@ -590,20 +663,24 @@ func (h *OnboardingRoute) setMerchantCompany(ctx echo.Context) error {
 
 // @Description Set company contact information in merchant onboarding process
 // @Example curl -X PUT -H 'Authorization: Bearer %access_token_here%' -H 'Content-Type: application/json' \
-//  -d '{"authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "1234567890", "position": "CEO"},
-//    	"technical": {"name": "Unit Test", "email": "test@unit.test", "phone": "1234567890"}}' \
+//  -d '{"authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "+12015550120", "position": "CEO"},
+//    	"technical": {"name": "Unit Test", "email": "test@unit.test", "phone": "+12015550120"}}' \
 //  https://api.paysuper.online/admin/api/v1/merchants/contacts
 //
 // @Example curl -X PUT -H 'Authorization: Bearer %access_token_here%' -H 'Content-Type: application/json' \
-//  -d '{"authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "1234567891", "position": "CEO"},
-//    	"technical": {"name": "Unit Test", "email": "test@unit.test", "phone": "1234567890"}}' \
+//  -d '{"authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "+12015550121", "position": "CEO"},
+//    	"technical": {"name": "Unit Test", "email": "test@unit.test", "phone": "+12015550120"}}' \
 //  https://api.paysuper.online/admin/api/v1/merchants/5d4847f61986ee46ec581e26/contacts
 func (h *OnboardingRoute) setMerchantContacts(ctx echo.Context) error {
 	authUser := common.ExtractUserContext(ctx)
 	in := &billing.MerchantContact{}
-	err := ctx.Bind(in)
+	err := (&common.OnboardingChangeMerchantContactsBinder{}).Bind(in, ctx)
 
 	if err != nil {
+		if rspErr, ok := err.(*grpc.ResponseErrorMessage); ok {
+			return echo.NewHTTPError(http.StatusBadRequest, rspErr)
+		}
+
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
 	}
 
@@ -893,3 +970,39 @@ func (h *OnboardingRoute) changeMerchantManualPayout(ctx echo.Context, enableMan
 
 	return ctx.JSON(http.StatusOK, res.Item)
 }
+
+// getUserMerchant returns merchant of authenticated user, merchants of other users are forbidden
+func getUserMerchant(ctx echo.Context, set common.HandlerSet, log logger.Logger, merchantId string) (*billing.Merchant, error) {
+	if !bson.IsObjectIdHex(merchantId) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectMerchantId)
+	}
+
+	merchant, err := getAuthUserMerchant(ctx, set, log)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant.Id != merchantId {
+		return nil, echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	return merchant, nil
+}
+
+// getAuthUserMerchant returns merchant of the authenticated user
+func getAuthUserMerchant(ctx echo.Context, set common.HandlerSet, log logger.Logger) (*billing.Merchant, error) {
+	req := &grpc.GetMerchantByRequest{UserId: common.ExtractUserContext(ctx).Id}
+	res, err := set.Services.Billing.GetMerchantBy(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(log, err, pkg.ServiceName, "GetMerchantBy", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item, nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/globalsign/mgo/bson"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/labstack/echo/v4"
 	awsWrapper "github.com/paysuper/paysuper-aws-manager"
 	awsWrapperMocks "github.com/paysuper/paysuper-aws-manager/pkg/mocks"
@@ -27,7 +28,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 )
 
 type OnboardingTestSuite struct {
@@ -1647,6 +1650,81 @@ func (suite *OnboardingTestSuite) TestOnboarding_SetMerchantContacts_ValidationE
 	assert.Regexp(suite.T(), "Phone", msg.Details)
 }
 
+func (suite *OnboardingTestSuite) TestOnboarding_SetMerchantContacts_PhoneNormalized_Ok() {
+	b := `{
+        "authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "+1 (201) 555-0123", "position": "CEO"},
+        "technical": {"name": "Unit Test", "email": "test@unit.test", "phone": "+44 121 234 5678"}
+    }`
+
+	billingService := &billMock.BillingService{}
+	billingService.On("ChangeMerchant", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeMerchantResponse{Status: pkg.ResponseStatusOk, Item: &billing.Merchant{}}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Path(common.AuthUserGroupPath + merchantsContactsPath).
+		Init(test.ReqInitJSON()).
+		BodyString(b).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	billingService.AssertCalled(suite.T(), "ChangeMerchant", mock2.Anything, mock2.MatchedBy(func(req *grpc.OnboardingRequest) bool {
+		return req.Contacts.Authorized.Phone == "+12015550123" && req.Contacts.Technical.Phone == "+441212345678"
+	}))
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_SetMerchantContacts_NationalPhoneKept_Ok() {
+	b := `{
+        "authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "1234567890", "position": "CEO"},
+        "technical": {"name": "Unit Test", "email": "test@unit.test", "phone": "(201) 555-0123"}
+    }`
+
+	billingService := &billMock.BillingService{}
+	billingService.On("ChangeMerchant", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeMerchantResponse{Status: pkg.ResponseStatusOk, Item: &billing.Merchant{}}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Path(common.AuthUserGroupPath + merchantsContactsPath).
+		Init(test.ReqInitJSON()).
+		BodyString(b).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	billingService.AssertCalled(suite.T(), "ChangeMerchant", mock2.Anything, mock2.MatchedBy(func(req *grpc.OnboardingRequest) bool {
+		return req.Contacts.Authorized.Phone == "1234567890" && req.Contacts.Technical.Phone == "(201) 555-0123"
+	}))
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_SetMerchantContacts_InvalidPhone() {
+	b := `{
+        "authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "unit test", "position": "CEO"},
+        "technical": {"name": "Unit Test", "email": "test@unit.test", "phone": "+12015550123"}
+    }`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Path(common.AuthUserGroupPath + merchantsContactsPath).
+		Init(test.ReqInitJSON()).
+		BodyString(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageIncorrectPhone.Code, msg.Code)
+	assert.Equal(suite.T(), "authorized.phone", msg.Details)
+}
+
 func (suite *OnboardingTestSuite) TestOnboarding_SetMerchantContacts_ValidationError_AuthorizedPosition() {
 	b := `{
         "authorized": {"name": "Unit Test", "email": "test@unit.test", "phone": "1234567890"},
@@ -2460,3 +2538,173 @@ func (suite *OnboardingTestSuite) TestOnboarding_disableMerchantManualPayout_Ok(
 
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
 }
+
+func (suite *OnboardingTestSuite) TestOnboarding_ListMerchants_IsSignedFilter_Ok() {
+	billingService := &billMock.BillingService{}
+	billingService.On("ListMerchants", mock2.Anything, mock2.Anything).
+		Return(&grpc.MerchantListingResponse{}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	for val, expected := range map[string]int32{"false": 1, "true": 2} {
+		_, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			SetQueryParam(common.RequestParameterIsSigned, val).
+			Path(common.AuthUserGroupPath + merchantsPath).
+			Init(test.ReqInitJSON()).
+			Exec(suite.T())
+
+		assert.NoError(suite.T(), err)
+
+		expected := expected
+		billingService.AssertCalled(suite.T(), "ListMerchants", mock2.Anything, mock2.MatchedBy(func(req *grpc.MerchantListingRequest) bool {
+			return req.IsSigned == expected
+		}))
+	}
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_ListMerchants_InvalidDateRange_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam("received_date_from", "1568332800").
+		SetQueryParam("received_date_to", "1568246400").
+		Path(common.AuthUserGroupPath + merchantsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorRequestParamsIncorrect, httpErr.Message)
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_ListMerchants_InvalidSort_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam(common.QueryParameterNameSort, "name;drop").
+		Path(common.AuthUserGroupPath + merchantsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_ListMerchants_DefaultSort_Ok() {
+	billingService := &billMock.BillingService{}
+	billingService.On("ListMerchants", mock2.Anything, mock2.Anything).
+		Return(&grpc.MerchantListingResponse{}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	q := make(url.Values)
+	q.Add(common.QueryParameterNameSort, "_id")
+	q.Add(common.QueryParameterNameSort, "-status_last_updated_at")
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParams(q).
+		Path(common.AuthUserGroupPath + merchantsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	billingService.AssertCalled(suite.T(), "ListMerchants", mock2.Anything, mock2.MatchedBy(func(req *grpc.MerchantListingRequest) bool {
+		return len(req.Sort) == 2 && req.Sort[0] == "_id" && req.Sort[1] == "-status_last_updated_at"
+	}))
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_ListMerchants_StatusesFilter_Ok() {
+	billingService := &billMock.BillingService{}
+	billingService.On("ListMerchants", mock2.Anything, mock2.Anything).
+		Return(&grpc.MerchantListingResponse{}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	q := make(url.Values)
+	q.Add(common.RequestParameterStatuses, "1")
+	q.Add(common.RequestParameterStatuses, "4")
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParams(q).
+		Path(common.AuthUserGroupPath + merchantsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	billingService.AssertCalled(suite.T(), "ListMerchants", mock2.Anything, mock2.MatchedBy(func(req *grpc.MerchantListingRequest) bool {
+		return len(req.Statuses) == 2 && req.Statuses[0] == 1 && req.Statuses[1] == 4
+	}))
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_ListMerchants_CountryAndActivityFilter_Ok() {
+	now := time.Now().Unix()
+	merchants := []*billing.Merchant{
+		{
+			Id:        bson.NewObjectId().Hex(),
+			Company:   &billing.MerchantCompanyInfo{Country: "DE"},
+			UpdatedAt: &timestamp.Timestamp{Seconds: now - 3600},
+		},
+		{
+			Id:        bson.NewObjectId().Hex(),
+			Company:   &billing.MerchantCompanyInfo{Country: "RU"},
+			UpdatedAt: &timestamp.Timestamp{Seconds: now - 3600},
+		},
+		{
+			Id:        bson.NewObjectId().Hex(),
+			Company:   &billing.MerchantCompanyInfo{Country: "DE"},
+			UpdatedAt: &timestamp.Timestamp{Seconds: now - 30*86400},
+		},
+		{
+			Id:        bson.NewObjectId().Hex(),
+			Company:   &billing.MerchantCompanyInfo{Country: "FR"},
+			UpdatedAt: &timestamp.Timestamp{Seconds: now - 60},
+		},
+	}
+	billingService := &billMock.BillingService{}
+	billingService.On("ListMerchants", mock2.Anything, mock2.Anything).
+		Return(&grpc.MerchantListingResponse{Count: int32(len(merchants)), Items: merchants}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	q := make(url.Values)
+	q.Add(common.RequestParameterCountries, "DE")
+	q.Add(common.RequestParameterCountries, "FR")
+	q.Set(common.RequestParameterLastActivityFrom, strconv.FormatInt(now-86400, 10))
+	q.Set(common.RequestParameterOffset, "1")
+	q.Set(common.RequestParameterLimit, "1")
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParams(q).
+		Path(common.AuthUserGroupPath + merchantsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	list := &grpc.MerchantListingResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), list))
+	assert.EqualValues(suite.T(), 2, list.Count)
+	assert.Len(suite.T(), list.Items, 1)
+	assert.Equal(suite.T(), merchants[3].Id, list.Items[0].Id)
+}
+
+func (suite *OnboardingTestSuite) TestOnboarding_ListMerchants_InvalidCountry_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam(common.RequestParameterCountries, "Germany").
+		Path(common.AuthUserGroupPath + merchantsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
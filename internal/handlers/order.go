@@ -2,16 +2,27 @@ package handlers
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
 	u "github.com/PuerkitoBio/purell"
+	"github.com/globalsign/mgo/bson"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/money"
+	"github.com/paysuper/paysuper-management-api/internal/vault"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -21,6 +32,7 @@ const (
 	orderPath                = "/order"
 	paymentPath              = "/payment"
 	orderRefundsPath         = "/order/:order_id/refunds"
+	orderEmailScreeningPath  = "/order/:order_id/email_screening"
 	orderRefundsIdsPath      = "/order/:order_id/refunds/:refund_id"
 	orderReplaceCodePath     = "/order/:order_id/replace_code"
 	orderLanguagePath        = "/orders/:order_id/language"
@@ -30,12 +42,164 @@ const (
 	orderNotifyNewRegionPath = "/orders/:order_id/notify_new_region"
 	orderPlatformPath        = "/orders/:order_id/platform"
 	orderReceiptPath         = "/orders/receipt/:receipt_id/:order_id"
+	receiptPath              = "/receipts/:receipt_id"
+	refundsBulkPath          = "/refunds/bulk"
+	refundsBulkIdPath        = "/refunds/bulk/:id"
+	refundsApprovalsPath     = "/refunds/approvals"
+	refundsApprovalsIdPath   = "/refunds/approvals/:id"
+	refundsApprovePath       = "/refunds/approvals/:id/approve"
+	refundsRejectPath        = "/refunds/approvals/:id/reject"
+	orderExportPath          = "/order/export"
 )
 
 const (
 	errorTemplateName = "error.html"
+
+	orderVirtualCurrencyAmountField = "virtual_currency_amount"
+	orderPlatformField              = common.RequestParameterPlatform
+	orderMetadataParameterPrefix    = "metadata_"
+	orderCartItemQuantityMax        = 100
+
+	orderExportFormatCsv    = "csv"
+	orderExportFormatJson   = "json"
+	orderExportCsvFileName  = "orders.csv"
+	orderExportJsonFileName = "orders.ndjson"
+	orderExportFormatParam  = "format"
+	orderExportAfterParam   = "after"
+
+	orderExportStatusComplete = "complete"
+	orderExportStatusFailed   = "failed"
+
+	headerTotalCount   = "X-Total-Count"
+	headerTrailer      = "Trailer"
+	headerExportStatus = "X-Export-Status"
+
+	mimeApplicationNdjson = "application/x-ndjson"
 )
 
+// orders are exported in order of creation, listing sorted by immutable creation time isn't shifted by orders
+// changed or created during the export
+var orderExportSort = []string{"created_at", "_id"}
+
+// orderAttributionCreateSkew is the largest difference between create time of the order in billing server and
+// in attribution index
+const orderAttributionCreateSkew = time.Minute
+
+type orderCartItem struct {
+	ProductId string `json:"product_id" validate:"required,hexadecimal,len=24"`
+	Quantity  int32  `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+type orderCart struct {
+	Cart []*orderCartItem `json:"cart" validate:"omitempty,max=50,dive"`
+}
+
+type orderAttribution struct {
+	UtmSource   string `json:"utm_source" validate:"omitempty,max=128,printascii"`
+	UtmMedium   string `json:"utm_medium" validate:"omitempty,max=128,printascii"`
+	UtmCampaign string `json:"utm_campaign" validate:"omitempty,max=128,printascii"`
+	AdId        string `json:"ad_id" validate:"omitempty,max=128,printascii"`
+}
+
+// orderMetadata is merchant's own key-value data carried through the order, non-string values are rejected by decoding
+type orderMetadata struct {
+	Metadata map[string]string `json:"metadata" validate:"omitempty,max=20,dive,keys,min=1,max=40,printascii,endkeys,max=500"`
+}
+
+type bulkRefundItem struct {
+	OrderId string  `json:"order_id"`
+	Amount  float64 `json:"amount"`
+	Reason  string  `json:"reason"`
+}
+
+type bulkRefundRequest struct {
+	Reason string            `json:"reason"`
+	Items  []*bulkRefundItem `json:"items"`
+}
+
+type orderExportRequest struct {
+	Format string `validate:"omitempty,oneof=csv json"`
+	After  string `validate:"omitempty,uuid"`
+}
+
+type orderPlatform struct {
+	Platform string `json:"platform" validate:"omitempty,max=32,printascii"`
+}
+
+// orderVirtualCurrency amount is limited by VIRTUAL_CURRENCY_MAX_AMOUNT, max is the ceiling of the setting,
+// so order amount in minor units doesn't overflow for any sane price
+type orderVirtualCurrency struct {
+	Amount int64 `json:"virtual_currency_amount" validate:"omitempty,min=1,max=1000000000"`
+}
+
+// orderJsonExtra is fields of json order request which aren't passed to billing server as is, they're decoded
+// by the order binder together with the order
+type orderJsonExtra struct {
+	orderAttribution
+	orderMetadata
+	orderCart
+	orderPlatform
+	orderVirtualCurrency
+}
+
+// orderProject reads project of the created order on first use, so checks of the order request share one read
+type orderProject struct {
+	h         *OrderRoute
+	ctx       echo.Context
+	projectId string
+	rsp       *grpc.ChangeProjectResponse
+	err       error
+}
+
+func (p *orderProject) get() (*billing.Project, error) {
+	if p.rsp == nil && p.err == nil {
+		req := &grpc.GetProjectRequest{ProjectId: p.projectId}
+		p.rsp, p.err = p.h.dispatch.Services.Billing.GetProject(p.ctx.Request().Context(), req)
+
+		if p.err != nil {
+			common.LogSrvCallFailedGRPC(p.h.L(), p.err, pkg.ServiceName, "GetProject", req)
+		}
+	}
+
+	if p.err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if p.rsp.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(p.rsp.Status), p.rsp.Message)
+	}
+
+	return p.rsp.Item, nil
+}
+
+// orderReceiptPublic is receipt of the order shown to payer without authorization, it contains data the payer
+// already knows only, payer's email, address and ip aren't returned and card number is masked
+type orderReceiptPublic struct {
+	ReceiptId          string                           `json:"receipt_id"`
+	TransactionId      string                           `json:"transaction_id"`
+	TransactionDate    string                           `json:"transaction_date"`
+	ProjectName        string                           `json:"project_name"`
+	MerchantName       string                           `json:"merchant_name"`
+	PlatformName       string                           `json:"platform_name,omitempty"`
+	Items              []*orderReceiptPublicItem        `json:"items"`
+	TotalPrice         string                           `json:"total_price"`
+	TotalCharge        string                           `json:"total_charge"`
+	VatPayer           string                           `json:"vat_payer"`
+	VatInOrderCurrency string                           `json:"vat_in_order_currency"`
+	VatInPayerCurrency string                           `json:"vat_in_payer_currency"`
+	PaymentMethod      *orderReceiptPublicPaymentMethod `json:"payment_method,omitempty"`
+}
+
+type orderReceiptPublicItem struct {
+	Name  string `json:"name"`
+	Price string `json:"price"`
+}
+
+type orderReceiptPublicPaymentMethod struct {
+	Name      string `json:"name"`
+	MaskedPan string `json:"masked_pan,omitempty"`
+}
+
 type CreateOrderJsonProjectResponse struct {
 	Id              string                    `json:"id"`
 	PaymentFormUrl  string                    `json:"payment_form_url"`
@@ -67,8 +231,19 @@ func (b *OrderListRefundsBinder) Bind(i interface{}, ctx echo.Context) error {
 }
 
 type OrderRoute struct {
-	dispatch common.HandlerSet
-	cfg      common.Config
+	dispatch        common.HandlerSet
+	cfg             common.Config
+	rateLimits      *common.RateLimits
+	receiptLimiter  *common.RateLimiter
+	projectIssues   *common.ProjectIssues
+	refundPolicies  *common.RefundPolicies
+	refundApprovals *common.RefundApprovals
+	bulkRefunds     *common.BulkRefunds
+	attributions    *common.OrderAttributions
+	carts           *common.OrderCarts
+	exportSlots     *common.ExportSlots
+	emailScreening  *common.EmailScreenings
+	emailResolver   common.EmailResolver
 	provider.LMT
 }
 
@@ -82,19 +257,39 @@ func NewOrderRoute(set common.HandlerSet, cfg *common.Config) *OrderRoute {
 }
 
 func (h *OrderRoute) Route(groups *common.Groups) {
+	h.projectIssues = groups.ProjectIssues
+	h.rateLimits = groups.RateLimits
+	h.receiptLimiter = groups.RateLimits.Limiter("receipt", h.cfg.ReceiptRateLimit, h.cfg.ReceiptRateLimitWindow)
+	h.refundPolicies = common.NewRefundPolicies(groups.Redis)
+	h.refundApprovals = common.NewRefundApprovals(groups.Redis, h.cfg.RefundApprovalTtl)
+	h.bulkRefunds = common.NewBulkRefunds(groups.Redis, h.cfg.RefundBulkTtl)
+	h.attributions = common.NewOrderAttributions(groups.Redis, h.cfg.OrderAttributionTTL)
+	h.carts = common.NewOrderCarts(groups.Redis, h.cfg.OrderAttributionTTL)
+	h.exportSlots = common.NewExportSlots(groups.Redis, h.cfg.ExportConcurrencyLimit)
+	h.emailScreening = common.NewEmailScreenings(groups.Redis, h.cfg.PaymentEmailScreeningVerdictTtl)
+	h.emailResolver = net.DefaultResolver
+
+	// form order creation isn't in integration api group, it's logged in requests of merchant of the project too
+	requestLog := groups.RequestLog.Middleware()
+
 	groups.AuthProject.GET(orderIdPath, h.getPaymentFormData)
-	groups.Common.GET(paylinkIdPath, h.getOrderForPaylink)       // TODO: Need a test
-	groups.Common.GET(orderCreatePath, h.createFromFormData)     // TODO: Need a test
-	groups.Common.POST(orderCreatePath, h.createFromFormData)    // TODO: Need a test
-	groups.AuthProject.POST(orderPath, h.createJson)             // TODO: Need a test
-	groups.AuthProject.POST(paymentPath, h.processCreatePayment) // TODO: Need a test
+	groups.Common.GET(paylinkIdPath, h.getOrderForPaylink)                // TODO: Need a test
+	groups.Common.GET(orderCreatePath, h.createFromFormData, requestLog)  // TODO: Need a test
+	groups.Common.POST(orderCreatePath, h.createFromFormData, requestLog) // TODO: Need a test
+	groups.AuthProject.POST(orderPath, h.createJson)                      // TODO: Need a test
+	groups.AuthProject.POST(paymentPath, h.processCreatePayment)          // TODO: Need a test
 
 	groups.AuthUser.GET(orderPath, h.listOrdersPublic)
+	groups.AuthUser.GET(orderExportPath, h.exportOrders)
 	groups.AuthUser.GET(orderIdPath, h.getOrderPublic) // TODO: Need a test
+	groups.AuthUser.GET(orderEmailScreeningPath, h.getEmailScreeningVerdict)
 
 	groups.AuthUser.GET(orderRefundsPath, h.listRefunds)
 	groups.AuthUser.GET(orderRefundsIdsPath, h.getRefund)
 	groups.AuthUser.POST(orderRefundsPath, h.createRefund)
+	groups.AuthUser.POST(refundsBulkPath, h.createBulkRefund)
+	groups.AuthUser.GET(refundsBulkIdPath, h.getBulkRefund)
+	groups.AuthUser.GET(refundsApprovalsIdPath, h.getRefundApproval)
 	groups.AuthUser.PUT(orderReplaceCodePath, h.replaceCode)
 
 	groups.AuthProject.PATCH(orderLanguagePath, h.changeLanguage)
@@ -105,6 +300,11 @@ func (h *OrderRoute) Route(groups *common.Groups) {
 	groups.AuthProject.POST(orderPlatformPath, h.changePlatform)
 
 	groups.Common.GET(orderReceiptPath, h.getReceipt)
+	groups.AuthProject.GET(receiptPath, h.getReceiptPublic)
+
+	groups.System.GET(refundsApprovalsPath, h.listRefundApprovals)
+	groups.System.POST(refundsApprovePath, h.approveRefund)
+	groups.System.POST(refundsRejectPath, h.rejectRefund)
 }
 
 // @Summary Create order with HTML form
@@ -139,7 +339,15 @@ func (h *OrderRoute) createFromFormData(ctx echo.Context) error {
 		IsJson:  false,
 	}
 
-	if err := (&common.OrderFormBinder{}).Bind(req, ctx); err != nil {
+	err := (&common.OrderFormBinder{}).Bind(req, ctx)
+	common.SetRequestLogProject(ctx, req.ProjectId)
+
+	if err != nil {
+		// invalid payer phone is reported with the field name
+		if rspErr, ok := err.(*grpc.ResponseErrorMessage); ok {
+			return echo.NewHTTPError(http.StatusBadRequest, rspErr)
+		}
+
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestDataInvalid)
 	}
 
@@ -149,6 +357,14 @@ func (h *OrderRoute) createFromFormData(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
+	if err := h.processAttribution(ctx, req, nil); err != nil {
+		return err
+	}
+
+	if err := common.CheckAmountPrecision(req.Currency, req.Amount); err != nil {
+		return err
+	}
+
 	orderResponse, err := h.dispatch.Services.Billing.OrderCreateProcess(ctx.Request().Context(), req)
 
 	if err != nil {
@@ -156,10 +372,14 @@ func (h *OrderRoute) createFromFormData(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorUnknown)
 	}
 
+	h.recordOrderCreate(req.ProjectId, orderResponse)
+
 	if orderResponse.Status != http.StatusOK {
 		return echo.NewHTTPError(int(orderResponse.Status), orderResponse.Message)
 	}
 
+	h.recordAttribution(req, orderResponse.Item)
+
 	rUrl := "/order/" + orderResponse.Item.Id
 
 	return ctx.Redirect(http.StatusFound, rUrl)
@@ -172,9 +392,22 @@ func (h *OrderRoute) createFromFormData(ctx echo.Context) error {
 // 3) By payment form client request without anything user identification information.
 func (h *OrderRoute) createJson(ctx echo.Context) error {
 	req := &billing.OrderCreateRequest{}
-	err := (&common.OrderJsonBinder{}).Bind(req, ctx)
+	extra := &orderJsonExtra{}
+	err := (&common.OrderJsonBinder{Extra: extra}).Bind(req, ctx)
+	common.SetRequestLogProject(ctx, req.ProjectId)
 
 	if err != nil {
+		// invalid user phone is reported with the field name
+		if rspErr, ok := err.(*grpc.ResponseErrorMessage); ok {
+			return echo.NewHTTPError(http.StatusBadRequest, rspErr)
+		}
+
+		// non-string metadata values are rejected by decoding
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok &&
+			(typeErr.Field == "metadata" || strings.HasPrefix(typeErr.Field, "metadata.")) {
+			return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageOrderMetadataInvalid)
+		}
+
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
 	}
 
@@ -184,15 +417,54 @@ func (h *OrderRoute) createJson(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
-	// If request contain user object then paysuper must check request signature
+	project := &orderProject{h: h, ctx: ctx, projectId: req.ProjectId}
+
+	// If request contain user object then paysuper must check request signature, the signature is checked before
+	// the request causes any catalog lookups. Project which can't be read leaves the check to billing server
 	if req.User != nil {
-		httpErr := common.CheckProjectAuthRequestSignature(h.dispatch, ctx, req.ProjectId)
+		getProject := func() *billing.Project {
+			item, _ := project.get()
+			return item
+		}
+		httpErr := common.CheckProjectAuthRequestSignature(h.dispatch, ctx, req.ProjectId, getProject)
 
 		if httpErr != nil {
 			return httpErr
 		}
 	}
 
+	// additional order parameters are decoded from json body only, form request carries them as form fields
+	var attr *orderAttribution
+
+	if strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) &&
+		len(common.ExtractRawBodyContext(ctx)) > 0 {
+		attr = &extra.orderAttribution
+	}
+
+	if err = h.processAttribution(ctx, req, attr); err != nil {
+		return err
+	}
+
+	if err = h.processMetadata(req, &extra.orderMetadata); err != nil {
+		return err
+	}
+
+	if err = h.processCart(ctx, req, &extra.orderCart, &extra.orderPlatform, project); err != nil {
+		return err
+	}
+
+	if err = h.processVirtualCurrency(req, &extra.orderVirtualCurrency, project); err != nil {
+		return err
+	}
+
+	if err = h.processPlatform(ctx, req, &extra.orderPlatform, project); err != nil {
+		return err
+	}
+
+	if err = common.CheckAmountPrecision(req.Currency, req.Amount); err != nil {
+		return err
+	}
+
 	ctxReq := ctx.Request().Context()
 	req.IssuerUrl = ctx.Request().Header.Get(common.HeaderReferer)
 
@@ -227,11 +499,15 @@ func (h *OrderRoute) createJson(ctx echo.Context) error {
 			return echo.NewHTTPError(http.StatusBadRequest, common.ErrorUnknown)
 		}
 
+		h.recordOrderCreate(req.ProjectId, orderResponse)
+
 		if orderResponse.Status != http.StatusOK {
 			return echo.NewHTTPError(int(orderResponse.Status), orderResponse.Message)
 		}
 
 		order = orderResponse.Item
+		h.recordAttribution(req, order)
+		h.recordCart(req, order)
 	}
 
 	response := &CreateOrderJsonProjectResponse{
@@ -242,6 +518,91 @@ func (h *OrderRoute) createJson(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, response)
 }
 
+// recordAttribution indexes attribution parameters of the created order for filtering and reports by them,
+// failure is logged only, the order is created already
+func (h *OrderRoute) recordAttribution(req *billing.OrderCreateRequest, order *billing.Order) {
+	if h.attributions == nil || order == nil {
+		return
+	}
+
+	if err := h.attributions.Record(req.ProjectId, order.Uuid, req.Other, time.Now()); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", order.Uuid))
+	}
+}
+
+// recordCart saves quantities of products of the created order, products of cart are repeated by their quantity
+// in the order create request. Orders without repeated products aren't saved, quantity of their products is one
+func (h *OrderRoute) recordCart(req *billing.OrderCreateRequest, order *billing.Order) {
+	if h.carts == nil || order == nil {
+		return
+	}
+
+	quantities := make(map[string]int32, len(req.Products))
+	repeated := false
+
+	for _, productId := range req.Products {
+		quantities[productId]++
+		repeated = repeated || quantities[productId] > 1
+	}
+
+	if !repeated {
+		return
+	}
+
+	if err := h.carts.Record(order.Uuid, quantities); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", order.Uuid))
+	}
+}
+
+// recordOrderCreate counts order creation rejected by billing server against the latest configuration change
+// of the project and notifies the merchant once the change is detected to break payments
+func (h *OrderRoute) recordOrderCreate(projectId string, res *grpc.OrderCreateProcessResponse) {
+	if h.projectIssues == nil {
+		return
+	}
+
+	errorCode := ""
+
+	if res.Status != http.StatusOK {
+		errorCode = strconv.Itoa(int(res.Status))
+
+		if res.Message != nil && res.Message.Code != "" {
+			errorCode = res.Message.Code
+		}
+	}
+
+	issue, err := h.projectIssues.RecordOrderCreate(projectId, errorCode)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", projectId))
+		return
+	}
+
+	if issue == nil {
+		return
+	}
+
+	// call with background context to notify the merchant regardless of the order creation response
+	go func() {
+		req := &grpc.NotificationRequest{
+			MerchantId: issue.MerchantId,
+			UserId:     issue.UserId,
+			Title:      projectIssueNotificationTitle,
+			Message:    getProjectIssueMessage(issue),
+		}
+		res, err := h.dispatch.Services.Billing.CreateNotification(context.Background(), req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateNotification", req)
+			return
+		}
+
+		if res.Status != pkg.ResponseStatusOk {
+			h.L().Error("project issue notification failed", logger.PairArgs("project_id", issue.ProjectId, "status", res.Status))
+		}
+	}()
+}
+
 func (h *OrderRoute) getPaymentFormData(ctx echo.Context) error {
 	id := ctx.Param(common.RequestParameterId)
 
@@ -356,10 +717,12 @@ func (h *OrderRoute) getOrderPublic(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, res.Item)
 }
 
-// @Description Get orders list
+// @Description Get orders list. Orders of one project may be filtered by attribution parameters utm_source,
+// @Description utm_medium, utm_campaign, ad_id and by platform, such filter can't be combined with other filters
 // @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' -H 'Content-Type: application/json' \
 //  https://api.paysuper.online/admin/api/v1/order?project[]=%project_identifier_here%
 func (h *OrderRoute) listOrdersPublic(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
 
 	req := &grpc.ListOrdersRequest{}
 	err := ctx.Bind(req)
@@ -368,6 +731,14 @@ func (h *OrderRoute) listOrdersPublic(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
 	}
 
+	projects, ok := authUser.FilterProjects(req.Project)
+
+	if !ok {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	req.Project = projects
+
 	if req.Limit <= 0 {
 		req.Limit = h.cfg.LimitDefault
 	}
@@ -382,6 +753,14 @@ func (h *OrderRoute) listOrdersPublic(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
+	if err = h.checkOrderSearchComplexity(req); err != nil {
+		return err
+	}
+
+	if filter := h.getAttributionFilter(ctx); len(filter) > 0 {
+		return h.listOrdersByAttribution(ctx, req, filter)
+	}
+
 	res, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx.Request().Context(), req)
 
 	if err != nil {
@@ -393,155 +772,1350 @@ func (h *OrderRoute) listOrdersPublic(ctx echo.Context) error {
 		return echo.NewHTTPError(int(res.Status), res.Message)
 	}
 
-	return ctx.JSON(http.StatusOK, res.Item)
+	meta := &common.EnvelopeMeta{Count: res.GetItem().GetCount(), Limit: req.Limit, Offset: req.Offset}
+	return common.ListResponse(ctx, res.Item, res.GetItem().GetItems(), meta)
 }
 
-// Create payment by order
-// route POST /api/v1/payment
-func (h *OrderRoute) processCreatePayment(ctx echo.Context) error {
-	data := make(map[string]string)
-	err := (&common.PaymentCreateProcessBinder{}).Bind(data, ctx)
+func (h *OrderRoute) getAttributionFilter(ctx echo.Context) map[string]string {
+	filter := make(map[string]string)
 
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestDataInvalid)
+	for _, dimension := range common.OrderAttributionDimensions {
+		if val := ctx.QueryParam(dimension); val != "" {
+			filter[dimension] = val
+		}
 	}
 
-	req := &grpc.PaymentCreateRequest{
-		Data:           data,
-		AcceptLanguage: ctx.Request().Header.Get(common.HeaderAcceptLanguage),
-		UserAgent:      ctx.Request().Header.Get(common.HeaderUserAgent),
-		Ip:             ctx.RealIP(),
-	}
-	res, err := h.dispatch.Services.Billing.PaymentCreateProcess(ctx.Request().Context(), req)
+	return filter
+}
 
-	if err != nil {
-		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "PaymentCreateProcess", req)
-		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorUnknown)
+// listOrdersByAttribution lists orders of the project found in attribution index, billing server can't filter
+// orders by attribution parameters, so orders of the page are requested at once by period of their creation
+// and picked by identifier
+func (h *OrderRoute) listOrdersByAttribution(
+	ctx echo.Context,
+	req *grpc.ListOrdersRequest,
+	filter map[string]string,
+) error {
+	if len(req.Project) != 1 || len(req.Status) > 0 || req.Account != "" || req.QuickSearch != "" ||
+		req.PmDateFrom > 0 || req.PmDateTo > 0 || req.ProjectDateFrom > 0 || req.ProjectDateTo > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageOrderAttributionFilterInvalid)
 	}
 
-	if res.Status != pkg.ResponseStatusOk {
-		return echo.NewHTTPError(int(res.Status), res.Message)
-	}
+	project, err := getUserProject(ctx, h.dispatch, h.L(), req.Project[0])
 
-	body := map[string]interface{}{
-		"redirect_url":  res.RedirectUrl,
-		"need_redirect": res.NeedRedirect,
+	if err != nil {
+		return err
 	}
 
-	return ctx.JSON(http.StatusOK, body)
-}
+	page, err := h.attributions.Find(project.Id, filter, int64(req.Offset), int64(req.Limit))
 
-func (h *OrderRoute) getRefund(ctx echo.Context) error {
-	req := &grpc.GetRefundRequest{
-		OrderId:  ctx.Param(common.RequestParameterOrderId),
-		RefundId: ctx.Param(common.RequestParameterRefundId),
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
 	}
 
-	err := h.dispatch.Validate.Struct(req)
+	orders, err := h.findOrdersByIds(ctx.Request().Context(), project.Id, page)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+		return err
 	}
 
-	res, err := h.dispatch.Services.Billing.GetRefund(ctx.Request().Context(), req)
+	items := make([]*billing.OrderViewPublic, 0, len(page.Ids))
 
-	if err != nil {
-		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetRefund", req)
-		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	// index outlives orders removed from billing server, they aren't listed
+	for _, id := range page.Ids {
+		if order, ok := orders[id]; ok {
+			items = append(items, order)
+		}
 	}
 
-	if res.Status != pkg.ResponseStatusOk {
-		return echo.NewHTTPError(int(res.Status), res.Message)
-	}
+	legacy := &grpc.ListOrdersPublicResponseItem{Count: int32(page.Count), Items: items}
+	meta := &common.EnvelopeMeta{Count: legacy.Count, Limit: req.Limit, Offset: req.Offset}
 
-	return ctx.JSON(http.StatusOK, res.Item)
+	return common.ListResponse(ctx, legacy, items, meta)
 }
 
-func (h *OrderRoute) listRefunds(ctx echo.Context) error {
-	req := &grpc.ListRefundsRequest{}
-	err := (&OrderListRefundsBinder{}).Bind(req, ctx)
+// findOrdersByIds returns orders of the attribution page by identifier. Orders are listed by period of creation
+// of the page orders, the period is widened by orderAttributionCreateSkew as orders are indexed after billing server
+// creates them. Listing stops once all orders of the page are found
+func (h *OrderRoute) findOrdersByIds(
+	ctx context.Context,
+	projectId string,
+	page *common.OrderAttributionPage,
+) (map[string]*billing.OrderViewPublic, error) {
+	orders := make(map[string]*billing.OrderViewPublic, len(page.Ids))
 
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	if len(page.Ids) == 0 {
+		return orders, nil
 	}
 
-	err = h.dispatch.Validate.Struct(req)
+	ids := make(map[string]bool, len(page.Ids))
 
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	for _, id := range page.Ids {
+		ids[id] = true
 	}
 
-	res, err := h.dispatch.Services.Billing.ListRefunds(ctx.Request().Context(), req)
-
-	if err != nil {
-		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListRefunds", req)
-		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	req := &grpc.ListOrdersRequest{
+		Project:         []string{projectId},
+		ProjectDateFrom: page.From.Add(-orderAttributionCreateSkew).Unix(),
+		ProjectDateTo:   page.To.Add(orderAttributionCreateSkew).Unix(),
+		Limit:           h.cfg.LimitMax,
+		Sort:            orderExportSort,
 	}
 
-	return ctx.JSON(http.StatusOK, res)
+	for {
+		res, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx, req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "FindAllOrdersPublic", req)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if res.Status != pkg.ResponseStatusOk {
+			return nil, echo.NewHTTPError(int(res.Status), res.Message)
+		}
+
+		for _, order := range res.Item.GetItems() {
+			if ids[order.GetUuid()] {
+				orders[order.GetUuid()] = order
+			}
+		}
+
+		req.Offset += int32(len(res.Item.GetItems()))
+
+		if len(orders) == len(ids) || len(res.Item.GetItems()) == 0 || req.Offset >= res.Item.GetCount() {
+			return orders, nil
+		}
+	}
 }
 
-func (h *OrderRoute) replaceCode(ctx echo.Context) error {
-	req := &grpc.ChangeCodeInOrderRequest{}
+// @Description Export orders matching the filters of orders list as csv (default) or newline delimited json,
+// @Description rows are streamed page by page in order of creation. Header X-Total-Count is number of orders
+// @Description matching the filters, trailer X-Export-Status is "failed" when the export was interrupted.
+// @Description Interrupted export is resumed after the last received order with parameter after=%order_id%
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/order/export?format=csv&project[]=%project_identifier_here%&after=%order_id%
+func (h *OrderRoute) exportOrders(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+
+	req := &grpc.ListOrdersRequest{}
+	exportReq := &orderExportRequest{
+		Format: ctx.QueryParam(orderExportFormatParam),
+		After:  ctx.QueryParam(orderExportAfterParam),
+	}
+
 	if err := ctx.Bind(req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
 	}
 
-	req.OrderId = ctx.Param("order_id")
-	if err := h.dispatch.Validate.Struct(req); err != nil {
+	if err := h.dispatch.Validate.Struct(exportReq); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
-	res := &grpc.ChangeCodeInOrderResponse{}
+	projects, ok := authUser.FilterProjects(req.Project)
 
-	res, err := h.dispatch.Services.Billing.ChangeCodeInOrder(ctx.Request().Context(), req)
-	if err != nil {
-		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
-		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	if !ok {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
 	}
 
-	if res.Status != pkg.ResponseStatusOk {
-		return echo.NewHTTPError(int(res.Status), res.Message)
+	req.Project = projects
+	req.Limit = h.cfg.LimitMax
+	req.Offset = 0
+	req.Sort = orderExportSort
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
-	return ctx.JSON(http.StatusOK, res.Order)
-}
+	if err := h.checkOrderSearchComplexity(req); err != nil {
+		return err
+	}
 
-func (h *OrderRoute) createRefund(ctx echo.Context) error {
-	authUser := common.ExtractUserContext(ctx)
-	req := &grpc.CreateRefundRequest{}
-	err := ctx.Bind(req)
+	merchant, err := getAuthUserMerchant(ctx, h.dispatch, h.L())
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+		return err
 	}
 
-	req.OrderId = ctx.Param(common.RequestParameterOrderId)
-	err = h.dispatch.Validate.Struct(req)
+	cursor, err := h.getOrderExportCursor(ctx, exportReq.After)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+		return err
 	}
 
-	req.CreatorId = authUser.Id
-	res, err := h.dispatch.Services.Billing.CreateRefund(ctx.Request().Context(), req)
+	// the slot is held while rows are streamed
+	slot, ok := h.exportSlots.Acquire(merchant.Id, h.cfg.ExportSlotTtl)
 
-	if err != nil {
-		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateRefund", req)
-		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	if !ok {
+		return echo.NewHTTPError(http.StatusTooManyRequests, common.ErrorMessageTooManyRequests)
 	}
 
-	if res.Status != pkg.ResponseStatusOk {
-		return echo.NewHTTPError(int(res.Status), res.Message)
+	defer h.exportSlots.Release(merchant.Id, slot)
+
+	res, err := h.findOrdersForExport(ctx.Request().Context(), req)
+
+	if err != nil {
+		return err
 	}
 
-	return ctx.JSON(http.StatusCreated, res.Item)
-}
+	w := newOrderExportWriter(ctx.Response(), exportReq.Format)
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, w.contentType())
+	header.Set(echo.HeaderContentDisposition, "attachment; filename="+w.fileName())
+	header.Set(headerTotalCount, strconv.Itoa(int(res.GetItem().GetCount())))
+	header.Set(headerTrailer, headerExportStatus)
+	ctx.Response().WriteHeader(http.StatusOK)
 
-func (h *OrderRoute) changeLanguage(ctx echo.Context) error {
-	orderId := ctx.Param(common.RequestParameterOrderId)
+	// the response is committed from here, failures are reported by the trailer and the client resumes
+	// the export after the last order it has received
+	status := orderExportStatusFailed
+	defer func() { header.Set(headerExportStatus, status) }()
 
-	if orderId == "" {
+	if cursor == nil {
+		w.writeHeader()
+	} else {
+		cursor.seek(req)
+
+		if res, err = h.findOrdersForExport(ctx.Request().Context(), req); err != nil {
+			return nil
+		}
+	}
+
+	for {
+		items := res.GetItem().GetItems()
+
+		for _, order := range items {
+			if cursor != nil && cursor.skip(order) {
+				continue
+			}
+
+			w.write(order)
+		}
+
+		if err = w.flush(); err != nil {
+			h.L().Error("orders export interrupted", logger.PairArgs("err", err.Error(), "offset", req.Offset))
+			return nil
+		}
+
+		ctx.Response().Flush()
+
+		if int32(len(items)) < req.Limit {
+			status = orderExportStatusComplete
+			return nil
+		}
+
+		// next page starts from creation time of the last order
+		last := items[len(items)-1].GetCreatedAt().GetSeconds()
+
+		if last > req.ProjectDateFrom {
+			req.Offset = 0
+			req.ProjectDateFrom = last
+		}
+
+		for _, order := range items {
+			if order.GetCreatedAt().GetSeconds() == last {
+				req.Offset++
+			}
+		}
+
+		if res, err = h.findOrdersForExport(ctx.Request().Context(), req); err != nil {
+			return nil
+		}
+	}
+}
+
+// getOrderExportCursor returns position of the order after which interrupted export is resumed,
+// nil is returned when export isn't resumed
+func (h *OrderRoute) getOrderExportCursor(ctx echo.Context, orderId string) (*orderExportCursor, error) {
+	if orderId == "" {
+		return nil, nil
+	}
+
+	req := &grpc.GetOrderRequest{Id: orderId}
+	res, err := h.dispatch.Services.Billing.GetOrderPublic(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetOrderPublic", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageExportCursorInvalid)
+	}
+
+	if _, err = getUserProject(ctx, h.dispatch, h.L(), res.Item.GetProject().GetId()); err != nil {
+		return nil, err
+	}
+
+	return &orderExportCursor{orderId: orderId, createdAt: res.Item.GetCreatedAt().GetSeconds()}, nil
+}
+
+func (h *OrderRoute) findOrdersForExport(
+	ctx context.Context,
+	req *grpc.ListOrdersRequest,
+) (*grpc.ListOrdersPublicResponse, error) {
+	res, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "FindAllOrdersPublic", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		h.L().Error(
+			"orders export failed",
+			logger.PairArgs("status", res.Status, "message", res.Message, "offset", req.Offset),
+		)
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res, nil
+}
+
+// orderExportCursor is the last order received by the client, orders created in the same second are listed
+// by identifier, so orders of this second are skipped up to the cursor order
+type orderExportCursor struct {
+	orderId   string
+	createdAt int64
+	passed    bool
+}
+
+// seek moves the listing to the second of creation of the cursor order
+func (c *orderExportCursor) seek(req *grpc.ListOrdersRequest) {
+	if c.createdAt > req.ProjectDateFrom {
+		req.ProjectDateFrom = c.createdAt
+	}
+}
+
+// skip returns true for orders received by the client before the export was interrupted
+func (c *orderExportCursor) skip(order *billing.OrderViewPublic) bool {
+	if c.passed {
+		return false
+	}
+
+	if order.Uuid == c.orderId {
+		c.passed = true
+		return true
+	}
+
+	if order.GetCreatedAt().GetSeconds() <= c.createdAt {
+		return true
+	}
+
+	c.passed = true
+
+	return false
+}
+
+// orderExportWriter writes exported orders to the response, one row or json document per line
+type orderExportWriter struct {
+	format string
+	csv    *csv.Writer
+	json   *json.Encoder
+	err    error
+}
+
+func newOrderExportWriter(out io.Writer, format string) *orderExportWriter {
+	if format == orderExportFormatJson {
+		return &orderExportWriter{format: format, json: json.NewEncoder(out)}
+	}
+
+	return &orderExportWriter{format: orderExportFormatCsv, csv: csv.NewWriter(out)}
+}
+
+func (w *orderExportWriter) contentType() string {
+	if w.format == orderExportFormatJson {
+		return mimeApplicationNdjson
+	}
+
+	return common.MIMETextCsv
+}
+
+func (w *orderExportWriter) fileName() string {
+	if w.format == orderExportFormatJson {
+		return orderExportJsonFileName
+	}
+
+	return orderExportCsvFileName
+}
+
+func (w *orderExportWriter) writeHeader() {
+	if w.csv == nil {
+		return
+	}
+
+	w.setErr(w.csv.Write([]string{"id", "status", "project_id", "transaction_date", "amount", "currency", "user_id"}))
+}
+
+func (w *orderExportWriter) write(order *billing.OrderViewPublic) {
+	if w.err != nil {
+		return
+	}
+
+	if w.json != nil {
+		w.setErr(w.json.Encode(order))
+		return
+	}
+
+	date := ""
+
+	if order.GetTransactionDate() != nil {
+		date = time.Unix(order.GetTransactionDate().GetSeconds(), 0).UTC().Format(time.RFC3339)
+	}
+
+	w.setErr(w.csv.Write([]string{
+		order.Uuid,
+		order.Status,
+		order.GetProject().GetId(),
+		date,
+		money.Format(order.GetNetRevenue().GetAmount(), order.GetNetRevenue().GetCurrency()),
+		order.GetNetRevenue().GetCurrency(),
+		order.GetUser().GetId(),
+	}))
+}
+
+func (w *orderExportWriter) flush() error {
+	if w.csv != nil && w.err == nil {
+		w.csv.Flush()
+		w.setErr(w.csv.Error())
+	}
+
+	return w.err
+}
+
+func (w *orderExportWriter) setErr(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// checkOrderSearchComplexity rejects searches by account or text (regular expressions in billing server) without
+// a limited period, they scan all orders of the merchant or of the filtered projects
+func (h *OrderRoute) checkOrderSearchComplexity(req *grpc.ListOrdersRequest) error {
+	if h.cfg.OrderSearchMaxPeriod <= 0 || (req.Account == "" && req.QuickSearch == "") {
+		return nil
+	}
+
+	maxPeriod := int64(h.cfg.OrderSearchMaxPeriod / time.Second)
+	isPeriodLimited := func(from, to int64) bool {
+		return from > 0 && to > 0 && to-from <= maxPeriod
+	}
+
+	if isPeriodLimited(req.PmDateFrom, req.PmDateTo) || isPeriodLimited(req.ProjectDateFrom, req.ProjectDateTo) {
+		return nil
+	}
+
+	rspErr := *common.ErrorMessageOrderSearchTooBroad
+	rspErr.Details = fmt.Sprintf(
+		"set pm_date_from and pm_date_to or project_date_from and project_date_to not more than %d days apart",
+		int64(h.cfg.OrderSearchMaxPeriod/(24*time.Hour)),
+	)
+
+	return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+}
+
+// getTokenizationPaymentMethods returns payment methods requiring card token, either listed explicitly or having vault
+func (h *OrderRoute) getTokenizationPaymentMethods() []string {
+	methods := append([]string{}, h.cfg.CardTokenizationPaymentMethods...)
+
+	for methodId := range h.cfg.CardTokenizationVaults {
+		methods = append(methods, methodId)
+	}
+
+	return methods
+}
+
+// Create payment by order
+// route POST /api/v1/payment
+func (h *OrderRoute) processCreatePayment(ctx echo.Context) error {
+	data := make(map[string]string)
+	binder := &common.PaymentCreateProcessBinder{
+		TokenizationMode:           h.cfg.CardTokenizationMode,
+		TokenizationPaymentMethods: h.getTokenizationPaymentMethods(),
+	}
+	err := binder.Bind(data, ctx)
+	common.SetRequestLogOrder(ctx, data[common.PaymentCreateFieldOrderId])
+
+	if err != nil {
+		if e, ok := err.(*grpc.ResponseErrorMessage); ok {
+			return echo.NewHTTPError(http.StatusBadRequest, e)
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestDataInvalid)
+	}
+
+	if err = h.screenPaymentEmail(ctx, data); err != nil {
+		return err
+	}
+
+	req := &grpc.PaymentCreateRequest{
+		Data:           data,
+		AcceptLanguage: ctx.Request().Header.Get(common.HeaderAcceptLanguage),
+		UserAgent:      ctx.Request().Header.Get(common.HeaderUserAgent),
+		Ip:             ctx.RealIP(),
+	}
+	res, err := h.dispatch.Services.Billing.PaymentCreateProcess(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "PaymentCreateProcess", req)
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	body := map[string]interface{}{
+		"redirect_url":  res.RedirectUrl,
+		"need_redirect": res.NeedRedirect,
+	}
+
+	return ctx.JSON(http.StatusOK, body)
+}
+
+// screenPaymentEmail checks payer email by screening mode of the order project or by the global one, in flag mode
+// rejected emails are only logged. Verdict of both modes is saved for the order
+func (h *OrderRoute) screenPaymentEmail(ctx echo.Context, data map[string]string) error {
+	email := data[common.PaymentCreateFieldEmail]
+	orderId := data[common.PaymentCreateFieldOrderId]
+
+	if email == "" {
+		return nil
+	}
+
+	screening := &common.EmailScreening{
+		MxLookup:          h.cfg.PaymentEmailScreeningMxLookup,
+		DisposableDomains: h.cfg.PaymentEmailDisposableDomains,
+		Resolver:          h.emailResolver,
+		Timeout:           h.cfg.PaymentEmailScreeningDnsTimeout,
+	}
+	mode := h.cfg.PaymentEmailScreeningMode
+	projectId := h.getPaymentOrderProjectId(ctx.Request().Context(), orderId)
+
+	if projectId != "" {
+		policy, err := h.emailScreening.GetPolicy(projectId)
+
+		// screening fails closed, payer email is screened in block mode when policy of the project can't be read
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", projectId))
+			mode = common.EmailScreeningModeBlock
+		} else if policy.Mode != "" {
+			mode = policy.Mode
+			screening.MxLookup = policy.MxLookup
+		}
+	}
+
+	if mode != common.EmailScreeningModeFlag && mode != common.EmailScreeningModeBlock {
+		return nil
+	}
+
+	verdict := screening.Screen(ctx.Request().Context(), email)
+
+	if projectId != "" {
+		saved := &common.EmailScreeningVerdict{
+			OrderId:    orderId,
+			Verdict:    verdict,
+			Mode:       mode,
+			ScreenedAt: time.Now().UTC(),
+		}
+
+		if saved.Verdict == "" {
+			saved.Verdict = common.EmailVerdictAccepted
+		}
+
+		if err := h.emailScreening.SaveVerdict(saved); err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", orderId))
+		}
+	}
+
+	if verdict == "" {
+		return nil
+	}
+
+	h.L().Warn(
+		"payer email rejected by screening",
+		logger.PairArgs("order_id", orderId, "verdict", verdict, "mode", mode),
+	)
+
+	if mode != common.EmailScreeningModeBlock {
+		return nil
+	}
+
+	rspErr := *common.ErrorMessagePaymentEmailRejected
+	rspErr.Details = verdict
+
+	return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+}
+
+// getPaymentOrderProjectId returns project of the order of payment create request, empty string is returned
+// when the order isn't found, payment of such order is rejected by billing server
+func (h *OrderRoute) getPaymentOrderProjectId(ctx context.Context, orderId string) string {
+	if _, err := uuid.Parse(orderId); err != nil {
+		return ""
+	}
+
+	req := &grpc.GetOrderRequest{Id: orderId}
+	res, err := h.dispatch.Services.Billing.GetOrderPublic(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetOrderPublic", req)
+		return ""
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return ""
+	}
+
+	return res.Item.GetProject().GetId()
+}
+
+// @Description Get result of screening of payer email of the order
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/order/%order_id_here%/email_screening
+func (h *OrderRoute) getEmailScreeningVerdict(ctx echo.Context) error {
+	req := &grpc.GetOrderRequest{Id: ctx.Param(common.RequestParameterOrderId)}
+
+	if _, err := uuid.Parse(req.Id); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectOrderId)
+	}
+
+	res, err := h.dispatch.Services.Billing.GetOrderPublic(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetOrderPublic", req)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	if _, err = getUserProject(ctx, h.dispatch, h.L(), res.Item.GetProject().GetId()); err != nil {
+		return err
+	}
+
+	verdict, err := h.emailScreening.GetVerdict(req.Id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", req.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if verdict == nil {
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessagePaymentEmailVerdictNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, verdict)
+}
+
+func (h *OrderRoute) getRefund(ctx echo.Context) error {
+	req := &grpc.GetRefundRequest{
+		OrderId:  ctx.Param(common.RequestParameterOrderId),
+		RefundId: ctx.Param(common.RequestParameterRefundId),
+	}
+
+	err := h.dispatch.Validate.Struct(req)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	res, err := h.dispatch.Services.Billing.GetRefund(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetRefund", req)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return ctx.JSON(http.StatusOK, res.Item)
+}
+
+func (h *OrderRoute) listRefunds(ctx echo.Context) error {
+	req := &grpc.ListRefundsRequest{}
+	err := (&OrderListRefundsBinder{}).Bind(req, ctx)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	err = h.dispatch.Validate.Struct(req)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	res, err := h.dispatch.Services.Billing.ListRefunds(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListRefunds", req)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *OrderRoute) replaceCode(ctx echo.Context) error {
+	req := &grpc.ChangeCodeInOrderRequest{}
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	req.OrderId = ctx.Param("order_id")
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	res := &grpc.ChangeCodeInOrderResponse{}
+
+	res, err := h.dispatch.Services.Billing.ChangeCodeInOrder(ctx.Request().Context(), req)
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return ctx.JSON(http.StatusOK, res.Order)
+}
+
+func (h *OrderRoute) createRefund(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+	req := &grpc.CreateRefundRequest{}
+	err := ctx.Bind(req)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	req.OrderId = ctx.Param(common.RequestParameterOrderId)
+	err = h.dispatch.Validate.Struct(req)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	req.CreatorId = authUser.Id
+	refund, approval, err := h.processRefund(ctx.Request().Context(), req, false)
+
+	if err != nil {
+		return err
+	}
+
+	if approval != nil {
+		return ctx.JSON(http.StatusAccepted, approval)
+	}
+
+	return ctx.JSON(http.StatusCreated, refund)
+}
+
+// @Description Get refund held for approval of support because its amount exceeds auto-approve threshold
+// @Description of the project, the approval is available for creator of the refund only
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/refunds/approvals/%approval_id_here%
+func (h *OrderRoute) getRefundApproval(ctx echo.Context) error {
+	approval, err := h.getRefundApprovalItem(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if approval.CreatorId != common.ExtractUserContext(ctx).Id {
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageRefundApprovalNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, approval)
+}
+
+// @Description List refunds waiting for approval of support, the oldest first
+// @Example curl -X GET -H 'Authorization: Bearer %system_token_here%' \
+//  https://api.paysuper.online/system/api/v1/refunds/approvals?limit=10&offset=0
+func (h *OrderRoute) listRefundApprovals(ctx echo.Context) error {
+	limit, offset := int64(h.cfg.LimitDefault), int64(0)
+
+	if v, err := strconv.ParseInt(ctx.QueryParam(common.RequestParameterLimit), 10, 32); err == nil && v > 0 {
+		limit = v
+	}
+
+	if v, err := strconv.ParseInt(ctx.QueryParam(common.RequestParameterOffset), 10, 32); err == nil && v > 0 {
+		offset = v
+	}
+
+	if limit > int64(h.cfg.LimitMax) {
+		limit = int64(h.cfg.LimitMax)
+	}
+
+	list, count, err := h.refundApprovals.ListPending(limit, offset)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	meta := &common.EnvelopeMeta{Count: int32(count), Limit: int32(limit), Offset: int32(offset)}
+	return common.ListResponse(ctx, map[string]interface{}{"count": count, "items": list}, list, meta)
+}
+
+// @Description Approve refund exceeding auto-approve threshold of the project, the refund is created by the approval.
+// @Description Other rules of refund policy of the project are checked again. Called by operator with personal
+// @Description system api token only
+// @Example curl -X POST -H 'Authorization: Bearer %system_token_here%' \
+//  https://api.paysuper.online/system/api/v1/refunds/approvals/%approval_id_here%/approve
+func (h *OrderRoute) approveRefund(ctx echo.Context) error {
+	return h.resolveRefundApproval(ctx, common.RefundApprovalStatusApproved)
+}
+
+// @Description Reject refund exceeding auto-approve threshold of the project, the refund isn't created. Called by
+// @Description operator with personal system api token only
+// @Example curl -X POST -H 'Authorization: Bearer %system_token_here%' \
+//  https://api.paysuper.online/system/api/v1/refunds/approvals/%approval_id_here%/reject
+func (h *OrderRoute) rejectRefund(ctx echo.Context) error {
+	return h.resolveRefundApproval(ctx, common.RefundApprovalStatusRejected)
+}
+
+// resolveRefundApproval approves or rejects pending approval, the approval is resolved by one operator at once.
+// Approval is saved as approved before the refund is created and is returned to pending when refund create fails,
+// so approval interrupted by death of the instance is never approved twice
+func (h *OrderRoute) resolveRefundApproval(ctx echo.Context, status string) error {
+	if !common.ExtractUserContext(ctx).Operator {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	id := ctx.Param(common.RequestParameterId)
+
+	if !bson.IsObjectIdHex(id) {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	lock, err := h.refundApprovals.Lock(id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "approval_id", id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	if lock == nil {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageRefundApprovalInProgress)
+	}
+
+	defer h.releaseLock(lock, "approval_id", id)
+
+	approval, err := h.getRefundApprovalItem(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if approval.Status != common.RefundApprovalStatusPending {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageRefundApprovalResolved)
+	}
+
+	resolvedAt := time.Now().UTC()
+	approval.Status = status
+	approval.ResolverId = common.ExtractUserContext(ctx).Id
+	approval.ResolvedAt = &resolvedAt
+
+	if err = h.saveRefundApproval(approval); err != nil {
+		return err
+	}
+
+	if status == common.RefundApprovalStatusRejected {
+		return ctx.JSON(http.StatusOK, approval)
+	}
+
+	req := &grpc.CreateRefundRequest{
+		OrderId:   approval.OrderId,
+		Amount:    approval.Amount,
+		Reason:    approval.Reason,
+		CreatorId: approval.CreatorId,
+	}
+	refund, _, err := h.processRefund(ctx.Request().Context(), req, true)
+
+	if err != nil {
+		approval.Status = common.RefundApprovalStatusPending
+		approval.ResolverId = ""
+		approval.ResolvedAt = nil
+		_ = h.saveRefundApproval(approval)
+
+		return err
+	}
+
+	approval.RefundId = refund.Id
+
+	if err = h.saveRefundApproval(approval); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, approval)
+}
+
+func (h *OrderRoute) getRefundApprovalItem(ctx echo.Context) (*common.RefundApproval, error) {
+	id := ctx.Param(common.RequestParameterId)
+
+	if !bson.IsObjectIdHex(id) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	approval, err := h.refundApprovals.Get(id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "approval_id", id))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	if approval == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageRefundApprovalNotFound)
+	}
+
+	return approval, nil
+}
+
+func (h *OrderRoute) saveRefundApproval(approval *common.RefundApproval) error {
+	if err := h.refundApprovals.Save(approval); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "approval_id", approval.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	return nil
+}
+
+// releaseLock releases the lock when it's still held by the handler, failure is logged only, the lock expires anyway
+func (h *OrderRoute) releaseLock(lock *common.Lock, key, id string) {
+	if err := lock.Release(); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), key, id))
+	}
+}
+
+// @Description Create refunds for list of orders, e.g. for cancelled game launch. Rows are accepted as json
+// @Description or as csv body with columns order_id, amount and optional reason. Refunds are created in background,
+// @Description request repeated with the same Idempotency-Key header returns the same bulk refund
+// @Example curl -X POST -H 'Authorization: Bearer %access_token_here%' -H 'Content-Type: text/csv' \
+//  -H 'Idempotency-Key: %unique_key_here%' \
+//  --data-binary $'order_id,amount,reason\n%order_id_here%,10,launch cancelled' \
+//  https://api.paysuper.online/admin/api/v1/refunds/bulk
+func (h *OrderRoute) createBulkRefund(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+	idempotencyKey := ctx.Request().Header.Get(common.HeaderIdempotencyKey)
+
+	if idempotencyKey == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageBulkRefundIdempotencyKeyRequired)
+	}
+
+	req := &bulkRefundRequest{}
+
+	if strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), common.MIMETextCsv) {
+		items, err := h.parseBulkRefundCsv(ctx.Request().Body)
+
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageBulkRefundCsvInvalid)
+		}
+
+		req.Items = items
+	} else if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if len(req.Items) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if len(req.Items) > h.cfg.RefundBulkMaxRows {
+		rspErr := *common.ErrorMessageBulkRefundRowsLimitExceeded
+		rspErr.Details = strconv.Itoa(h.cfg.RefundBulkMaxRows)
+
+		return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	job := &common.BulkRefund{
+		CreatorId: authUser.Id,
+		Total:     len(req.Items),
+		CreatedAt: time.Now().UTC(),
+		Rows:      make([]*common.BulkRefundRow, 0, len(req.Items)),
+	}
+
+	for i, item := range req.Items {
+		row := &common.BulkRefundRow{
+			Row:     i + 1,
+			OrderId: item.OrderId,
+			Amount:  item.Amount,
+			Reason:  item.Reason,
+			Status:  common.BulkRefundRowStatusPending,
+		}
+
+		if row.Reason == "" {
+			row.Reason = req.Reason
+		}
+
+		// rows with malformed order identifier are failed before the job starts, they never reach billing server
+		if _, err := uuid.Parse(row.OrderId); err != nil {
+			row.Status = common.BulkRefundRowStatusFailed
+			row.Error = common.ErrorIncorrectOrderId
+			job.Failed++
+		}
+
+		job.Rows = append(job.Rows, row)
+	}
+
+	job, created, err := h.bulkRefunds.Create(idempotencyKey, job)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "user_id", authUser.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if job.Completed {
+		return ctx.JSON(http.StatusOK, job)
+	}
+
+	// job of the repeated request is resumed if instance processing it has died
+	go h.processBulkRefund(job.Id)
+
+	if !created {
+		return ctx.JSON(http.StatusOK, job)
+	}
+
+	return ctx.JSON(http.StatusAccepted, job)
+}
+
+// @Description Get progress of bulk refund with result of each row, the bulk refund is available for its creator only
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/refunds/bulk/%bulk_refund_id_here%
+func (h *OrderRoute) getBulkRefund(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+	id := ctx.Param(common.RequestParameterId)
+
+	if !bson.IsObjectIdHex(id) {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	job, err := h.bulkRefunds.Get(id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "bulk_refund_id", id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if job == nil || job.CreatorId != authUser.Id {
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageBulkRefundNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, job)
+}
+
+// processBulkRefund creates refunds of pending rows of the job, the job is saved before and after each refund,
+// so refund interrupted by death of the instance is reported as failed instead of being created twice on resume.
+// The lock of the job is refreshed on each save, processing stops without saving when the lock is lost,
+// so the job taken by another instance isn't processed twice
+func (h *OrderRoute) processBulkRefund(id string) {
+	lock, err := h.bulkRefunds.Lock(id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "bulk_refund_id", id))
+		return
+	}
+
+	if lock == nil {
+		return
+	}
+
+	defer h.releaseLock(lock, "bulk_refund_id", id)
+
+	job, err := h.bulkRefunds.Get(id)
+
+	if err != nil || job == nil {
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "bulk_refund_id", id))
+		}
+
+		return
+	}
+
+	for _, row := range job.Rows {
+		switch row.Status {
+		case common.BulkRefundRowStatusProcessing:
+			row.Status = common.BulkRefundRowStatusFailed
+			row.Error = common.ErrorMessageBulkRefundRowInterrupted
+			job.Failed++
+		case common.BulkRefundRowStatusPending:
+			row.Status = common.BulkRefundRowStatusProcessing
+
+			if !h.saveBulkRefund(job, lock) {
+				return
+			}
+
+			h.processBulkRefundRow(job, row)
+		default:
+			continue
+		}
+
+		if !h.saveBulkRefund(job, lock) {
+			return
+		}
+	}
+
+	job.Completed = true
+	h.saveBulkRefund(job, lock)
+}
+
+func (h *OrderRoute) processBulkRefundRow(job *common.BulkRefund, row *common.BulkRefundRow) {
+	req := &grpc.CreateRefundRequest{
+		OrderId:   row.OrderId,
+		Amount:    row.Amount,
+		Reason:    row.Reason,
+		CreatorId: job.CreatorId,
+	}
+	refund, approval, err := h.processBulkRefundItem(context.Background(), req)
+
+	if err != nil {
+		row.Status = common.BulkRefundRowStatusFailed
+		row.Error = err.Error()
+
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			row.Error = httpErr.Message
+		}
+
+		job.Failed++
+		return
+	}
+
+	if approval != nil {
+		row.Status = common.BulkRefundRowStatusApprovalRequired
+		row.ApprovalId = approval.Id
+		row.Error = common.ErrorMessageRefundApprovalRequired
+		job.ApprovalRequired++
+		return
+	}
+
+	row.Status = common.BulkRefundRowStatusCreated
+	row.RefundId = refund.Id
+	job.Succeeded++
+}
+
+// saveBulkRefund refreshes the lock of the job and saves it, false is returned when the job can't be saved
+// or the lock is lost and the job is processed by another instance now
+func (h *OrderRoute) saveBulkRefund(job *common.BulkRefund, lock *common.Lock) bool {
+	ok, err := lock.Refresh()
+
+	if err == nil && ok {
+		err = h.bulkRefunds.Save(job)
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "bulk_refund_id", job.Id))
+		return false
+	}
+
+	if !ok {
+		h.L().Error("Lock of bulk refund is lost, processing is stopped", logger.PairArgs("bulk_refund_id", job.Id))
+	}
+
+	return ok
+}
+
+func (h *OrderRoute) processBulkRefundItem(
+	ctx context.Context,
+	req *grpc.CreateRefundRequest,
+) (*billing.Refund, *common.RefundApproval, error) {
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	return h.processRefund(ctx, req, false)
+}
+
+// parseBulkRefundCsv reads rows of bulk refund, first row may be header with column names
+func (h *OrderRoute) parseBulkRefundCsv(body io.Reader) ([]*bulkRefundItem, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) > 0 && rows[0][0] == common.RequestParameterOrderId {
+		rows = rows[1:]
+	}
+
+	items := make([]*bulkRefundItem, 0, len(rows))
+
+	for _, row := range rows {
+		if len(row) < 2 || len(row) > 3 {
+			return nil, common.ErrorMessageBulkRefundCsvInvalid
+		}
+
+		amount, err := strconv.ParseFloat(row[1], 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		item := &bulkRefundItem{OrderId: row[0], Amount: amount}
+
+		if len(row) == 3 {
+			item.Reason = row[2]
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// processRefund checks refund policy of the order and creates refund, refunds of the order are created one by one
+// by all instances, so the policy can't be bypassed by concurrent requests. Refund exceeding auto-approve threshold
+// of the project isn't created, it's held for approval of support and the approval is returned instead,
+// approved refund is created without the threshold check
+func (h *OrderRoute) processRefund(
+	ctx context.Context,
+	req *grpc.CreateRefundRequest,
+	approved bool,
+) (*billing.Refund, *common.RefundApproval, error) {
+	lock, err := h.refundPolicies.LockOrder(req.OrderId)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", req.OrderId))
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if lock == nil {
+		return nil, nil, echo.NewHTTPError(http.StatusConflict, common.ErrorMessageRefundInProgress)
+	}
+
+	defer h.releaseLock(lock, "order_id", req.OrderId)
+
+	// calls of billing server are canceled when they outlast the lock, so refund isn't created after another
+	// instance has locked the order
+	ctx, cancel := context.WithTimeout(ctx, common.RefundOrderLockTTL)
+	defer cancel()
+
+	projectId, policy, err := h.checkRefundPolicy(ctx, req)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !approved && policy.AutoApproveThreshold > 0 && req.Amount > policy.AutoApproveThreshold {
+		approval := &common.RefundApproval{
+			OrderId:   req.OrderId,
+			ProjectId: projectId,
+			Amount:    req.Amount,
+			Reason:    req.Reason,
+			CreatorId: req.CreatorId,
+		}
+
+		if err = h.refundApprovals.Create(approval); err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", req.OrderId))
+			return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		return nil, approval, nil
+	}
+
+	// lock is extended for refund create, it could be expired by slow policy checks
+	if ok, err := lock.Refresh(); err != nil || !ok {
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", req.OrderId))
+		}
+
+		return nil, nil, echo.NewHTTPError(http.StatusConflict, common.ErrorMessageRefundInProgress)
+	}
+
+	res, err := h.dispatch.Services.Billing.CreateRefund(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateRefund", req)
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item, nil, nil
+}
+
+// checkRefundPolicy checks refund against refund policy of the order project and global refunds count limit,
+// project of the order and its policy are returned for auto-approve threshold check
+func (h *OrderRoute) checkRefundPolicy(
+	ctx context.Context,
+	req *grpc.CreateRefundRequest,
+) (string, *common.RefundPolicy, error) {
+	oReq := &grpc.GetOrderRequest{Id: req.OrderId}
+	order, err := h.dispatch.Services.Billing.GetOrderPublic(ctx, oReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetOrderPublic", oReq)
+		return "", nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if order.Status != pkg.ResponseStatusOk {
+		return "", nil, echo.NewHTTPError(int(order.Status), order.Message)
+	}
+
+	projectId := order.Item.GetProject().GetId()
+	policy, err := h.refundPolicies.Get(projectId)
+
+	if err == common.ErrPolicyStoreLost {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", req.OrderId))
+		return "", nil, echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageRefundPolicyUnavailable)
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "order_id", req.OrderId))
+		return "", nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if policy.MaxDaysAfterPurchase > 0 {
+		purchasedAt := time.Unix(order.Item.GetTransactionDate().GetSeconds(), 0)
+
+		if time.Since(purchasedAt) > time.Duration(policy.MaxDaysAfterPurchase)*24*time.Hour {
+			rspErr := *common.ErrorMessageRefundPeriodExpired
+			rspErr.Details = strconv.Itoa(int(policy.MaxDaysAfterPurchase))
+
+			return "", nil, echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+		}
+	}
+
+	maxCount := h.cfg.RefundMaxCount
+
+	if policy.MaxRefundsPerOrder > 0 && (maxCount <= 0 || policy.MaxRefundsPerOrder < maxCount) {
+		maxCount = policy.MaxRefundsPerOrder
+	}
+
+	if maxCount <= 0 {
+		return projectId, policy, nil
+	}
+
+	listReq := &grpc.ListRefundsRequest{OrderId: req.OrderId, Limit: 1}
+	listRes, err := h.dispatch.Services.Billing.ListRefunds(ctx, listReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListRefunds", listReq)
+		return "", nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if listRes.Count >= maxCount {
+		rspErr := *common.ErrorMessageRefundCountLimitExceeded
+		rspErr.Details = strconv.Itoa(int(maxCount))
+
+		return "", nil, echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	return projectId, policy, nil
+}
+
+func (h *OrderRoute) changeLanguage(ctx echo.Context) error {
+	orderId := ctx.Param(common.RequestParameterOrderId)
+
+	if orderId == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectOrderId)
 	}
 
@@ -779,3 +2353,517 @@ func (h *OrderRoute) getReceipt(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, res.Receipt)
 }
+
+// @Description Get order receipt data for the post-payment receipt page
+// @Example curl -X GET https://api.paysuper.online/api/v1/receipts/ffffffff-ffff-ffff-ffff-ffffffffffff
+func (h *OrderRoute) getReceiptPublic(ctx echo.Context) error {
+	if !h.receiptLimiter.Allow(h.rateLimits.ClientIp(ctx)) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, common.ErrorMessageTooManyRequests)
+	}
+
+	receiptId := ctx.Param(common.RequestParameterReceiptId)
+
+	if _, err := uuid.Parse(receiptId); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	req := &grpc.OrderReceiptRequest{ReceiptId: receiptId}
+	res, err := h.dispatch.Services.Billing.OrderReceipt(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "OrderReceipt", req)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != http.StatusOK {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	receipt := &orderReceiptPublic{
+		ReceiptId:          res.Receipt.ReceiptId,
+		TransactionId:      res.Receipt.TransactionId,
+		TransactionDate:    res.Receipt.TransactionDate,
+		ProjectName:        res.Receipt.ProjectName,
+		MerchantName:       res.Receipt.MerchantName,
+		PlatformName:       res.Receipt.PlatformName,
+		Items:              make([]*orderReceiptPublicItem, 0, len(res.Receipt.Items)),
+		TotalPrice:         res.Receipt.TotalPrice,
+		TotalCharge:        res.Receipt.TotalCharge,
+		VatPayer:           res.Receipt.VatPayer,
+		VatInOrderCurrency: res.Receipt.VatInOrderCurrency,
+		VatInPayerCurrency: res.Receipt.VatInPayerCurrency,
+	}
+
+	for _, item := range res.Receipt.Items {
+		receipt.Items = append(receipt.Items, &orderReceiptPublicItem{Name: item.Name, Price: item.Price})
+	}
+
+	if receipt.PaymentMethod, err = h.getReceiptPaymentMethod(ctx.Request().Context(), receipt.TransactionId); err != nil {
+		return err
+	}
+
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+
+	return ctx.JSON(http.StatusOK, receipt)
+}
+
+// getReceiptPaymentMethod returns payment method of the order of receipt with masked card number,
+// nil is returned when the order isn't found
+func (h *OrderRoute) getReceiptPaymentMethod(
+	ctx context.Context,
+	orderId string,
+) (*orderReceiptPublicPaymentMethod, error) {
+	if _, err := uuid.Parse(orderId); err != nil {
+		return nil, nil
+	}
+
+	req := &grpc.GetOrderRequest{Id: orderId}
+	res, err := h.dispatch.Services.Billing.GetOrderPublic(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetOrderPublic", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk || res.Item.GetPaymentMethod() == nil {
+		return nil, nil
+	}
+
+	method := &orderReceiptPublicPaymentMethod{Name: res.Item.PaymentMethod.Name}
+
+	if card := res.Item.PaymentMethod.GetCard(); card != nil && card.Masked != "" {
+		method.MaskedPan = vault.MaskPan(card.Masked)
+	}
+
+	return method, nil
+}
+
+// processAttribution validates marketing attribution parameters (utm tags and advertisement identifier)
+// and passes them to billing server in order additional parameters, attribution decoded from json body
+// is nil for form requests
+func (h *OrderRoute) processAttribution(ctx echo.Context, req *billing.OrderCreateRequest, attr *orderAttribution) error {
+	if attr == nil {
+		attr = &orderAttribution{}
+
+		// form bound to order of json route has no additional parameters, they are read from the form
+		param := func(name string) string {
+			if val, ok := req.Other[name]; ok {
+				return val
+			}
+
+			return ctx.FormValue(name)
+		}
+
+		attr.UtmSource = param(common.RequestParameterUtmSource)
+		attr.UtmMedium = param(common.RequestParameterUtmMedium)
+		attr.UtmCampaign = param(common.RequestParameterUtmCampaign)
+		attr.AdId = param(common.RequestParameterAdId)
+	}
+
+	if err := h.dispatch.Validate.Struct(attr); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	params := map[string]string{
+		common.RequestParameterUtmSource:   attr.UtmSource,
+		common.RequestParameterUtmMedium:   attr.UtmMedium,
+		common.RequestParameterUtmCampaign: attr.UtmCampaign,
+		common.RequestParameterAdId:        attr.AdId,
+	}
+
+	for key, val := range params {
+		if val == "" {
+			continue
+		}
+
+		if req.Other == nil {
+			req.Other = make(map[string]string)
+		}
+
+		req.Other[key] = val
+	}
+
+	return nil
+}
+
+// processMetadata validates order metadata and passes it to billing server in order additional parameters
+// with orderMetadataParameterPrefix, so metadata keys can't overlap reserved parameters
+func (h *OrderRoute) processMetadata(req *billing.OrderCreateRequest, metadata *orderMetadata) error {
+	if len(metadata.Metadata) == 0 {
+		return nil
+	}
+
+	if err := h.dispatch.Validate.Struct(metadata); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	for key, val := range metadata.Metadata {
+		if req.Other == nil {
+			req.Other = make(map[string]string)
+		}
+
+		req.Other[orderMetadataParameterPrefix+key] = val
+	}
+
+	return nil
+}
+
+// processCart validates cart items against the project catalog, fills order products with cart items
+// (each product repeated by its quantity) and calculates order amount as sum of cart lines. Cart with platform
+// is a cart of key products priced by the platform
+func (h *OrderRoute) processCart(
+	ctx echo.Context,
+	req *billing.OrderCreateRequest,
+	cart *orderCart,
+	platform *orderPlatform,
+	project *orderProject,
+) error {
+	if len(cart.Cart) == 0 {
+		return nil
+	}
+
+	if len(req.Products) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageOrderCartWithProducts)
+	}
+
+	if err := h.dispatch.Validate.Struct(cart); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	if err := h.dispatch.Validate.Struct(platform); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	items, err := mergeCartItems(cart.Cart)
+
+	if err != nil {
+		return err
+	}
+
+	projectItem, err := project.get()
+
+	if err != nil {
+		return err
+	}
+
+	reqCtx := ctx.Request().Context()
+
+	var (
+		total    money.Money
+		products []string
+	)
+
+	for _, item := range items {
+		var (
+			prices          []*billing.ProductPrice
+			defaultCurrency string
+		)
+
+		if platform.Platform == "" {
+			prices, defaultCurrency, err = h.getCartProductPrices(reqCtx, projectItem, item.ProductId)
+		} else {
+			prices, defaultCurrency, err = h.getCartKeyProductPrices(reqCtx, projectItem, item.ProductId, platform.Platform)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if req.Currency == "" {
+			req.Currency = defaultCurrency
+		}
+
+		line, ok := getCartLineAmount(prices, req.Currency, item.Quantity)
+
+		if !ok {
+			rspErr := *common.ErrorMessageOrderCartPriceNotFound
+			rspErr.Details = item.ProductId
+
+			return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+		}
+
+		if total.Currency == "" {
+			total = money.New(0, req.Currency)
+		}
+
+		total, _ = total.Add(line)
+
+		for i := int32(0); i < item.Quantity; i++ {
+			products = append(products, item.ProductId)
+		}
+	}
+
+	if req.Amount > 0 && req.Amount != total.Float64() {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageOrderCartAmountMismatch)
+	}
+
+	req.Amount = total.Float64()
+	req.Products = products
+
+	if platform.Platform != "" {
+		if req.Other == nil {
+			req.Other = make(map[string]string)
+		}
+
+		req.Other[orderPlatformField] = platform.Platform
+	}
+
+	return nil
+}
+
+func (h *OrderRoute) getCartProductPrices(
+	ctx context.Context,
+	project *billing.Project,
+	productId string,
+) ([]*billing.ProductPrice, string, error) {
+	req := &grpc.RequestProduct{Id: productId, MerchantId: project.MerchantId}
+	res, err := h.dispatch.Services.Billing.GetProduct(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetProduct", req)
+		return nil, "", echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk || res.Item.ProjectId != project.Id || !res.Item.Enabled {
+		rspErr := *common.ErrorMessageOrderCartProductInvalid
+		rspErr.Details = productId
+
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	return res.Item.Prices, res.Item.DefaultCurrency, nil
+}
+
+func (h *OrderRoute) getCartKeyProductPrices(
+	ctx context.Context,
+	project *billing.Project,
+	productId, platform string,
+) ([]*billing.ProductPrice, string, error) {
+	req := &grpc.RequestKeyProductMerchant{Id: productId, MerchantId: project.MerchantId}
+	res, err := h.dispatch.Services.Billing.GetKeyProduct(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetKeyProduct", req)
+		return nil, "", echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk || res.Product.ProjectId != project.Id || !res.Product.Enabled {
+		rspErr := *common.ErrorMessageOrderCartProductInvalid
+		rspErr.Details = productId
+
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	for _, val := range res.Product.Platforms {
+		if val.Id == platform {
+			return val.Prices, res.Product.DefaultCurrency, nil
+		}
+	}
+
+	rspErr := *common.ErrorMessageOrderPlatformNotAvailable
+	rspErr.Details = productId
+
+	return nil, "", echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+}
+
+// mergeCartItems sums quantities of repeated cart products, so each product is fetched from catalog once
+func mergeCartItems(cart []*orderCartItem) ([]*orderCartItem, error) {
+	items := make([]*orderCartItem, 0, len(cart))
+	merged := make(map[string]*orderCartItem, len(cart))
+
+	for _, item := range cart {
+		if val, ok := merged[item.ProductId]; ok {
+			val.Quantity += item.Quantity
+
+			if val.Quantity > orderCartItemQuantityMax {
+				rspErr := *common.ErrorMessageOrderCartProductInvalid
+				rspErr.Details = item.ProductId
+
+				return nil, echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+			}
+
+			continue
+		}
+
+		val := &orderCartItem{ProductId: item.ProductId, Quantity: item.Quantity}
+		merged[item.ProductId] = val
+		items = append(items, val)
+	}
+
+	return items, nil
+}
+
+func getCartLineAmount(prices []*billing.ProductPrice, currency string, quantity int32) (money.Money, bool) {
+	info := money.Info(currency)
+
+	for _, price := range prices {
+		if price.Currency != info.Code {
+			continue
+		}
+
+		amount, err := money.FromFloat(price.Amount, info.Code, info.Rounding)
+
+		if err != nil {
+			return money.Money{}, false
+		}
+
+		return money.New(amount.Amount*int64(quantity), info.Code), true
+	}
+
+	return money.Money{}, false
+}
+
+// processPlatform checks that selected platform is available for each of the order key products
+// and passes it to billing server in order additional parameters
+func (h *OrderRoute) processPlatform(
+	ctx echo.Context,
+	req *billing.OrderCreateRequest,
+	p *orderPlatform,
+	project *orderProject,
+) error {
+	// platform of cart key products is checked together with the cart
+	if p.Platform == "" || req.Other[orderPlatformField] != "" {
+		return nil
+	}
+
+	if err := h.dispatch.Validate.Struct(p); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	if len(req.Products) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageOrderPlatformNotAvailable)
+	}
+
+	projectItem, err := project.get()
+
+	if err != nil {
+		return err
+	}
+
+	reqCtx := ctx.Request().Context()
+	checked := make(map[string]bool)
+
+	for _, productId := range req.Products {
+		if checked[productId] {
+			continue
+		}
+
+		kpReq := &grpc.RequestKeyProductMerchant{Id: productId, MerchantId: projectItem.MerchantId}
+		product, err := h.dispatch.Services.Billing.GetKeyProduct(reqCtx, kpReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetKeyProduct", kpReq)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if product.Status != pkg.ResponseStatusOk || !hasKeyProductPlatform(product.Product, p.Platform) {
+			rspErr := *common.ErrorMessageOrderPlatformNotAvailable
+			rspErr.Details = productId
+
+			return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+		}
+
+		checked[productId] = true
+	}
+
+	if req.Other == nil {
+		req.Other = make(map[string]string)
+	}
+
+	req.Other[orderPlatformField] = p.Platform
+
+	return nil
+}
+
+func hasKeyProductPlatform(product *grpc.KeyProduct, platform string) bool {
+	if product == nil {
+		return false
+	}
+
+	for _, val := range product.Platforms {
+		if val.Id == platform {
+			return true
+		}
+	}
+
+	return false
+}
+
+// processVirtualCurrency calculates amount of the virtual currency top-up order by the project virtual currency
+// price in the order currency and saves purchased units into order additional parameters
+func (h *OrderRoute) processVirtualCurrency(
+	req *billing.OrderCreateRequest,
+	vc *orderVirtualCurrency,
+	project *orderProject,
+) error {
+	if vc.Amount == 0 {
+		return nil
+	}
+
+	if len(req.Products) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageVirtualCurrencyWithProducts)
+	}
+
+	if err := h.dispatch.Validate.Struct(vc); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	if h.cfg.VirtualCurrencyMaxAmount > 0 && vc.Amount > h.cfg.VirtualCurrencyMaxAmount {
+		rspErr := *common.ErrorMessageVirtualCurrencyAmountTooLarge
+		rspErr.Details = strconv.FormatInt(h.cfg.VirtualCurrencyMaxAmount, 10)
+
+		return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	projectItem, err := project.get()
+
+	if err != nil {
+		return err
+	}
+
+	if projectItem.VirtualCurrency == nil || len(projectItem.VirtualCurrency.Prices) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageVirtualCurrencyNotConfigured)
+	}
+
+	info := money.Info(req.Currency)
+	var price *billing.ProductPrice
+
+	for _, val := range projectItem.VirtualCurrency.Prices {
+		if val.Currency == info.Code {
+			price = val
+			break
+		}
+	}
+
+	if price == nil {
+		rspErr := *common.ErrorMessageVirtualCurrencyPriceNotFound
+		rspErr.Details = req.Currency
+
+		return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	unit, err := money.FromFloat(price.Amount, info.Code, info.Rounding)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestDataInvalid)
+	}
+
+	total, err := unit.Mul(vc.Amount)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageVirtualCurrencyAmountTooLarge)
+	}
+
+	if req.Amount > 0 && req.Amount != total.Float64() {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageVirtualCurrencyAmountMismatch)
+	}
+
+	if req.Other == nil {
+		req.Other = make(map[string]string)
+	}
+
+	req.Amount = total.Float64()
+	req.Other[orderVirtualCurrencyAmountField] = strconv.FormatInt(vc.Amount, 10)
+
+	return nil
+}
@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/globalsign/mgo/bson"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/micro/go-micro/client"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
@@ -18,8 +25,12 @@ import (
 	mock2 "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -219,6 +230,543 @@ func (suite *OrderTestSuite) TestOrder_CreateRefund_Ok() {
 	assert.NotEmpty(suite.T(), res.Body.String())
 }
 
+func (suite *OrderTestSuite) TestOrder_CreateRefund_CountLimitExceeded_Error() {
+	suite.router.cfg.RefundMaxCount = 2
+	data := `{"amount": 10, "reason": "test"}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":order_id", uuid.New().String()).
+		Path(common.AuthUserGroupPath + orderRefundsPath).
+		Init(test.ReqInitJSON()).
+		BodyString(data).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageRefundCountLimitExceeded.Code, msg.Code)
+	assert.Equal(suite.T(), "2", msg.Details)
+}
+
+func (suite *OrderTestSuite) getRefundPolicyBillingMock(projectId string, purchasedAt time.Time) *billMock.BillingService {
+	date, err := ptypes.TimestampProto(purchasedAt)
+	assert.NoError(suite.T(), err)
+
+	bill := &billMock.BillingService{}
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.OrderViewPublic{Project: &billing.ProjectOrder{Id: projectId}, TransactionDate: date},
+		}, nil)
+	bill.On("ListRefunds", mock2.Anything, mock2.Anything).Return(&grpc.ListRefundsResponse{Count: 1}, nil)
+	bill.On("CreateRefund", mock2.Anything, mock2.Anything).
+		Return(&grpc.CreateRefundResponse{Status: pkg.ResponseStatusOk, Item: &billing.Refund{Id: bson.NewObjectId().Hex()}}, nil)
+
+	return bill
+}
+
+func (suite *OrderTestSuite) createRefund(amount string) (*httptest.ResponseRecorder, *grpc.ResponseErrorMessage) {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":order_id", uuid.New().String()).
+		Path(common.AuthUserGroupPath + orderRefundsPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"amount": ` + amount + `, "reason": "test"}`).
+		Exec(suite.T())
+
+	if err == nil {
+		return res, nil
+	}
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+
+	return nil, msg
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateRefund_ProjectPolicy_Error() {
+	projectId := bson.NewObjectId().Hex()
+	policies := common.NewRefundPolicies(test.Redis())
+	err := policies.Set(projectId, &common.RefundPolicy{MaxDaysAfterPurchase: 30, MaxRefundsPerOrder: 1, AutoApproveThreshold: 50})
+	assert.NoError(suite.T(), err)
+
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(projectId, time.Now().AddDate(0, 0, -31))
+	_, msg := suite.createRefund("10")
+	assert.Equal(suite.T(), common.ErrorMessageRefundPeriodExpired.Code, msg.Code)
+	assert.Equal(suite.T(), "30", msg.Details)
+
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(projectId, time.Now().AddDate(0, 0, -1))
+	_, msg = suite.createRefund("10")
+	assert.Equal(suite.T(), common.ErrorMessageRefundCountLimitExceeded.Code, msg.Code)
+	assert.Equal(suite.T(), "1", msg.Details)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateRefund_ProjectPolicy_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	policies := common.NewRefundPolicies(test.Redis())
+	err := policies.Set(projectId, &common.RefundPolicy{MaxDaysAfterPurchase: 30, MaxRefundsPerOrder: 2, AutoApproveThreshold: 50})
+	assert.NoError(suite.T(), err)
+
+	bill := suite.getRefundPolicyBillingMock(projectId, time.Now().AddDate(0, 0, -1))
+	suite.router.dispatch.Services.Billing = bill
+
+	res, msg := suite.createRefund("50")
+	assert.Nil(suite.T(), msg)
+	assert.Equal(suite.T(), http.StatusCreated, res.Code)
+	bill.AssertNumberOfCalls(suite.T(), "CreateRefund", 1)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateRefund_OrderLocked_Error() {
+	orderId := uuid.New().String()
+	policies := common.NewRefundPolicies(test.Redis())
+	lock, err := policies.LockOrder(orderId)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), lock)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":order_id", orderId).
+		Path(common.AuthUserGroupPath + orderRefundsPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"amount": 10, "reason": "test"}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusConflict, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageRefundInProgress, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateRefund_PolicyLost_Error() {
+	test.RedisServer().FlushAll()
+	bill := suite.getRefundPolicyBillingMock(bson.NewObjectId().Hex(), time.Now())
+	suite.router.dispatch.Services.Billing = bill
+
+	_, msg := suite.createRefund("10")
+	assert.Equal(suite.T(), common.ErrorMessageRefundPolicyUnavailable.Code, msg.Code)
+	bill.AssertNotCalled(suite.T(), "CreateRefund", mock2.Anything, mock2.Anything)
+
+	err := common.NewRefundPolicies(test.Redis()).Init()
+	assert.NoError(suite.T(), err)
+
+	res, msg := suite.createRefund("10")
+	assert.Nil(suite.T(), msg)
+	assert.Equal(suite.T(), http.StatusCreated, res.Code)
+}
+
+func (suite *OrderTestSuite) createRefundApproval(projectId string) *common.RefundApproval {
+	policies := common.NewRefundPolicies(test.Redis())
+	err := policies.Set(projectId, &common.RefundPolicy{AutoApproveThreshold: 50})
+	assert.NoError(suite.T(), err)
+
+	res, msg := suite.createRefund("100")
+	assert.Nil(suite.T(), msg)
+	assert.Equal(suite.T(), http.StatusAccepted, res.Code)
+
+	approval := &common.RefundApproval{}
+	err = json.Unmarshal(res.Body.Bytes(), approval)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.RefundApprovalStatusPending, approval.Status)
+	assert.Equal(suite.T(), projectId, approval.ProjectId)
+	assert.Equal(suite.T(), float64(100), approval.Amount)
+
+	return approval
+}
+
+func (suite *OrderTestSuite) resolveRefundApproval(path, id string) (*common.RefundApproval, error) {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, id).
+		Path(common.SystemGroupPath + path).
+		Init(test.ReqInitJSON()).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			middleware.Pre(test.PreAuthUserMiddleware(&common.AuthUser{Id: "operator", Operator: true}))
+		}).
+		Exec(suite.T())
+
+	if err != nil {
+		return nil, err
+	}
+
+	approval := &common.RefundApproval{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), approval))
+
+	return approval, nil
+}
+
+func (suite *OrderTestSuite) TestOrder_ApproveRefund_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getRefundPolicyBillingMock(projectId, time.Now())
+	suite.router.dispatch.Services.Billing = bill
+
+	approval := suite.createRefundApproval(projectId)
+	bill.AssertNotCalled(suite.T(), "CreateRefund", mock2.Anything, mock2.Anything)
+
+	list, count, err := suite.router.refundApprovals.ListPending(10, 0)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, count)
+	assert.Len(suite.T(), list, 1)
+
+	approved, err := suite.resolveRefundApproval(refundsApprovePath, approval.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.RefundApprovalStatusApproved, approved.Status)
+	assert.NotEmpty(suite.T(), approved.RefundId)
+	assert.NotNil(suite.T(), approved.ResolvedAt)
+	bill.AssertNumberOfCalls(suite.T(), "CreateRefund", 1)
+
+	_, err = suite.resolveRefundApproval(refundsApprovePath, approval.Id)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusConflict, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageRefundApprovalResolved, httpErr.Message)
+	bill.AssertNumberOfCalls(suite.T(), "CreateRefund", 1)
+
+	_, count, err = suite.router.refundApprovals.ListPending(10, 0)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 0, count)
+}
+
+func (suite *OrderTestSuite) TestOrder_ApproveRefund_RefundFailed_Pending() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getRefundPolicyBillingMock(projectId, time.Now())
+	suite.router.dispatch.Services.Billing = bill
+	approval := suite.createRefundApproval(projectId)
+
+	bill = &billMock.BillingService{}
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{Status: pkg.ResponseStatusOk, Item: &billing.OrderViewPublic{}}, nil)
+	bill.On("ListRefunds", mock2.Anything, mock2.Anything).Return(&grpc.ListRefundsResponse{}, nil)
+	bill.On("CreateRefund", mock2.Anything, mock2.Anything).Return(nil, errors.New("some error"))
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.resolveRefundApproval(refundsApprovePath, approval.Id)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+
+	pending, err := suite.router.refundApprovals.Get(approval.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.RefundApprovalStatusPending, pending.Status)
+	assert.Empty(suite.T(), pending.ResolverId)
+}
+
+func (suite *OrderTestSuite) TestOrder_RejectRefund_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getRefundPolicyBillingMock(projectId, time.Now())
+	suite.router.dispatch.Services.Billing = bill
+	approval := suite.createRefundApproval(projectId)
+
+	rejected, err := suite.resolveRefundApproval(refundsRejectPath, approval.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.RefundApprovalStatusRejected, rejected.Status)
+	assert.Empty(suite.T(), rejected.RefundId)
+
+	_, err = suite.resolveRefundApproval(refundsApprovePath, approval.Id)
+	assert.Error(suite.T(), err)
+	bill.AssertNotCalled(suite.T(), "CreateRefund", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OrderTestSuite) TestOrder_ApproveRefund_Locked_Error() {
+	approval := &common.RefundApproval{OrderId: uuid.New().String()}
+	assert.NoError(suite.T(), suite.router.refundApprovals.Create(approval))
+
+	lock, err := suite.router.refundApprovals.Lock(approval.Id)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), lock)
+
+	_, err = suite.resolveRefundApproval(refundsApprovePath, approval.Id)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusConflict, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageRefundApprovalInProgress, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) createBulkRefund(idempotencyKey, contentType, data string) (*common.BulkRefund, int) {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + refundsBulkPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, contentType)
+			request.Header.Set(common.HeaderIdempotencyKey, idempotencyKey)
+		}).
+		BodyString(data).
+		Exec(suite.T())
+
+	require.NoError(suite.T(), err)
+
+	job := &common.BulkRefund{}
+	err = json.Unmarshal(res.Body.Bytes(), job)
+	require.NoError(suite.T(), err)
+
+	return job, res.Code
+}
+
+// waitBulkRefund polls progress of bulk refund until its rows are processed in background
+func (suite *OrderTestSuite) waitBulkRefund(id string) *common.BulkRefund {
+	for i := 0; i < 100; i++ {
+		res, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Params(":"+common.RequestParameterId, id).
+			Path(common.AuthUserGroupPath + refundsBulkIdPath).
+			Exec(suite.T())
+
+		require.NoError(suite.T(), err)
+
+		job := &common.BulkRefund{}
+		err = json.Unmarshal(res.Body.Bytes(), job)
+		require.NoError(suite.T(), err)
+
+		if job.Completed {
+			return job
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	suite.FailNow("bulk refund isn't completed")
+
+	return nil
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_Json_Ok() {
+	orderId := uuid.New().String()
+	data := `{"reason": "launch cancelled", "items": [{"order_id": "` + orderId + `", "amount": 10},
+		{"order_id": "` + uuid.New().String() + `", "amount": 5, "reason": "duplicate"}]}`
+
+	job, code := suite.createBulkRefund(uuid.New().String(), echo.MIMEApplicationJSON, data)
+	assert.Equal(suite.T(), http.StatusAccepted, code)
+	assert.Equal(suite.T(), 2, job.Total)
+	assert.Equal(suite.T(), "ffffffffffffffffffffffff", job.CreatorId)
+
+	job = suite.waitBulkRefund(job.Id)
+	assert.Equal(suite.T(), 2, job.Succeeded)
+	assert.Equal(suite.T(), orderId, job.Rows[0].OrderId)
+	assert.Equal(suite.T(), "launch cancelled", job.Rows[0].Reason)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusCreated, job.Rows[0].Status)
+	assert.NotEmpty(suite.T(), job.Rows[0].RefundId)
+	assert.Equal(suite.T(), "duplicate", job.Rows[1].Reason)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_Csv_PartialFailure() {
+	bill := &billMock.BillingService{}
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{Status: pkg.ResponseStatusOk, Item: &billing.OrderViewPublic{}}, nil)
+	bill.On("CreateRefund", mock2.Anything, mock2.MatchedBy(func(req *grpc.CreateRefundRequest) bool {
+		return req.Amount == 10
+	})).Return(&grpc.CreateRefundResponse{Status: pkg.ResponseStatusOk, Item: &billing.Refund{Id: bson.NewObjectId().Hex()}}, nil)
+	bill.On("CreateRefund", mock2.Anything, mock2.Anything).
+		Return(&grpc.CreateRefundResponse{Status: pkg.ResponseStatusBadData, Message: &grpc.ResponseErrorMessage{Message: "refund amount exceeded"}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	data := "order_id,amount,reason\n" +
+		uuid.New().String() + ",10,launch cancelled\n" +
+		uuid.New().String() + ",1000,launch cancelled\n" +
+		"not_an_order,5\n"
+
+	job, code := suite.createBulkRefund(uuid.New().String(), common.MIMETextCsv, data)
+	assert.Equal(suite.T(), http.StatusAccepted, code)
+	assert.Equal(suite.T(), 3, job.Total)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusFailed, job.Rows[2].Status)
+
+	job = suite.waitBulkRefund(job.Id)
+	assert.Equal(suite.T(), 1, job.Succeeded)
+	assert.Equal(suite.T(), 2, job.Failed)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusCreated, job.Rows[0].Status)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusFailed, job.Rows[1].Status)
+	assert.NotNil(suite.T(), job.Rows[1].Error)
+	assert.Equal(suite.T(), 3, job.Rows[2].Row)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusFailed, job.Rows[2].Status)
+	assert.Equal(suite.T(), common.ErrorIncorrectOrderId.Code, job.Rows[2].Error.(map[string]interface{})["code"])
+	bill.AssertNumberOfCalls(suite.T(), "CreateRefund", 2)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_SameIdempotencyKey_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{Status: pkg.ResponseStatusOk, Item: &billing.OrderViewPublic{}}, nil)
+	bill.On("CreateRefund", mock2.Anything, mock2.Anything).
+		Return(&grpc.CreateRefundResponse{Status: pkg.ResponseStatusOk, Item: &billing.Refund{Id: bson.NewObjectId().Hex()}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	key := uuid.New().String()
+	data := `{"reason": "launch cancelled", "items": [{"order_id": "` + uuid.New().String() + `", "amount": 10}]}`
+
+	job, code := suite.createBulkRefund(key, echo.MIMEApplicationJSON, data)
+	assert.Equal(suite.T(), http.StatusAccepted, code)
+	suite.waitBulkRefund(job.Id)
+
+	repeated, code := suite.createBulkRefund(key, echo.MIMEApplicationJSON, data)
+	assert.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), job.Id, repeated.Id)
+	assert.True(suite.T(), repeated.Completed)
+	bill.AssertNumberOfCalls(suite.T(), "CreateRefund", 1)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_ResumeInterrupted_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{Status: pkg.ResponseStatusOk, Item: &billing.OrderViewPublic{}}, nil)
+	bill.On("CreateRefund", mock2.Anything, mock2.Anything).
+		Return(&grpc.CreateRefundResponse{Status: pkg.ResponseStatusOk, Item: &billing.Refund{Id: bson.NewObjectId().Hex()}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	// instance has died while the first row was sent to billing server
+	key := uuid.New().String()
+	job, created, err := common.NewBulkRefunds(test.Redis(), time.Hour).Create(key, &common.BulkRefund{
+		CreatorId: "ffffffffffffffffffffffff",
+		Total:     2,
+		Rows: []*common.BulkRefundRow{
+			{Row: 1, OrderId: uuid.New().String(), Amount: 10, Status: common.BulkRefundRowStatusProcessing},
+			{Row: 2, OrderId: uuid.New().String(), Amount: 5, Status: common.BulkRefundRowStatusPending},
+		},
+	})
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), created)
+
+	repeated, code := suite.createBulkRefund(key, echo.MIMEApplicationJSON, `{"items": [{"order_id": "`+uuid.New().String()+`", "amount": 1}]}`)
+	assert.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), job.Id, repeated.Id)
+
+	job = suite.waitBulkRefund(job.Id)
+	assert.Equal(suite.T(), 1, job.Succeeded)
+	assert.Equal(suite.T(), 1, job.Failed)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusFailed, job.Rows[0].Status)
+	assert.Equal(suite.T(), common.ErrorMessageBulkRefundRowInterrupted.Code, job.Rows[0].Error.(map[string]interface{})["code"])
+	assert.Equal(suite.T(), common.BulkRefundRowStatusCreated, job.Rows[1].Status)
+	bill.AssertNumberOfCalls(suite.T(), "CreateRefund", 1)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_LockLost_Stopped() {
+	bulkRefunds := common.NewBulkRefunds(test.Redis(), time.Hour)
+	job, _, err := bulkRefunds.Create(uuid.New().String(), &common.BulkRefund{
+		CreatorId: "ffffffffffffffffffffffff",
+		Total:     2,
+		Rows: []*common.BulkRefundRow{
+			{Row: 1, OrderId: uuid.New().String(), Amount: 10, Status: common.BulkRefundRowStatusPending},
+			{Row: 2, OrderId: uuid.New().String(), Amount: 5, Status: common.BulkRefundRowStatusPending},
+		},
+	})
+	assert.NoError(suite.T(), err)
+
+	// lock expires while the first row is sent to billing server and the job is taken by another instance
+	lockKey := fmt.Sprintf("bulk_refund_lock:%s", job.Id)
+	bill := &billMock.BillingService{}
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{Status: pkg.ResponseStatusOk, Item: &billing.OrderViewPublic{}}, nil)
+	bill.On("CreateRefund", mock2.Anything, mock2.Anything).
+		Run(func(args mock2.Arguments) {
+			test.Redis().Set(lockKey, "another", time.Minute)
+		}).
+		Return(&grpc.CreateRefundResponse{Status: pkg.ResponseStatusOk, Item: &billing.Refund{Id: bson.NewObjectId().Hex()}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	suite.router.processBulkRefund(job.Id)
+
+	job, err = bulkRefunds.Get(job.Id)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), job.Completed)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusProcessing, job.Rows[0].Status)
+	assert.Equal(suite.T(), common.BulkRefundRowStatusPending, job.Rows[1].Status)
+	bill.AssertNumberOfCalls(suite.T(), "CreateRefund", 1)
+
+	owner, err := test.Redis().Get(lockKey).Result()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "another", owner)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_IdempotencyKeyRequired_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + refundsBulkPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"items": [{"order_id": "` + uuid.New().String() + `", "amount": 10}]}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageBulkRefundIdempotencyKeyRequired, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetBulkRefund_OtherCreator_NotFound() {
+	job, _, err := common.NewBulkRefunds(test.Redis(), time.Hour).Create(uuid.New().String(), &common.BulkRefund{
+		CreatorId: bson.NewObjectId().Hex(),
+		Completed: true,
+	})
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, job.Id).
+		Path(common.AuthUserGroupPath + refundsBulkIdPath).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageBulkRefundNotFound, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_CsvInvalid_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + refundsBulkPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, common.MIMETextCsv)
+			request.Header.Set(common.HeaderIdempotencyKey, uuid.New().String())
+		}).
+		BodyString(uuid.New().String() + ",ten\n").
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageBulkRefundCsvInvalid, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateBulkRefund_RowsLimitExceeded_Error() {
+	suite.router.cfg.RefundBulkMaxRows = 1
+	data := `{"reason": "launch cancelled", "items": [{"order_id": "` + uuid.New().String() + `", "amount": 10},
+		{"order_id": "` + uuid.New().String() + `", "amount": 5}]}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + refundsBulkPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			request.Header.Set(common.HeaderIdempotencyKey, uuid.New().String())
+		}).
+		BodyString(data).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageBulkRefundRowsLimitExceeded.Code, msg.Code)
+}
+
 func (suite *OrderTestSuite) TestOrder_CreateRefund_BindError() {
 	data := `{"amount": "qwerty", "reason": "test"}`
 
@@ -634,6 +1182,32 @@ func (suite *OrderTestSuite) TestOrder_CalculateAmounts_ValidationZipError() {
 	assert.Regexp(suite.T(), "Zip", msg.Details)
 }
 
+func (suite *OrderTestSuite) TestOrder_CalculateAmounts_ZipByCountryFormat_Error() {
+	for _, body := range []string{
+		`{"country": "RU", "zip": "1010"}`,
+		`{"country": "AR", "zip": "12345"}`,
+	} {
+		_, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Params(":order_id", uuid.New().String()).
+			Path(common.AuthProjectGroupPath + orderBillingAddressPath).
+			Init(test.ReqInitJSON()).
+			BodyString(body).
+			Exec(suite.T())
+
+		assert.Error(suite.T(), err)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+		msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), common.ErrorMessageIncorrectZip.Code, msg.Code)
+		assert.Regexp(suite.T(), "Zip", msg.Details)
+	}
+}
+
 func (suite *OrderTestSuite) TestOrder_CalculateAmounts_BillingServerSystemError() {
 	body := `{"country": "US", "zip": "98001"}`
 
@@ -707,47 +1281,69 @@ func (suite *OrderTestSuite) TestOrder_CreateJson_Ok() {
 	assert.NotEmpty(suite.T(), response.Id)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_Ok() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:     bson.NewObjectId().Hex(),
-		PaymentMethod: "BANKCARD",
-		Currency:      "RUB",
-		Amount:        100,
-		Description:   "unit test",
-		OrderId:       bson.NewObjectId().Hex(),
-		User: &billing.OrderUser{
-			ExternalId:    bson.NewObjectId().Hex(),
-			Ip:            "127.0.0.1",
-			Locale:        "ru",
-			Name:          "Unit Test",
-			Email:         "test@unit.test",
-			EmailVerified: true,
-			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
-		},
-	}
+func (suite *OrderTestSuite) getCartBillingMock(projectId string) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, MerchantId: bson.NewObjectId().Hex()},
+		}, nil)
+	bill.On("GetProduct", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetProductResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &grpc.Product{
+				Id:              bson.NewObjectId().Hex(),
+				ProjectId:       projectId,
+				DefaultCurrency: "USD",
+				Enabled:         true,
+				Prices:          []*billing.ProductPrice{{Currency: "USD", Amount: 10.5}},
+			},
+		}, nil)
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
 
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
+	return bill
+}
 
-	reqInit := func(request *http.Request, middleware test.Middleware) {
-		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
-	}
+func (suite *OrderTestSuite) TestOrder_CreateJson_Cart_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getCartBillingMock(projectId)
+	suite.router.dispatch.Services.Billing = bill
+
+	first, second := bson.NewObjectId().Hex(), bson.NewObjectId().Hex()
+	body := `{"project_id": "` + projectId + `", "currency": "USD", "cart": [{"product_id": "` + first + `", "quantity": 2},
+		{"product_id": "` + second + `", "quantity": 1}]}`
 
 	res, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
-		Init(reqInit).
-		BodyBytes(b).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
-	assert.NotEmpty(suite.T(), res.Body.String())
+
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return len(req.Products) == 3 && req.Amount == 31.5 && req.Currency == "USD"
+	}))
+
+	rsp := &CreateOrderJsonProjectResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+
+	// quantities are kept for products report, billing server lists each product of the order once
+	carts, err := suite.router.carts.Get([]string{rsp.Id})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string]int32{first: 2, second: 1}, carts[rsp.Id])
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_BindError() {
-	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": "qwerty"}`
+func (suite *OrderTestSuite) TestOrder_CreateJson_CartWithProducts_Error() {
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "products": ["` + bson.NewObjectId().Hex() + `"],
+		"cart": [{"product_id": "` + bson.NewObjectId().Hex() + `", "quantity": 1}]}`
 
 	_, err := suite.caller.Builder().
 		Method(http.MethodPost).
@@ -761,36 +1357,19 @@ func (suite *OrderTestSuite) TestOrder_CreateJson_BindError() {
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
 	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Equal(suite.T(), common.ErrorRequestParamsIncorrect, httpErr.Message)
+	assert.Equal(suite.T(), common.ErrorMessageOrderCartWithProducts, httpErr.Message)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_EmptyRequestSignature_Error() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:     bson.NewObjectId().Hex(),
-		PaymentMethod: "BANKCARD",
-		Currency:      "RUB",
-		Amount:        100,
-		Description:   "unit test",
-		OrderId:       bson.NewObjectId().Hex(),
-		User: &billing.OrderUser{
-			ExternalId:    bson.NewObjectId().Hex(),
-			Ip:            "127.0.0.1",
-			Locale:        "ru",
-			Name:          "Unit Test",
-			Email:         "test@unit.test",
-			EmailVerified: true,
-			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
-		},
-	}
+func (suite *OrderTestSuite) TestOrder_CreateJson_CartProductFromOtherProject_Error() {
+	suite.router.dispatch.Services.Billing = suite.getCartBillingMock(bson.NewObjectId().Hex())
 
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "cart": [{"product_id": "` + bson.NewObjectId().Hex() + `", "quantity": 1}]}`
 
-	_, err = suite.caller.Builder().
+	_, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
@@ -798,135 +1377,215 @@ func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_EmptyRequestSignature
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
 	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Equal(suite.T(), common.ErrorMessageSignatureHeaderIsEmpty, httpErr.Message)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageOrderCartProductInvalid.Code, msg.Code)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_BillingServerSystemError() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:     bson.NewObjectId().Hex(),
-		PaymentMethod: "BANKCARD",
-		Currency:      "RUB",
-		Amount:        100,
-		Description:   "unit test",
-		OrderId:       bson.NewObjectId().Hex(),
-		User: &billing.OrderUser{
-			ExternalId:    bson.NewObjectId().Hex(),
-			Ip:            "127.0.0.1",
-			Locale:        "ru",
-			Name:          "Unit Test",
-			Email:         "test@unit.test",
-			EmailVerified: true,
-			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
-		},
-	}
+func (suite *OrderTestSuite) TestOrder_CreateJson_CartRepeatedProduct_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getCartBillingMock(projectId)
+	suite.router.dispatch.Services.Billing = bill
+
+	productId := bson.NewObjectId().Hex()
+	body := `{"project_id": "` + projectId + `", "currency": "USD", "cart": [{"product_id": "` + productId + `", "quantity": 2},
+		{"product_id": "` + productId + `", "quantity": 1}]}`
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
 
-	b, err := json.Marshal(order)
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertNumberOfCalls(suite.T(), "GetProduct", 1)
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return len(req.Products) == 3 && req.Amount == 31.5
+	}))
+}
 
-	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+func (suite *OrderTestSuite) TestOrder_CreateJson_CartRepeatedProductQuantityLimit_Error() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getCartBillingMock(projectId)
+	suite.router.dispatch.Services.Billing = bill
 
-	reqInit := func(request *http.Request, middleware test.Middleware) {
-		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
-	}
+	productId := bson.NewObjectId().Hex()
+	body := `{"project_id": "` + projectId + `", "cart": [{"product_id": "` + productId + `", "quantity": 100},
+		{"product_id": "` + productId + `", "quantity": 1}]}`
 
-	_, err = suite.caller.Builder().
+	_, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
-		Init(reqInit).
-		BodyBytes(b).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
 
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
-	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	bill.AssertNotCalled(suite.T(), "GetProduct", mock2.Anything, mock2.Anything)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_BillingServerResultFail_Error() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:     bson.NewObjectId().Hex(),
-		PaymentMethod: "BANKCARD",
-		Currency:      "RUB",
-		Amount:        100,
-		Description:   "unit test",
-		OrderId:       bson.NewObjectId().Hex(),
-		User: &billing.OrderUser{
-			ExternalId:    bson.NewObjectId().Hex(),
-			Ip:            "127.0.0.1",
-			Locale:        "ru",
-			Name:          "Unit Test",
-			Email:         "test@unit.test",
-			EmailVerified: true,
-			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
-		},
-	}
+func (suite *OrderTestSuite) TestOrder_CreateJson_CartWithPlatform_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, MerchantId: bson.NewObjectId().Hex()},
+		}, nil)
+	bill.On("GetKeyProduct", mock2.Anything, mock2.Anything).
+		Return(&grpc.KeyProductResponse{
+			Status: pkg.ResponseStatusOk,
+			Product: &grpc.KeyProduct{
+				ProjectId:       projectId,
+				DefaultCurrency: "USD",
+				Enabled:         true,
+				Platforms: []*grpc.PlatformPrice{
+					{Id: "gog", Prices: []*billing.ProductPrice{{Currency: "USD", Amount: 5}}},
+					{Id: "steam", Prices: []*billing.ProductPrice{{Currency: "USD", Amount: 7}}},
+				},
+			},
+		}, nil)
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	body := `{"project_id": "` + projectId + `", "platform": "steam", "cart": [{"product_id": "` + bson.NewObjectId().Hex() + `", "quantity": 2}]}`
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
 
-	b, err := json.Marshal(order)
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertNumberOfCalls(suite.T(), "GetKeyProduct", 1)
+	bill.AssertNotCalled(suite.T(), "GetProduct", mock2.Anything, mock2.Anything)
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return len(req.Products) == 2 && req.Amount == 14 && req.Currency == "USD" && req.Other[orderPlatformField] == "steam"
+	}))
+}
 
-	suite.router.dispatch.Services.Billing = mock.NewBillingServerErrorMock()
+func (suite *OrderTestSuite) TestOrder_CreateJson_CartWithUser_SignatureCheckedFirst() {
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getCartBillingMock(projectId)
+	suite.router.dispatch.Services.Billing = bill
 
-	reqInit := func(request *http.Request, middleware test.Middleware) {
-		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
-	}
+	body := `{"project_id": "` + projectId + `", "user": {"external_id": "user"},
+		"cart": [{"product_id": "` + bson.NewObjectId().Hex() + `", "quantity": 1}]}`
 
-	_, err = suite.caller.Builder().
+	_, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
-		Init(reqInit).
-		BodyBytes(b).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
 
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
+	assert.Equal(suite.T(), common.ErrorMessageSignatureHeaderIsEmpty, httpErr.Message)
+	bill.AssertNotCalled(suite.T(), "GetProject", mock2.Anything, mock2.Anything)
+	bill.AssertNotCalled(suite.T(), "GetProduct", mock2.Anything, mock2.Anything)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_ValidationError() {
-	body := `{"amount": -10}`
+func (suite *OrderTestSuite) TestOrder_CreateJson_VirtualCurrency_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &billing.Project{
+				Id: projectId,
+				VirtualCurrency: &billing.ProjectVirtualCurrency{
+					Prices: []*billing.ProductPrice{{Currency: "USD", Amount: 0.15}},
+				},
+			},
+		}, nil)
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	_, err := suite.caller.Builder().
+	body := `{"project_id": "` + projectId + `", "currency": "USD", "virtual_currency_amount": 100}`
+
+	res, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
 		BodyString(body).
 		Exec(suite.T())
 
-	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
 
-	httpErr, ok := err.(*echo.HTTPError)
-	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Regexp(suite.T(), common.NewValidationError("Amount"), httpErr.Message)
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return req.Amount == 15 && req.Other[orderVirtualCurrencyAmountField] == "100"
+	}))
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_OrderCreateError() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:     mock.SomeMerchantId,
-		PaymentMethod: "BANKCARD",
-		Currency:      "RUB",
-		Amount:        100,
-		Description:   "unit test",
-		OrderId:       bson.NewObjectId().Hex(),
+func (suite *OrderTestSuite) TestOrder_CreateJson_VirtualCurrencyAmountTooLarge_Error() {
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &billing.Project{
+				VirtualCurrency: &billing.ProjectVirtualCurrency{
+					Prices: []*billing.ProductPrice{{Currency: "USD", Amount: 1}},
+				},
+			},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	amounts := []string{"4611686018427387905", "1000000001", strconv.FormatInt(suite.router.cfg.VirtualCurrencyMaxAmount+1, 10)}
+
+	for _, amount := range amounts {
+		body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "currency": "USD", "virtual_currency_amount": ` + amount + `}`
+
+		_, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Path(common.AuthProjectGroupPath + orderPath).
+			Init(test.ReqInitJSON()).
+			BodyString(body).
+			Exec(suite.T())
+
+		assert.Error(suite.T(), err)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
 	}
 
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
+	bill.AssertNotCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.Anything)
+}
 
-	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+func (suite *OrderTestSuite) TestOrder_CreateJson_VirtualCurrencyNotConfigured_Error() {
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: &billing.Project{}}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	_, err = suite.caller.Builder().
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "currency": "USD", "virtual_currency_amount": 100}`
+
+	_, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
@@ -934,227 +1593,397 @@ func (suite *OrderTestSuite) TestOrder_CreateJson_OrderCreateError() {
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
 	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
+	assert.Equal(suite.T(), common.ErrorMessageVirtualCurrencyNotConfigured, httpErr.Message)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_PaymentFormJsonDataProcessError() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:     mock.SomeMerchantId1,
-		PaymentMethod: "BANKCARD",
-		Currency:      "RUB",
-		Amount:        100,
-		Description:   "unit test",
-		OrderId:       bson.NewObjectId().Hex(),
-	}
-
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
+func (suite *OrderTestSuite) TestOrder_CreateJson_Attribution_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD",
+		"utm_source": "google", "utm_campaign": "winter_sale", "ad_id": "123456"}`
 
-	_, err = suite.caller.Builder().
+	res, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
 		Exec(suite.T())
 
-	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
 
-	httpErr, ok := err.(*echo.HTTPError)
-	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		_, ok := req.Other[common.RequestParameterUtmMedium]
+		return req.Other[common.RequestParameterUtmSource] == "google" &&
+			req.Other[common.RequestParameterUtmCampaign] == "winter_sale" &&
+			req.Other[common.RequestParameterAdId] == "123456" && !ok
+	}))
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_ProductionEnvironment_Ok() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:     bson.NewObjectId().Hex(),
-		PaymentMethod: "BANKCARD",
-		Currency:      "RUB",
-		Amount:        100,
-		Description:   "unit test",
-		OrderId:       bson.NewObjectId().Hex(),
-	}
+func (suite *OrderTestSuite) TestOrder_CreateJson_AttributionForm_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
+	form := url.Values{}
+	form.Set(common.OrderFieldProjectId, bson.NewObjectId().Hex())
+	form.Set(common.OrderFieldAmount, "10")
+	form.Set(common.OrderFieldCurrency, "USD")
+	form.Set(common.RequestParameterUtmSource, "google")
 
 	res, err := suite.caller.Builder().
 		Method(http.MethodPost).
-		Params(":"+common.RequestParameterId, uuid.New().String()).
 		Path(common.AuthProjectGroupPath + orderPath).
-		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		Init(test.ReqInitApplicationForm()).
+		BodyString(form.Encode()).
 		Exec(suite.T())
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
-	assert.NotEmpty(suite.T(), res.Body.String())
 
-	var response *CreateOrderJsonProjectResponse
-	err = json.Unmarshal(res.Body.Bytes(), &response)
-	assert.NoError(suite.T(), err)
-	assert.Nil(suite.T(), response.PaymentFormData)
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return req.Other[common.RequestParameterUtmSource] == "google"
+	}))
 }
 
-func (suite *OrderTestSuite) TestOrder_GetPaymentFormData_Ok() {
+func (suite *OrderTestSuite) TestOrder_ListOrdersPublic_ByAttribution_Ok() {
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), MerchantId: mock.OnboardingMerchantMock.Id}
+	attributions := common.NewOrderAttributions(test.Redis(), time.Hour)
+	attr := map[string]string{common.RequestParameterUtmSource: "google", common.RequestParameterUtmCampaign: "sale"}
+	now := time.Now()
+
+	assert.NoError(suite.T(), attributions.Record(project.Id, "order1", attr, now.Add(-time.Minute)))
+	assert.NoError(suite.T(), attributions.Record(project.Id, "order2", attr, now))
+	assert.NoError(suite.T(), attributions.Record(
+		project.Id, "order3", map[string]string{common.RequestParameterUtmSource: "google"}, now,
+	))
+	assert.NoError(suite.T(), attributions.Record(bson.NewObjectId().Hex(), "order4", attr, now))
+
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}, nil)
+
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return len(req.Project) == 1 && req.Project[0] == project.Id &&
+			req.ProjectDateFrom <= now.Add(-time.Minute).Unix() && req.ProjectDateTo >= now.Unix()
+	})).Return(&grpc.ListOrdersPublicResponse{
+		Status: pkg.ResponseStatusOk,
+		Item: &grpc.ListOrdersPublicResponseItem{
+			Count: 3,
+			Items: []*billing.OrderViewPublic{
+				{Uuid: "order1", Project: &billing.ProjectOrder{Id: project.Id}},
+				{Uuid: "order3", Project: &billing.ProjectOrder{Id: project.Id}},
+				{Uuid: "order2", Project: &billing.ProjectOrder{Id: project.Id}},
+			},
+		},
+	}, nil)
+
+	suite.router.dispatch.Services.Billing = bill
+
 	res, err := suite.caller.Builder().
 		Method(http.MethodGet).
-		Params(":"+common.RequestParameterId, uuid.New().String()).
-		Path(common.AuthProjectGroupPath + orderIdPath).
+		Path(common.AuthUserGroupPath+orderPath).
+		SetQueryParam("project[]", project.Id).
+		SetQueryParam(common.RequestParameterUtmSource, "google").
+		SetQueryParam(common.RequestParameterUtmCampaign, "sale").
 		Init(test.ReqInitJSON()).
 		Exec(suite.T())
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
-	assert.NotEmpty(suite.T(), res.Body.String())
-	assert.Equal(suite.T(), echo.MIMEApplicationJSONCharsetUTF8, res.Header().Get(echo.HeaderContentType))
 
-	data := new(grpc.PaymentFormJsonData)
-	err = json.Unmarshal(res.Body.Bytes(), data)
-	assert.NoError(suite.T(), err)
-	assert.NotEmpty(suite.T(), data.Cookie)
+	rsp := &grpc.ListOrdersPublicResponseItem{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), rsp))
+	assert.EqualValues(suite.T(), 2, rsp.Count)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), "order2", rsp.Items[0].Uuid)
+	assert.Equal(suite.T(), "order1", rsp.Items[1].Uuid)
+	bill.AssertNumberOfCalls(suite.T(), "FindAllOrdersPublic", 1)
+	bill.AssertNotCalled(suite.T(), "GetOrderPublic", mock2.Anything, mock2.Anything)
 }
 
-func (suite *OrderTestSuite) TestOrder_GetOrderForm_TokenCookieExist_Ok() {
-	cookie := new(http.Cookie)
-	cookie.Name = common.CustomerTokenCookiesName
-	cookie.Value = bson.NewObjectId().Hex()
-	cookie.Expires = time.Now().Add(suite.router.cfg.CustomerTokenCookiesLifetime)
-	cookie.HttpOnly = true
-
-	res, err := suite.caller.Builder().
+func (suite *OrderTestSuite) TestOrder_ListOrdersPublic_ByAttributionWithoutProject_Error() {
+	_, err := suite.caller.Builder().
 		Method(http.MethodGet).
-		AddCookie(cookie).
-		Params(":"+common.RequestParameterId, mock.SomeMerchantId1).
-		Path(common.AuthProjectGroupPath + orderIdPath).
+		Path(common.AuthUserGroupPath+orderPath).
+		SetQueryParam(common.RequestParameterUtmSource, "google").
 		Init(test.ReqInitJSON()).
 		Exec(suite.T())
 
-	assert.NoError(suite.T(), err)
-	assert.Equal(suite.T(), http.StatusOK, res.Code)
-	assert.NotEmpty(suite.T(), res.Body.String())
-	assert.Equal(suite.T(), echo.MIMEApplicationJSONCharsetUTF8, res.Header().Get(echo.HeaderContentType))
+	assert.Error(suite.T(), err)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageOrderAttributionFilterInvalid, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_RawBodyForwarded_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	order := &billing.OrderCreateRequest{ProjectId: bson.NewObjectId().Hex(), Amount: 10, Currency: "USD"}
+
+	for _, signature := range []string{"", "signature"} {
+		order.Signature = signature
+		b, err := json.Marshal(order)
+		assert.NoError(suite.T(), err)
+
+		res, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Path(common.AuthProjectGroupPath + orderPath).
+			Init(test.ReqInitJSON()).
+			BodyBytes(b).
+			Exec(suite.T())
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusOK, res.Code)
+		bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+			return req.Signature == signature && req.RawBody == string(b)
+		}))
+	}
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_SignatureCheckedByProjectSecret_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, SecretKey: "secret"},
+		}, nil)
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	body := `{"project_id": "` + projectId + `", "amount": 10, "currency": "USD", "user": {"external_id": "user"}}`
+	hash := sha512.Sum512([]byte(body + "secret"))
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			request.Header.Set(common.HeaderXApiSignatureHeader, hex.EncodeToString(hash[:]))
+		}).
+		BodyString(body).
+		Exec(suite.T())
 
-	data := new(grpc.PaymentFormJsonData)
-	err = json.Unmarshal(res.Body.Bytes(), data)
 	assert.NoError(suite.T(), err)
-	assert.NotEmpty(suite.T(), data.Cookie)
-	assert.Equal(suite.T(), cookie.Value, data.Cookie)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertNotCalled(suite.T(), "CheckProjectRequestSignature", mock2.Anything, mock2.Anything)
 }
 
-func (suite *OrderTestSuite) TestOrder_GetOrderForm_ParameterIdNotFound_Error() {
+func (suite *OrderTestSuite) TestOrder_CreateJson_SignatureMismatch_CheckedByBilling() {
+	projectId := bson.NewObjectId().Hex()
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, SecretKey: "secret"},
+		}, nil)
+	bill.On("CheckProjectRequestSignature", mock2.Anything, mock2.Anything).
+		Return(&grpc.CheckProjectRequestSignatureResponse{Status: pkg.ResponseStatusBadData, Message: mock.SomeError}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	body := `{"project_id": "` + projectId + `", "amount": 10, "currency": "USD", "user": {"external_id": "user"}}`
 
 	_, err := suite.caller.Builder().
-		Method(http.MethodGet).
-		Params(":"+common.RequestParameterId, "").
-		Path(orderIdPath).
-		Init(test.ReqInitJSON()).
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
+		}).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
 
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusNotFound, httpErr.Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	bill.AssertCalled(suite.T(), "CheckProjectRequestSignature", mock2.Anything, mock2.MatchedBy(func(req *grpc.CheckProjectRequestSignatureRequest) bool {
+		return req.Body == body && req.Signature == "signature"
+	}))
 }
 
-func (suite *OrderTestSuite) TestOrder_GetOrderForm_BillingServerSystemError() {
+func (suite *OrderTestSuite) TestOrder_CreateJson_CartWithUser_ProjectReadOnce() {
+	projectId := bson.NewObjectId().Hex()
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, MerchantId: bson.NewObjectId().Hex(), SecretKey: "secret"},
+		}, nil)
+	bill.On("GetProduct", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetProductResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &grpc.Product{
+				ProjectId:       projectId,
+				DefaultCurrency: "USD",
+				Enabled:         true,
+				Prices:          []*billing.ProductPrice{{Currency: "USD", Amount: 10.5}},
+			},
+		}, nil)
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+	body := `{"project_id": "` + projectId + `", "user": {"external_id": "user"}, "utm_source": "newsletter",
+		"metadata": {"invoice_id": "INV-1001"}, "cart": [{"product_id": "` + bson.NewObjectId().Hex() + `", "quantity": 2}]}`
+	hash := sha512.Sum512([]byte(body + "secret"))
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			request.Header.Set(common.HeaderXApiSignatureHeader, hex.EncodeToString(hash[:]))
+		}).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertNumberOfCalls(suite.T(), "GetProject", 1)
+	bill.AssertNotCalled(suite.T(), "CheckProjectRequestSignature", mock2.Anything, mock2.Anything)
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return req.Amount == 21 && req.Other[common.RequestParameterUtmSource] == "newsletter" &&
+			req.Other[orderMetadataParameterPrefix+"invoice_id"] == "INV-1001"
+	}))
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_SignatureCheckedTwice_Ok() {
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), SecretKey: "secret"}
+	body := []byte(`{"project_id": "` + project.Id + `", "amount": 10, "currency": "USD", "user": {"external_id": "user"}}`)
+	hash := sha512.Sum512(append(append([]byte{}, body...), "secret"...))
+
+	req := httptest.NewRequest(http.MethodPost, common.AuthProjectGroupPath+orderPath, bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(common.HeaderXApiSignatureHeader, hex.EncodeToString(hash[:]))
+	ctx := echo.New().NewContext(req, httptest.NewRecorder())
+	assert.NoError(suite.T(), (&common.OrderJsonBinder{}).Bind(&billing.OrderCreateRequest{}, ctx))
+
+	// billing server isn't asked, the signature matches by hash of the body both times
+	bill := &billMock.BillingService{}
+	suite.router.dispatch.Services.Billing = bill
+	getProject := func() *billing.Project {
+		return project
+	}
+
+	assert.NoError(suite.T(), common.CheckProjectAuthRequestSignature(suite.router.dispatch, ctx, project.Id, getProject))
+	assert.NoError(suite.T(), common.CheckProjectAuthRequestSignature(suite.router.dispatch, ctx, project.Id, getProject))
+	bill.AssertNotCalled(suite.T(), "CheckProjectRequestSignature", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_TrailingData_Error() {
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD"} {}`
 
 	_, err := suite.caller.Builder().
-		Method(http.MethodGet).
-		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
-		Path(common.AuthProjectGroupPath + orderIdPath).
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
 
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
-	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorRequestParamsIncorrect, httpErr.Message)
 }
 
-func (suite *OrderTestSuite) TestOrder_GetOrders_Ok() {
+func (suite *OrderTestSuite) TestOrder_CreateJson_UserPhoneNormalized_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusNotFound}, nil)
+	bill.On("CheckProjectRequestSignature", mock2.Anything, mock2.Anything).
+		Return(&grpc.CheckProjectRequestSignatureResponse{Status: pkg.ResponseStatusOk}, nil)
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	bs := &billMock.BillingService{}
-	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything, mock2.Anything).
-		Return(
-			&grpc.ListOrdersPublicResponse{
-				Status: pkg.ResponseStatusOk,
-				Item: &grpc.ListOrdersPublicResponseItem{
-					Count: 1,
-					Items: []*billing.OrderViewPublic{},
-				},
-			},
-			nil,
-		)
-	suite.router.dispatch.Services.Billing = bs
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD",
+		"user": {"external_id": "` + bson.NewObjectId().Hex() + `", "phone": "0121 234 5678",
+		"address": {"country": "GB"}}}`
+	reqInit := func(request *http.Request, middleware test.Middleware) {
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
+	}
 
 	res, err := suite.caller.Builder().
-		Method(http.MethodGet).
-		Path(common.AuthUserGroupPath + orderPath).
-		Init(test.ReqInitJSON()).
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(reqInit).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
-	assert.NotEmpty(suite.T(), res.Body.String())
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return req.User.Phone == "+441212345678"
+	}))
 }
 
-func (suite *OrderTestSuite) TestOrder_GetOrders_BillingServerError() {
-
-	bs := &billMock.BillingService{}
-	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything, mock2.Anything).
-		Return(nil, errors.New("some error"))
-	suite.router.dispatch.Services.Billing = bs
+func (suite *OrderTestSuite) TestOrder_CreateJson_UserPhoneInvalid_Error() {
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD",
+		"user": {"external_id": "` + bson.NewObjectId().Hex() + `", "phone": "unit test"}}`
 
 	_, err := suite.caller.Builder().
-		Method(http.MethodGet).
-		Path(common.AuthUserGroupPath + orderPath).
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
 
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
-	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
-}
-
-func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_Id() {
-	q := make(url.Values)
-	q.Set(common.RequestParameterId, bson.NewObjectId().Hex())
-	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "Id", "uuid"))
-}
-
-func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_Project() {
-	q := url.Values{common.RequestParameterProject: []string{"foo"}}
-	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "Project[0]", "hexadecimal"))
-}
-
-func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_PaymentMethod() {
-	q := url.Values{common.RequestParameterPaymentMethod: []string{"foo"}}
-	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "PaymentMethod[0]", "hexadecimal"))
-}
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
 
-func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_Country() {
-	q := url.Values{common.RequestParameterCountries: []string{"foo"}}
-	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "Country[0]", "len"))
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageIncorrectPhone.Code, msg.Code)
+	assert.Equal(suite.T(), "user.phone", msg.Details)
 }
 
-func (suite *OrderTestSuite) testGetOrdersBindError(q url.Values, error string) {
+func (suite *OrderTestSuite) TestOrder_CreateJson_AttributionTooLong_Error() {
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD",
+		"utm_source": "` + strings.Repeat("a", 129) + `"}`
 
 	_, err := suite.caller.Builder().
-		Method(http.MethodGet).
-		SetQueryParams(q).
-		Path(common.AuthUserGroupPath + orderPath).
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
@@ -1162,72 +1991,64 @@ func (suite *OrderTestSuite) testGetOrdersBindError(q url.Values, error string)
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
 	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Equal(suite.T(), common.NewValidationError(error), httpErr.Message)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_WithPreparedOrderId_Ok() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:    bson.NewObjectId().Hex(),
-		PspOrderUuid: uuid.New().String(),
-	}
+func (suite *OrderTestSuite) TestOrder_CreateJson_Metadata_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD",
+		"metadata": {"invoice_id": "INV-1001", "customer_ref": "42"}}`
 
 	res, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
-	assert.NotEmpty(suite.T(), res.Body.String())
-}
-
-func (suite *OrderTestSuite) TestOrder_CreateJson_WithPreparedOrderId_BillingServiceSystemError() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:    bson.NewObjectId().Hex(),
-		PspOrderUuid: uuid.New().String(),
-	}
 
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return req.Other[orderMetadataParameterPrefix+"invoice_id"] == "INV-1001" &&
+			req.Other[orderMetadataParameterPrefix+"customer_ref"] == "42"
+	}))
+}
 
-	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+func (suite *OrderTestSuite) TestOrder_CreateJson_MetadataNotString_Error() {
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD",
+		"metadata": {"invoice_id": 1001}}`
 
-	_, err = suite.caller.Builder().
+	_, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
 
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
-	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageOrderMetadataInvalid, httpErr.Message)
 }
 
-func (suite *OrderTestSuite) TestOrder_CreateJson_WithPreparedOrderId_BillingServiceResultError() {
-	order := &billing.OrderCreateRequest{
-		ProjectId:    bson.NewObjectId().Hex(),
-		PspOrderUuid: uuid.New().String(),
-	}
-
-	b, err := json.Marshal(order)
-	assert.NoError(suite.T(), err)
-
-	suite.router.dispatch.Services.Billing = mock.NewBillingServerErrorMock()
+func (suite *OrderTestSuite) TestOrder_CreateJson_MetadataTooLong_Error() {
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": 10, "currency": "USD",
+		"metadata": {"invoice_id": "` + strings.Repeat("a", 501) + `"}}`
 
-	_, err = suite.caller.Builder().
+	_, err := suite.caller.Builder().
 		Method(http.MethodPost).
 		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
@@ -1235,247 +2056,1707 @@ func (suite *OrderTestSuite) TestOrder_CreateJson_WithPreparedOrderId_BillingSer
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
 	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
-	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
 }
 
-func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_Ok() {
-	shouldBe := require.New(suite.T())
-
-	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
-		KeyProductId: bson.NewObjectId().Hex(),
-	}
-	b, err := json.Marshal(changeOrderRequest)
-	assert.NoError(suite.T(), err)
-
-	billingService := &billMock.BillingService{}
-	billingService.On("ChangeCodeInOrder", mock2.Anything, mock2.Anything).Return(&grpc.ChangeCodeInOrderResponse{
-		Status: pkg.ResponseStatusOk,
-		Order:  &billing.Order{},
-	}, nil)
+func (suite *OrderTestSuite) getPlatformBillingMock(platform string) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{MerchantId: bson.NewObjectId().Hex()},
+		}, nil)
+	bill.On("GetKeyProduct", mock2.Anything, mock2.Anything).
+		Return(&grpc.KeyProductResponse{
+			Status:  pkg.ResponseStatusOk,
+			Product: &grpc.KeyProduct{Platforms: []*grpc.PlatformPrice{{Id: platform}}},
+		}, nil)
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderCreateProcessResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Order{Uuid: uuid.New().String()},
+		}, nil)
+
+	return bill
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_Platform_Ok() {
+	bill := suite.getPlatformBillingMock("steam")
+	suite.router.dispatch.Services.Billing = bill
 
-	suite.router.dispatch.Services.Billing = billingService
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "products": ["` + bson.NewObjectId().Hex() + `"], "platform": "steam"}`
 
 	res, err := suite.caller.Builder().
-		Method(http.MethodPut).
-		Params(":order_id", bson.NewObjectId().Hex()).
-		Path(common.AuthUserGroupPath + orderReplaceCodePath).
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
 		Exec(suite.T())
 
-	shouldBe.NoError(err)
-	shouldBe.Nil(err)
-	shouldBe.EqualValues(http.StatusOK, res.Code)
-	shouldBe.NotEmpty(res.Body.String())
-}
-
-func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_ServiceError() {
-	shouldBe := require.New(suite.T())
-
-	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
-		KeyProductId: bson.NewObjectId().Hex(),
-	}
-	b, err := json.Marshal(changeOrderRequest)
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
 
-	billingService := &billMock.BillingService{}
-	billingService.On("ChangeCodeInOrder", mock2.Anything, mock2.Anything).Return(nil, errors.New("some error"))
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return req.Other[orderPlatformField] == "steam"
+	}))
+}
 
-	suite.router.dispatch.Services.Billing = billingService
+func (suite *OrderTestSuite) TestOrder_CreateJson_PlatformNotAvailable_Error() {
+	suite.router.dispatch.Services.Billing = suite.getPlatformBillingMock("gog")
 
-	_, err = suite.caller.Builder().
-		Method(http.MethodPut).
-		Params(":order_id", bson.NewObjectId().Hex()).
-		Path(common.AuthUserGroupPath + orderReplaceCodePath).
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "products": ["` + bson.NewObjectId().Hex() + `"], "platform": "steam"}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageOrderPlatformNotAvailable.Code, msg.Code)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_Ok() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:     bson.NewObjectId().Hex(),
+		PaymentMethod: "BANKCARD",
+		Currency:      "RUB",
+		Amount:        100,
+		Description:   "unit test",
+		OrderId:       bson.NewObjectId().Hex(),
+		User: &billing.OrderUser{
+			ExternalId:    bson.NewObjectId().Hex(),
+			Ip:            "127.0.0.1",
+			Locale:        "ru",
+			Name:          "Unit Test",
+			Email:         "test@unit.test",
+			EmailVerified: true,
+			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
+		},
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	reqInit := func(request *http.Request, middleware test.Middleware) {
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
+	}
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(reqInit).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotEmpty(suite.T(), res.Body.String())
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_BindError() {
+	body := `{"project_id": "` + bson.NewObjectId().Hex() + `", "amount": "qwerty"}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorRequestParamsIncorrect, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_EmptyRequestSignature_Error() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:     bson.NewObjectId().Hex(),
+		PaymentMethod: "BANKCARD",
+		Currency:      "RUB",
+		Amount:        100,
+		Description:   "unit test",
+		OrderId:       bson.NewObjectId().Hex(),
+		User: &billing.OrderUser{
+			ExternalId:    bson.NewObjectId().Hex(),
+			Ip:            "127.0.0.1",
+			Locale:        "ru",
+			Name:          "Unit Test",
+			Email:         "test@unit.test",
+			EmailVerified: true,
+			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
+		},
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageSignatureHeaderIsEmpty, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_BillingServerSystemError() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:     bson.NewObjectId().Hex(),
+		PaymentMethod: "BANKCARD",
+		Currency:      "RUB",
+		Amount:        100,
+		Description:   "unit test",
+		OrderId:       bson.NewObjectId().Hex(),
+		User: &billing.OrderUser{
+			ExternalId:    bson.NewObjectId().Hex(),
+			Ip:            "127.0.0.1",
+			Locale:        "ru",
+			Name:          "Unit Test",
+			Email:         "test@unit.test",
+			EmailVerified: true,
+			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
+		},
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	reqInit := func(request *http.Request, middleware test.Middleware) {
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
+	}
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(reqInit).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_WithUser_BillingServerResultFail_Error() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:     bson.NewObjectId().Hex(),
+		PaymentMethod: "BANKCARD",
+		Currency:      "RUB",
+		Amount:        100,
+		Description:   "unit test",
+		OrderId:       bson.NewObjectId().Hex(),
+		User: &billing.OrderUser{
+			ExternalId:    bson.NewObjectId().Hex(),
+			Ip:            "127.0.0.1",
+			Locale:        "ru",
+			Name:          "Unit Test",
+			Email:         "test@unit.test",
+			EmailVerified: true,
+			Metadata:      map[string]string{"field1": "val1", "field2": "val2"},
+		},
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerErrorMock()
+
+	reqInit := func(request *http.Request, middleware test.Middleware) {
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(common.HeaderXApiSignatureHeader, "signature")
+	}
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(reqInit).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_ValidationError() {
+	body := `{"amount": -10}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Regexp(suite.T(), common.NewValidationError("Amount"), httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_OrderCreateError() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:     mock.SomeMerchantId,
+		PaymentMethod: "BANKCARD",
+		Currency:      "RUB",
+		Amount:        100,
+		Description:   "unit test",
+		OrderId:       bson.NewObjectId().Hex(),
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_PaymentFormJsonDataProcessError() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:     mock.SomeMerchantId1,
+		PaymentMethod: "BANKCARD",
+		Currency:      "RUB",
+		Amount:        100,
+		Description:   "unit test",
+		OrderId:       bson.NewObjectId().Hex(),
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_ProductionEnvironment_Ok() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:     bson.NewObjectId().Hex(),
+		PaymentMethod: "BANKCARD",
+		Currency:      "RUB",
+		Amount:        100,
+		Description:   "unit test",
+		OrderId:       bson.NewObjectId().Hex(),
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, uuid.New().String()).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotEmpty(suite.T(), res.Body.String())
+
+	var response *CreateOrderJsonProjectResponse
+	err = json.Unmarshal(res.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), response.PaymentFormData)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetPaymentFormData_Ok() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, uuid.New().String()).
+		Path(common.AuthProjectGroupPath + orderIdPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotEmpty(suite.T(), res.Body.String())
+	assert.Equal(suite.T(), echo.MIMEApplicationJSONCharsetUTF8, res.Header().Get(echo.HeaderContentType))
+
+	data := new(grpc.PaymentFormJsonData)
+	err = json.Unmarshal(res.Body.Bytes(), data)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), data.Cookie)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrderForm_TokenCookieExist_Ok() {
+	cookie := new(http.Cookie)
+	cookie.Name = common.CustomerTokenCookiesName
+	cookie.Value = bson.NewObjectId().Hex()
+	cookie.Expires = time.Now().Add(suite.router.cfg.CustomerTokenCookiesLifetime)
+	cookie.HttpOnly = true
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		AddCookie(cookie).
+		Params(":"+common.RequestParameterId, mock.SomeMerchantId1).
+		Path(common.AuthProjectGroupPath + orderIdPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotEmpty(suite.T(), res.Body.String())
+	assert.Equal(suite.T(), echo.MIMEApplicationJSONCharsetUTF8, res.Header().Get(echo.HeaderContentType))
+
+	data := new(grpc.PaymentFormJsonData)
+	err = json.Unmarshal(res.Body.Bytes(), data)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), data.Cookie)
+	assert.Equal(suite.T(), cookie.Value, data.Cookie)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrderForm_ParameterIdNotFound_Error() {
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, "").
+		Path(orderIdPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.Code)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrderForm_BillingServerSystemError() {
+
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthProjectGroupPath + orderIdPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_Ok() {
+
+	bs := &billMock.BillingService{}
+	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything, mock2.Anything).
+		Return(
+			&grpc.ListOrdersPublicResponse{
+				Status: pkg.ResponseStatusOk,
+				Item: &grpc.ListOrdersPublicResponseItem{
+					Count: 1,
+					Items: []*billing.OrderViewPublic{},
+				},
+			},
+			nil,
+		)
+	suite.router.dispatch.Services.Billing = bs
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotEmpty(suite.T(), res.Body.String())
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_BillingServerError() {
+
+	bs := &billMock.BillingService{}
+	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything, mock2.Anything).
+		Return(nil, errors.New("some error"))
+	suite.router.dispatch.Services.Billing = bs
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_SearchTooBroad_Error() {
+	suite.router.cfg.OrderSearchMaxPeriod = 30 * 24 * time.Hour
+
+	bs := &billMock.BillingService{}
+	suite.router.dispatch.Services.Billing = bs
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+orderPath).
+		SetQueryParam("account", "test@unit.test").
+		SetQueryParam("pm_date_from", "1569888000").
+		SetQueryParam("pm_date_to", "1577836800").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageOrderSearchTooBroad.Code, msg.Code)
+	assert.Regexp(suite.T(), "30 days", msg.Details)
+	bs.AssertNotCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_SearchByProjectTooBroad_Error() {
+	suite.router.cfg.OrderSearchMaxPeriod = 30 * 24 * time.Hour
+
+	bs := &billMock.BillingService{}
+	suite.router.dispatch.Services.Billing = bs
+
+	// project filter doesn't make the search cheap, orders of the project are scanned for the whole period
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+orderPath).
+		SetQueryParam("account", "test@unit.test").
+		SetQueryParam("project[]", bson.NewObjectId().Hex()).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageOrderSearchTooBroad.Code, msg.Code)
+	bs.AssertNotCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_SearchWithLimitedPeriod_Ok() {
+	suite.router.cfg.OrderSearchMaxPeriod = 30 * 24 * time.Hour
+
+	bs := &billMock.BillingService{}
+	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListOrdersPublicResponse{Status: pkg.ResponseStatusOk, Item: &grpc.ListOrdersPublicResponseItem{}}, nil)
+	suite.router.dispatch.Services.Billing = bs
+
+	for _, q := range []url.Values{
+		{"quick_search": {"test"}, "pm_date_from": {"1569888000"}, "pm_date_to": {"1572566399"}},
+		{
+			"quick_search":      {"test"},
+			"project[]":         {bson.NewObjectId().Hex()},
+			"project_date_from": {"1569888000"},
+			"project_date_to":   {"1572566399"},
+		},
+	} {
+		res, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Path(common.AuthUserGroupPath + orderPath).
+			SetQueryParams(q).
+			Init(test.ReqInitJSON()).
+			Exec(suite.T())
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusOK, res.Code)
+	}
+
+	bs.AssertNumberOfCalls(suite.T(), "FindAllOrdersPublic", 2)
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_Id() {
+	q := make(url.Values)
+	q.Set(common.RequestParameterId, bson.NewObjectId().Hex())
+	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "Id", "uuid"))
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_Project() {
+	q := url.Values{common.RequestParameterProject: []string{"foo"}}
+	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "Project[0]", "hexadecimal"))
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_PaymentMethod() {
+	q := url.Values{common.RequestParameterPaymentMethod: []string{"foo"}}
+	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "PaymentMethod[0]", "hexadecimal"))
+}
+
+func (suite *OrderTestSuite) TestOrder_GetOrders_BindError_Country() {
+	q := url.Values{common.RequestParameterCountries: []string{"foo"}}
+	suite.testGetOrdersBindError(q, fmt.Sprintf(common.ErrorMessageMask, "Country[0]", "len"))
+}
+
+func (suite *OrderTestSuite) testGetOrdersBindError(q url.Values, error string) {
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParams(q).
+		Path(common.AuthUserGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.NewValidationError(error), httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_WithPreparedOrderId_Ok() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:    bson.NewObjectId().Hex(),
+		PspOrderUuid: uuid.New().String(),
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotEmpty(suite.T(), res.Body.String())
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_WithPreparedOrderId_BillingServiceSystemError() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:    bson.NewObjectId().Hex(),
+		PspOrderUuid: uuid.New().String(),
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_CreateJson_WithPreparedOrderId_BillingServiceResultError() {
+	order := &billing.OrderCreateRequest{
+		ProjectId:    bson.NewObjectId().Hex(),
+		PspOrderUuid: uuid.New().String(),
+	}
+
+	b, err := json.Marshal(order)
+	assert.NoError(suite.T(), err)
+
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerErrorMock()
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + orderPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_Ok() {
+	shouldBe := require.New(suite.T())
+
+	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
+		KeyProductId: bson.NewObjectId().Hex(),
+	}
+	b, err := json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+
+	billingService := &billMock.BillingService{}
+	billingService.On("ChangeCodeInOrder", mock2.Anything, mock2.Anything).Return(&grpc.ChangeCodeInOrderResponse{
+		Status: pkg.ResponseStatusOk,
+		Order:  &billing.Order{},
+	}, nil)
+
+	suite.router.dispatch.Services.Billing = billingService
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":order_id", bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + orderReplaceCodePath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	shouldBe.NoError(err)
+	shouldBe.Nil(err)
+	shouldBe.EqualValues(http.StatusOK, res.Code)
+	shouldBe.NotEmpty(res.Body.String())
+}
+
+func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_ServiceError() {
+	shouldBe := require.New(suite.T())
+
+	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
+		KeyProductId: bson.NewObjectId().Hex(),
+	}
+	b, err := json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+
+	billingService := &billMock.BillingService{}
+	billingService.On("ChangeCodeInOrder", mock2.Anything, mock2.Anything).Return(nil, errors.New("some error"))
+
+	suite.router.dispatch.Services.Billing = billingService
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":order_id", bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + orderReplaceCodePath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	shouldBe.NotNil(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.EqualValues(http.StatusInternalServerError, httpErr.Code)
+}
+
+func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_ErrorInService() {
+	shouldBe := require.New(suite.T())
+
+	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
+		KeyProductId: bson.NewObjectId().Hex(),
+	}
+	b, err := json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+
+	billingService := &billMock.BillingService{}
+	billingService.On("ChangeCodeInOrder", mock2.Anything, mock2.Anything).Return(&grpc.ChangeCodeInOrderResponse{
+		Status:  400,
+		Message: &grpc.ResponseErrorMessage{Message: "Some error"},
+	}, nil)
+
+	suite.router.dispatch.Services.Billing = billingService
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":order_id", bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + orderReplaceCodePath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	shouldBe.NotNil(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.EqualValues(http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *OrderTestSuite) TestOrder_ChangePlatformPayment_InternalError() {
+	shouldBe := require.New(suite.T())
+	billingService := &billMock.BillingService{}
+	billingService.On("PaymentFormPlatformChanged", mock2.Anything, mock2.Anything).Return(nil, errors.New("error"))
+	suite.router.dispatch.Services.Billing = billingService
+
+	changeOrderRequest := &grpc.PaymentFormUserChangePlatformRequest{
+		Platform: "gog",
+	}
+	b, err := json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":order_id", uuid.New().String()).
+		Path(common.AuthProjectGroupPath + orderPlatformPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	shouldBe.EqualValuesf(500, err.(*echo.HTTPError).Code, "%v", err.Error())
+}
+
+func (suite *OrderTestSuite) TestOrder_ChangePlatformPayment_Error() {
+	shouldBe := require.New(suite.T())
+	billingService := &billMock.BillingService{}
+	billingService.On("PaymentFormPlatformChanged", mock2.Anything, mock2.Anything).Return(&grpc.EmptyResponseWithStatus{
+		Status:  400,
+		Message: &grpc.ResponseErrorMessage{Message: "Some error"},
+	}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	changeOrderRequest := &grpc.PaymentFormUserChangePlatformRequest{
+		Platform: "gog",
+	}
+	b, err := json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":order_id", uuid.New().String()).
+		Path(common.AuthProjectGroupPath + orderPlatformPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	shouldBe.EqualValues(400, err.(*echo.HTTPError).Code)
+}
+
+func (suite *OrderTestSuite) TestOrder_ChangePlatformPayment_Ok() {
+	shouldBe := require.New(suite.T())
+	billingService := &billMock.BillingService{}
+	billingService.On("PaymentFormPlatformChanged", mock2.Anything, mock2.Anything).Return(&grpc.EmptyResponseWithStatus{
+		Status: 200,
+	}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	changeOrderRequest := &grpc.PaymentFormUserChangePlatformRequest{
+		Platform: "gog",
+	}
+	b, err := json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":order_id", uuid.New().String()).
+		Path(common.AuthProjectGroupPath + orderPlatformPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	shouldBe.NoError(err)
+}
+
+func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_ValidationError() {
+	shouldBe := require.New(suite.T())
+
+	// Missing key product id
+	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
+		KeyProductId: "",
+	}
+	b, err := json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":order_id", bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + orderReplaceCodePath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
 		Exec(suite.T())
 
 	shouldBe.NotNil(err)
 	httpErr, ok := err.(*echo.HTTPError)
 	shouldBe.True(ok)
-	shouldBe.EqualValues(http.StatusInternalServerError, httpErr.Code)
+	shouldBe.EqualValues(http.StatusBadRequest, httpErr.Code)
+
+	// Wrong order id
+	changeOrderRequest = &grpc.ChangeCodeInOrderRequest{
+		KeyProductId: bson.NewObjectId().Hex(),
+	}
+	b, err = json.Marshal(changeOrderRequest)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *OrderTestSuite) TestOrder_getReceipt_Ok() {
+	bill := &billMock.BillingService{}
+	bill.
+		On("OrderReceipt", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderReceiptResponse{Status: int32(200), Receipt: &billing.OrderReceipt{}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterReceiptId, uuid.New().String(), ":"+common.RequestParameterOrderId, uuid.New().String()).
+		Path(orderReceiptPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotEmpty(suite.T(), res.Body.String())
+	assert.Equal(suite.T(), echo.MIMEApplicationJSONCharsetUTF8, res.Header().Get(echo.HeaderContentType))
+}
+
+func (suite *OrderTestSuite) TestOrder_getReceipt_ParameterOrderIdNotFound_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterReceiptId, uuid.New().String(), ":"+common.RequestParameterOrderId, "invalid").
+		Path(orderReceiptPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *OrderTestSuite) TestOrder_getReceipt_ParameterReceiptIdNotFound_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterReceiptId, "", ":"+common.RequestParameterOrderId, uuid.New().String()).
+		Path(orderReceiptPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *OrderTestSuite) TestOrder_getReceipt_BillingServerSystemError() {
+	bill := &billMock.BillingService{}
+	bill.On("OrderReceipt", mock2.Anything, mock2.Anything, mock2.Anything).Return(nil, errors.New("error"))
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterReceiptId, uuid.New().String(), ":"+common.RequestParameterOrderId, uuid.New().String()).
+		Path(orderReceiptPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_TokenizationMode_RawCardData_Error() {
+	suite.router.cfg.CardTokenizationMode = true
+	body := `{"order_id": "` + uuid.New().String() + `", "pan": "4000000000000002", "cvv": "123"}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageRawCardDataNotAllowed, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_TokenizationMode_CardTokenRequired_Error() {
+	methodId := bson.NewObjectId().Hex()
+	suite.router.cfg.CardTokenizationMode = true
+	suite.router.cfg.CardTokenizationPaymentMethods = []string{methodId}
+	body := `{"order_id": "` + uuid.New().String() + `", "payment_method_id": "` + methodId + `"}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardTokenRequired, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_TokenizationMode_Ok() {
+	methodId := bson.NewObjectId().Hex()
+	suite.router.cfg.CardTokenizationMode = true
+	suite.router.cfg.CardTokenizationPaymentMethods = []string{methodId}
+
+	bill := &billMock.BillingService{}
+	bill.On("PaymentCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.PaymentCreateResponse{Status: pkg.ResponseStatusOk}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	body := `{"order_id": "` + uuid.New().String() + `", "payment_method_id": "` + methodId + `", "card_token": "tok_123"}`
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
 }
 
-func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_ErrorInService() {
-	shouldBe := require.New(suite.T())
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_TokenizationMode_VaultCardTokenRequired_Error() {
+	methodId := bson.NewObjectId().Hex()
+	suite.router.cfg.CardTokenizationMode = true
+	suite.router.cfg.CardTokenizationVaults = map[string]string{methodId: "cardpay"}
+	body := `{"order_id": "` + uuid.New().String() + `", "payment_method_id": "` + methodId + `"}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardTokenRequired, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_EmailScreeningBlock_Disposable_Error() {
+	suite.router.cfg.PaymentEmailScreeningMode = common.EmailScreeningModeBlock
+	suite.router.cfg.PaymentEmailDisposableDomains = []string{"mailinator.com"}
+
+	bill := suite.getEmailScreeningBillingMock(bson.NewObjectId().Hex())
+	suite.router.dispatch.Services.Billing = bill
+
+	body := `{"order_id": "` + uuid.New().String() + `", "email": "payer@eu.mailinator.com"}`
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessagePaymentEmailRejected.Code, msg.Code)
+	assert.Equal(suite.T(), common.EmailVerdictDisposable, msg.Details)
+	bill.AssertNotCalled(suite.T(), "PaymentCreateProcess", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_EmailScreeningBlock_InvalidSyntax_Error() {
+	suite.router.cfg.PaymentEmailScreeningMode = common.EmailScreeningModeBlock
+
+	body := `{"order_id": "` + uuid.New().String() + `", "email": "payer@localhost"}`
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.EmailVerdictInvalidSyntax, msg.Details)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_EmailScreeningFlag_Ok() {
+	suite.router.cfg.PaymentEmailScreeningMode = common.EmailScreeningModeFlag
+	suite.router.cfg.PaymentEmailDisposableDomains = []string{"mailinator.com"}
+
+	bill := suite.getEmailScreeningBillingMock(bson.NewObjectId().Hex())
+	suite.router.dispatch.Services.Billing = bill
+
+	orderId := uuid.New().String()
+	body := `{"order_id": "` + orderId + `", "email": "payer@mailinator.com"}`
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertCalled(suite.T(), "PaymentCreateProcess", mock2.Anything, mock2.Anything)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterOrderId, orderId).
+		Path(common.AuthUserGroupPath + orderEmailScreeningPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	verdict := &common.EmailScreeningVerdict{}
+	err = json.Unmarshal(res.Body.Bytes(), verdict)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), orderId, verdict.OrderId)
+	assert.Equal(suite.T(), common.EmailVerdictDisposable, verdict.Verdict)
+	assert.Equal(suite.T(), common.EmailScreeningModeFlag, verdict.Mode)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_EmailScreeningProjectPolicy_Error() {
+	suite.router.cfg.PaymentEmailScreeningMode = ""
+	suite.router.cfg.PaymentEmailDisposableDomains = []string{"mailinator.com"}
+
+	projectId := bson.NewObjectId().Hex()
+	bill := suite.getEmailScreeningBillingMock(projectId)
+	suite.router.dispatch.Services.Billing = bill
+
+	policy := &common.EmailScreeningPolicy{Mode: common.EmailScreeningModeBlock}
+	err := common.NewEmailScreenings(test.Redis(), time.Hour).SetPolicy(projectId, policy)
+	assert.NoError(suite.T(), err)
+
+	body := `{"order_id": "` + uuid.New().String() + `", "email": "payer@mailinator.com"}`
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	bill.AssertNotCalled(suite.T(), "PaymentCreateProcess", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_EmailScreeningPoliciesLost_Error() {
+	suite.router.cfg.PaymentEmailScreeningMode = common.EmailScreeningModeOff
+	suite.router.cfg.PaymentEmailDisposableDomains = []string{"mailinator.com"}
+	test.RedisServer().FlushAll()
+
+	bill := suite.getEmailScreeningBillingMock(bson.NewObjectId().Hex())
+	suite.router.dispatch.Services.Billing = bill
+
+	body := `{"order_id": "` + uuid.New().String() + `", "email": "payer@mailinator.com"}`
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessagePaymentEmailRejected.Code, httpErr.Message.(*grpc.ResponseErrorMessage).Code)
+	bill.AssertNotCalled(suite.T(), "PaymentCreateProcess", mock2.Anything, mock2.Anything)
+}
+
+func (suite *OrderTestSuite) TestOrder_ProcessCreatePayment_EmailScreeningMxLookup() {
+	suite.router.cfg.PaymentEmailScreeningMode = common.EmailScreeningModeBlock
+	suite.router.cfg.PaymentEmailScreeningMxLookup = true
+	suite.router.emailResolver = &emailResolverMock{
+		mx: map[string][]*net.MX{
+			"mx.unit.test":     {{Host: "mail.unit.test", Pref: 10}},
+			"nullmx.unit.test": {{Host: ".", Pref: 0}},
+		},
+		hosts: map[string][]string{"implicit.unit.test": {"127.0.0.1"}},
+	}
+	suite.router.dispatch.Services.Billing = suite.getEmailScreeningBillingMock(bson.NewObjectId().Hex())
+
+	cases := map[string]string{
+		"payer@mx.unit.test":       "",
+		"payer@implicit.unit.test": "",
+		"payer@nullmx.unit.test":   common.EmailVerdictNoMx,
+		"payer@missing.unit.test":  common.EmailVerdictNoMx,
+		"payer@timeout.unit.test":  "",
+	}
+
+	for email, verdict := range cases {
+		body := `{"order_id": "` + uuid.New().String() + `", "email": "` + email + `"}`
+		res, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Path(common.AuthProjectGroupPath + paymentPath).
+			Init(test.ReqInitJSON()).
+			BodyString(body).
+			Exec(suite.T())
+
+		if verdict == "" {
+			assert.NoError(suite.T(), err, email)
+			assert.Equal(suite.T(), http.StatusOK, res.Code, email)
+			continue
+		}
+
+		assert.Error(suite.T(), err, email)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok, email)
+		assert.Equal(suite.T(), verdict, httpErr.Message.(*grpc.ResponseErrorMessage).Details, email)
+	}
+}
+
+func (suite *OrderTestSuite) TestOrder_GetEmailScreeningVerdict_NotScreened_Error() {
+	suite.router.dispatch.Services.Billing = suite.getEmailScreeningBillingMock(bson.NewObjectId().Hex())
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterOrderId, uuid.New().String()).
+		Path(common.AuthUserGroupPath + orderEmailScreeningPath).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessagePaymentEmailVerdictNotFound, httpErr.Message)
+}
+
+func (suite *OrderTestSuite) getEmailScreeningBillingMock(projectId string) *billMock.BillingService {
+	merchantId := bson.NewObjectId().Hex()
+	bill := &billMock.BillingService{}
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.OrderViewPublic{Project: &billing.ProjectOrder{Id: projectId}},
+		}, nil)
+	bill.On("PaymentCreateProcess", mock2.Anything, mock2.Anything).
+		Return(&grpc.PaymentCreateResponse{Status: pkg.ResponseStatusOk}, nil)
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: &billing.Merchant{Id: merchantId}}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, MerchantId: merchantId},
+		}, nil)
+
+	return bill
+}
+
+// emailResolverMock resolves domains of the maps, timeout.unit.test times out and other domains don't exist
+type emailResolverMock struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+}
+
+func (r *emailResolverMock) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if name == "timeout.unit.test" {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+	}
+
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
 
-	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
-		KeyProductId: bson.NewObjectId().Hex(),
+	return nil, &net.DNSError{Err: "no such host", Name: name}
+}
+
+func (r *emailResolverMock) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
 	}
-	b, err := json.Marshal(changeOrderRequest)
-	assert.NoError(suite.T(), err)
 
-	billingService := &billMock.BillingService{}
-	billingService.On("ChangeCodeInOrder", mock2.Anything, mock2.Anything).Return(&grpc.ChangeCodeInOrderResponse{
-		Status:  400,
-		Message: &grpc.ResponseErrorMessage{Message: "Some error"},
-	}, nil)
+	return nil, &net.DNSError{Err: "no such host", Name: host}
+}
 
-	suite.router.dispatch.Services.Billing = billingService
+func (suite *OrderTestSuite) TestOrder_getReceiptPublic_Ok() {
+	bill := &billMock.BillingService{}
+	bill.
+		On("OrderReceipt", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderReceiptResponse{Status: int32(200), Receipt: &billing.OrderReceipt{}}, nil)
+	suite.router.dispatch.Services.Billing = bill
 
-	_, err = suite.caller.Builder().
-		Method(http.MethodPut).
-		Params(":order_id", bson.NewObjectId().Hex()).
-		Path(common.AuthUserGroupPath + orderReplaceCodePath).
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterReceiptId, uuid.New().String()).
+		Path(common.AuthProjectGroupPath + receiptPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
 		Exec(suite.T())
 
-	shouldBe.NotNil(err)
-	httpErr, ok := err.(*echo.HTTPError)
-	shouldBe.True(ok)
-	shouldBe.EqualValues(http.StatusBadRequest, httpErr.Code)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), "no-store", res.Header().Get("Cache-Control"))
 }
 
-func (suite *OrderTestSuite) TestOrder_ChangePlatformPayment_InternalError() {
-	shouldBe := require.New(suite.T())
-	billingService := &billMock.BillingService{}
-	billingService.On("PaymentFormPlatformChanged", mock2.Anything, mock2.Anything).Return(nil, errors.New("error"))
-	suite.router.dispatch.Services.Billing = billingService
+func (suite *OrderTestSuite) TestOrder_getReceiptPublic_PayerDataNotLeaked_Ok() {
+	orderId := uuid.New().String()
+	bill := &billMock.BillingService{}
+	bill.
+		On("OrderReceipt", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderReceiptResponse{
+			Status: int32(200),
+			Receipt: &billing.OrderReceipt{
+				TransactionId: orderId,
+				ProjectName:   "Unit test",
+				TotalPrice:    "$9.99",
+				Items:         []*billing.OrderReceiptItem{{Name: "Sword", Price: "$9.99"}},
+			},
+		}, nil)
+	bill.
+		On("GetOrderPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.GetOrderRequest) bool {
+			return req.Id == orderId
+		})).
+		Return(&grpc.GetOrderPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &billing.OrderViewPublic{
+				Uuid: orderId,
+				User: &billing.OrderUser{Email: "payer@unit.test", Ip: "192.0.2.10", Name: "John Doe"},
+				PaymentMethod: &billing.PaymentMethodOrder{
+					Name: "Bank card",
+					Card: &billing.PaymentMethodCard{Masked: "4000000000000002"},
+				},
+			},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterReceiptId, uuid.New().String()).
+		Path(common.AuthProjectGroupPath + receiptPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
 
-	changeOrderRequest := &grpc.PaymentFormUserChangePlatformRequest{
-		Platform: "gog",
-	}
-	b, err := json.Marshal(changeOrderRequest)
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.NotContains(suite.T(), res.Body.String(), "payer@unit.test")
+	assert.NotContains(suite.T(), res.Body.String(), "192.0.2.10")
+	assert.NotContains(suite.T(), res.Body.String(), "John Doe")
+	assert.NotContains(suite.T(), res.Body.String(), "4000000000000002")
 
-	_, err = suite.caller.Builder().
-		Method(http.MethodPost).
-		Params(":order_id", uuid.New().String()).
-		Path(common.AuthProjectGroupPath + orderPlatformPath).
+	receipt := &orderReceiptPublic{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), receipt))
+	assert.Equal(suite.T(), "Unit test", receipt.ProjectName)
+	assert.Len(suite.T(), receipt.Items, 1)
+	assert.Equal(suite.T(), &orderReceiptPublicPaymentMethod{Name: "Bank card", MaskedPan: "400000******0002"}, receipt.PaymentMethod)
+}
+
+func (suite *OrderTestSuite) TestOrder_getReceiptPublic_ParameterReceiptIdIncorrect_Error() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterReceiptId, "invalid").
+		Path(common.AuthProjectGroupPath + receiptPath).
 		Init(test.ReqInitJSON()).
-		BodyBytes(b).
 		Exec(suite.T())
 
-	shouldBe.Error(err)
-	shouldBe.EqualValuesf(500, err.(*echo.HTTPError).Code, "%v", err.Error())
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
 }
 
-func (suite *OrderTestSuite) TestOrder_ChangePlatformPayment_Error() {
-	shouldBe := require.New(suite.T())
-	billingService := &billMock.BillingService{}
-	billingService.On("PaymentFormPlatformChanged", mock2.Anything, mock2.Anything).Return(&grpc.EmptyResponseWithStatus{
-		Status:  400,
-		Message: &grpc.ResponseErrorMessage{Message: "Some error"},
-	}, nil)
-	suite.router.dispatch.Services.Billing = billingService
+func (suite *OrderTestSuite) TestOrder_getReceiptPublic_TooManyRequests_Error() {
+	bill := &billMock.BillingService{}
+	bill.
+		On("OrderReceipt", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderReceiptResponse{Status: int32(200), Receipt: &billing.OrderReceipt{}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.receiptLimiter = suite.router.rateLimits.Limiter("receipt", 1, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		res, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Params(":"+common.RequestParameterReceiptId, uuid.New().String()).
+			Path(common.AuthProjectGroupPath + receiptPath).
+			Init(test.ReqInitJSON()).
+			Exec(suite.T())
+
+		if i == 0 {
+			assert.NoError(suite.T(), err)
+			assert.Equal(suite.T(), http.StatusOK, res.Code)
+			continue
+		}
 
-	changeOrderRequest := &grpc.PaymentFormUserChangePlatformRequest{
-		Platform: "gog",
+		assert.Error(suite.T(), err)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusTooManyRequests, httpErr.Code)
+		assert.Equal(suite.T(), common.ErrorMessageTooManyRequests, httpErr.Message)
 	}
-	b, err := json.Marshal(changeOrderRequest)
-	assert.NoError(suite.T(), err)
+}
 
-	_, err = suite.caller.Builder().
-		Method(http.MethodPost).
-		Params(":order_id", uuid.New().String()).
-		Path(common.AuthProjectGroupPath + orderPlatformPath).
-		Init(test.ReqInitJSON()).
-		BodyBytes(b).
-		Exec(suite.T())
+func (suite *OrderTestSuite) TestOrder_getReceiptPublic_ForwardedForSpoofed_TooManyRequests_Error() {
+	bill := &billMock.BillingService{}
+	bill.
+		On("OrderReceipt", mock2.Anything, mock2.Anything).
+		Return(&grpc.OrderReceiptResponse{Status: int32(200), Receipt: &billing.OrderReceipt{}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.receiptLimiter = suite.router.rateLimits.Limiter("receipt", 1, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Params(":"+common.RequestParameterReceiptId, uuid.New().String()).
+			Path(common.AuthProjectGroupPath + receiptPath).
+			Init(func(request *http.Request, middleware test.Middleware) {
+				request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				request.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("10.0.0.%d", i+1))
+				request.Header.Set(echo.HeaderXRealIP, fmt.Sprintf("10.0.1.%d", i+1))
+			}).
+			Exec(suite.T())
+
+		if i == 0 {
+			assert.NoError(suite.T(), err)
+			continue
+		}
 
-	shouldBe.Error(err)
-	shouldBe.EqualValues(400, err.(*echo.HTTPError).Code)
+		assert.Error(suite.T(), err)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusTooManyRequests, httpErr.Code)
+	}
+
+	bill.AssertNumberOfCalls(suite.T(), "OrderReceipt", 1)
 }
 
-func (suite *OrderTestSuite) TestOrder_ChangePlatformPayment_Ok() {
-	shouldBe := require.New(suite.T())
-	billingService := &billMock.BillingService{}
-	billingService.On("PaymentFormPlatformChanged", mock2.Anything, mock2.Anything).Return(&grpc.EmptyResponseWithStatus{
-		Status: 200,
-	}, nil)
-	suite.router.dispatch.Services.Billing = billingService
+func getExportOrderId(i int32) string {
+	return fmt.Sprintf("%08d-0000-4000-8000-000000000000", i)
+}
 
-	changeOrderRequest := &grpc.PaymentFormUserChangePlatformRequest{
-		Platform: "gog",
+func (suite *OrderTestSuite) getExportBillingMock(count int32) *billMock.BillingService {
+	bs := &billMock.BillingService{}
+	bs.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+
+	var orders []*billing.OrderViewPublic
+
+	// two orders are created in each second
+	for i := int32(0); i < count; i++ {
+		orders = append(orders, &billing.OrderViewPublic{
+			Uuid:       getExportOrderId(i),
+			Status:     riskOrderStatusProcessed,
+			NetRevenue: &billing.OrderViewMoney{Amount: 10, Currency: "USD"},
+			CreatedAt:  &timestamp.Timestamp{Seconds: int64(100 + i/2)},
+		})
 	}
-	b, err := json.Marshal(changeOrderRequest)
+
+	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(func(_ context.Context, req *grpc.ListOrdersRequest, _ ...client.CallOption) *grpc.ListOrdersPublicResponse {
+			var found []*billing.OrderViewPublic
+
+			for _, order := range orders {
+				if order.CreatedAt.Seconds >= req.ProjectDateFrom {
+					found = append(found, order)
+				}
+			}
+
+			item := &grpc.ListOrdersPublicResponseItem{Count: int32(len(found))}
+
+			for i := req.Offset; i < int32(len(found)) && i < req.Offset+req.Limit; i++ {
+				item.Items = append(item.Items, found[i])
+			}
+
+			return &grpc.ListOrdersPublicResponse{Status: pkg.ResponseStatusOk, Item: item}
+		}, nil)
+
+	suite.router.dispatch.Services.Billing = bs
+
+	return bs
+}
+
+func (suite *OrderTestSuite) TestOrder_ExportOrders_Csv_Ok() {
+	suite.router.cfg.LimitMax = 2
+	bs := suite.getExportBillingMock(3)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + orderExportPath).
+		Exec(suite.T())
+
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), common.MIMETextCsv, res.Header().Get(echo.HeaderContentType))
+	assert.Equal(suite.T(), "3", res.Header().Get(headerTotalCount))
+	assert.Equal(suite.T(), orderExportStatusComplete, res.Result().Trailer.Get(headerExportStatus))
+	assert.True(suite.T(), res.Flushed)
 
-	_, err = suite.caller.Builder().
-		Method(http.MethodPost).
-		Params(":order_id", uuid.New().String()).
-		Path(common.AuthProjectGroupPath + orderPlatformPath).
-		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+	rows := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	assert.Len(suite.T(), rows, 4)
+	assert.Equal(suite.T(), "id,status,project_id,transaction_date,amount,currency,user_id", rows[0])
+	assert.Equal(suite.T(), getExportOrderId(2)+",processed,,,10.00,USD,", rows[3])
+	bs.AssertNumberOfCalls(suite.T(), "FindAllOrdersPublic", 2)
+	bs.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.ProjectDateFrom == 100 && req.Offset == 2
+	}))
+}
+
+func (suite *OrderTestSuite) TestOrder_ExportOrders_Json_Ok() {
+	suite.router.cfg.LimitMax = 2
+	suite.getExportBillingMock(3)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+orderExportPath).
+		SetQueryParam(orderExportFormatParam, orderExportFormatJson).
 		Exec(suite.T())
 
-	shouldBe.NoError(err)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), mimeApplicationNdjson, res.Header().Get(echo.HeaderContentType))
+
+	lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	assert.Len(suite.T(), lines, 3)
+
+	order := &billing.OrderViewPublic{}
+	assert.NoError(suite.T(), json.Unmarshal([]byte(lines[1]), order))
+	assert.Equal(suite.T(), getExportOrderId(1), order.Uuid)
 }
 
-func (suite *OrderTestSuite) TestOrder_ChangeOrderCode_ValidationError() {
-	shouldBe := require.New(suite.T())
+func (suite *OrderTestSuite) TestOrder_ExportOrders_SameSecondPages_Ok() {
+	suite.router.cfg.LimitMax = 1
+	bs := suite.getExportBillingMock(4)
 
-	// Missing key product id
-	changeOrderRequest := &grpc.ChangeCodeInOrderRequest{
-		KeyProductId: "",
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + orderExportPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+
+	rows := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	assert.Len(suite.T(), rows, 5)
+
+	for i, row := range rows[1:] {
+		assert.True(suite.T(), strings.HasPrefix(row, getExportOrderId(int32(i))+","))
 	}
-	b, err := json.Marshal(changeOrderRequest)
+
+	bs.AssertNumberOfCalls(suite.T(), "FindAllOrdersPublic", 5)
+}
+
+func (suite *OrderTestSuite) setExportCursorOrder(bs *billMock.BillingService, orderId string, createdAt int64) {
+	projectId := bson.NewObjectId().Hex()
+	bs.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &billing.OrderViewPublic{
+				Uuid:      orderId,
+				Project:   &billing.ProjectOrder{Id: projectId},
+				CreatedAt: &timestamp.Timestamp{Seconds: createdAt},
+			},
+		}, nil)
+	bs.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: projectId, MerchantId: mock.OnboardingMerchantMock.Id},
+		}, nil)
+}
+
+func (suite *OrderTestSuite) TestOrder_ExportOrders_Resume_Ok() {
+	suite.router.cfg.LimitMax = 2
+	bs := suite.getExportBillingMock(5)
+	suite.setExportCursorOrder(bs, getExportOrderId(2), 101)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+orderExportPath).
+		SetQueryParam(orderExportAfterParam, getExportOrderId(2)).
+		Exec(suite.T())
+
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), "5", res.Header().Get(headerTotalCount))
+	assert.Equal(suite.T(), orderExportStatusComplete, res.Result().Trailer.Get(headerExportStatus))
 
-	_, err = suite.caller.Builder().
-		Method(http.MethodPut).
-		Params(":order_id", bson.NewObjectId().Hex()).
-		Path(common.AuthUserGroupPath + orderReplaceCodePath).
-		Init(test.ReqInitJSON()).
-		BodyBytes(b).
+	rows := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	assert.Len(suite.T(), rows, 2)
+	assert.True(suite.T(), strings.HasPrefix(rows[0], getExportOrderId(3)+","))
+	assert.True(suite.T(), strings.HasPrefix(rows[1], getExportOrderId(4)+","))
+}
+
+func (suite *OrderTestSuite) TestOrder_ExportOrders_ResumeCursorOrderNotListed_Ok() {
+	suite.router.cfg.LimitMax = 2
+	bs := suite.getExportBillingMock(5)
+	orderId := uuid.New().String()
+	suite.setExportCursorOrder(bs, orderId, 101)
+
+	// status of the cursor order changed, orders of its second are received by the client
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+orderExportPath).
+		SetQueryParam(orderExportAfterParam, orderId).
 		Exec(suite.T())
 
-	shouldBe.NotNil(err)
-	httpErr, ok := err.(*echo.HTTPError)
-	shouldBe.True(ok)
-	shouldBe.EqualValues(http.StatusBadRequest, httpErr.Code)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), getExportOrderId(4)+",processed,,,10.00,USD,\n", res.Body.String())
+}
 
-	// Wrong order id
-	changeOrderRequest = &grpc.ChangeCodeInOrderRequest{
-		KeyProductId: bson.NewObjectId().Hex(),
+func (suite *OrderTestSuite) TestOrder_ExportOrders_CursorInvalid_Error() {
+	suite.router.cfg.LimitMax = 2
+	bs := suite.getExportBillingMock(3)
+	bs.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{Status: pkg.ResponseStatusNotFound, Message: &grpc.ResponseErrorMessage{}}, nil)
+
+	for _, value := range []string{"order-1", uuid.New().String()} {
+		_, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Path(common.AuthUserGroupPath+orderExportPath).
+			SetQueryParam(orderExportAfterParam, value).
+			Exec(suite.T())
+
+		assert.Error(suite.T(), err)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
 	}
-	b, err = json.Marshal(changeOrderRequest)
-	assert.NoError(suite.T(), err)
+
+	bs.AssertNumberOfCalls(suite.T(), "GetOrderPublic", 1)
+	bs.AssertNotCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.Anything)
 }
 
-func (suite *OrderTestSuite) TestOrder_getReceipt_Ok() {
-	bill := &billMock.BillingService{}
-	bill.
-		On("OrderReceipt", mock2.Anything, mock2.Anything).
-		Return(&grpc.OrderReceiptResponse{Status: int32(200), Receipt: &billing.OrderReceipt{}}, nil)
-	suite.router.dispatch.Services.Billing = bill
+func (suite *OrderTestSuite) TestOrder_ExportOrders_Interrupted() {
+	suite.router.cfg.LimitMax = 2
+	bs := &billMock.BillingService{}
+	bs.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.ProjectDateFrom == 0
+	})).
+		Return(&grpc.ListOrdersPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item: &grpc.ListOrdersPublicResponseItem{
+				Count: 3,
+				Items: []*billing.OrderViewPublic{
+					{Uuid: getExportOrderId(0), CreatedAt: &timestamp.Timestamp{Seconds: 100}},
+					{Uuid: getExportOrderId(1), CreatedAt: &timestamp.Timestamp{Seconds: 100}},
+				},
+			},
+		}, nil)
+	bs.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).Return(nil, errors.New("some error"))
+	suite.router.dispatch.Services.Billing = bs
 
 	res, err := suite.caller.Builder().
 		Method(http.MethodGet).
-		Params(":"+common.RequestParameterReceiptId, uuid.New().String(), ":"+common.RequestParameterOrderId, uuid.New().String()).
-		Path(orderReceiptPath).
-		Init(test.ReqInitJSON()).
+		Path(common.AuthUserGroupPath + orderExportPath).
 		Exec(suite.T())
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
-	assert.NotEmpty(suite.T(), res.Body.String())
-	assert.Equal(suite.T(), echo.MIMEApplicationJSONCharsetUTF8, res.Header().Get(echo.HeaderContentType))
+	assert.Equal(suite.T(), "3", res.Header().Get(headerTotalCount))
+	assert.Equal(suite.T(), orderExportStatusFailed, res.Result().Trailer.Get(headerExportStatus))
+	assert.Len(suite.T(), strings.Split(strings.TrimSpace(res.Body.String()), "\n"), 3)
 }
 
-func (suite *OrderTestSuite) TestOrder_getReceipt_ParameterOrderIdNotFound_Error() {
+func (suite *OrderTestSuite) TestOrder_ExportOrders_ConcurrencyLimit_Error() {
+	suite.router.cfg.LimitMax = 2
+	bs := suite.getExportBillingMock(3)
+	suite.router.exportSlots = common.NewExportSlots(test.Redis(), 1)
+
+	// running report of the merchant holds its only slot
+	slot, ok := suite.router.exportSlots.Acquire(mock.OnboardingMerchantMock.Id, time.Minute)
+	assert.True(suite.T(), ok)
+
 	_, err := suite.caller.Builder().
 		Method(http.MethodGet).
-		Params(":"+common.RequestParameterReceiptId, uuid.New().String(), ":"+common.RequestParameterOrderId, "invalid").
-		Path(orderReceiptPath).
-		Init(test.ReqInitJSON()).
+		Path(common.AuthUserGroupPath + orderExportPath).
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
 
 	httpErr, ok := err.(*echo.HTTPError)
 	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, httpErr.Code)
+	bs.AssertNotCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.Anything)
+
+	// slot of finished export is released
+	suite.router.exportSlots.Release(mock.OnboardingMerchantMock.Id, slot)
+
+	for i := 0; i < 2; i++ {
+		res, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Path(common.AuthUserGroupPath + orderExportPath).
+			Exec(suite.T())
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusOK, res.Code)
+	}
 }
 
-func (suite *OrderTestSuite) TestOrder_getReceipt_ParameterReceiptIdNotFound_Error() {
+func (suite *OrderTestSuite) TestOrder_ExportOrders_FormatInvalid_Error() {
 	_, err := suite.caller.Builder().
 		Method(http.MethodGet).
-		Params(":"+common.RequestParameterReceiptId, "", ":"+common.RequestParameterOrderId, uuid.New().String()).
-		Path(orderReceiptPath).
-		Init(test.ReqInitJSON()).
+		Path(common.AuthUserGroupPath+orderExportPath).
+		SetQueryParam(orderExportFormatParam, "xml").
 		Exec(suite.T())
 
 	assert.Error(suite.T(), err)
@@ -1485,22 +3766,24 @@ func (suite *OrderTestSuite) TestOrder_getReceipt_ParameterReceiptIdNotFound_Err
 	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
 }
 
-func (suite *OrderTestSuite) TestOrder_getReceipt_BillingServerSystemError() {
-	bill := &billMock.BillingService{}
-	bill.On("OrderReceipt", mock2.Anything, mock2.Anything, mock2.Anything).Return(nil, errors.New("error"))
-	suite.router.dispatch.Services.Billing = bill
+func BenchmarkOrderJsonBinder_Bind(b *testing.B) {
+	body := []byte(`{"project_id": "5bdc39a95d1e1100019fb7df", "amount": 10.5, "currency": "USD",
+		"account": "unit_test_account", "description": "benchmark order", "signature": "signature",
+		"user": {"external_id": "user", "email": "test@unit.test", "locale": "en", "ip": "127.0.0.1"},
+		"utm_source": "newsletter", "metadata": {"field1": "val1"}}`)
+	e := echo.New()
 
-	_, err := suite.caller.Builder().
-		Method(http.MethodGet).
-		Params(":"+common.RequestParameterReceiptId, uuid.New().String(), ":"+common.RequestParameterOrderId, uuid.New().String()).
-		Path(orderReceiptPath).
-		Init(test.ReqInitJSON()).
-		Exec(suite.T())
+	b.ReportAllocs()
+	b.ResetTimer()
 
-	assert.Error(suite.T(), err)
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, common.AuthProjectGroupPath+orderPath, bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		ctx := e.NewContext(req, httptest.NewRecorder())
+		common.SetRawBodyContext(ctx, body)
 
-	httpErr, ok := err.(*echo.HTTPError)
-	assert.True(suite.T(), ok)
-	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
-	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+		if err := (&common.OrderJsonBinder{Extra: &orderJsonExtra{}}).Bind(&billing.OrderCreateRequest{}, ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
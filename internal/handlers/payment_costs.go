@@ -9,6 +9,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"net/http"
+	"time"
 )
 
 type PaymentCostRoute struct {
@@ -32,39 +33,47 @@ const (
 	paymentCostsChannelMerchantPath      = "/payment_costs/channel/merchant/:id"
 	paymentCostsChannelMerchantAllPath   = "/payment_costs/channel/merchant/:id/all"
 	paymentCostsChannelSystemIdPath      = "/payment_costs/channel/system/:id"
-	paymentCostsChannelMerchantIdsPath   = "/payment_costs/channel/merchant/:merchant_id/:rate_id"
+	paymentCostsChannelMerchantIdsPath   = "/payment_costs/channel/merchant/:id/:rate_id"
 	paymentCostsMoneyBackAllPath         = "/payment_costs/money_back/system/all"
 	paymentCostsMoneyBackMerchantPath    = "/payment_costs/money_back/merchant/:id"
 	paymentCostsMoneyBackMerchantAllPath = "/payment_costs/money_back/merchant/:id/all"
+	paymentCostsMoneyBackMerchantIdsPath = "/payment_costs/money_back/merchant/:id/:rate_id"
 	paymentCostsMoneyBackSystemPath      = "/payment_costs/money_back/system"
 	paymentCostsMoneyBackSystemIdPath    = "/payment_costs/money_back/system/:id"
 )
 
 func (h *PaymentCostRoute) Route(groups *common.Groups) {
-	groups.AuthUser.GET(paymentCostsChannelSystemAllPath, h.getAllPaymentChannelCostSystem)
-	groups.AuthUser.GET(paymentCostsChannelMerchantAllPath, h.getAllPaymentChannelCostMerchant) //надо править
-	groups.AuthUser.GET(paymentCostsMoneyBackAllPath, h.getAllMoneyBackCostSystem)
-	groups.AuthUser.GET(paymentCostsMoneyBackMerchantAllPath, h.getAllMoneyBackCostMerchant) //надо править
+	matrix := groups.RouteCache.Cache(common.RouteCachePolicy{
+		Key:           "{param.id}",
+		TTL:           time.Hour,
+		InvalidatedBy: []string{common.RouteCacheEventPaymentCosts},
+	})
+	invalidate := groups.RouteCache.Invalidate(common.RouteCacheEventPaymentCosts)
+
+	groups.AuthUser.GET(paymentCostsChannelSystemAllPath, h.getAllPaymentChannelCostSystem, matrix)
+	groups.AuthUser.GET(paymentCostsChannelMerchantAllPath, h.getAllPaymentChannelCostMerchant, matrix) //надо править
+	groups.AuthUser.GET(paymentCostsMoneyBackAllPath, h.getAllMoneyBackCostSystem, matrix)
+	groups.AuthUser.GET(paymentCostsMoneyBackMerchantAllPath, h.getAllMoneyBackCostMerchant, matrix) //надо править
 
 	groups.AuthUser.GET(paymentCostsChannelSystemPath, h.getPaymentChannelCostSystem)
 	groups.AuthUser.GET(paymentCostsChannelMerchantPath, h.getPaymentChannelCostMerchant)
 	groups.AuthUser.GET(paymentCostsMoneyBackSystemPath, h.getMoneyBackCostSystem)
 	groups.AuthUser.GET(paymentCostsMoneyBackMerchantPath, h.getMoneyBackCostMerchant)
 
-	groups.AuthUser.DELETE(paymentCostsChannelSystemIdPath, h.deletePaymentChannelCostSystem)
-	groups.AuthUser.DELETE(paymentCostsChannelMerchantPath, h.deletePaymentChannelCostMerchant)
-	groups.AuthUser.DELETE(paymentCostsMoneyBackSystemIdPath, h.deleteMoneyBackCostSystem)
-	groups.AuthUser.DELETE(paymentCostsMoneyBackMerchantPath, h.deleteMoneyBackCostMerchant)
+	groups.AuthUser.DELETE(paymentCostsChannelSystemIdPath, h.deletePaymentChannelCostSystem, invalidate)
+	groups.AuthUser.DELETE(paymentCostsChannelMerchantPath, h.deletePaymentChannelCostMerchant, invalidate)
+	groups.AuthUser.DELETE(paymentCostsMoneyBackSystemIdPath, h.deleteMoneyBackCostSystem, invalidate)
+	groups.AuthUser.DELETE(paymentCostsMoneyBackMerchantPath, h.deleteMoneyBackCostMerchant, invalidate)
 
-	groups.AuthUser.POST(paymentCostsChannelSystemPath, h.setPaymentChannelCostSystem)
-	groups.AuthUser.POST(paymentCostsChannelMerchantPath, h.setPaymentChannelCostMerchant)
-	groups.AuthUser.POST(paymentCostsMoneyBackSystemPath, h.setMoneyBackCostSystem)
-	groups.AuthUser.POST(paymentCostsMoneyBackMerchantPath, h.setMoneyBackCostMerchant)
+	groups.AuthUser.POST(paymentCostsChannelSystemPath, h.setPaymentChannelCostSystem, invalidate)
+	groups.AuthUser.POST(paymentCostsChannelMerchantPath, h.setPaymentChannelCostMerchant, invalidate)
+	groups.AuthUser.POST(paymentCostsMoneyBackSystemPath, h.setMoneyBackCostSystem, invalidate)
+	groups.AuthUser.POST(paymentCostsMoneyBackMerchantPath, h.setMoneyBackCostMerchant, invalidate)
 
-	groups.AuthUser.PUT(paymentCostsChannelSystemIdPath, h.setPaymentChannelCostSystem)
-	groups.AuthUser.PUT(paymentCostsChannelMerchantIdsPath, h.setPaymentChannelCostMerchant)
-	groups.AuthUser.PUT(paymentCostsMoneyBackSystemIdPath, h.setMoneyBackCostSystem)
-	groups.AuthUser.PUT(paymentCostsChannelMerchantIdsPath, h.setMoneyBackCostMerchant)
+	groups.AuthUser.PUT(paymentCostsChannelSystemIdPath, h.setPaymentChannelCostSystem, invalidate)
+	groups.AuthUser.PUT(paymentCostsChannelMerchantIdsPath, h.setPaymentChannelCostMerchant, invalidate)
+	groups.AuthUser.PUT(paymentCostsMoneyBackSystemIdPath, h.setMoneyBackCostSystem, invalidate)
+	groups.AuthUser.PUT(paymentCostsMoneyBackMerchantIdsPath, h.setMoneyBackCostMerchant, invalidate)
 }
 
 // @Description Get system costs for payments operations
@@ -8,6 +8,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"net/http"
+	"time"
 )
 
 const (
@@ -33,16 +34,23 @@ func NewPaymentMethodApiV1(set common.HandlerSet, cfg *common.Config) *PaymentMe
 }
 
 func (h *PaymentMethodApiV1) Route(groups *common.Groups) {
-	groups.AuthProject.POST(paymentMethodPath, h.create)
-	groups.AuthProject.PUT(paymentMethodIdPath, h.update)
-	groups.AuthProject.POST(paymentMethodProductionPath, h.createProductionSettings)
-	groups.AuthProject.PUT(paymentMethodProductionPath, h.updateProductionSettings)
-	groups.AuthProject.GET(paymentMethodProductionPath, h.getProductionSettings)
-	groups.AuthProject.DELETE(paymentMethodProductionPath, h.deleteProductionSettings)
-	groups.AuthProject.POST(paymentMethodTestPath, h.createTestSettings)
-	groups.AuthProject.PUT(paymentMethodTestPath, h.updateTestSettings)
-	groups.AuthProject.GET(paymentMethodTestPath, h.getTestSettings)
-	groups.AuthProject.DELETE(paymentMethodTestPath, h.deleteTestSettings)
+	matrix := groups.RouteCache.Cache(common.RouteCachePolicy{
+		Key:           "{param.id}|{query}",
+		TTL:           time.Hour,
+		InvalidatedBy: []string{common.RouteCacheEventPaymentMethods},
+	})
+	invalidate := groups.RouteCache.Invalidate(common.RouteCacheEventPaymentMethods)
+
+	groups.AuthProject.POST(paymentMethodPath, h.create, invalidate)
+	groups.AuthProject.PUT(paymentMethodIdPath, h.update, invalidate)
+	groups.AuthProject.POST(paymentMethodProductionPath, h.createProductionSettings, invalidate)
+	groups.AuthProject.PUT(paymentMethodProductionPath, h.updateProductionSettings, invalidate)
+	groups.AuthProject.GET(paymentMethodProductionPath, h.getProductionSettings, matrix)
+	groups.AuthProject.DELETE(paymentMethodProductionPath, h.deleteProductionSettings, invalidate)
+	groups.AuthProject.POST(paymentMethodTestPath, h.createTestSettings, invalidate)
+	groups.AuthProject.PUT(paymentMethodTestPath, h.updateTestSettings, invalidate)
+	groups.AuthProject.GET(paymentMethodTestPath, h.getTestSettings, matrix)
+	groups.AuthProject.DELETE(paymentMethodTestPath, h.deleteTestSettings, invalidate)
 }
 
 // Create new payment method
@@ -240,6 +240,41 @@ func (suite *PaymentMethodTestSuite) TestPaymentMethod_getProductionSettings_Ok(
 	assert.NoError(suite.T(), err)
 }
 
+func (suite *PaymentMethodTestSuite) TestPaymentMethod_getProductionSettings_Cached() {
+	billingService := &billingMocks.BillingService{}
+	billingService.On("GetPaymentMethodProductionSettings", mock2.Anything, mock2.Anything).Return(&grpc.GetPaymentMethodSettingsResponse{}, nil)
+	billingService.On("DeletePaymentMethodProductionSettings", mock2.Anything, mock2.Anything).Return(&grpc.ChangePaymentMethodParamsResponse{}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	get := func() string {
+		res, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Params(":"+common.RequestParameterId, "507f1f77bcf86cd799439011").
+			Path(common.AuthProjectGroupPath + paymentMethodProductionPath).
+			Exec(suite.T())
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+		return res.Header().Get(common.HeaderCache)
+	}
+
+	assert.Equal(suite.T(), common.RouteCacheMiss, get())
+	assert.Equal(suite.T(), common.RouteCacheHit, get())
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodDelete).
+		Params(":"+common.RequestParameterId, "507f1f77bcf86cd799439011").
+		Path(common.AuthProjectGroupPath + paymentMethodProductionPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"currency_a3": "rub"}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), common.RouteCacheMiss, get())
+	billingService.AssertNumberOfCalls(suite.T(), "GetPaymentMethodProductionSettings", 2)
+}
+
 func (suite *PaymentMethodTestSuite) TestPaymentMethod_getTestSettings_BindError_RequiredPaymentMethodId() {
 	data := `{"currency_a3": "rub"}`
 
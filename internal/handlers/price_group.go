@@ -7,6 +7,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"net/http"
+	"time"
 )
 
 const (
@@ -31,9 +32,11 @@ func NewPriceGroupRoute(set common.HandlerSet, cfg *common.Config) *PriceGroup {
 }
 
 func (h *PriceGroup) Route(groups *common.Groups) {
-	groups.AuthProject.GET(priceGroupCountryPath, h.getPriceGroupByCountry)
-	groups.AuthProject.GET(priceGroupCurrenciesPath, h.getCurrencyList)
-	groups.AuthProject.GET(priceGroupRegionPath, h.getCurrencyByRegion)
+	priceGroups := common.RouteCachePolicy{Key: "{query}", TTL: time.Hour, InvalidatedBy: []string{common.RouteCacheEventPriceGroups}}
+
+	groups.AuthProject.GET(priceGroupCountryPath, h.getPriceGroupByCountry, groups.RouteCache.Cache(priceGroups))
+	groups.AuthProject.GET(priceGroupCurrenciesPath, h.getCurrencyList, groups.RouteCache.Cache(priceGroups))
+	groups.AuthProject.GET(priceGroupRegionPath, h.getCurrencyByRegion, groups.RouteCache.Cache(priceGroups))
 }
 
 // Get currency and region by country code
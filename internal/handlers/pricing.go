@@ -7,6 +7,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"net/http"
+	"time"
 )
 
 const (
@@ -33,7 +34,11 @@ func NewPricingRoute(set common.HandlerSet, cfg *common.Config) *Pricing {
 func (h *Pricing) Route(groups *common.Groups) {
 	groups.AuthProject.GET(pricingRecommendedConversionPath, h.getRecommendedByConversion)
 	groups.AuthProject.GET(pricingRecommendedSteamPath, h.getRecommendedBySteam)
-	groups.AuthProject.GET(pricingRecommendedTablePath, h.getRecommendedTable)
+	groups.AuthProject.GET(pricingRecommendedTablePath, h.getRecommendedTable, groups.RouteCache.Cache(common.RouteCachePolicy{
+		Key:           "{query}",
+		TTL:           time.Hour,
+		InvalidatedBy: []string{common.RouteCacheEventPriceTables, common.RouteCacheEventPriceGroups},
+	}))
 }
 
 // Get recommended prices by currency conversion
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
 	"github.com/globalsign/mgo/bson"
@@ -9,6 +10,7 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -16,11 +18,20 @@ const (
 	productsMerchantPath = "/products/merchant/:id"
 	productsIdPath       = "/products/:id"
 	productsPricesPath   = "/products/:id/prices"
+
+	productsDuplicatePath = "/products/:id/duplicate"
+	productsArchivePath   = "/products/:id/archive"
+	productsUnarchivePath = "/products/:id/unarchive"
 )
 
+type productDuplicateRequest struct {
+	Sku string `json:"sku" validate:"required,min=1,max=255"`
+}
+
 type ProductRoute struct {
-	dispatch common.HandlerSet
-	cfg      common.Config
+	dispatch         common.HandlerSet
+	cfg              common.Config
+	archivedProducts *common.ArchivedProducts
 	provider.LMT
 }
 
@@ -34,14 +45,23 @@ func NewProductRoute(set common.HandlerSet, cfg *common.Config) *ProductRoute {
 }
 
 func (h *ProductRoute) Route(groups *common.Groups) {
+	h.archivedProducts = common.NewArchivedProducts(groups.Redis)
+
 	groups.AuthUser.GET(productsPath, h.getProductsList)
-	groups.AuthUser.GET(productsMerchantPath, h.getProductsList)
+	groups.AuthUser.GET(
+		productsMerchantPath,
+		h.getProductsList,
+		groups.Deprecations.Deprecate(merchantIdRoutesSunset, common.AuthUserGroupPath+productsPath),
+	)
 	groups.AuthUser.POST(productsPath, h.createProduct)
 	groups.AuthUser.GET(productsIdPath, h.getProduct)
 	groups.AuthUser.PUT(productsIdPath, h.updateProduct)
 	groups.AuthUser.DELETE(productsIdPath, h.deleteProduct)
 	groups.AuthUser.GET(productsPricesPath, h.getProductPrices)    // TODO: Need test
 	groups.AuthUser.PUT(productsPricesPath, h.updateProductPrices) // TODO: Need test
+	groups.AuthUser.POST(productsDuplicatePath, h.duplicateProduct)
+	groups.AuthUser.POST(productsArchivePath, h.archiveProduct)
+	groups.AuthUser.POST(productsUnarchivePath, h.unarchiveProduct)
 }
 
 // @Description Get list of products for authenticated merchant
@@ -49,12 +69,17 @@ func (h *ProductRoute) Route(groups *common.Groups) {
 func (h *ProductRoute) getProductsList(ctx echo.Context) error {
 	authUser := common.ExtractUserContext(ctx)
 	req := &grpc.ListProductsRequest{}
-	err := (&common.ProductsGetProductsListBinder{
+	binder := &common.ProductsGetProductsListBinder{
 		LimitDefault:  h.cfg.LimitDefault,
 		OffsetDefault: h.cfg.OffsetDefault,
-	}).Bind(req, ctx)
+	}
+	err := binder.Bind(req, ctx)
 
 	if err != nil {
+		if rspErr, ok := err.(*grpc.ResponseErrorMessage); ok {
+			return echo.NewHTTPError(http.StatusBadRequest, rspErr)
+		}
+
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
 	}
 
@@ -83,13 +108,39 @@ func (h *ProductRoute) getProductsList(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
-	res, err := h.dispatch.Services.Billing.ListProducts(reqCtx, req)
+	// token restricted to project scope lists products of one allowed project, so billing server paginates them
+	if req.ProjectId == "" && len(authUser.Projects) == 1 {
+		for projectId := range authUser.Projects {
+			req.ProjectId = projectId
+		}
+	}
+
+	if req.ProjectId == "" && len(authUser.Projects) > 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageProjectScopeFilterRequired)
+	}
+
+	if !authUser.IsProjectAllowed(req.ProjectId) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	var res *grpc.ListProductsResponse
+
+	// listing of ids filter contains known products only, they are fetched by id with their archive mark
+	if len(binder.Ids) > 0 {
+		res, err = h.getProductsByIds(ctx, req, binder.Ids, func(product *grpc.Product) bool {
+			_, archived := common.GetProductArchived(product)
+			return binder.IncludeArchived || !archived
+		})
+	} else {
+		res, err = h.listProducts(ctx, req, binder.IncludeArchived)
+	}
+
 	if err != nil {
-		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProducts", req)
-		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+		return err
 	}
 
-	return ctx.JSON(http.StatusOK, res)
+	meta := &common.EnvelopeMeta{Count: res.Total, Limit: req.Limit, Offset: req.Offset}
+	return common.ListResponse(ctx, res, res.Products, meta)
 }
 
 // @Description Get product for authenticated merchant
@@ -131,6 +182,10 @@ func (h *ProductRoute) getProduct(ctx echo.Context) error {
 		return echo.NewHTTPError(int(res.Status), res.Message)
 	}
 
+	if !authUser.IsProjectAllowed(res.Item.ProjectId) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
 	return ctx.JSON(http.StatusOK, res)
 }
 
@@ -202,6 +257,10 @@ func (h *ProductRoute) createOrUpdateProduct(ctx echo.Context, binder echo.Binde
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
 	}
 
+	if !authUser.IsProjectAllowed(req.ProjectId) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
 	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(ctx.Request().Context(), &grpc.GetMerchantByRequest{UserId: authUser.Id})
 
 	if err != nil || merchant.Item == nil {
@@ -218,6 +277,26 @@ func (h *ProductRoute) createOrUpdateProduct(ctx echo.Context, binder echo.Binde
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
+	// archive mark is reserved, archived product stays disabled until it's unarchived and enabled state of the update
+	// is restored on unarchive
+	delete(req.Metadata, common.ProductMetadataArchived)
+
+	if req.Id != "" {
+		pReq := &grpc.RequestProduct{Id: req.Id, MerchantId: req.MerchantId}
+		current, err := h.dispatch.Services.Billing.GetProduct(ctx.Request().Context(), pReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetProduct", pReq)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+		}
+
+		if current.Status == pkg.ResponseStatusOk && current.Item != nil {
+			if _, archived := common.GetProductArchived(current.Item); archived {
+				common.SetProductArchived(req, true)
+			}
+		}
+	}
+
 	res, err := h.dispatch.Services.Billing.CreateOrUpdateProduct(ctx.Request().Context(), req)
 
 	if err != nil {
@@ -271,3 +350,320 @@ func (h *ProductRoute) updateProductPrices(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, res)
 }
+
+// @Description Create copy of the product with new sku
+// @Example curl -X POST -H "Accept: application/json" -H "Content-Type: application/json" \
+//      -H "Authorization: Bearer %access_token_here%" -d '{"sku": "ru_0_doom_2_copy"}' \
+//      https://api.paysuper.online/admin/api/v1/products/5c99288068add43f74be9c1d/duplicate
+func (h *ProductRoute) duplicateProduct(ctx echo.Context) error {
+	req := &productDuplicateRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	product, err := h.getMerchantProduct(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// deep copy to not share prices, metadata and localizations with the original product
+	b, err := json.Marshal(product)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	copied := &grpc.Product{}
+
+	if err = json.Unmarshal(b, copied); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.WithFields(logger.Fields{"err": err.Error()}))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	copied.Id = ""
+	copied.Sku = req.Sku
+	// copy of archived product isn't archived
+	common.SetProductArchived(copied, false)
+
+	res, err := h.dispatch.Services.Billing.CreateOrUpdateProduct(ctx.Request().Context(), copied)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateOrUpdateProduct", copied)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	return ctx.JSON(http.StatusCreated, res)
+}
+
+// @Description Archive product, archived product is disabled for new orders and hidden from products list
+// @Example POST /admin/api/v1/products/5c99288068add43f74be9c1d/archive
+func (h *ProductRoute) archiveProduct(ctx echo.Context) error {
+	return h.setProductArchived(ctx, true)
+}
+
+// @Description Restore archived product, the product is enabled if it was enabled before archive
+// @Example POST /admin/api/v1/products/5c99288068add43f74be9c1d/unarchive
+func (h *ProductRoute) unarchiveProduct(ctx echo.Context) error {
+	return h.setProductArchived(ctx, false)
+}
+
+func (h *ProductRoute) setProductArchived(ctx echo.Context, archived bool) error {
+	product, err := h.getMerchantProduct(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// archive mark is saved with the product, enabled state before archive is kept with the mark
+	if _, ok := common.GetProductArchived(product); ok != archived {
+		common.SetProductArchived(product, archived)
+
+		if _, err = h.dispatch.Services.Billing.CreateOrUpdateProduct(ctx.Request().Context(), product); err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateOrUpdateProduct", product)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+		}
+	}
+
+	// index is updated after the product, so the request failed on index update is retried
+	if archived {
+		err = h.archivedProducts.Add(product)
+	} else {
+		err = h.archivedProducts.Remove(product.MerchantId, product.Id)
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "product_id", product.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	return ctx.JSON(http.StatusOK, product)
+}
+
+func (h *ProductRoute) getMerchantProduct(ctx echo.Context) (*grpc.Product, error) {
+	authUser := common.ExtractUserContext(ctx)
+	id := ctx.Param(common.RequestParameterId)
+
+	if id == "" || bson.IsObjectIdHex(id) == false {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectProductId)
+	}
+
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(ctx.Request().Context(), mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	req := &grpc.RequestProduct{Id: id, MerchantId: merchant.Item.Id}
+	res, err := h.dispatch.Services.Billing.GetProduct(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetProduct", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	if !authUser.IsProjectAllowed(res.Item.ProjectId) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	return res.Item, nil
+}
+
+// listProducts returns page of the listing request, archived products are excluded unless they are included
+// explicitly. Billing server paginates the listing when none of archived products of the merchant is matched
+// by filters of the request
+func (h *ProductRoute) listProducts(
+	ctx echo.Context,
+	req *grpc.ListProductsRequest,
+	includeArchived bool,
+) (*grpc.ListProductsResponse, error) {
+	excluded := int32(0)
+
+	if !includeArchived {
+		archived, err := h.getArchivedProducts(ctx, req.MerchantId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range archived {
+			if isProductMatched(&grpc.Product{ProjectId: product.ProjectId, Sku: product.Sku, Name: product.Name}, req) {
+				excluded++
+			}
+		}
+	}
+
+	if excluded > 0 {
+		return h.listProductsExceptArchived(ctx, req, excluded)
+	}
+
+	res, err := h.dispatch.Services.Billing.ListProducts(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProducts", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	return res, nil
+}
+
+// getArchivedProducts returns index of archived products of the merchant, lost index is rebuilt from products
+// of the merchant kept by billing server
+func (h *ProductRoute) getArchivedProducts(
+	ctx echo.Context,
+	merchantId string,
+) (map[string]*common.ArchivedProduct, error) {
+	archived, ok, err := h.archivedProducts.List(merchantId)
+
+	if err == nil && !ok {
+		products := []*grpc.Product{}
+		pReq := &grpc.ListProductsRequest{MerchantId: merchantId, Limit: h.cfg.LimitMax}
+
+		for {
+			page, err := h.dispatch.Services.Billing.ListProducts(ctx.Request().Context(), pReq)
+
+			if err != nil {
+				common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProducts", pReq)
+				return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+			}
+
+			products = append(products, page.Products...)
+			pReq.Offset += int32(len(page.Products))
+
+			if len(page.Products) == 0 || pReq.Offset >= page.Total {
+				break
+			}
+		}
+
+		if err = h.archivedProducts.Rebuild(merchantId, products); err == nil {
+			archived, _, err = h.archivedProducts.List(merchantId)
+		}
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	return archived, nil
+}
+
+// listProductsExceptArchived returns page of the listing request without archived products, excluded is number
+// of archived products matched by the request. Billing server can't filter products by archive mark, so products
+// are read from the beginning of the listing up to the page only
+func (h *ProductRoute) listProductsExceptArchived(
+	ctx echo.Context,
+	req *grpc.ListProductsRequest,
+	excluded int32,
+) (*grpc.ListProductsResponse, error) {
+	res := &grpc.ListProductsResponse{Products: []*grpc.Product{}}
+	kept := int32(0)
+
+	pReq := *req
+	pReq.Limit = h.cfg.LimitMax
+	pReq.Offset = 0
+
+	for {
+		page, err := h.dispatch.Services.Billing.ListProducts(ctx.Request().Context(), &pReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProducts", &pReq)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+		}
+
+		if res.Total = page.Total - excluded; res.Total < 0 {
+			res.Total = 0
+		}
+
+		for _, product := range page.Products {
+			if _, archived := common.GetProductArchived(product); archived {
+				continue
+			}
+
+			if kept >= req.Offset && kept < req.Offset+req.Limit {
+				res.Products = append(res.Products, product)
+			}
+
+			kept++
+		}
+
+		pReq.Offset += int32(len(page.Products))
+
+		if kept >= req.Offset+req.Limit || len(page.Products) == 0 || pReq.Offset >= page.Total {
+			return res, nil
+		}
+	}
+}
+
+// getProductsByIds returns page of the listing request of products with the identifiers matched by filters
+// of the request and kept, products are in order of the identifiers, unknown products are skipped
+func (h *ProductRoute) getProductsByIds(
+	ctx echo.Context,
+	req *grpc.ListProductsRequest,
+	ids []string,
+	keep func(product *grpc.Product) bool,
+) (*grpc.ListProductsResponse, error) {
+	res := &grpc.ListProductsResponse{Products: []*grpc.Product{}}
+
+	for _, id := range ids {
+		pReq := &grpc.RequestProduct{Id: id, MerchantId: req.MerchantId}
+		product, err := h.dispatch.Services.Billing.GetProduct(ctx.Request().Context(), pReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetProduct", pReq)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+		}
+
+		if product.Status != pkg.ResponseStatusOk || product.Item == nil || product.Item.MerchantId != req.MerchantId ||
+			!isProductMatched(product.Item, req) || !keep(product.Item) {
+			continue
+		}
+
+		if res.Total >= req.Offset && res.Total < req.Offset+req.Limit {
+			res.Products = append(res.Products, product.Item)
+		}
+
+		res.Total++
+	}
+
+	return res, nil
+}
+
+// isProductMatched reports whether the product is matched by project, name and sku filters of the listing request
+func isProductMatched(product *grpc.Product, req *grpc.ListProductsRequest) bool {
+	if req.ProjectId != "" && product.ProjectId != req.ProjectId {
+		return false
+	}
+
+	if req.Sku != "" && !strings.Contains(strings.ToLower(product.Sku), strings.ToLower(req.Sku)) {
+		return false
+	}
+
+	if req.Name == "" {
+		return true
+	}
+
+	for _, name := range product.Name {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(req.Name)) {
+			return true
+		}
+	}
+
+	return false
+}
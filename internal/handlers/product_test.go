@@ -1,12 +1,21 @@
 package handlers
 
 import (
+	"encoding/json"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"github.com/paysuper/paysuper-management-api/internal/mock"
 	"github.com/paysuper/paysuper-management-api/internal/test"
 	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"net/http"
+	"strconv"
 	"testing"
 )
 
@@ -120,4 +129,312 @@ func (suite *ProductTestSuite) TestProduct_updateProduct_Ok() {
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, res.Code)
 	assert.NotEmpty(suite.T(), res.Body.String())
-}
\ No newline at end of file
+}
+func (suite *ProductTestSuite) getProductBillingMock(product *grpc.Product) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProduct", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetProductResponse{Status: pkg.ResponseStatusOk, Item: product}, nil)
+	bill.On("CreateOrUpdateProduct", mock2.Anything, mock2.Anything).Return(product, nil)
+	bill.On("ListProducts", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListProductsResponse{Total: 1, Products: []*grpc.Product{product}}, nil)
+
+	return bill
+}
+
+func (suite *ProductTestSuite) TestProduct_duplicateProduct_Ok() {
+	product := &grpc.Product{
+		Id:     "5c99391568add439ccf0ffaf",
+		Sku:    "ru_double_yeti_rel",
+		Prices: []*billing.ProductPrice{{Currency: "USD", Amount: 10}},
+	}
+	bill := suite.getProductBillingMock(product)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, product.Id).
+		Path(common.AuthUserGroupPath + productsDuplicatePath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"sku": "ru_double_yeti_copy"}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusCreated, res.Code)
+
+	bill.AssertCalled(suite.T(), "CreateOrUpdateProduct", mock2.Anything, mock2.MatchedBy(func(req *grpc.Product) bool {
+		return req.Id == "" && req.Sku == "ru_double_yeti_copy" && len(req.Prices) == 1 &&
+			req.Prices[0] != product.Prices[0]
+	}))
+	assert.Equal(suite.T(), "ru_double_yeti_rel", product.Sku)
+}
+
+func (suite *ProductTestSuite) TestProduct_archiveProduct_Ok() {
+	product := &grpc.Product{Id: "5c99391568add439ccf0ffaf", MerchantId: mock.OnboardingMerchantMock.Id, Enabled: true}
+	bill := suite.getProductBillingMock(product)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, product.Id).
+		Path(common.AuthUserGroupPath + productsArchivePath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.False(suite.T(), product.Enabled)
+	assert.Equal(suite.T(), "true", product.Metadata[common.ProductMetadataArchived])
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + productsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+
+	list := &grpc.ListProductsResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), list))
+	assert.Empty(suite.T(), list.Products)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+productsPath).
+		SetQueryParam(common.RequestParameterIncludeArchived, "true").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+
+	list = &grpc.ListProductsResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), list))
+	assert.Len(suite.T(), list.Products, 1)
+}
+
+func (suite *ProductTestSuite) TestProduct_getProductsList_IdsFilter_Ok() {
+	product := &grpc.Product{Id: "5c99391568add439ccf0ffaf", MerchantId: mock.OnboardingMerchantMock.Id}
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProduct", mock2.Anything, mock2.MatchedBy(func(req *grpc.RequestProduct) bool {
+		return req.Id == product.Id
+	})).Return(&grpc.GetProductResponse{Status: pkg.ResponseStatusOk, Item: product}, nil)
+	bill.On("GetProduct", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetProductResponse{Status: pkg.ResponseStatusNotFound, Message: mock.SomeError}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+productsPath).
+		SetQueryParam(common.RequestParameterIds, product.Id+",5c99391568add439ccf0ffff").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	list := &grpc.ListProductsResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), list))
+	assert.Len(suite.T(), list.Products, 1)
+	assert.EqualValues(suite.T(), 1, list.Total)
+	assert.Equal(suite.T(), product.Id, list.Products[0].Id)
+	bill.AssertNumberOfCalls(suite.T(), "GetProduct", 2)
+	bill.AssertNotCalled(suite.T(), "ListProducts", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProductTestSuite) TestProduct_getProductsList_IdsFilter_Invalid() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+productsPath).
+		SetQueryParam(common.RequestParameterIds, "5c99391568add439ccf0ffaf,qwerty").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageIdsFilterInvalid, httpErr.Message)
+}
+
+func (suite *ProductTestSuite) TestProduct_unarchiveProduct_Ok() {
+	for _, enabled := range []bool{true, false} {
+		product := &grpc.Product{
+			Id:         bson.NewObjectId().Hex(),
+			MerchantId: mock.OnboardingMerchantMock.Id,
+			Metadata:   map[string]string{common.ProductMetadataArchived: strconv.FormatBool(enabled)},
+		}
+		suite.router.dispatch.Services.Billing = suite.getProductBillingMock(product)
+		assert.NoError(suite.T(), suite.router.archivedProducts.Add(product))
+
+		res, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Params(":"+common.RequestParameterId, product.Id).
+			Path(common.AuthUserGroupPath + productsUnarchivePath).
+			Init(test.ReqInitJSON()).
+			Exec(suite.T())
+
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), http.StatusOK, res.Code)
+		assert.Equal(suite.T(), enabled, product.Enabled)
+		assert.NotContains(suite.T(), product.Metadata, common.ProductMetadataArchived)
+
+		archived, _, err := suite.router.archivedProducts.List(product.MerchantId)
+		assert.NoError(suite.T(), err)
+		assert.NotContains(suite.T(), archived, product.Id)
+	}
+}
+
+func (suite *ProductTestSuite) TestProduct_updateProduct_ArchivedStaysDisabled() {
+	product := &grpc.Product{
+		Id:         "5c99391568add439ccf0ffaf",
+		MerchantId: mock.OnboardingMerchantMock.Id,
+		Metadata:   map[string]string{common.ProductMetadataArchived: "true"},
+	}
+	bill := suite.getProductBillingMock(product)
+	suite.router.dispatch.Services.Billing = bill
+
+	body := `{"object": "product", "billing_type": "real", "pricing": "manual", "type": "simple_product",
+		"sku": "ru_double_yeti", "name": {"en": "Double Yeti"}, "default_currency": "USD", "enabled": true,
+		"prices": [{"amount": 10, "currency": "USD", "region": "USD"}], "description": {"en": "Double Yeti"},
+		"long_description": {}, "project_id": "5bdc39a95d1e1100019fb7df", "metadata": {"_archived": "false"}}`
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":"+common.RequestParameterId, product.Id).
+		Path(common.AuthUserGroupPath + productsIdPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertCalled(suite.T(), "CreateOrUpdateProduct", mock2.Anything, mock2.MatchedBy(func(req *grpc.Product) bool {
+		return req.Id == product.Id && !req.Enabled && req.Metadata[common.ProductMetadataArchived] == "true"
+	}))
+}
+
+func (suite *ProductTestSuite) TestProduct_getProductsList_ArchivedPaginated_Ok() {
+	var products []*grpc.Product
+
+	for i := 0; i < 5; i++ {
+		products = append(products, &grpc.Product{Id: bson.NewObjectId().Hex()})
+	}
+
+	// index of archived products is lost, it's rebuilt from archive marks of the products
+	common.SetProductArchived(products[0], true)
+	common.SetProductArchived(products[2], true)
+
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("ListProducts", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListProductsRequest) bool {
+		return req.Offset == 0
+	})).Return(&grpc.ListProductsResponse{Total: 5, Products: products[:3]}, nil)
+	bill.On("ListProducts", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListProductsRequest) bool {
+		return req.Offset == 3
+	})).Return(&grpc.ListProductsResponse{Total: 5, Products: products[3:]}, nil)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.cfg.LimitMax = 3
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+productsPath).
+		SetQueryParam(common.RequestParameterLimit, "2").
+		SetQueryParam(common.RequestParameterOffset, "1").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	list := &grpc.ListProductsResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), list))
+	assert.EqualValues(suite.T(), 3, list.Total)
+	assert.Len(suite.T(), list.Products, 2)
+	assert.Equal(suite.T(), products[3].Id, list.Products[0].Id)
+	assert.Equal(suite.T(), products[4].Id, list.Products[1].Id)
+}
+
+func (suite *ProductTestSuite) TestProduct_getProductsList_ArchivedPageFailed_Error() {
+	product := &grpc.Product{Id: bson.NewObjectId().Hex()}
+	common.SetProductArchived(product, true)
+	assert.NoError(suite.T(), suite.router.archivedProducts.Rebuild(mock.OnboardingMerchantMock.Id, []*grpc.Product{product}))
+
+	bill := mock.NewBillingServerBuilder().
+		Method("GetMerchantBy").Always(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}).
+		Method("ListProducts").
+		Respond(&grpc.ListProductsResponse{Total: 2, Products: []*grpc.Product{product}}).
+		Fail(mock.SomeError).
+		Build()
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.cfg.LimitMax = 1
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + productsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	bill.AssertNumberOfCalls(suite.T(), "ListProducts", 2)
+}
+
+func (suite *ProductTestSuite) TestProduct_getProductsList_ProjectScope_ProjectFilterRequired() {
+	user := &common.AuthUser{
+		Id:       "ffffffffffffffffffffffff",
+		Projects: map[string]bool{bson.NewObjectId().Hex(): true, bson.NewObjectId().Hex(): true},
+	}
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + productsPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			middleware.Pre(test.PreAuthUserMiddleware(user))
+		}).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageProjectScopeFilterRequired, httpErr.Message)
+}
+
+func (suite *ProductTestSuite) TestProduct_getProductsList_ProjectScope_Ok() {
+	projectId := bson.NewObjectId().Hex()
+	user := &common.AuthUser{
+		Id:       "ffffffffffffffffffffffff",
+		Projects: map[string]bool{projectId: true},
+	}
+
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: &billing.Merchant{Id: bson.NewObjectId().Hex()}}, nil)
+	bill.On("ListProducts", mock2.Anything, mock2.Anything).Return(&grpc.ListProductsResponse{}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + productsPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			middleware.Pre(test.PreAuthUserMiddleware(user))
+		}).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertCalled(suite.T(), "ListProducts", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListProductsRequest) bool {
+		return req.ProjectId == projectId
+	}))
+}
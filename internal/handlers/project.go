@@ -1,25 +1,78 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/ProtocolONE/go-core/v2/pkg/logger"
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/globalsign/mgo/bson"
 	"github.com/labstack/echo/v4"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
 const (
 	projectsPath    = "/projects"
 	projectsIdPath  = "/projects/:id"
 	projectsSkuPath = "/projects/:id/sku"
+
+	projectsVirtualCurrencyPricesPath = "/projects/:id/virtual_currency/prices"
+
+	projectsGoLiveChecklistPath = "/projects/:id/go_live_checklist"
+	projectsSubmitForReviewPath = "/projects/:id/submit_for_review"
+	projectsApprovePath         = "/projects/:id/approve"
+	projectsRejectPath          = "/projects/:id/reject"
+
+	projectsIssuesPath = "/projects/:id/issues"
+
+	projectsRefundPolicyPath   = "/projects/:id/refund_policy"
+	projectsEmailScreeningPath = "/projects/:id/email_screening"
+	projectsPoliciesInitPath   = "/projects/policies/init"
 )
 
+const (
+	projectChecklistPaymentMethods = "payment_methods"
+	projectChecklistRedirectUrls   = "redirect_urls"
+	projectChecklistProducts       = "products"
+	projectChecklistReadiness      = "integration_readiness"
+)
+
+const (
+	projectIssueNotificationTitle = "Project configuration breaks payments"
+	projectIssueNotificationMask  = "Project %s: %d of %d orders failed to be created since change of %s at %s " +
+		"(%.0f%% failed before the change), errors: %s"
+)
+
+var projectIssuesIgnoredSettings = map[string]bool{
+	"id":          true,
+	"merchant_id": true,
+	"created_at":  true,
+	"updated_at":  true,
+}
+
+type projectChecklistItem struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+}
+
+type projectVirtualCurrencyPricesRequest struct {
+	Prices []*billing.ProductPrice `json:"prices" validate:"required,min=1,dive"`
+}
+
 type ProjectRoute struct {
-	dispatch common.HandlerSet
-	cfg      common.Config
+	dispatch       common.HandlerSet
+	cfg            common.Config
+	projectIssues  *common.ProjectIssues
+	refundPolicies *common.RefundPolicies
+	emailScreening *common.EmailScreenings
+	readiness      *common.IntegrationReadiness
 	provider.LMT
 }
 
@@ -33,12 +86,29 @@ func NewProjectRoute(set common.HandlerSet, cfg *common.Config) *ProjectRoute {
 }
 
 func (h *ProjectRoute) Route(groups *common.Groups) {
+	h.projectIssues = groups.ProjectIssues
+	h.refundPolicies = common.NewRefundPolicies(groups.Redis)
+	h.emailScreening = common.NewEmailScreenings(groups.Redis, h.cfg.PaymentEmailScreeningVerdictTtl)
+	h.readiness = common.NewIntegrationReadiness(groups.Redis, h.cfg.IntegrationTestResultTtl)
+
 	groups.AuthUser.GET(projectsPath, h.listProjects)
 	groups.AuthUser.GET(projectsIdPath, h.getProject)
 	groups.AuthUser.POST(projectsPath, h.createProject)
 	groups.AuthUser.PATCH(projectsIdPath, h.updateProject)
 	groups.AuthUser.DELETE(projectsIdPath, h.deleteProject)
 	groups.AuthUser.POST(projectsSkuPath, h.checkSku)
+	groups.AuthUser.GET(projectsVirtualCurrencyPricesPath, h.getVirtualCurrencyPrices)
+	groups.AuthUser.PUT(projectsVirtualCurrencyPricesPath, h.updateVirtualCurrencyPrices)
+	groups.AuthUser.GET(projectsGoLiveChecklistPath, h.getGoLiveChecklist)
+	groups.AuthUser.POST(projectsSubmitForReviewPath, h.submitForReview)
+	groups.System.POST(projectsApprovePath, h.approveProject)
+	groups.System.POST(projectsRejectPath, h.rejectProject)
+	groups.AuthUser.GET(projectsIssuesPath, h.listIssues)
+	groups.AuthUser.GET(projectsRefundPolicyPath, h.getRefundPolicy)
+	groups.AuthUser.PUT(projectsRefundPolicyPath, h.setRefundPolicy)
+	groups.AuthUser.GET(projectsEmailScreeningPath, h.getEmailScreeningPolicy)
+	groups.AuthUser.PUT(projectsEmailScreeningPath, h.setEmailScreeningPolicy)
+	groups.System.POST(projectsPoliciesInitPath, h.initPolicies)
 }
 
 func (h *ProjectRoute) createProject(ctx echo.Context) error {
@@ -55,6 +125,12 @@ func (h *ProjectRoute) createProject(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
+	err = common.CheckAmountPrecision(req.LimitsCurrency, req.MinPaymentAmount, req.MaxPaymentAmount)
+
+	if err != nil {
+		return err
+	}
+
 	res, err := h.dispatch.Services.Billing.ChangeProject(ctx.Request().Context(), req)
 
 	if err != nil {
@@ -70,6 +146,13 @@ func (h *ProjectRoute) createProject(ctx echo.Context) error {
 }
 
 func (h *ProjectRoute) updateProject(ctx echo.Context) error {
+	if !common.ExtractUserContext(ctx).IsProjectAllowed(ctx.Param(common.RequestParameterId)) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	// settings of the project are taken before the binder merges the request into the project,
+	// so the settings the request changes are found by comparison with them
+	stored := h.getProjectSettings(ctx)
 	req := &billing.Project{}
 	binder := common.NewChangeProjectRequestBinder(h.dispatch, h.cfg)
 	err := binder.Bind(req, ctx)
@@ -84,6 +167,12 @@ func (h *ProjectRoute) updateProject(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
+	err = common.CheckAmountPrecision(req.LimitsCurrency, req.MinPaymentAmount, req.MaxPaymentAmount)
+
+	if err != nil {
+		return err
+	}
+
 	res, err := h.dispatch.Services.Billing.ChangeProject(ctx.Request().Context(), req)
 
 	if err != nil {
@@ -95,10 +184,57 @@ func (h *ProjectRoute) updateProject(ctx echo.Context) error {
 		return echo.NewHTTPError(int(res.Status), res.Message)
 	}
 
+	if h.projectIssues != nil && stored != nil {
+		err = h.projectIssues.RecordChange(
+			req.Id,
+			req.MerchantId,
+			common.ExtractUserContext(ctx).Id,
+			getProjectChangedSettings(stored, getProjectSettings(req)),
+		)
+
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", req.Id))
+		}
+	}
+
 	return ctx.JSON(http.StatusOK, res.Item)
 }
 
+// @Description Spikes of failed order creations detected after changes of project configuration, the latest first.
+// @Description Every issue contains changed settings, number of order creation attempts and failures by error code
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/projects/%project_id_here%/issues
+func (h *ProjectRoute) listIssues(ctx echo.Context) error {
+	projectId := ctx.Param(common.RequestParameterId)
+
+	if !bson.IsObjectIdHex(projectId) {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectProjectId)
+	}
+
+	if !common.ExtractUserContext(ctx).IsProjectAllowed(projectId) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	issues := []*common.ProjectIssue{}
+
+	if h.projectIssues != nil {
+		var err error
+		issues, err = h.projectIssues.List(projectId)
+
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", projectId))
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, issues)
+}
+
 func (h *ProjectRoute) getProject(ctx echo.Context) error {
+	if !common.ExtractUserContext(ctx).IsProjectAllowed(ctx.Param(common.RequestParameterId)) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
 	req := &grpc.GetProjectRequest{
 		ProjectId: ctx.Param(common.RequestParameterId),
 	}
@@ -131,6 +267,12 @@ func (h *ProjectRoute) listProjects(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
 	}
 
+	filter, err := common.ParseIdsParam(ctx.QueryParam(common.RequestParameterIds))
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
 	if req.Limit <= 0 {
 		req.Limit = h.cfg.LimitDefault
 	}
@@ -141,6 +283,40 @@ func (h *ProjectRoute) listProjects(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
+	authUser := common.ExtractUserContext(ctx)
+
+	// listing of ids filter and listing of token restricted to project scope contain known projects only,
+	// the projects are fetched by id, so count and pages contain allowed and requested projects only
+	if len(authUser.Projects) > 0 || len(filter) > 0 {
+		merchant, err := getAuthUserMerchant(ctx, h.dispatch, h.L())
+
+		if err != nil {
+			return err
+		}
+
+		ids, _ := authUser.FilterProjects(filter)
+		items, err := h.getProjectsByIds(ctx.Request().Context(), req, merchant.Id, ids)
+
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		res := &grpc.ListProjectsResponse{Count: int32(len(items)), Items: []*billing.Project{}}
+
+		if int(req.Offset) < len(items) {
+			items = items[req.Offset:]
+
+			if len(items) > int(req.Limit) {
+				items = items[:req.Limit]
+			}
+
+			res.Items = items
+		}
+
+		meta := &common.EnvelopeMeta{Count: res.Count, Limit: req.Limit, Offset: req.Offset}
+		return common.ListResponse(ctx, res, res.Items, meta)
+	}
+
 	res, err := h.dispatch.Services.Billing.ListProjects(ctx.Request().Context(), req)
 
 	if err != nil {
@@ -148,10 +324,74 @@ func (h *ProjectRoute) listProjects(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
 	}
 
-	return ctx.JSON(http.StatusOK, res)
+	meta := &common.EnvelopeMeta{Count: res.Count, Limit: req.Limit, Offset: req.Offset}
+	return common.ListResponse(ctx, res, res.Items, meta)
+}
+
+// getProjectsByIds returns projects of the merchant with the identifiers matched by filters of the listing request
+// in order of the identifiers, unknown projects and projects of other merchants are skipped
+func (h *ProjectRoute) getProjectsByIds(
+	ctx context.Context,
+	req *grpc.ListProjectsRequest,
+	merchantId string,
+	ids []string,
+) ([]*billing.Project, error) {
+	items := make([]*billing.Project, 0, len(ids))
+	statuses := make(map[int32]bool, len(req.Statuses))
+
+	for _, status := range req.Statuses {
+		statuses[status] = true
+	}
+
+	for _, id := range ids {
+		pReq := &grpc.GetProjectRequest{ProjectId: id}
+		res, err := h.dispatch.Services.Billing.GetProject(ctx, pReq)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetProject", pReq)
+			return nil, err
+		}
+
+		if res.Status != pkg.ResponseStatusOk || res.Item == nil {
+			continue
+		}
+
+		if res.Item.MerchantId != merchantId || (req.MerchantId != "" && req.MerchantId != merchantId) {
+			continue
+		}
+
+		if len(statuses) > 0 && !statuses[res.Item.Status] {
+			continue
+		}
+
+		if req.QuickSearch != "" && !isProjectNameMatched(res.Item, req.QuickSearch) {
+			continue
+		}
+
+		items = append(items, res.Item)
+	}
+
+	return items, nil
+}
+
+// isProjectNameMatched reports whether name of the project in any language contains the quick search string
+func isProjectNameMatched(project *billing.Project, search string) bool {
+	search = strings.ToLower(search)
+
+	for _, name := range project.Name {
+		if strings.Contains(strings.ToLower(name), search) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (h *ProjectRoute) deleteProject(ctx echo.Context) error {
+	if !common.ExtractUserContext(ctx).IsProjectAllowed(ctx.Param(common.RequestParameterId)) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
 	req := &grpc.GetProjectRequest{
 		ProjectId: ctx.Param(common.RequestParameterId),
 	}
@@ -176,6 +416,10 @@ func (h *ProjectRoute) deleteProject(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, res)
 }
 func (h *ProjectRoute) checkSku(ctx echo.Context) error {
+	if !common.ExtractUserContext(ctx).IsProjectAllowed(ctx.Param(common.RequestParameterId)) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
 	req := &grpc.CheckSkuAndKeyProjectRequest{}
 
 	if err := ctx.Bind(req); err != nil {
@@ -200,4 +444,503 @@ func (h *ProjectRoute) checkSku(ctx echo.Context) error {
 	}
 
 	return ctx.NoContent(http.StatusOK)
-}
\ No newline at end of file
+}
+
+func (h *ProjectRoute) getVirtualCurrencyPrices(ctx echo.Context) error {
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	if project.VirtualCurrency == nil {
+		return echo.NewHTTPError(http.StatusNotFound, common.ErrorMessageVirtualCurrencyNotConfigured)
+	}
+
+	return ctx.JSON(http.StatusOK, project.VirtualCurrency.Prices)
+}
+
+func (h *ProjectRoute) updateVirtualCurrencyPrices(ctx echo.Context) error {
+	req := &projectVirtualCurrencyPricesRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	for _, price := range req.Prices {
+		if err := common.CheckAmountPrecision(price.Currency, price.Amount); err != nil {
+			return err
+		}
+	}
+
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	if project.VirtualCurrency == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageVirtualCurrencyNotConfigured)
+	}
+
+	project.VirtualCurrency.Prices = req.Prices
+	res, err := h.dispatch.Services.Billing.ChangeProject(ctx.Request().Context(), project)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ChangeProject", project)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return ctx.JSON(http.StatusOK, res.Item.VirtualCurrency.Prices)
+}
+
+// @Description Get refund policy of the project, zero value of the rule means the rule is disabled
+// @Example GET /admin/api/v1/projects/5bdc39a95d1e1100019fb7df/refund_policy
+func (h *ProjectRoute) getRefundPolicy(ctx echo.Context) error {
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	policy, err := h.refundPolicies.Get(project.Id)
+
+	if err == common.ErrPolicyStoreLost {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageRefundPolicyUnavailable)
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.JSON(http.StatusOK, policy)
+}
+
+// @Description Set refund policy of the project checked on each refund of the project orders
+// @Example curl -X PUT -H 'Authorization: Bearer %access_token_here%' -H 'Content-Type: application/json' \
+//  -d '{"max_days_after_purchase": 14, "max_refunds_per_order": 1, "auto_approve_threshold": 100}' \
+//  https://api.paysuper.online/admin/api/v1/projects/5bdc39a95d1e1100019fb7df/refund_policy
+func (h *ProjectRoute) setRefundPolicy(ctx echo.Context) error {
+	req := &common.RefundPolicy{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	if err = h.refundPolicies.Set(project.Id, req); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.JSON(http.StatusOK, req)
+}
+
+// @Description Initialize policies of projects on the first deploy or after they're lost with redis,
+// @Description features enforcing the policies are suspended until then. Policies kept in redis aren't changed,
+// @Description lost policies must be set again by merchants
+// @Example curl -X POST -H 'Authorization: Bearer %system_token_here%' \
+//  https://api.paysuper.online/system/api/v1/projects/policies/init
+func (h *ProjectRoute) initPolicies(ctx echo.Context) error {
+	err := h.refundPolicies.Init()
+
+	if err == nil {
+		err = h.emailScreening.InitPolicies()
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// @Description Get payer email screening policy of the project, empty mode means screening configured for all projects
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/projects/5bdc39a95d1e1100019fb7df/email_screening
+func (h *ProjectRoute) getEmailScreeningPolicy(ctx echo.Context) error {
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	policy, err := h.emailScreening.GetPolicy(project.Id)
+
+	if err == common.ErrPolicyStoreLost {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageEmailScreeningPolicyUnavailable)
+	}
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.JSON(http.StatusOK, policy)
+}
+
+// @Description Set payer email screening of payments of the project: "off", "flag" logs rejected emails,
+// @Description "block" rejects the payment, empty mode falls back to screening configured for all projects
+// @Example curl -X PUT -H 'Authorization: Bearer %access_token_here%' -H 'Content-Type: application/json' \
+//  -d '{"mode": "block", "mx_lookup": true}' \
+//  https://api.paysuper.online/admin/api/v1/projects/5bdc39a95d1e1100019fb7df/email_screening
+func (h *ProjectRoute) setEmailScreeningPolicy(ctx echo.Context) error {
+	req := &common.EmailScreeningPolicy{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	if err = h.emailScreening.SetPolicy(project.Id, req); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return ctx.JSON(http.StatusOK, req)
+}
+
+// @Description Get project go-live requirements and their state
+// @Example GET /admin/api/v1/projects/5bdc39a95d1e1100019fb7df/go_live_checklist
+func (h *ProjectRoute) getGoLiveChecklist(ctx echo.Context) error {
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	checklist, err := h.getProjectChecklist(ctx, project)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, checklist)
+}
+
+// @Description Submit draft (or rejected) project for review, project must meet all go-live requirements
+// @Example POST /admin/api/v1/projects/5bdc39a95d1e1100019fb7df/submit_for_review
+func (h *ProjectRoute) submitForReview(ctx echo.Context) error {
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	if project.Status != pkg.ProjectStatusDraft && project.Status != pkg.ProjectStatusTestFailed {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageProjectStatusTransitionInvalid)
+	}
+
+	checklist, err := h.getProjectChecklist(ctx, project)
+
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+
+	for _, item := range checklist {
+		if !item.Passed {
+			failed = append(failed, item.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		rspErr := *common.ErrorMessageProjectGoLiveChecklistFailed
+		rspErr.Details = strings.Join(failed, ",")
+
+		return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	}
+
+	return h.changeProjectStatus(ctx, project, pkg.ProjectStatusTestCompleted)
+}
+
+// @Description Approve project which is on review and move it to production, called by operator with personal
+// @Description system api token only
+// @Example POST /system/api/v1/projects/5bdc39a95d1e1100019fb7df/approve
+func (h *ProjectRoute) approveProject(ctx echo.Context) error {
+	return h.reviewProject(ctx, pkg.ProjectStatusInProduction)
+}
+
+// @Description Reject project which is on review, merchant can fix it and submit for review again. Called by operator
+// @Description with personal system api token only
+// @Example POST /system/api/v1/projects/5bdc39a95d1e1100019fb7df/reject
+func (h *ProjectRoute) rejectProject(ctx echo.Context) error {
+	return h.reviewProject(ctx, pkg.ProjectStatusTestFailed)
+}
+
+func (h *ProjectRoute) reviewProject(ctx echo.Context, status int32) error {
+	if !common.ExtractUserContext(ctx).Operator {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	project, err := h.getProjectItem(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if project.Status != pkg.ProjectStatusTestCompleted {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageProjectStatusTransitionInvalid)
+	}
+
+	return h.changeProjectStatus(ctx, project, status)
+}
+
+func (h *ProjectRoute) changeProjectStatus(ctx echo.Context, project *billing.Project, status int32) error {
+	project.Status = status
+	res, err := h.dispatch.Services.Billing.ChangeProject(ctx.Request().Context(), project)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ChangeProject", project)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	// orders made before approval are counted by go-live readiness as made with test keys
+	if status == pkg.ProjectStatusInProduction {
+		if err = h.readiness.SetLiveAt(project.Id, time.Now()); err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, res.Item)
+}
+
+func (h *ProjectRoute) getProjectChecklist(ctx echo.Context, project *billing.Project) ([]*projectChecklistItem, error) {
+	reqCtx := ctx.Request().Context()
+	mReq := &grpc.GetMerchantByRequest{MerchantId: project.MerchantId}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	paymentMethods := &projectChecklistItem{Name: projectChecklistPaymentMethods}
+
+	for _, pm := range merchant.Item.PaymentMethods {
+		if pm.IsActive {
+			paymentMethods.Passed = true
+			break
+		}
+	}
+
+	readiness, err := getIntegrationReadiness(ctx, h.dispatch, h.L(), h.readiness, project)
+
+	if err != nil {
+		return nil, err
+	}
+
+	checklist := []*projectChecklistItem{
+		paymentMethods,
+		{
+			Name:   projectChecklistRedirectUrls,
+			Passed: project.UrlRedirectSuccess != "" && project.UrlRedirectFail != "",
+		},
+		{
+			Name:   projectChecklistReadiness,
+			Passed: readiness.Score >= h.cfg.ProjectReviewMinReadinessScore,
+		},
+	}
+
+	if !project.IsProductsCheckout {
+		return checklist, nil
+	}
+
+	pReq := &grpc.ListProductsRequest{MerchantId: project.MerchantId, ProjectId: project.Id, Limit: 1}
+	products, err := h.dispatch.Services.Billing.ListProducts(reqCtx, pReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProducts", pReq)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	checklist = append(checklist, &projectChecklistItem{Name: projectChecklistProducts, Passed: products.Total > 0})
+
+	return checklist, nil
+}
+
+func (h *ProjectRoute) getProjectItem(ctx echo.Context) (*billing.Project, error) {
+	req := &grpc.GetProjectRequest{ProjectId: ctx.Param(common.RequestParameterId)}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	res, err := h.dispatch.Services.Billing.GetProject(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetProject", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item, nil
+}
+
+// getUserProject returns project of the merchant of authenticated user, projects of other merchants and projects
+// outside of the token scope are forbidden
+func getUserProject(ctx echo.Context, set common.HandlerSet, log logger.Logger, projectId string) (*billing.Project, error) {
+	authUser := common.ExtractUserContext(ctx)
+
+	if !bson.IsObjectIdHex(projectId) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectProjectId)
+	}
+
+	if !authUser.IsProjectAllowed(projectId) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := set.Services.Billing.GetMerchantBy(ctx.Request().Context(), mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(log, err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	pReq := &grpc.GetProjectRequest{ProjectId: projectId}
+	project, err := set.Services.Billing.GetProject(ctx.Request().Context(), pReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(log, err, pkg.ServiceName, "GetProject", pReq)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if project.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(project.Status), project.Message)
+	}
+
+	if project.Item.MerchantId != merchant.Item.Id {
+		return nil, echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	return project.Item, nil
+}
+
+// getProjectSettings returns settings of the project stored by billing server before the change,
+// nil is returned when the project can't be read, so the change isn't attributed to any settings
+func (h *ProjectRoute) getProjectSettings(ctx echo.Context) map[string]json.RawMessage {
+	projectId := ctx.Param(common.RequestParameterId)
+
+	if h.projectIssues == nil || !bson.IsObjectIdHex(projectId) {
+		return nil
+	}
+
+	req := &grpc.GetProjectRequest{ProjectId: projectId}
+	res, err := h.dispatch.Services.Billing.GetProject(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetProject", req)
+		return nil
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil
+	}
+
+	return getProjectSettings(res.Item)
+}
+
+// getProjectSettings returns settings of the project by json names
+func getProjectSettings(project *billing.Project) map[string]json.RawMessage {
+	settings := make(map[string]json.RawMessage)
+	data, err := json.Marshal(project)
+
+	if err != nil || json.Unmarshal(data, &settings) != nil {
+		return nil
+	}
+
+	return settings
+}
+
+// getProjectChangedSettings returns names of settings which differ in the stored and the changed project,
+// identifiers and timestamps of the project aren't settings
+func getProjectChangedSettings(stored, changed map[string]json.RawMessage) []string {
+	settings := make([]string, 0)
+
+	for name, value := range changed {
+		if !projectIssuesIgnoredSettings[name] && string(stored[name]) != string(value) {
+			settings = append(settings, name)
+		}
+	}
+
+	for name := range stored {
+		if _, ok := changed[name]; !ok && !projectIssuesIgnoredSettings[name] {
+			settings = append(settings, name)
+		}
+	}
+
+	return settings
+}
+
+func getProjectIssueMessage(issue *common.ProjectIssue) string {
+	codes := make([]string, 0, len(issue.Errors))
+
+	for code := range issue.Errors {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+	errs := make([]string, 0, len(codes))
+
+	for _, code := range codes {
+		errs = append(errs, fmt.Sprintf("%s x%d", code, issue.Errors[code]))
+	}
+
+	return fmt.Sprintf(
+		projectIssueNotificationMask,
+		issue.ProjectId,
+		issue.Failures,
+		issue.Attempts,
+		strings.Join(issue.Settings, ", "),
+		issue.ChangedAt.UTC().Format(time.RFC3339),
+		issue.BaselineRate*100,
+		strings.Join(errs, ", "),
+	)
+}
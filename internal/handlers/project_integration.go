@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	projectsIntegrationTestPath      = "/projects/:id/integration_test"
+	projectsIntegrationReadinessPath = "/integration/readiness"
+)
+
+const (
+	integrationTestCheckAccount   = "check_account"
+	integrationTestProcessPayment = "process_payment"
+
+	integrationTestSignatureHeader = "X-PAYSUPER-SIGNATURE"
+	integrationTestUserId          = "paysuper_integration_test"
+	integrationTestMaxResponseSize = 64 * 1024
+
+	integrationTestErrorUrlEmpty          = "url isn't configured"
+	integrationTestErrorUrlInvalid        = "url must be absolute http or https url"
+	integrationTestErrorRequest           = "request failed: %s"
+	integrationTestErrorStatus            = "unexpected response status %d, 2xx expected"
+	integrationTestErrorFormat            = "response body isn't valid json"
+	integrationTestErrorSignatureRequired = "response signature header is missing"
+	integrationTestErrorSignatureInvalid  = "response signature is invalid"
+
+	integrationTestDialTimeout = 5 * time.Second
+
+	integrationReadinessPayment = "payment"
+	integrationReadinessRefund  = "refund"
+)
+
+// integrationTestDeniedNetworks are private, loopback, link-local and other special-purpose networks, test requests
+// to them are rejected, so merchant url can't be used to reach internal services
+var integrationTestDeniedNetworks = mustParseNetworks(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+	"192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15", "224.0.0.0/3",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+var errorIntegrationTestAddressDenied = errors.New("address of url isn't public")
+
+type integrationTestPayload struct {
+	Id        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Test      bool                   `json:"test"`
+	ProjectId string                 `json:"project_id"`
+	CreatedAt int64                  `json:"created_at"`
+	Object    map[string]interface{} `json:"object"`
+}
+
+// integrationTestCheck is result of the check, reasons of failure are logged only, so the response of the url
+// isn't disclosed to the caller
+type integrationTestCheck struct {
+	Name    string `json:"name"`
+	Url     string `json:"url"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped"`
+	errors  []string
+}
+
+type integrationTestReport struct {
+	ProjectId string                  `json:"project_id"`
+	Passed    bool                    `json:"passed"`
+	TestedAt  int64                   `json:"tested_at"`
+	Checks    []*integrationTestCheck `json:"checks"`
+}
+
+type integrationReadinessRequest struct {
+	ProjectId string `query:"project_id" validate:"required,hexadecimal,len=24"`
+}
+
+type integrationReadinessFlow struct {
+	Name      string `json:"name"`
+	Required  bool   `json:"required"`
+	Exercised bool   `json:"exercised"`
+}
+
+// integrationReadinessReport is readiness of the project by flows exercised with its test keys: integration tests
+// and orders made before the project was approved for production
+type integrationReadinessReport struct {
+	ProjectId  string                      `json:"project_id"`
+	Score      int                         `json:"score"`
+	LastTestAt int64                       `json:"last_test_at,omitempty"`
+	LiveAt     int64                       `json:"live_at,omitempty"`
+	Flows      []*integrationReadinessFlow `json:"flows"`
+}
+
+type ProjectIntegrationRoute struct {
+	dispatch   common.HandlerSet
+	cfg        common.Config
+	httpClient *http.Client
+	provider.LMT
+
+	// allowedNetworks are exempted from integrationTestDeniedNetworks
+	allowedNetworks []*net.IPNet
+	readiness       *common.IntegrationReadiness
+}
+
+// NewProjectIntegrationRoute
+func NewProjectIntegrationRoute(set common.HandlerSet, cfg *common.Config) *ProjectIntegrationRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "ProjectIntegrationRoute"})
+	h := &ProjectIntegrationRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+
+	allowedNetworks, err := common.ParseTrustedProxies(cfg.IntegrationTestAllowedNetworks)
+
+	if err != nil {
+		h.L().Error("invalid integration test allowed networks", logger.PairArgs("err", err.Error()))
+	}
+
+	h.allowedNetworks = allowedNetworks
+
+	// address is checked after name resolution right before connect, so dns of the url can't point it to
+	// internal address after validation. Proxy isn't used and redirects aren't followed for the same reason
+	dialer := &net.Dialer{Timeout: integrationTestDialTimeout, Control: h.controlDial}
+	h.httpClient = &http.Client{
+		Timeout: cfg.IntegrationTestTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: integrationTestDialTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return h
+}
+
+func (h *ProjectIntegrationRoute) Route(groups *common.Groups) {
+	h.readiness = common.NewIntegrationReadiness(groups.Redis, h.cfg.IntegrationTestResultTtl)
+	groups.AuthUser.POST(projectsIntegrationTestPath, h.runIntegrationTest)
+	groups.AuthUser.GET(projectsIntegrationReadinessPath, h.getReadiness)
+}
+
+// @Description Verify merchant integration: send signed test requests to project check account and process payment urls
+// @Example curl -X POST -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/projects/%project_id_here%/integration_test
+func (h *ProjectIntegrationRoute) runIntegrationTest(ctx echo.Context) error {
+	// requests are signed by secret key of the project, so the project must belong to merchant of the user
+	project, err := getUserProject(ctx, h.dispatch, h.L(), ctx.Param(common.RequestParameterId))
+
+	if err != nil {
+		return err
+	}
+
+	report := &integrationTestReport{ProjectId: project.Id, Passed: true, TestedAt: time.Now().Unix()}
+	objects := map[string]map[string]interface{}{
+		integrationTestCheckAccount: {
+			"user": map[string]interface{}{"external_id": integrationTestUserId},
+		},
+		integrationTestProcessPayment: {
+			"order_id": uuid.New().String(),
+			"amount":   1,
+			"currency": project.CallbackCurrency,
+			"user":     map[string]interface{}{"external_id": integrationTestUserId},
+		},
+	}
+	urls := []struct{ name, url string }{
+		{integrationTestCheckAccount, project.UrlCheckAccount},
+		{integrationTestProcessPayment, project.UrlProcessPayment},
+	}
+
+	for _, val := range urls {
+		check := h.runCheck(ctx.Request().Context(), project, val.name, val.url, objects[val.name])
+		report.Checks = append(report.Checks, check)
+
+		if !check.Passed && !check.Skipped {
+			report.Passed = false
+			h.L().Info(
+				"project integration check failed",
+				logger.PairArgs("project_id", project.Id, "check", check.Name, "errors", check.errors),
+			)
+		}
+	}
+
+	result := &common.IntegrationTestResult{
+		ProjectId: report.ProjectId,
+		TestedAt:  report.TestedAt,
+		Checks:    make(map[string]bool, len(report.Checks)),
+	}
+
+	for _, check := range report.Checks {
+		result.Checks[check.Name] = check.Passed
+	}
+
+	// the report is returned even when it isn't kept, only readiness of the project misses it
+	if err = h.readiness.SaveTestResult(result); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// @Description Go-live readiness of the project: flows required by its configuration, whether they were exercised
+// @Description with test keys by integration tests and orders made before the project was approved for production,
+// @Description and score as percent of exercised required flows. Submit for review requires the minimal score
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  'https://api.paysuper.online/admin/api/v1/integration/readiness?project_id=5bdc39a95d1e1100019fb7df'
+func (h *ProjectIntegrationRoute) getReadiness(ctx echo.Context) error {
+	req := &integrationReadinessRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	// orders of the project are read on behalf of its merchant, so the project must belong to merchant of the user
+	project, err := getUserProject(ctx, h.dispatch, h.L(), req.ProjectId)
+
+	if err != nil {
+		return err
+	}
+
+	report, err := getIntegrationReadiness(ctx, h.dispatch, h.L(), h.readiness, project)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// getIntegrationReadiness returns go-live readiness of the project. Flows are exercised with test keys only:
+// by the last integration test and by orders made before the project was approved for production. Orders of project
+// which is in production without known approval time aren't counted
+func getIntegrationReadiness(
+	ctx echo.Context,
+	set common.HandlerSet,
+	log logger.Logger,
+	readiness *common.IntegrationReadiness,
+	project *billing.Project,
+) (*integrationReadinessReport, error) {
+	lastTest, err := readiness.GetTestResult(project.Id)
+
+	if err != nil {
+		log.Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	liveAt, err := readiness.GetLiveAt(project.Id)
+
+	if err != nil {
+		log.Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "project_id", project.Id))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	report := &integrationReadinessReport{ProjectId: project.Id}
+	paid, refunded := false, false
+
+	if project.Status != pkg.ProjectStatusInProduction || !liveAt.IsZero() {
+		if !liveAt.IsZero() {
+			report.LiveAt = liveAt.Unix()
+		}
+
+		if paid, err = hasTestOrders(ctx, set, log, project, riskOrderStatusProcessed, liveAt); err != nil {
+			return nil, err
+		}
+
+		if refunded, err = hasTestOrders(ctx, set, log, project, riskOrderStatusRefunded, liveAt); err != nil {
+			return nil, err
+		}
+	}
+
+	passed := make(map[string]bool)
+
+	if lastTest != nil {
+		report.LastTestAt = lastTest.TestedAt
+		passed = lastTest.Checks
+	}
+
+	// refund notifications are sent to the same url as payment notifications
+	report.Flows = []*integrationReadinessFlow{
+		{Name: integrationReadinessPayment, Required: true, Exercised: paid},
+		{
+			Name:      integrationTestCheckAccount,
+			Required:  project.UrlCheckAccount != "",
+			Exercised: passed[integrationTestCheckAccount],
+		},
+		{
+			Name:      integrationTestProcessPayment,
+			Required:  project.UrlProcessPayment != "",
+			Exercised: passed[integrationTestProcessPayment],
+		},
+		{Name: integrationReadinessRefund, Required: project.UrlProcessPayment != "", Exercised: refunded},
+	}
+
+	required, exercised := 0, 0
+
+	for _, flow := range report.Flows {
+		if !flow.Required {
+			continue
+		}
+
+		required++
+
+		if flow.Exercised {
+			exercised++
+		}
+	}
+
+	report.Score = exercised * 100 / required
+
+	return report, nil
+}
+
+// hasTestOrders checks the project has orders of the status made before liveAt, all orders when it's zero
+func hasTestOrders(
+	ctx echo.Context,
+	set common.HandlerSet,
+	log logger.Logger,
+	project *billing.Project,
+	status string,
+	liveAt time.Time,
+) (bool, error) {
+	req := &grpc.ListOrdersRequest{
+		Merchant: []string{project.MerchantId},
+		Project:  []string{project.Id},
+		Status:   []string{status},
+		Limit:    1,
+	}
+
+	if !liveAt.IsZero() {
+		req.PmDateTo = liveAt.Unix()
+	}
+
+	res, err := set.Services.Billing.FindAllOrdersPublic(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(log, err, pkg.ServiceName, "FindAllOrdersPublic", req)
+		return false, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return false, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.GetItem().GetCount() > 0, nil
+}
+
+func (h *ProjectIntegrationRoute) runCheck(
+	ctx context.Context,
+	project *billing.Project,
+	name, rawUrl string,
+	object map[string]interface{},
+) *integrationTestCheck {
+	check := &integrationTestCheck{Name: name, Url: rawUrl}
+
+	if rawUrl == "" {
+		check.Skipped = true
+		check.errors = append(check.errors, integrationTestErrorUrlEmpty)
+		return check
+	}
+
+	u, err := url.Parse(rawUrl)
+
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		check.errors = append(check.errors, integrationTestErrorUrlInvalid)
+		return check
+	}
+
+	payload := &integrationTestPayload{
+		Id:        uuid.New().String(),
+		Type:      name,
+		Test:      true,
+		ProjectId: project.Id,
+		CreatedAt: time.Now().Unix(),
+		Object:    object,
+	}
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+
+	if err != nil {
+		check.errors = append(check.errors, fmt.Sprintf(integrationTestErrorRequest, err.Error()))
+		return check
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(integrationTestSignatureHeader, getIntegrationTestSignature(body, project.SecretKey))
+
+	rsp, err := h.httpClient.Do(req)
+
+	if err != nil {
+		check.errors = append(check.errors, fmt.Sprintf(integrationTestErrorRequest, err.Error()))
+		return check
+	}
+
+	defer rsp.Body.Close()
+
+	rspBody, err := ioutil.ReadAll(io.LimitReader(rsp.Body, integrationTestMaxResponseSize))
+
+	if err != nil {
+		check.errors = append(check.errors, fmt.Sprintf(integrationTestErrorRequest, err.Error()))
+		return check
+	}
+
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		check.errors = append(check.errors, fmt.Sprintf(integrationTestErrorStatus, rsp.StatusCode))
+	}
+
+	if len(rspBody) > 0 && !json.Valid(rspBody) {
+		check.errors = append(check.errors, integrationTestErrorFormat)
+	}
+
+	signature := rsp.Header.Get(integrationTestSignatureHeader)
+
+	if signature == "" && project.SignatureRequired {
+		check.errors = append(check.errors, integrationTestErrorSignatureRequired)
+	}
+
+	if signature != "" && signature != getIntegrationTestSignature(rspBody, project.SecretKey) {
+		check.errors = append(check.errors, integrationTestErrorSignatureInvalid)
+	}
+
+	check.Passed = len(check.errors) == 0
+
+	return check
+}
+
+// controlDial rejects connection to address of integrationTestDeniedNetworks not listed in allowedNetworks
+func (h *ProjectIntegrationRoute) controlDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		return errorIntegrationTestAddressDenied
+	}
+
+	for _, allowed := range h.allowedNetworks {
+		if allowed.Contains(ip) {
+			return nil
+		}
+	}
+
+	for _, denied := range integrationTestDeniedNetworks {
+		if denied.Contains(ip) {
+			return errorIntegrationTestAddressDenied
+		}
+	}
+
+	return nil
+}
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	var networks []*net.IPNet
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+
+		if err != nil {
+			panic(err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func getIntegrationTestSignature(body []byte, secret string) string {
+	h := sha256.New()
+	h.Write(body)
+	h.Write([]byte(secret))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
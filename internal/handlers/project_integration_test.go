@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type ProjectIntegrationTestSuite struct {
+	suite.Suite
+	router *ProjectIntegrationRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_ProjectIntegration(t *testing.T) {
+	suite.Run(t, new(ProjectIntegrationTestSuite))
+}
+
+func (suite *ProjectIntegrationTestSuite) SetupTest() {
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		// test servers listen on loopback address
+		set.GlobalConfig.IntegrationTestAllowedNetworks = []string{"127.0.0.1"}
+		suite.router = NewProjectIntegrationRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *ProjectIntegrationTestSuite) TearDownTest() {}
+
+func (suite *ProjectIntegrationTestSuite) setProject(project *billing.Project) {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}, nil)
+	suite.router.dispatch.Services.Billing = bill
+}
+
+func (suite *ProjectIntegrationTestSuite) executeRaw(projectId string) []byte {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsIntegrationTestPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	return res.Body.Bytes()
+}
+
+func (suite *ProjectIntegrationTestSuite) execute(projectId string) *integrationTestReport {
+	report := &integrationTestReport{}
+	err := json.Unmarshal(suite.executeRaw(projectId), report)
+	assert.NoError(suite.T(), err)
+
+	return report
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Run_Ok() {
+	secret := "secret"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if r.Header.Get(integrationTestSignatureHeader) != getIntegrationTestSignature(body, secret) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		rsp := []byte(`{"status": "ok"}`)
+		w.Header().Set(integrationTestSignatureHeader, getIntegrationTestSignature(rsp, secret))
+		_, _ = w.Write(rsp)
+	}))
+	defer srv.Close()
+
+	project := &billing.Project{
+		Id:                bson.NewObjectId().Hex(),
+		MerchantId:        mock.OnboardingMerchantMock.Id,
+		SecretKey:         secret,
+		SignatureRequired: true,
+		UrlCheckAccount:   srv.URL + "/check",
+		UrlProcessPayment: srv.URL + "/payment",
+	}
+	suite.setProject(project)
+
+	report := suite.execute(project.Id)
+	assert.True(suite.T(), report.Passed)
+	assert.Len(suite.T(), report.Checks, 2)
+
+	for _, check := range report.Checks {
+		assert.True(suite.T(), check.Passed)
+	}
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Run_Failed() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("internal error"))
+	}))
+	defer srv.Close()
+
+	project := &billing.Project{
+		Id:              bson.NewObjectId().Hex(),
+		MerchantId:      mock.OnboardingMerchantMock.Id,
+		SecretKey:       "secret",
+		UrlCheckAccount: srv.URL,
+	}
+	suite.setProject(project)
+
+	report := suite.execute(project.Id)
+	assert.False(suite.T(), report.Passed)
+	assert.Len(suite.T(), report.Checks, 2)
+
+	assert.Equal(suite.T(), integrationTestCheckAccount, report.Checks[0].Name)
+	assert.False(suite.T(), report.Checks[0].Passed)
+	assert.NotContains(suite.T(), string(suite.executeRaw(project.Id)), "internal error")
+
+	assert.Equal(suite.T(), integrationTestProcessPayment, report.Checks[1].Name)
+	assert.True(suite.T(), report.Checks[1].Skipped)
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Run_PrivateAddressDenied() {
+	requested := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+
+	suite.router.allowedNetworks = nil
+	project := &billing.Project{
+		Id:              bson.NewObjectId().Hex(),
+		MerchantId:      mock.OnboardingMerchantMock.Id,
+		UrlCheckAccount: srv.URL,
+	}
+	suite.setProject(project)
+
+	report := suite.execute(project.Id)
+	assert.False(suite.T(), report.Passed)
+	assert.False(suite.T(), report.Checks[0].Passed)
+	assert.False(suite.T(), requested)
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Run_RedirectNotFollowed() {
+	requested := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer target.Close()
+
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer srv.Close()
+
+	project := &billing.Project{
+		Id:              bson.NewObjectId().Hex(),
+		MerchantId:      mock.OnboardingMerchantMock.Id,
+		UrlCheckAccount: srv.URL,
+	}
+	suite.setProject(project)
+
+	report := suite.execute(project.Id)
+	assert.False(suite.T(), report.Checks[0].Passed)
+	assert.False(suite.T(), requested)
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Run_ProjectOfOtherMerchant() {
+	requested := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer srv.Close()
+
+	project := &billing.Project{
+		Id:              bson.NewObjectId().Hex(),
+		MerchantId:      bson.NewObjectId().Hex(),
+		SecretKey:       "secret",
+		UrlCheckAccount: srv.URL,
+	}
+	suite.setProject(project)
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.AuthUserGroupPath + projectsIntegrationTestPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.False(suite.T(), requested)
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Run_BillingServerError() {
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + projectsIntegrationTestPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+}
+
+func (suite *ProjectIntegrationTestSuite) setOrders(bill *billMock.BillingService, processed, refunded int32) {
+	for status, count := range map[string]int32{riskOrderStatusProcessed: processed, riskOrderStatusRefunded: refunded} {
+		status := status
+		bill.On("FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+			return len(req.Status) == 1 && req.Status[0] == status
+		})).Return(&grpc.ListOrdersPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &grpc.ListOrdersPublicResponseItem{Count: count},
+		}, nil)
+	}
+}
+
+func (suite *ProjectIntegrationTestSuite) getReadiness(projectId string) *integrationReadinessReport {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+projectsIntegrationReadinessPath).
+		SetQueryParam(common.RequestParameterProjectId, projectId).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	report := &integrationReadinessReport{}
+	err = json.Unmarshal(res.Body.Bytes(), report)
+	assert.NoError(suite.T(), err)
+
+	return report
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Readiness_Ok() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+
+	project := &billing.Project{
+		Id:              bson.NewObjectId().Hex(),
+		MerchantId:      mock.OnboardingMerchantMock.Id,
+		SecretKey:       "secret",
+		UrlCheckAccount: srv.URL,
+	}
+	suite.setProject(project)
+	suite.setOrders(suite.router.dispatch.Services.Billing.(*billMock.BillingService), 1, 0)
+
+	testReport := suite.execute(project.Id)
+	assert.True(suite.T(), testReport.Passed)
+
+	report := suite.getReadiness(project.Id)
+	assert.Equal(suite.T(), 100, report.Score)
+	assert.Equal(suite.T(), testReport.TestedAt, report.LastTestAt)
+	assert.Len(suite.T(), report.Flows, 4)
+
+	for _, flow := range report.Flows {
+		switch flow.Name {
+		case integrationReadinessPayment, integrationTestCheckAccount:
+			assert.True(suite.T(), flow.Required)
+			assert.True(suite.T(), flow.Exercised)
+		default:
+			assert.False(suite.T(), flow.Required)
+		}
+	}
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Readiness_NotTested() {
+	project := &billing.Project{
+		Id:                bson.NewObjectId().Hex(),
+		MerchantId:        mock.OnboardingMerchantMock.Id,
+		UrlCheckAccount:   "https://unit.test/check",
+		UrlProcessPayment: "https://unit.test/payment",
+	}
+	suite.setProject(project)
+	suite.setOrders(suite.router.dispatch.Services.Billing.(*billMock.BillingService), 1, 0)
+
+	report := suite.getReadiness(project.Id)
+	assert.Equal(suite.T(), 25, report.Score)
+	assert.Zero(suite.T(), report.LastTestAt)
+
+	for _, flow := range report.Flows {
+		assert.True(suite.T(), flow.Required)
+		assert.Equal(suite.T(), flow.Name == integrationReadinessPayment, flow.Exercised)
+	}
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Readiness_ProjectOfOtherMerchant() {
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), MerchantId: bson.NewObjectId().Hex()}
+	suite.setProject(project)
+	bill := suite.router.dispatch.Services.Billing.(*billMock.BillingService)
+	suite.setOrders(bill, 1, 1)
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+projectsIntegrationReadinessPath).
+		SetQueryParam(common.RequestParameterProjectId, project.Id).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	bill.AssertNotCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Readiness_LiveProject_TestOrdersOnly() {
+	project := &billing.Project{
+		Id:         bson.NewObjectId().Hex(),
+		MerchantId: mock.OnboardingMerchantMock.Id,
+		Status:     pkg.ProjectStatusInProduction,
+	}
+	suite.setProject(project)
+	bill := suite.router.dispatch.Services.Billing.(*billMock.BillingService)
+	suite.setOrders(bill, 1, 0)
+
+	report := suite.getReadiness(project.Id)
+	assert.Zero(suite.T(), report.Score)
+	assert.Zero(suite.T(), report.LiveAt)
+	bill.AssertNotCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.Anything)
+
+	liveAt := time.Now().Add(-time.Hour)
+	err := common.NewIntegrationReadiness(test.Redis(), time.Hour).SetLiveAt(project.Id, liveAt)
+	assert.NoError(suite.T(), err)
+
+	report = suite.getReadiness(project.Id)
+	assert.Equal(suite.T(), 100, report.Score)
+	assert.Equal(suite.T(), liveAt.Unix(), report.LiveAt)
+	bill.AssertCalled(suite.T(), "FindAllOrdersPublic", mock2.Anything, mock2.MatchedBy(func(req *grpc.ListOrdersRequest) bool {
+		return req.PmDateTo == liveAt.Unix()
+	}))
+}
+
+func (suite *ProjectIntegrationTestSuite) TestProjectIntegration_Readiness_ValidationError() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+projectsIntegrationReadinessPath).
+		SetQueryParam(common.RequestParameterProjectId, "qwerty").
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/globalsign/mgo/bson"
 	"github.com/labstack/echo/v4"
+	"github.com/micro/go-micro/client"
 	"github.com/paysuper/paysuper-billing-server/pkg"
 	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
@@ -17,7 +20,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"net/http"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 type ProjectTestSuite struct {
@@ -76,6 +82,39 @@ func (suite *ProjectTestSuite) TestProject_CreateProject_Ok() {
 	assert.NotEmpty(suite.T(), res.Body.String())
 }
 
+func (suite *ProjectTestSuite) TestProject_CreateProject_AmountPrecisionError() {
+	body := &billing.Project{
+		MerchantId:         bson.NewObjectId().Hex(),
+		Name:               map[string]string{"en": "A", "ru": "А"},
+		CallbackCurrency:   "JPY",
+		CallbackProtocol:   pkg.ProjectCallbackProtocolEmpty,
+		LimitsCurrency:     "JPY",
+		MinPaymentAmount:   0.5,
+		MaxPaymentAmount:   15000,
+		IsProductsCheckout: false,
+	}
+
+	b, err := json.Marshal(&body)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(test.ReqInitJSON()).
+		BodyBytes(b).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageAmountPrecisionInvalid.Code, msg.Code)
+}
+
 func (suite *ProjectTestSuite) TestProject_CreateProject_BindError() {
 	body := `{"name": "qwerty"}`
 
@@ -352,6 +391,30 @@ func (suite *ProjectTestSuite) TestProject_GetProject_BillingServerResultError()
 	assert.Equal(suite.T(), mock.SomeError, httpErr.Message)
 }
 
+func (suite *ProjectTestSuite) TestProject_GetProject_ProjectScope_AccessDenied() {
+	user := &common.AuthUser{
+		Id:       bson.NewObjectId().Hex(),
+		Projects: map[string]bool{bson.NewObjectId().Hex(): true},
+	}
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + projectsIdPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			middleware.Pre(test.PreAuthUserMiddleware(user))
+		}).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageAccessDenied, httpErr.Message)
+}
+
 func (suite *ProjectTestSuite) TestProject_ListProjects_Ok() {
 
 	res, err := suite.caller.Builder().
@@ -419,6 +482,202 @@ func (suite *ProjectTestSuite) TestProject_ListProjects_BillingServerError() {
 	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
 }
 
+func (suite *ProjectTestSuite) TestProject_ListProjects_Envelope_Ok() {
+	bill := &billMock.BillingService{}
+	bill.On("ListProjects", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListProjectsResponse{Count: 1, Items: []*billing.Project{{Id: bson.NewObjectId().Hex()}}}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam(common.RequestParameterLimit, "10").
+		SetQueryParam(common.RequestParameterOffset, "20").
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(test.ReqInitJSON()).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(common.HeaderXApiVersion, "2")
+		}).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), "2", res.Header().Get(common.HeaderXApiVersion))
+
+	envelope := &struct {
+		Data   []*billing.Project   `json:"data"`
+		Meta   *common.EnvelopeMeta `json:"meta"`
+		Errors []interface{}        `json:"errors"`
+	}{}
+	err = json.Unmarshal(res.Body.Bytes(), envelope)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), envelope.Data, 1)
+	assert.Equal(suite.T(), &common.EnvelopeMeta{Count: 1, Limit: 10, Offset: 20}, envelope.Meta)
+	assert.Empty(suite.T(), envelope.Errors)
+}
+
+func (suite *ProjectTestSuite) getProjectsByIdsBillingMock(projects ...*billing.Project) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(func(ctx context.Context, req *grpc.GetProjectRequest, opts ...client.CallOption) *grpc.ChangeProjectResponse {
+			for _, project := range projects {
+				if project.Id == req.ProjectId {
+					return &grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}
+				}
+			}
+
+			return &grpc.ChangeProjectResponse{Status: pkg.ResponseStatusNotFound, Message: mock.SomeError}
+		}, nil)
+
+	return bill
+}
+
+func (suite *ProjectTestSuite) TestProject_ListProjects_IdsFilter_Ok() {
+	ids := []string{bson.NewObjectId().Hex(), bson.NewObjectId().Hex(), bson.NewObjectId().Hex()}
+	bill := suite.getProjectsByIdsBillingMock(
+		&billing.Project{Id: ids[0], MerchantId: mock.OnboardingMerchantMock.Id},
+		&billing.Project{Id: ids[2], MerchantId: mock.OnboardingMerchantMock.Id},
+	)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam(common.RequestParameterIds, strings.Join(ids, ",")).
+		SetQueryParam(common.RequestParameterLimit, "1").
+		SetQueryParam(common.RequestParameterOffset, "1").
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	list := &grpc.ListProjectsResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), list))
+	assert.EqualValues(suite.T(), 2, list.Count)
+	assert.Len(suite.T(), list.Items, 1)
+	assert.Equal(suite.T(), ids[2], list.Items[0].Id)
+	bill.AssertNumberOfCalls(suite.T(), "GetProject", 3)
+	bill.AssertNotCalled(suite.T(), "ListProjects", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProjectTestSuite) TestProject_ListProjects_IdsFilter_OtherMerchantSkipped() {
+	ids := []string{bson.NewObjectId().Hex(), bson.NewObjectId().Hex()}
+	suite.router.dispatch.Services.Billing = suite.getProjectsByIdsBillingMock(
+		&billing.Project{Id: ids[0], MerchantId: mock.OnboardingMerchantMock.Id},
+		&billing.Project{Id: ids[1], MerchantId: bson.NewObjectId().Hex()},
+	)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam(common.RequestParameterIds, strings.Join(ids, ",")).
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	list := &grpc.ListProjectsResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), list))
+	assert.EqualValues(suite.T(), 1, list.Count)
+	assert.Len(suite.T(), list.Items, 1)
+	assert.Equal(suite.T(), ids[0], list.Items[0].Id)
+}
+
+func (suite *ProjectTestSuite) TestProject_ListProjects_IdsFilter_TooLong() {
+	ids := make([]string, common.IdsFilterMaxLength+1)
+
+	for i := range ids {
+		ids[i] = bson.NewObjectId().Hex()
+	}
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam(common.RequestParameterIds, strings.Join(ids, ",")).
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageIdsFilterTooLong, httpErr.Message)
+}
+
+func (suite *ProjectTestSuite) TestProject_ListProjects_Envelope_Error() {
+	suite.router.dispatch.Services.Billing = mock.NewBillingServerSystemErrorMock()
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(test.ReqInitJSON()).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(common.HeaderXApiVersion, "2")
+		}).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusInternalServerError, res.Code)
+
+	envelope := &struct {
+		Data   interface{}                  `json:"data"`
+		Errors []*grpc.ResponseErrorMessage `json:"errors"`
+	}{}
+	err = json.Unmarshal(res.Body.Bytes(), envelope)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), envelope.Data)
+	assert.Len(suite.T(), envelope.Errors, 1)
+	assert.Equal(suite.T(), common.ErrorUnknown.Code, envelope.Errors[0].Code)
+}
+
+func (suite *ProjectTestSuite) TestProject_ListProjects_LegacyVersion() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), "1", res.Header().Get(common.HeaderXApiVersion))
+
+	legacy := &grpc.ListProjectsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), legacy)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ProjectTestSuite) TestProject_GetProject_Envelope_Ok() {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + projectsIdPath).
+		Init(test.ReqInitJSON()).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(common.HeaderXApiVersion, "2")
+		}).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	envelope := &struct {
+		Data   *grpc.ChangeProjectResponse `json:"data"`
+		Meta   *common.EnvelopeMeta        `json:"meta"`
+		Errors []interface{}               `json:"errors"`
+	}{}
+	err = json.Unmarshal(res.Body.Bytes(), envelope)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), envelope.Data)
+	assert.NotNil(suite.T(), envelope.Data.Item)
+	assert.Nil(suite.T(), envelope.Meta)
+	assert.NotNil(suite.T(), envelope.Errors)
+	assert.Empty(suite.T(), envelope.Errors)
+}
+
 func (suite *ProjectTestSuite) TestProject_DeleteProject_Ok() {
 
 	res, err := suite.caller.Builder().
@@ -575,3 +834,730 @@ func (suite *ProjectTestSuite) TestProjectCheckSku_Ok() {
 
 	shouldBe.NoError(err)
 }
+
+func (suite *ProjectTestSuite) TestProject_UpdateVirtualCurrencyPrices_Ok() {
+	shouldBe := require.New(suite.T())
+	body := `{"prices": [{"currency": "USD", "amount": 0.15}, {"currency": "EUR", "amount": 0.13}]}`
+
+	project := &billing.Project{
+		Id:              bson.NewObjectId().Hex(),
+		MerchantId:      mock.OnboardingMerchantMock.Id,
+		VirtualCurrency: &billing.ProjectVirtualCurrency{},
+	}
+	billingService := &billMock.BillingService{}
+	billingService.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	billingService.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}, nil)
+	billingService.On("ChangeProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.AuthUserGroupPath + projectsVirtualCurrencyPricesPath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	shouldBe.NoError(err)
+	shouldBe.Equal(http.StatusOK, res.Code)
+
+	billingService.AssertCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.MatchedBy(func(req *billing.Project) bool {
+		return len(req.VirtualCurrency.Prices) == 2 && req.VirtualCurrency.Prices[0].Amount == 0.15
+	}))
+}
+
+func (suite *ProjectTestSuite) TestProject_GetVirtualCurrencyPrices_NotConfigured() {
+	shouldBe := require.New(suite.T())
+
+	billingService := &billMock.BillingService{}
+	billingService.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	billingService.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{MerchantId: mock.OnboardingMerchantMock.Id},
+		}, nil)
+	suite.router.dispatch.Services.Billing = billingService
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + projectsVirtualCurrencyPricesPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.Equal(http.StatusNotFound, httpErr.Code)
+}
+
+func (suite *ProjectTestSuite) getGoLiveBillingMock(
+	project *billing.Project,
+	productsTotal, ordersCount int32,
+) *billMock.BillingService {
+	billingService := &billMock.BillingService{}
+	billingService.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}, nil)
+	billingService.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	billingService.On("ListProducts", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListProductsResponse{Total: productsTotal}, nil)
+	billingService.On("ChangeProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: project}, nil)
+	billingService.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListOrdersPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &grpc.ListOrdersPublicResponseItem{Count: ordersCount},
+		}, nil)
+
+	return billingService
+}
+
+func (suite *ProjectTestSuite) TestProject_SubmitForReview_Ok() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{
+		Id:                 bson.NewObjectId().Hex(),
+		MerchantId:         mock.OnboardingMerchantMock.Id,
+		Status:             pkg.ProjectStatusDraft,
+		UrlRedirectSuccess: "https://example.com/success",
+		UrlRedirectFail:    "https://example.com/fail",
+		IsProductsCheckout: true,
+	}
+	billingService := suite.getGoLiveBillingMock(project, 1, 1)
+	suite.router.dispatch.Services.Billing = billingService
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.AuthUserGroupPath + projectsSubmitForReviewPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	shouldBe.NoError(err)
+	shouldBe.Equal(http.StatusOK, res.Code)
+	billingService.AssertCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.MatchedBy(func(req *billing.Project) bool {
+		return req.Status == pkg.ProjectStatusTestCompleted
+	}))
+}
+
+func (suite *ProjectTestSuite) TestProject_SubmitForReview_ChecklistFailed() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{
+		Id:                 bson.NewObjectId().Hex(),
+		MerchantId:         mock.OnboardingMerchantMock.Id,
+		Status:             pkg.ProjectStatusDraft,
+		UrlRedirectSuccess: "https://example.com/success",
+		IsProductsCheckout: true,
+	}
+	suite.router.dispatch.Services.Billing = suite.getGoLiveBillingMock(project, 0, 0)
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.AuthUserGroupPath + projectsSubmitForReviewPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.Equal(http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	shouldBe.True(ok)
+	shouldBe.Equal(common.ErrorMessageProjectGoLiveChecklistFailed.Code, msg.Code)
+	shouldBe.Equal(
+		projectChecklistRedirectUrls+","+projectChecklistReadiness+","+projectChecklistProducts,
+		msg.Details,
+	)
+}
+
+func (suite *ProjectTestSuite) TestProject_ApproveProject_NotOnReview() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusDraft}
+	suite.router.dispatch.Services.Billing = suite.getGoLiveBillingMock(project, 0, 0)
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.SystemGroupPath + projectsApprovePath).
+		Init(test.ReqInitJSON()).
+		Init(suite.operatorInit()).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.Equal(http.StatusBadRequest, httpErr.Code)
+	shouldBe.Equal(common.ErrorMessageProjectStatusTransitionInvalid, httpErr.Message)
+}
+
+func (suite *ProjectTestSuite) TestProject_ApproveProject_Ok() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusTestCompleted}
+	suite.router.dispatch.Services.Billing = suite.getGoLiveBillingMock(project, 0, 0)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.SystemGroupPath + projectsApprovePath).
+		Init(test.ReqInitJSON()).
+		Init(suite.operatorInit()).
+		Exec(suite.T())
+
+	shouldBe.NoError(err)
+	shouldBe.Equal(http.StatusOK, res.Code)
+	shouldBe.Equal(int32(pkg.ProjectStatusInProduction), project.Status)
+
+	liveAt, err := common.NewIntegrationReadiness(test.Redis(), time.Hour).GetLiveAt(project.Id)
+	shouldBe.NoError(err)
+	shouldBe.False(liveAt.IsZero())
+}
+
+func (suite *ProjectTestSuite) TestProject_SubmitForReview_ReadinessTooLow() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{
+		Id:                 bson.NewObjectId().Hex(),
+		MerchantId:         mock.OnboardingMerchantMock.Id,
+		Status:             pkg.ProjectStatusDraft,
+		UrlRedirectSuccess: "https://example.com/success",
+		UrlRedirectFail:    "https://example.com/fail",
+		UrlCheckAccount:    "https://example.com/check",
+	}
+	billingService := suite.getGoLiveBillingMock(project, 0, 1)
+	suite.router.dispatch.Services.Billing = billingService
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.AuthUserGroupPath + projectsSubmitForReviewPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.Equal(http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	shouldBe.True(ok)
+	shouldBe.Equal(projectChecklistReadiness, msg.Details)
+	billingService.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProjectTestSuite) TestProject_ApproveProject_MerchantUser_Forbidden() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusTestCompleted}
+	billingService := suite.getGoLiveBillingMock(project, 0, 0)
+	suite.router.dispatch.Services.Billing = billingService
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.SystemGroupPath + projectsApprovePath).
+		Init(test.ReqInitJSON()).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			middleware.Pre(test.PreAuthUserMiddleware(&common.AuthUser{Id: "ffffffffffffffffffffffff"}))
+		}).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.Equal(http.StatusForbidden, httpErr.Code)
+	shouldBe.Equal(common.ErrorMessageAccessDenied, httpErr.Message)
+	shouldBe.Equal(int32(pkg.ProjectStatusTestCompleted), project.Status)
+	billingService.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProjectTestSuite) TestProject_SubmitForReview_OtherMerchant_Forbidden() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{
+		Id:                 bson.NewObjectId().Hex(),
+		MerchantId:         bson.NewObjectId().Hex(),
+		Status:             pkg.ProjectStatusDraft,
+		UrlRedirectSuccess: "https://example.com/success",
+		UrlRedirectFail:    "https://example.com/fail",
+	}
+	billingService := suite.getGoLiveBillingMock(project, 1, 1)
+	suite.router.dispatch.Services.Billing = billingService
+
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPost, projectsSubmitForReviewPath, ""},
+		{http.MethodGet, projectsGoLiveChecklistPath, ""},
+		{http.MethodGet, projectsVirtualCurrencyPricesPath, ""},
+		{http.MethodPut, projectsVirtualCurrencyPricesPath, `{"prices": [{"currency": "USD", "amount": 0.15}]}`},
+	} {
+		_, err := suite.caller.Builder().
+			Method(req.method).
+			Params(":"+common.RequestParameterId, project.Id).
+			Path(common.AuthUserGroupPath + req.path).
+			Init(test.ReqInitJSON()).
+			BodyString(req.body).
+			Exec(suite.T())
+
+		shouldBe.Error(err, req.path)
+		httpErr, ok := err.(*echo.HTTPError)
+		shouldBe.True(ok)
+		shouldBe.Equal(http.StatusForbidden, httpErr.Code, req.path)
+		shouldBe.Equal(common.ErrorMessageAccessDenied, httpErr.Message)
+	}
+
+	shouldBe.Equal(int32(pkg.ProjectStatusDraft), project.Status)
+	billingService.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProjectTestSuite) TestProject_UpdateProject_Status_Error() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusDraft}
+	billingService := suite.getGoLiveBillingMock(project, 0, 0)
+	suite.router.dispatch.Services.Billing = billingService
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPatch).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.AuthUserGroupPath + projectsIdPath).
+		Init(test.ReqInitJSON()).
+		BodyString(fmt.Sprintf(`{"status": %d}`, pkg.ProjectStatusInProduction)).
+		Exec(suite.T())
+
+	shouldBe.Error(err)
+	httpErr, ok := err.(*echo.HTTPError)
+	shouldBe.True(ok)
+	shouldBe.Equal(http.StatusBadRequest, httpErr.Code)
+	shouldBe.Equal(common.ErrorMessageProjectStatusTransitionInvalid, httpErr.Message)
+	billingService.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProjectTestSuite) TestProject_RejectProject_Ok() {
+	shouldBe := require.New(suite.T())
+	project := &billing.Project{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusTestCompleted}
+	suite.router.dispatch.Services.Billing = suite.getGoLiveBillingMock(project, 0, 0)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterId, project.Id).
+		Path(common.SystemGroupPath + projectsRejectPath).
+		Init(test.ReqInitJSON()).
+		Init(suite.operatorInit()).
+		Exec(suite.T())
+
+	shouldBe.NoError(err)
+	shouldBe.Equal(http.StatusOK, res.Code)
+	shouldBe.Equal(int32(pkg.ProjectStatusTestFailed), project.Status)
+}
+
+func (suite *ProjectTestSuite) operatorInit() func(*http.Request, test.Middleware) {
+	return func(request *http.Request, middleware test.Middleware) {
+		middleware.Pre(test.PreAuthUserMiddleware(&common.AuthUser{Id: "operator", Name: "System User", Operator: true}))
+	}
+}
+
+func (suite *ProjectTestSuite) TestProject_ListIssues_Ok() {
+	projectId := bson.NewObjectId().Hex()
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPatch).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsIdPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"min_payment_amount": 10, "name": {"en": "Project"}}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	for i := 0; i < common.ProjectIssuesMinFailures; i++ {
+		issue, err := suite.router.projectIssues.RecordOrderCreate(projectId, "fm000023")
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), i == common.ProjectIssuesMinFailures-1, issue != nil)
+	}
+
+	_, err = suite.router.projectIssues.RecordOrderCreate(projectId, "")
+	assert.NoError(suite.T(), err)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsIssuesPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	var issues []*common.ProjectIssue
+	err = json.Unmarshal(res.Body.Bytes(), &issues)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), issues, 1)
+	assert.Equal(suite.T(), []string{"min_payment_amount", "name"}, issues[0].Settings)
+	assert.Equal(suite.T(), common.ProjectIssuesMinFailures+1, issues[0].Attempts)
+	assert.Equal(suite.T(), common.ProjectIssuesMinFailures, issues[0].Failures)
+	assert.Equal(suite.T(), common.ProjectIssuesMinFailures, issues[0].Errors["fm000023"])
+
+	msg := getProjectIssueMessage(issues[0])
+	assert.Contains(suite.T(), msg, "10 of 11 orders")
+	assert.Contains(suite.T(), msg, "min_payment_amount, name")
+	assert.Contains(suite.T(), msg, "fm000023 x10")
+}
+
+func (suite *ProjectTestSuite) TestProject_ListIssues_FailuresBeforeChange() {
+	projectId := bson.NewObjectId().Hex()
+
+	// successful order creation makes the project known, failures of unknown projects aren't counted
+	_, err := suite.router.projectIssues.RecordOrderCreate(projectId, "")
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < 2*common.ProjectIssuesMinFailures; i++ {
+		_, err = suite.router.projectIssues.RecordOrderCreate(projectId, "fm000023")
+		assert.NoError(suite.T(), err)
+	}
+
+	err = suite.router.projectIssues.RecordChange(projectId, bson.NewObjectId().Hex(), "", []string{"name"})
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < 2*common.ProjectIssuesMinFailures; i++ {
+		issue, err := suite.router.projectIssues.RecordOrderCreate(projectId, "fm000023")
+		assert.NoError(suite.T(), err)
+		assert.Nil(suite.T(), issue)
+	}
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsIssuesPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Equal(suite.T(), "[]", strings.TrimSpace(res.Body.String()))
+}
+
+func (suite *ProjectTestSuite) TestProject_ListIssues_ChangedSettingsOnly() {
+	projectId := bson.NewObjectId().Hex()
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPatch).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsIdPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"name": {"en": "A"}, "limits_currency": "RUB", "max_payment_amount": 20000}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	// the issue is detected and listed by any instance of the api
+	issues := common.NewProjectIssues(test.Redis())
+
+	for i := 0; i < common.ProjectIssuesMinFailures; i++ {
+		_, err = issues.RecordOrderCreate(projectId, "fm000023")
+		assert.NoError(suite.T(), err)
+	}
+
+	issue, err := suite.router.projectIssues.RecordOrderCreate(projectId, "fm000023")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), issue)
+
+	list, err := suite.router.projectIssues.List(projectId)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), list, 1)
+	assert.Equal(suite.T(), []string{"max_payment_amount"}, list[0].Settings)
+	assert.Equal(suite.T(), common.ProjectIssuesMinFailures+1, list[0].Failures)
+}
+
+func (suite *ProjectTestSuite) TestProject_ListIssues_FailuresOfUnknownProject() {
+	issue, err := suite.router.projectIssues.RecordOrderCreate("project", "fm000023")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), issue)
+
+	projectId := bson.NewObjectId().Hex()
+
+	for i := 0; i < 2*common.ProjectIssuesMinFailures; i++ {
+		_, err = suite.router.projectIssues.RecordOrderCreate(projectId, "fm000023")
+		assert.NoError(suite.T(), err)
+	}
+
+	err = suite.router.projectIssues.RecordChange(projectId, bson.NewObjectId().Hex(), "", []string{"name"})
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < common.ProjectIssuesMinFailures; i++ {
+		issue, err = suite.router.projectIssues.RecordOrderCreate(projectId, "fm000023")
+		assert.NoError(suite.T(), err)
+	}
+
+	// failures sent before the change weren't kept, so they don't make the baseline
+	assert.NotNil(suite.T(), issue)
+	assert.Zero(suite.T(), issue.BaselineRate)
+	assert.Equal(suite.T(), common.ProjectIssuesMinFailures, issue.Attempts)
+}
+
+func (suite *ProjectTestSuite) TestProject_ListIssues_IncorrectProjectId() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, "project").
+		Path(common.AuthUserGroupPath + projectsIssuesPath).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorIncorrectProjectId, httpErr.Message)
+}
+
+func (suite *ProjectTestSuite) TestProject_ListProjects_ProjectScope_Ok() {
+	projects := []*billing.Project{
+		{Id: bson.NewObjectId().Hex(), MerchantId: mock.OnboardingMerchantMock.Id},
+		{Id: bson.NewObjectId().Hex(), MerchantId: mock.OnboardingMerchantMock.Id},
+		{Id: bson.NewObjectId().Hex(), MerchantId: mock.OnboardingMerchantMock.Id},
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Id < projects[j].Id
+	})
+	user := &common.AuthUser{
+		Id:       bson.NewObjectId().Hex(),
+		Projects: map[string]bool{projects[0].Id: true, projects[2].Id: true},
+	}
+
+	bill := suite.getProjectsByIdsBillingMock(projects...)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		SetQueryParam(common.RequestParameterLimit, "1").
+		SetQueryParam(common.RequestParameterOffset, "1").
+		Path(common.AuthUserGroupPath + projectsPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			middleware.Pre(test.PreAuthUserMiddleware(user))
+		}).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &grpc.ListProjectsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 2, rsp.Count)
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), projects[2].Id, rsp.Items[0].Id)
+	bill.AssertNumberOfCalls(suite.T(), "GetProject", 2)
+	bill.AssertNotCalled(suite.T(), "ListProjects", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ProjectTestSuite) getRefundPolicyBillingMock(merchantId, projectMerchantId string) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: &billing.Merchant{Id: merchantId}}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: bson.NewObjectId().Hex(), MerchantId: projectMerchantId},
+		}, nil)
+
+	return bill
+}
+
+func (suite *ProjectTestSuite) TestProject_RefundPolicy_Ok() {
+	merchantId := bson.NewObjectId().Hex()
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(merchantId, merchantId)
+	projectId := bson.NewObjectId().Hex()
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsRefundPolicyPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.JSONEq(suite.T(), `{"max_days_after_purchase": 0, "max_refunds_per_order": 0, "auto_approve_threshold": 0}`, res.Body.String())
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsRefundPolicyPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"max_days_after_purchase": 14, "max_refunds_per_order": 1, "auto_approve_threshold": 100}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsRefundPolicyPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+
+	policy := &common.RefundPolicy{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), policy))
+	assert.EqualValues(suite.T(), 14, policy.MaxDaysAfterPurchase)
+	assert.EqualValues(suite.T(), 1, policy.MaxRefundsPerOrder)
+	assert.EqualValues(suite.T(), 100, policy.AutoApproveThreshold)
+}
+
+func (suite *ProjectTestSuite) TestProject_RefundPolicy_PoliciesLost_Error() {
+	merchantId := bson.NewObjectId().Hex()
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(merchantId, merchantId)
+	projectId := bson.NewObjectId().Hex()
+	test.RedisServer().FlushAll()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsRefundPolicyPath).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageRefundPolicyUnavailable, httpErr.Message)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + projectsPoliciesInitPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusNoContent, res.Code)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsRefundPolicyPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+}
+
+func (suite *ProjectTestSuite) TestProject_RefundPolicy_OtherMerchant_Error() {
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(bson.NewObjectId().Hex(), bson.NewObjectId().Hex())
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + projectsRefundPolicyPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"max_days_after_purchase": 14}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageAccessDenied, httpErr.Message)
+}
+
+func (suite *ProjectTestSuite) TestProject_RefundPolicy_ValidationError() {
+	merchantId := bson.NewObjectId().Hex()
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(merchantId, merchantId)
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + projectsRefundPolicyPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"max_days_after_purchase": -1}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
+
+func (suite *ProjectTestSuite) TestProject_EmailScreening_Ok() {
+	merchantId := bson.NewObjectId().Hex()
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(merchantId, merchantId)
+	projectId := bson.NewObjectId().Hex()
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsEmailScreeningPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"mode": "block", "mx_lookup": true}`).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsEmailScreeningPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.JSONEq(suite.T(), `{"mode": "block", "mx_lookup": true}`, res.Body.String())
+}
+
+func (suite *ProjectTestSuite) TestProject_EmailScreening_PoliciesLost_Error() {
+	merchantId := bson.NewObjectId().Hex()
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(merchantId, merchantId)
+	projectId := bson.NewObjectId().Hex()
+	test.RedisServer().FlushAll()
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsEmailScreeningPath).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageEmailScreeningPolicyUnavailable, httpErr.Message)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + projectsPoliciesInitPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusNoContent, res.Code)
+
+	res, err = suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterId, projectId).
+		Path(common.AuthUserGroupPath + projectsEmailScreeningPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.JSONEq(suite.T(), `{"mode": "", "mx_lookup": false}`, res.Body.String())
+}
+
+func (suite *ProjectTestSuite) TestProject_EmailScreening_ValidationError() {
+	merchantId := bson.NewObjectId().Hex()
+	suite.router.dispatch.Services.Billing = suite.getRefundPolicyBillingMock(merchantId, merchantId)
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPut).
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + projectsEmailScreeningPath).
+		Init(test.ReqInitJSON()).
+		BodyString(`{"mode": "reject"}`).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+}
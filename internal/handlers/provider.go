@@ -5,7 +5,12 @@ import (
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
 	awsWrapper "github.com/paysuper/paysuper-aws-manager"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/kyb"
+	"github.com/paysuper/paysuper-management-api/internal/money"
+	"github.com/paysuper/paysuper-management-api/internal/registry"
+	"github.com/paysuper/paysuper-management-api/internal/vault"
 	"gopkg.in/go-playground/validator.v9"
+	"path/filepath"
 )
 
 // ProviderHandlers
@@ -29,6 +34,16 @@ func ProviderHandlers(initial config.Initial, srv common.Services, validator *va
 		return nil, func() {}, err
 	}
 
+	agreementStorage, err := registerStorage(srv.Integrations, common.IntegrationStorageAgreement, awsManagerAgreement, common.StorageOptions{
+		AccessKeyId:     cfg.AwsAccessKeyIdAgreement,
+		SecretAccessKey: cfg.AwsSecretAccessKeyAgreement,
+		Region:          cfg.AwsRegionAgreement,
+		Bucket:          cfg.AwsBucketAgreement,
+	})
+	if err != nil {
+		return nil, func() {}, err
+	}
+
 	// Reporter S3 AWS Client
 	awsOptions = []awsWrapper.Option{
 		awsWrapper.AccessKeyId(cfg.AwsAccessKeyIdReporter),
@@ -41,13 +56,60 @@ func ProviderHandlers(initial config.Initial, srv common.Services, validator *va
 		return nil, func() {}, err
 	}
 
+	reporterStorage, err := registerStorage(srv.Integrations, common.IntegrationStorageReporter, awsManagerReporter, common.StorageOptions{
+		AccessKeyId:     cfg.AwsAccessKeyIdReporter,
+		SecretAccessKey: cfg.AwsSecretAccessKeyReporter,
+		Region:          cfg.AwsRegionReporter,
+		Bucket:          cfg.AwsBucketReporter,
+	})
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	roundingPolicies, err := money.NewRoundingPolicies(cfg.VatRoundingRules, cfg.CommissionRoundingRules)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	if err = money.SetCurrencyRounding(cfg.CurrencyRoundingRules); err != nil {
+		return nil, func() {}, err
+	}
+
+	kybProvider := kyb.NewHttpProvider(
+		cfg.KybProviderUrl,
+		cfg.KybProviderApiKey,
+		cfg.KybCallbackSecret,
+		cfg.KybProviderTimeout,
+	)
+
+	companyRegistry := registry.NewHttpProvider(
+		cfg.CompanyRegistryUrl,
+		cfg.CompanyRegistryApiKey,
+		cfg.CompanyRegistryTimeout,
+	)
+
+	cardVault := vault.NewHttpProvider(
+		cfg.CardTokenizationVaultUrl,
+		cfg.CardTokenizationVaultApiKey,
+		cfg.CardTokenizationVaultTimeout,
+	)
+
+	subdivisionsPath := cfg.SubdivisionsCatalogPath
+	if !filepath.IsAbs(subdivisionsPath) {
+		subdivisionsPath = filepath.Join(initial.WorkDir, subdivisionsPath)
+	}
+	subdivisions, err := common.NewSubdivisions(subdivisionsPath)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
 	return []common.Handler{
 		NewCardPayWebHook(hSet, &copyCfg),
 		NewCountryApiV1(hSet, &copyCfg),
 		NewDashboardRoute(hSet, &copyCfg),
 		NewKeyRoute(hSet, &copyCfg),
 		NewKeyProductRoute(hSet, &copyCfg),
-		NewOnboardingRoute(hSet, initial, awsManagerAgreement, &copyCfg),
+		NewOnboardingRoute(hSet, initial, agreementStorage, &copyCfg),
 		NewOrderRoute(hSet, &copyCfg),
 		NewPayLinkRoute(hSet, &copyCfg),
 		NewPaymentCostRoute(hSet, &copyCfg),
@@ -55,7 +117,7 @@ func ProviderHandlers(initial config.Initial, srv common.Services, validator *va
 		NewPriceGroupRoute(hSet, &copyCfg),
 		NewProductRoute(hSet, &copyCfg),
 		NewProjectRoute(hSet, &copyCfg),
-		NewReportFileRoute(hSet, awsManagerReporter, &copyCfg),
+		NewReportFileRoute(hSet, reporterStorage, &copyCfg),
 		NewRoyaltyReportsRoute(hSet, &copyCfg),
 		NewTaxesRoute(hSet, &copyCfg),
 		NewTokenRoute(hSet, &copyCfg),
@@ -66,5 +128,46 @@ func ProviderHandlers(initial config.Initial, srv common.Services, validator *va
 		NewPayoutDocumentsRoute(hSet, &copyCfg),
 		NewPricingRoute(hSet, &copyCfg),
 		NewRecurringRoute(hSet, &copyCfg),
+		NewRiskRoute(hSet, &copyCfg),
+		NewFeeCalculatorRoute(hSet, roundingPolicies, &copyCfg),
+		NewProjectIntegrationRoute(hSet, &copyCfg),
+		NewKybRoute(hSet, kybProvider, &copyCfg),
+		NewLimitsRoute(hSet, &copyCfg),
+		NewDeprecationsRoute(hSet, &copyCfg),
+		NewSandboxRoute(hSet, &copyCfg),
+		NewAdjustmentsRoute(hSet, &copyCfg),
+		NewReviewQueueRoute(hSet, &copyCfg),
+		NewOffboardingRoute(hSet, &copyCfg),
+		NewEmbedRoute(hSet, &copyCfg),
+		NewChangelogRoute(hSet, &copyCfg),
+		NewAnalyticsRoute(hSet, &copyCfg),
+		NewIntegrationsRoute(hSet, &copyCfg),
+		NewRequestsRoute(hSet, &copyCfg),
+		NewSubdivisionsRoute(hSet, subdivisions, &copyCfg),
+		NewCompanyRegistryRoute(hSet, companyRegistry, &copyCfg),
+		NewCacheRoute(hSet, &copyCfg),
+		NewTokenizationRoute(hSet, cardVault, &copyCfg),
 	}, func() {}, nil
 }
+
+// registerStorage adds s3 bucket to integrations, its uploads and downloads are counted through the returned manager
+func registerStorage(
+	integrations *common.Integrations,
+	name string,
+	manager awsWrapper.AwsManagerInterface,
+	opts common.StorageOptions,
+) (awsWrapper.AwsManagerInterface, error) {
+	if integrations == nil {
+		return manager, nil
+	}
+
+	check, err := common.NewStorageCheck(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	integrations.RegisterDirect(name, common.IntegrationServiceS3, check)
+
+	return integrations.WrapStorage(name, manager), nil
+}
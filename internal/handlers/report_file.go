@@ -29,9 +29,10 @@ type reportFileRequest struct {
 }
 
 type ReportFileRoute struct {
-	dispatch   common.HandlerSet
-	awsManager awsWrapper.AwsManagerInterface
-	cfg        common.Config
+	dispatch    common.HandlerSet
+	awsManager  awsWrapper.AwsManagerInterface
+	cfg         common.Config
+	exportSlots *common.ExportSlots
 	provider.LMT
 }
 
@@ -46,6 +47,7 @@ func NewReportFileRoute(set common.HandlerSet, awsManager awsWrapper.AwsManagerI
 }
 
 func (h *ReportFileRoute) Route(groups *common.Groups) {
+	h.exportSlots = common.NewExportSlots(groups.Redis, h.cfg.ExportConcurrencyLimit)
 	groups.AuthUser.POST(reportFilePath, h.create)
 	groups.AuthUser.GET(reportFileDownloadPath, h.download)
 }
@@ -66,6 +68,14 @@ func (h *ReportFileRoute) create(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestDataInvalid)
 	}
 
+	// report of token restricted to project scope must be built for one allowed project, report without
+	// project filter covers all projects of the merchant
+	projectId, _ := data.Params[common.RequestParameterProjectId].(string)
+
+	if len(authUser.Projects) > 0 && (projectId == "" || !authUser.IsProjectAllowed(projectId)) {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
 	params, err := json.Marshal(data.Params)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorRequestDataInvalid)
@@ -76,6 +86,19 @@ func (h *ReportFileRoute) create(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
+	// exports are capped by the merchant of the user, merchant of the request is set by the client
+	merchant, err := getAuthUserMerchant(ctx, h.dispatch, h.L())
+
+	if err != nil {
+		return err
+	}
+
+	slot, ok := h.exportSlots.Acquire(merchant.Id, h.cfg.ReportFileExportSlotTtl)
+
+	if !ok {
+		return echo.NewHTTPError(http.StatusTooManyRequests, common.ErrorMessageTooManyRequests)
+	}
+
 	req := &reporterProto.ReportFile{
 		UserId:           authUser.Id,
 		MerchantId:       data.MerchantId,
@@ -88,6 +111,7 @@ func (h *ReportFileRoute) create(ctx echo.Context) error {
 
 	res, err := h.dispatch.Services.Reporter.CreateFile(ctx.Request().Context(), req)
 	if err != nil {
+		h.exportSlots.Release(merchant.Id, slot)
 		common.LogSrvCallFailedGRPC(h.L(), err, reporterPkg.ServiceName, "CreateFile", req)
 		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorMessageCreateReportFile)
 	}
@@ -136,6 +136,93 @@ func (suite *ReportFileTestSuite) TestReportFile_create_Ok() {
 	assert.NoError(suite.T(), err)
 }
 
+func (suite *ReportFileTestSuite) TestReportFile_create_ExportLimitExceeded() {
+	data := `{"merchant_id": "507f1f77bcf86cd799439011", "file_type": "pdf", "report_type": "vat"}`
+	suite.router.exportSlots = common.NewExportSlots(test.Redis(), 1)
+
+	reporterService := &reporterMocks.ReporterService{}
+	reporterService.
+		On("CreateFile", mock2.Anything, mock2.Anything).
+		Return(&reporterProto.CreateFileResponse{FileId: bson.NewObjectId().Hex()}, nil)
+	suite.router.dispatch.Services.Reporter = reporterService
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + reportFilePath).
+		Init(test.ReqInitJSON()).
+		BodyString(data).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + reportFilePath).
+		Init(test.ReqInitJSON()).
+		BodyString(data).
+		Exec(suite.T())
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, httpErr.Code)
+	reporterService.AssertNumberOfCalls(suite.T(), "CreateFile", 1)
+}
+
+func (suite *ReportFileTestSuite) TestReportFile_create_ExportLimitByUserMerchant() {
+	suite.router.exportSlots = common.NewExportSlots(test.Redis(), 1)
+
+	reporterService := &reporterMocks.ReporterService{}
+	reporterService.
+		On("CreateFile", mock2.Anything, mock2.Anything).
+		Return(&reporterProto.CreateFileResponse{FileId: bson.NewObjectId().Hex()}, nil)
+	suite.router.dispatch.Services.Reporter = reporterService
+
+	// merchant of the request doesn't give the user another slot
+	for i, merchantId := range []string{bson.NewObjectId().Hex(), bson.NewObjectId().Hex()} {
+		_, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Path(common.AuthUserGroupPath + reportFilePath).
+			Init(test.ReqInitJSON()).
+			BodyString(`{"merchant_id": "` + merchantId + `", "file_type": "pdf", "report_type": "vat"}`).
+			Exec(suite.T())
+
+		if i == 0 {
+			assert.NoError(suite.T(), err)
+			continue
+		}
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusTooManyRequests, httpErr.Code)
+	}
+
+	reporterService.AssertNumberOfCalls(suite.T(), "CreateFile", 1)
+}
+
+func (suite *ReportFileTestSuite) TestReportFile_create_Error_CreateFile_SlotReleased() {
+	suite.router.exportSlots = common.NewExportSlots(test.Redis(), 1)
+	data := `{"merchant_id": "507f1f77bcf86cd799439011", "file_type": "pdf", "report_type": "vat"}`
+
+	reporterService := &reporterMocks.ReporterService{}
+	reporterService.
+		On("CreateFile", mock2.Anything, mock2.Anything).
+		Return(nil, errors.New("error"))
+	suite.router.dispatch.Services.Reporter = reporterService
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Path(common.AuthUserGroupPath + reportFilePath).
+			Init(test.ReqInitJSON()).
+			BodyString(data).
+			Exec(suite.T())
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	}
+}
+
 func (suite *ReportFileTestSuite) TestReportFile_download_Error_EmptyId() {
 
 	_, err := suite.caller.Builder().
@@ -191,3 +278,26 @@ func (suite *ReportFileTestSuite) TestReportFile_download_Ok() {
 
 	assert.NoError(suite.T(), err)
 }
+
+func (suite *ReportFileTestSuite) TestReportFile_create_ProjectScope_AccessDenied() {
+	user := &common.AuthUser{
+		Id:       "ffffffffffffffffffffffff",
+		Projects: map[string]bool{bson.NewObjectId().Hex(): true},
+	}
+	data := `{"merchant_id": "507f1f77bcf86cd799439011", "file_type": "pdf", "report_type": "vat"}`
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + reportFilePath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			middleware.Pre(test.PreAuthUserMiddleware(user))
+		}).
+		BodyString(data).
+		Exec(suite.T())
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageAccessDenied, httpErr.Message)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	requestsPath = "/requests"
+
+	requestsStatusClassSuffix = "xx"
+)
+
+type requestsRequest struct {
+	DateFrom  int64  `query:"date_from" validate:"omitempty,min=0"`
+	DateTo    int64  `query:"date_to" validate:"omitempty,min=0"`
+	Method    string `query:"method" validate:"omitempty,oneof=GET POST PUT PATCH DELETE"`
+	Endpoint  string `query:"endpoint" validate:"omitempty,max=255"`
+	Status    string `query:"status" validate:"omitempty,len=3"`
+	ErrorCode string `query:"error_code" validate:"omitempty,max=32"`
+	RequestId string `query:"request_id" validate:"omitempty,max=64"`
+	Limit     int32  `query:"limit" validate:"omitempty,min=0"`
+}
+
+type requestsResponse struct {
+	Count int                       `json:"count"`
+	Items []*common.RequestLogEntry `json:"items"`
+}
+
+type RequestsRoute struct {
+	dispatch   common.HandlerSet
+	cfg        common.Config
+	requestLog *common.RequestLog
+	provider.LMT
+}
+
+// NewRequestsRoute
+func NewRequestsRoute(set common.HandlerSet, cfg *common.Config) *RequestsRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "RequestsRoute"})
+	return &RequestsRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *RequestsRoute) Route(groups *common.Groups) {
+	h.requestLog = groups.RequestLog
+	groups.AuthUser.GET(requestsPath, h.searchRequests)
+}
+
+// @Description Search requests of the merchant for the last 24 hours, the latest first: requests of users to admin api
+// @Description and requests to create orders, payments and tokens of its projects. Values of query parameters
+// @Description which may contain personal data and error details are redacted. Status filter is code (404) or class (4xx)
+// @Example curl -X GET -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/requests?status=4xx&endpoint=/order
+func (h *RequestsRoute) searchRequests(ctx echo.Context) error {
+	req := &requestsRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	status, err := parseRequestsStatus(req.Status)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageRequestsStatusInvalid)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = h.cfg.LimitDefault
+	}
+
+	if req.Limit > h.cfg.LimitMax {
+		req.Limit = h.cfg.LimitMax
+	}
+
+	filter := &common.RequestLogFilter{
+		Method:    req.Method,
+		Endpoint:  req.Endpoint,
+		Status:    status,
+		ErrorCode: req.ErrorCode,
+		RequestId: req.RequestId,
+		Limit:     int(req.Limit),
+	}
+
+	if req.DateFrom > 0 {
+		filter.From = time.Unix(req.DateFrom, 0)
+	}
+
+	if req.DateTo > 0 {
+		filter.To = time.Unix(req.DateTo, 0)
+	}
+
+	merchant, err := getAuthUserMerchant(ctx, h.dispatch, h.L())
+
+	if err != nil {
+		return err
+	}
+
+	res := &requestsResponse{Items: []*common.RequestLogEntry{}}
+
+	if h.requestLog != nil {
+		res.Items, err = h.requestLog.Search(merchant.Id, filter)
+
+		if err != nil {
+			h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+		}
+	}
+
+	res.Count = len(res.Items)
+	meta := &common.EnvelopeMeta{Count: int32(res.Count), Limit: req.Limit}
+
+	return common.ListResponse(ctx, res, res.Items, meta)
+}
+
+// parseRequestsStatus returns status code or status class (the first digit) for filter like 4xx
+func parseRequestsStatus(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	status, err := strconv.Atoi(strings.TrimSuffix(value, requestsStatusClassSuffix))
+
+	if err != nil || !(status >= 1 && status <= 5 || status >= 100 && status <= 599) {
+		return 0, common.ErrorMessageRequestsStatusInvalid
+	}
+
+	return status, nil
+}
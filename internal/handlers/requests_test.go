@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	requestsStubPath = "/requests_stub"
+
+	requestsProjectId = "5bdc39a95d1e1100019fb7df"
+	requestsOrderId   = "2d0e3f55-2e0c-4b5f-8c8a-1f0b8f0e6a11"
+)
+
+type requestsRouteStub struct{}
+
+func (r *requestsRouteStub) Route(groups *common.Groups) {
+	groups.AuthUser.GET(requestsStubPath, func(ctx echo.Context) error {
+		if ctx.QueryParam("fail") == "" {
+			return ctx.NoContent(http.StatusOK)
+		}
+
+		rspErr := *common.ErrorEmailFieldIncorrect
+		rspErr.Details = ctx.QueryParam("email")
+		return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+	})
+	groups.AuthProject.POST(requestsStubPath, func(ctx echo.Context) error {
+		if projectId := ctx.QueryParam("project"); projectId != "" {
+			common.SetRequestLogProject(ctx, projectId)
+		}
+
+		if orderId := ctx.QueryParam("order"); orderId != "" {
+			common.SetRequestLogOrder(ctx, orderId)
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	})
+}
+
+type RequestsTestSuite struct {
+	suite.Suite
+	router *RequestsRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Requests(t *testing.T) {
+	suite.Run(t, new(RequestsTestSuite))
+}
+
+func (suite *RequestsTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.MatchedBy(func(req *grpc.GetProjectRequest) bool {
+		return req.ProjectId == requestsProjectId
+	})).
+		Return(&grpc.ChangeProjectResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Project{Id: requestsProjectId, MerchantId: mock.OnboardingMerchantMock.Id},
+		}, nil)
+	bill.On("GetProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusNotFound}, nil)
+	bill.On("GetOrderPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetOrderPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.OrderViewPublic{Uuid: requestsOrderId, Project: &billing.ProjectOrder{Id: requestsProjectId}},
+		}, nil)
+
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: bill,
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewRequestsRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+			&requestsRouteStub{},
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	suite.router.cfg.LimitDefault = 100
+	suite.router.cfg.LimitMax = 1000
+}
+
+func (suite *RequestsTestSuite) TearDownTest() {}
+
+func (suite *RequestsTestSuite) callStub(fail bool) http.Header {
+	builder := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+requestsStubPath).
+		SetQueryParam("email", "customer@unit.test").
+		SetQueryParam(common.QueryParameterNameLimit, "10")
+
+	if fail {
+		builder.SetQueryParam("fail", "1")
+	}
+
+	res, _ := builder.Exec(suite.T())
+
+	return res.Header()
+}
+
+func (suite *RequestsTestSuite) search(status string) *requestsResponse {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath+requestsPath).
+		SetQueryParam("status", status).
+		SetQueryParam("endpoint", requestsStubPath).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &requestsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *RequestsTestSuite) TestRequests_Search_Ok() {
+	suite.callStub(false)
+	header := suite.callStub(true)
+
+	rsp := suite.search("4xx")
+	assert.Equal(suite.T(), 1, rsp.Count)
+
+	entry := rsp.Items[0]
+	assert.Equal(suite.T(), header.Get(echo.HeaderXRequestID), entry.RequestId)
+	assert.Equal(suite.T(), http.MethodGet, entry.Method)
+	assert.Equal(suite.T(), common.AuthUserGroupPath+requestsStubPath, entry.Endpoint)
+	assert.Equal(suite.T(), http.StatusBadRequest, entry.Status)
+	assert.Equal(suite.T(), common.ErrorEmailFieldIncorrect.Code, entry.ErrorCode)
+	assert.Equal(suite.T(), common.ErrorEmailFieldIncorrect.Message, entry.ErrorMessage)
+	assert.Contains(suite.T(), entry.Query, "email="+common.RequestLogRedacted)
+	assert.Contains(suite.T(), entry.Query, "limit=10")
+	assert.NotContains(suite.T(), entry.Query, "customer@unit.test")
+
+	rsp = suite.search("")
+	assert.Equal(suite.T(), 2, rsp.Count)
+	assert.Equal(suite.T(), http.StatusBadRequest, rsp.Items[0].Status)
+	assert.Equal(suite.T(), http.StatusOK, rsp.Items[1].Status)
+}
+
+func (suite *RequestsTestSuite) callProjectStub(query url.Values) {
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath+requestsStubPath).
+		SetQueryParams(query).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RequestsTestSuite) TestRequests_Search_IntegrationApi() {
+	suite.callProjectStub(url.Values{"project": {requestsProjectId}})
+	suite.callProjectStub(url.Values{"order": {requestsOrderId}})
+
+	// requests which can't be attributed to a merchant aren't logged
+	suite.callProjectStub(url.Values{"project": {bson.NewObjectId().Hex()}})
+	suite.callProjectStub(url.Values{"project": {"unknown"}})
+	suite.callProjectStub(url.Values{})
+
+	rsp := suite.search("4xx")
+	assert.Equal(suite.T(), 2, rsp.Count)
+
+	for _, entry := range rsp.Items {
+		assert.Equal(suite.T(), common.AuthProjectGroupPath+requestsStubPath, entry.Endpoint)
+		assert.Equal(suite.T(), common.ErrorRequestParamsIncorrect.Code, entry.ErrorCode)
+	}
+
+	// merchant of the project is resolved once
+	bill := suite.router.dispatch.Services.Billing.(*billMock.BillingService)
+	bill.AssertNumberOfCalls(suite.T(), "GetProject", 2)
+	bill.AssertNumberOfCalls(suite.T(), "GetOrderPublic", 1)
+}
+
+func (suite *RequestsTestSuite) TestRequests_Search_RecordedByOtherInstance() {
+	bill := suite.router.dispatch.Services.Billing.(*billMock.BillingService)
+	entry := &common.RequestLogEntry{
+		RequestId: "request",
+		Timestamp: time.Now(),
+		Method:    http.MethodPost,
+		Endpoint:  common.AuthProjectGroupPath + requestsStubPath,
+		Status:    http.StatusBadRequest,
+	}
+	err := common.NewRequestLog(test.Redis(), bill).Record(mock.OnboardingMerchantMock.Id, entry)
+	assert.NoError(suite.T(), err)
+
+	rsp := suite.search("")
+	assert.Equal(suite.T(), 1, rsp.Count)
+	assert.Equal(suite.T(), entry.RequestId, rsp.Items[0].RequestId)
+}
+
+func (suite *RequestsTestSuite) TestRequests_Search_ByStatusCode() {
+	suite.callStub(false)
+	suite.callStub(true)
+
+	rsp := suite.search("200")
+	assert.Equal(suite.T(), 1, rsp.Count)
+	assert.Empty(suite.T(), rsp.Items[0].ErrorCode)
+}
+
+func (suite *RequestsTestSuite) TestRequests_Search_StatusInvalid() {
+	for _, status := range []string{"4x1", "099", "6xx", "600"} {
+		_, err := suite.caller.Builder().
+			Method(http.MethodGet).
+			Path(common.AuthUserGroupPath+requestsPath).
+			SetQueryParam("status", status).
+			Exec(suite.T())
+
+		assert.Error(suite.T(), err)
+
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+		assert.Equal(suite.T(), common.ErrorMessageRequestsStatusInvalid, httpErr.Message)
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"time"
+)
+
+const (
+	reviewQueuePath         = "/review_queue"
+	reviewQueueClaimPath    = "/review_queue/:merchant_id/claim"
+	reviewQueueReleasePath  = "/review_queue/:merchant_id/release"
+	reviewQueueDecisionPath = "/review_queue/:merchant_id/decision"
+)
+
+type reviewQueueDecisionRequest struct {
+	Status  int32  `json:"status" validate:"required"`
+	Message string `json:"message" validate:"required"`
+}
+
+type reviewQueueItem struct {
+	Merchant     *billing.Merchant   `json:"merchant"`
+	Claim        *common.ReviewClaim `json:"claim"`
+	WaitingSince int64               `json:"waiting_since"`
+	SlaDueAt     int64               `json:"sla_due_at"`
+	Overdue      bool                `json:"overdue"`
+}
+
+type reviewQueueResponse struct {
+	Count int32              `json:"count"`
+	Items []*reviewQueueItem `json:"items"`
+}
+
+type ReviewQueueRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+	claims *common.ReviewClaims
+}
+
+// NewReviewQueueRoute
+func NewReviewQueueRoute(set common.HandlerSet, cfg *common.Config) *ReviewQueueRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "ReviewQueueRoute"})
+	return &ReviewQueueRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *ReviewQueueRoute) Route(groups *common.Groups) {
+	h.claims = common.NewReviewClaims(groups.Redis, h.cfg.ReviewQueueClaimTtl)
+	groups.System.GET(reviewQueuePath, h.listQueue)
+	groups.System.POST(reviewQueueClaimPath, h.claim)
+	groups.System.POST(reviewQueueReleasePath, h.release)
+	groups.System.POST(reviewQueueDecisionPath, h.decide)
+}
+
+// @Description Merchants awaiting onboarding review, oldest first, with reviewer who claimed the review and SLA timer.
+// @Description Review queue is available to operators with personal system api token only
+// @Example curl -X GET -H 'Authorization: Bearer %system_api_token_here%' \
+//  https://api.paysuper.online/system/api/v1/review_queue?limit=10&offset=0
+func (h *ReviewQueueRoute) listQueue(ctx echo.Context) error {
+	if !common.ExtractUserContext(ctx).Operator {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	reqCtx := ctx.Request().Context()
+	req := &grpc.MerchantListingRequest{}
+	err := (&common.OnboardingMerchantListingBinder{
+		LimitDefault:  h.cfg.LimitDefault,
+		OffsetDefault: h.cfg.OffsetDefault,
+	}).Bind(req, ctx)
+
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	req.Statuses = []int32{pkg.MerchantStatusAgreementSigning}
+	req.Sort = []string{"status_last_updated_at"}
+
+	res, err := h.dispatch.Services.Billing.ListMerchants(reqCtx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListMerchants", req)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	merchantIds := make([]string, len(res.Items))
+
+	for i, merchant := range res.Items {
+		merchantIds[i] = merchant.Id
+	}
+
+	claims, err := h.claims.GetMany(merchantIds)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	queue := &reviewQueueResponse{Count: res.Count, Items: make([]*reviewQueueItem, 0, len(res.Items))}
+	now := time.Now()
+
+	for _, merchant := range res.Items {
+		item := &reviewQueueItem{Merchant: merchant, Claim: claims[merchant.Id]}
+
+		if merchant.StatusLastUpdatedAt != nil {
+			item.WaitingSince = merchant.StatusLastUpdatedAt.Seconds
+			item.SlaDueAt = time.Unix(item.WaitingSince, 0).Add(h.cfg.ReviewQueueSla).Unix()
+			item.Overdue = now.Unix() > item.SlaDueAt
+		}
+
+		queue.Items = append(queue.Items, item)
+	}
+
+	return ctx.JSON(http.StatusOK, queue)
+}
+
+// @Description Assign review of the merchant to the operator, review claimed by other operator must be released
+// @Description or expire first
+// @Example curl -X POST -H 'Authorization: Bearer %system_api_token_here%' \
+//  https://api.paysuper.online/system/api/v1/review_queue/%merchant_id_here%/claim
+func (h *ReviewQueueRoute) claim(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+
+	if !authUser.Operator {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	merchant, err := h.getPendingMerchant(ctx.Request().Context(), ctx.Param(common.RequestParameterMerchantId))
+
+	if err != nil {
+		return err
+	}
+
+	claim, err := h.claims.Claim(merchant.Id, authUser.Id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if claim.Reviewer != authUser.Id {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageReviewClaimedByOther)
+	}
+
+	return ctx.JSON(http.StatusOK, claim)
+}
+
+// @Description Return review of the merchant claimed by the operator back to the queue
+// @Example curl -X POST -H 'Authorization: Bearer %system_api_token_here%' \
+//  https://api.paysuper.online/system/api/v1/review_queue/%merchant_id_here%/release
+func (h *ReviewQueueRoute) release(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+
+	if !authUser.Operator {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	merchant, err := h.getPendingMerchant(ctx.Request().Context(), ctx.Param(common.RequestParameterMerchantId))
+
+	if err != nil {
+		return err
+	}
+
+	if err = h.releaseClaim(merchant.Id, authUser.Id); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// @Description Record decision of the operator who claimed the review, merchant leaves the queue with the new status
+// @Example curl -X POST -H 'Authorization: Bearer %system_api_token_here%' -H 'Content-Type: application/json' \
+//  -d '{"status": 6, "message": "incomplete company documents"}' \
+//  https://api.paysuper.online/system/api/v1/review_queue/%merchant_id_here%/decision
+func (h *ReviewQueueRoute) decide(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+
+	if !authUser.Operator {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	req := &reviewQueueDecisionRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	reqCtx := ctx.Request().Context()
+	merchant, err := h.getPendingMerchant(reqCtx, ctx.Param(common.RequestParameterMerchantId))
+
+	if err != nil {
+		return err
+	}
+
+	claim, err := h.claims.Get(merchant.Id)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if claim == nil || claim.Reviewer != authUser.Id {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageReviewNotClaimed)
+	}
+
+	sReq := &grpc.MerchantChangeStatusRequest{
+		MerchantId: merchant.Id,
+		UserId:     authUser.Id,
+		Status:     req.Status,
+		Message:    req.Message,
+	}
+	res, err := h.dispatch.Services.Billing.ChangeMerchantStatus(reqCtx, sReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ChangeMerchantStatus", sReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	// merchant has left the queue, claim expires by itself when release fails
+	if _, err = h.claims.Release(merchant.Id, claim); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchant.Id))
+	}
+
+	return ctx.JSON(http.StatusOK, res.Item)
+}
+
+// getPendingMerchant returns merchant awaiting review
+func (h *ReviewQueueRoute) getPendingMerchant(ctx context.Context, merchantId string) (*billing.Merchant, error) {
+	if !bson.IsObjectIdHex(merchantId) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectMerchantId)
+	}
+
+	req := &grpc.GetMerchantByRequest{MerchantId: merchantId}
+	res, err := h.dispatch.Services.Billing.GetMerchantBy(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	if res.Item.Status != pkg.MerchantStatusAgreementSigning {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageReviewMerchantNotPending)
+	}
+
+	return res.Item, nil
+}
+
+// releaseClaim removes claim of the merchant review taken by the reviewer
+func (h *ReviewQueueRoute) releaseClaim(merchantId, reviewer string) error {
+	claim, err := h.claims.Get(merchantId)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if claim == nil || claim.Reviewer != reviewer {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageReviewNotClaimed)
+	}
+
+	released, err := h.claims.Release(merchantId, claim)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if !released {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageReviewNotClaimed)
+	}
+
+	return nil
+}
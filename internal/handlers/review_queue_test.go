@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/globalsign/mgo/bson"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	reviewQueueReviewer      = "5bdc39a95d1e1100019fb7df"
+	reviewQueueOtherReviewer = "5bdc39a95d1e1100019fb7e0"
+)
+
+type ReviewQueueTestSuite struct {
+	suite.Suite
+	router   *ReviewQueueRoute
+	caller   *test.EchoReqResCaller
+	merchant *billing.Merchant
+	user     *common.AuthUser
+	claims   *common.ReviewClaims
+}
+
+func Test_ReviewQueue(t *testing.T) {
+	suite.Run(t, new(ReviewQueueTestSuite))
+}
+
+func (suite *ReviewQueueTestSuite) SetupTest() {
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.user = &common.AuthUser{Id: reviewQueueReviewer, Name: "System User", Operator: true}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(suite.user))
+		suite.router = NewReviewQueueRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	suite.router.cfg.ReviewQueueSla = 48 * time.Hour
+	suite.claims = common.NewReviewClaims(test.Redis(), time.Hour)
+	suite.merchant = &billing.Merchant{
+		Id:                  bson.NewObjectId().Hex(),
+		Status:              pkg.MerchantStatusAgreementSigning,
+		StatusLastUpdatedAt: &timestamp.Timestamp{Seconds: time.Now().Add(-72 * time.Hour).Unix()},
+	}
+}
+
+func (suite *ReviewQueueTestSuite) TearDownTest() {}
+
+func (suite *ReviewQueueTestSuite) getBillingMock() *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("ListMerchants", mock2.Anything, mock2.Anything).
+		Return(&grpc.MerchantListingResponse{Count: 1, Items: []*billing.Merchant{suite.merchant}}, nil)
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: suite.merchant}, nil)
+	bill.On("ChangeMerchantStatus", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeMerchantStatusResponse{Status: pkg.ResponseStatusOk, Item: suite.merchant}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	return bill
+}
+
+func (suite *ReviewQueueTestSuite) claimBy(reviewer string) *common.ReviewClaim {
+	claim, err := suite.claims.Claim(suite.merchant.Id, reviewer)
+	assert.NoError(suite.T(), err)
+
+	return claim
+}
+
+func (suite *ReviewQueueTestSuite) post(path, body string) (*httptest.ResponseRecorder, error) {
+	return suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.SystemGroupPath + path).
+		Params(":"+common.RequestParameterMerchantId, suite.merchant.Id).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+}
+
+func (suite *ReviewQueueTestSuite) assertHTTPError(err error, code int, msg interface{}) {
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), code, httpErr.Code)
+	assert.Equal(suite.T(), msg, httpErr.Message)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_ListQueue_Ok() {
+	bill := suite.getBillingMock()
+	suite.claimBy(reviewQueueOtherReviewer)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.SystemGroupPath + reviewQueuePath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	queue := &reviewQueueResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), queue)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 1, queue.Count)
+	assert.Len(suite.T(), queue.Items, 1)
+	assert.Equal(suite.T(), suite.merchant.StatusLastUpdatedAt.Seconds, queue.Items[0].WaitingSince)
+	assert.True(suite.T(), queue.Items[0].Overdue)
+	assert.NotNil(suite.T(), queue.Items[0].Claim)
+	assert.Equal(suite.T(), reviewQueueOtherReviewer, queue.Items[0].Claim.Reviewer)
+
+	bill.AssertCalled(suite.T(), "ListMerchants", mock2.Anything, mock2.MatchedBy(func(req *grpc.MerchantListingRequest) bool {
+		return len(req.Statuses) == 1 && req.Statuses[0] == pkg.MerchantStatusAgreementSigning
+	}))
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_ListQueue_NotOperator() {
+	bill := suite.getBillingMock()
+	suite.user.Operator = false
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.SystemGroupPath + reviewQueuePath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	suite.assertHTTPError(err, http.StatusForbidden, common.ErrorMessageAccessDenied)
+	bill.AssertNotCalled(suite.T(), "ListMerchants", mock2.Anything, mock2.Anything)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Claim_Ok() {
+	suite.getBillingMock()
+
+	res, err := suite.post(reviewQueueClaimPath, "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	claim, err := suite.claims.Get(suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), claim)
+	assert.Equal(suite.T(), reviewQueueReviewer, claim.Reviewer)
+
+	// repeated claim of the same operator keeps the claim
+	res, err = suite.post(reviewQueueClaimPath, "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Claim_ReviewerFromBodyIgnored() {
+	suite.getBillingMock()
+
+	_, err := suite.post(reviewQueueClaimPath, `{"reviewer": "`+reviewQueueOtherReviewer+`"}`)
+	assert.NoError(suite.T(), err)
+
+	claim, err := suite.claims.Get(suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), reviewQueueReviewer, claim.Reviewer)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Claim_ClaimedByOther() {
+	suite.getBillingMock()
+	suite.claimBy(reviewQueueOtherReviewer)
+
+	_, err := suite.post(reviewQueueClaimPath, "")
+	suite.assertHTTPError(err, http.StatusConflict, common.ErrorMessageReviewClaimedByOther)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Claim_Expired_Ok() {
+	suite.getBillingMock()
+	suite.claimBy(reviewQueueOtherReviewer)
+	test.RedisServer().FastForward(2 * time.Hour)
+
+	res, err := suite.post(reviewQueueClaimPath, "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Claim_MerchantNotPending() {
+	suite.merchant.Status = pkg.MerchantStatusDraft
+	suite.getBillingMock()
+
+	_, err := suite.post(reviewQueueClaimPath, "")
+	suite.assertHTTPError(err, http.StatusBadRequest, common.ErrorMessageReviewMerchantNotPending)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Release_Ok() {
+	suite.getBillingMock()
+	suite.claimBy(reviewQueueReviewer)
+
+	res, err := suite.post(reviewQueueReleasePath, "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusNoContent, res.Code)
+
+	claim, err := suite.claims.Get(suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), claim)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Release_ClaimedByOther() {
+	suite.getBillingMock()
+	suite.claimBy(reviewQueueOtherReviewer)
+
+	_, err := suite.post(reviewQueueReleasePath, "")
+	suite.assertHTTPError(err, http.StatusConflict, common.ErrorMessageReviewNotClaimed)
+
+	claim, err := suite.claims.Get(suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), reviewQueueOtherReviewer, claim.Reviewer)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Decide_Ok() {
+	bill := suite.getBillingMock()
+	suite.claimBy(reviewQueueReviewer)
+
+	res, err := suite.post(reviewQueueDecisionPath, `{"status": 6, "message": "incomplete documents"}`)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	bill.AssertCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.MatchedBy(func(req *grpc.MerchantChangeStatusRequest) bool {
+		return req.MerchantId == suite.merchant.Id && req.UserId == reviewQueueReviewer && req.Status == 6 &&
+			strings.Contains(req.Message, "incomplete")
+	}))
+
+	claim, err := suite.claims.Get(suite.merchant.Id)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), claim)
+}
+
+func (suite *ReviewQueueTestSuite) TestReviewQueue_Decide_NotClaimed() {
+	bill := suite.getBillingMock()
+	suite.claimBy(reviewQueueOtherReviewer)
+
+	_, err := suite.post(reviewQueueDecisionPath, `{"status": 6, "message": "incomplete documents"}`)
+	suite.assertHTTPError(err, http.StatusConflict, common.ErrorMessageReviewNotClaimed)
+	bill.AssertNotCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.Anything)
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"time"
+)
+
+const (
+	riskOverviewPath         = "/risk/overview"
+	riskOverviewMerchantPath = "/risk/overview/:merchant_id"
+	riskReviewMerchantPath   = "/risk/overview/:merchant_id/review"
+)
+
+const (
+	riskOrderStatusProcessed  = "processed"
+	riskOrderStatusRefunded   = "refunded"
+	riskOrderStatusChargeback = "chargeback"
+
+	riskNotificationTitle   = "Merchant flagged for risk review"
+	riskNotificationMessage = "Chargeback ratio %.4f (threshold %.4f), refund ratio %.4f (threshold %.4f) for the last %s"
+)
+
+type riskOverview struct {
+	MerchantId         string  `json:"merchant_id"`
+	PeriodFrom         int64   `json:"period_from"`
+	PeriodTo           int64   `json:"period_to"`
+	Processed          int32   `json:"processed"`
+	Refunds            int32   `json:"refunds"`
+	Chargebacks        int32   `json:"chargebacks"`
+	RefundRatio        float64 `json:"refund_ratio"`
+	ChargebackRatio    float64 `json:"chargeback_ratio"`
+	ThresholdsExceeded bool    `json:"thresholds_exceeded"`
+	Flagged            bool    `json:"flagged"`
+}
+
+type RiskRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+}
+
+// NewRiskRoute
+func NewRiskRoute(set common.HandlerSet, cfg *common.Config) *RiskRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "RiskRoute"})
+	return &RiskRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *RiskRoute) Route(groups *common.Groups) {
+	groups.AuthUser.GET(riskOverviewPath, h.getRiskOverview)
+	groups.System.GET(riskOverviewMerchantPath, h.getMerchantRiskOverview)
+	groups.System.POST(riskReviewMerchantPath, h.reviewMerchantRisk)
+}
+
+// Get chargeback and refund ratios of current merchant for the rolling period
+// GET /admin/api/v1/risk/overview
+func (h *RiskRoute) getRiskOverview(ctx echo.Context) error {
+	merchant, err := h.getMerchant(ctx, &grpc.GetMerchantByRequest{UserId: common.ExtractUserContext(ctx).Id})
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.getOverview(ctx.Request().Context(), merchant)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// Get chargeback and refund ratios of any merchant by it's id for the rolling period
+// GET /system/api/v1/risk/overview/:merchant_id
+func (h *RiskRoute) getMerchantRiskOverview(ctx echo.Context) error {
+	merchant, err := h.getMerchant(ctx, &grpc.GetMerchantByRequest{MerchantId: ctx.Param(common.RequestParameterMerchantId)})
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.getOverview(ctx.Request().Context(), merchant)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// Check merchant ratios and move merchant to risk review status when thresholds are exceeded, called by operator
+// or by scheduled job with personal system api token of operator
+// POST /system/api/v1/risk/overview/:merchant_id/review
+func (h *RiskRoute) reviewMerchantRisk(ctx echo.Context) error {
+	authUser := common.ExtractUserContext(ctx)
+
+	if authUser.Id == "" {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageAccessDenied)
+	}
+
+	merchant, err := h.getMerchant(ctx, &grpc.GetMerchantByRequest{MerchantId: ctx.Param(common.RequestParameterMerchantId)})
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.getOverview(ctx.Request().Context(), merchant)
+
+	if err != nil {
+		return err
+	}
+
+	if res.ThresholdsExceeded && !res.Flagged && h.cfg.RiskReviewMerchantStatus > 0 {
+		if err = h.flagMerchant(ctx.Request().Context(), authUser, merchant, res); err != nil {
+			return err
+		}
+
+		res.Flagged = true
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *RiskRoute) getMerchant(ctx echo.Context, req *grpc.GetMerchantByRequest) (*billing.Merchant, error) {
+	if req.MerchantId == "" && req.UserId == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectMerchantId)
+	}
+
+	res, err := h.dispatch.Services.Billing.GetMerchantBy(ctx.Request().Context(), req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", req)
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return nil, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item, nil
+}
+
+// getOverview counts merchant orders by status, the overview is read only, merchant is flagged by review only
+func (h *RiskRoute) getOverview(ctx context.Context, merchant *billing.Merchant) (*riskOverview, error) {
+	var err error
+
+	to := time.Now()
+	from := to.Add(-h.cfg.RiskPeriod)
+
+	res := &riskOverview{
+		MerchantId: merchant.Id,
+		PeriodFrom: from.Unix(),
+		PeriodTo:   to.Unix(),
+	}
+
+	counters := map[string]*int32{
+		riskOrderStatusProcessed:  &res.Processed,
+		riskOrderStatusRefunded:   &res.Refunds,
+		riskOrderStatusChargeback: &res.Chargebacks,
+	}
+
+	for status, counter := range counters {
+		if *counter, err = h.countOrders(ctx, res, status); err != nil {
+			return nil, err
+		}
+	}
+
+	total := res.Processed + res.Refunds + res.Chargebacks
+
+	if total > 0 {
+		res.RefundRatio = float64(res.Refunds) / float64(total)
+		res.ChargebackRatio = float64(res.Chargebacks) / float64(total)
+	}
+
+	res.ThresholdsExceeded = res.ChargebackRatio > h.cfg.RiskChargebackRatioThreshold ||
+		res.RefundRatio > h.cfg.RiskRefundRatioThreshold
+	res.Flagged = h.cfg.RiskReviewMerchantStatus > 0 && merchant.Status == h.cfg.RiskReviewMerchantStatus
+
+	return res, nil
+}
+
+func (h *RiskRoute) countOrders(ctx context.Context, overview *riskOverview, status string) (int32, error) {
+	req := &grpc.ListOrdersRequest{
+		Merchant:   []string{overview.MerchantId},
+		Status:     []string{status},
+		PmDateFrom: overview.PeriodFrom,
+		PmDateTo:   overview.PeriodTo,
+		Limit:      1,
+	}
+	res, err := h.dispatch.Services.Billing.FindAllOrdersPublic(ctx, req)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "FindAllOrdersPublic", req)
+		return 0, echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if res.Status != pkg.ResponseStatusOk {
+		return 0, echo.NewHTTPError(int(res.Status), res.Message)
+	}
+
+	return res.Item.Count, nil
+}
+
+func (h *RiskRoute) flagMerchant(
+	ctx context.Context,
+	authUser *common.AuthUser,
+	merchant *billing.Merchant,
+	overview *riskOverview,
+) error {
+	message := fmt.Sprintf(
+		riskNotificationMessage,
+		overview.ChargebackRatio,
+		h.cfg.RiskChargebackRatioThreshold,
+		overview.RefundRatio,
+		h.cfg.RiskRefundRatioThreshold,
+		h.cfg.RiskPeriod.String(),
+	)
+
+	sReq := &grpc.MerchantChangeStatusRequest{
+		MerchantId: merchant.Id,
+		UserId:     authUser.Id,
+		Status:     h.cfg.RiskReviewMerchantStatus,
+		Message:    message,
+	}
+	sRes, err := h.dispatch.Services.Billing.ChangeMerchantStatus(ctx, sReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ChangeMerchantStatus", sReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if sRes.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(sRes.Status), sRes.Message)
+	}
+
+	nReq := &grpc.NotificationRequest{
+		MerchantId: merchant.Id,
+		UserId:     authUser.Id,
+		Title:      riskNotificationTitle,
+		Message:    message,
+	}
+	nRes, err := h.dispatch.Services.Billing.CreateNotification(ctx, nReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateNotification", nReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if nRes.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(nRes.Status), nRes.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type RiskTestSuite struct {
+	suite.Suite
+	router *RiskRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Risk(t *testing.T) {
+	suite.Run(t, new(RiskTestSuite))
+}
+
+func (suite *RiskTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewRiskRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *RiskTestSuite) TearDownTest() {}
+
+func (suite *RiskTestSuite) getBillingMock(ordersCount int32) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &billing.Merchant{Id: bson.NewObjectId().Hex(), Status: pkg.MerchantStatusAgreementSigning},
+		}, nil)
+	bill.On("FindAllOrdersPublic", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListOrdersPublicResponse{
+			Status: pkg.ResponseStatusOk,
+			Item:   &grpc.ListOrdersPublicResponseItem{Count: ordersCount},
+		}, nil)
+	bill.On("ChangeMerchantStatus", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeMerchantStatusResponse{Status: pkg.ResponseStatusOk}, nil)
+	bill.On("CreateNotification", mock2.Anything, mock2.Anything).
+		Return(&grpc.CreateNotificationResponse{Status: pkg.ResponseStatusOk}, nil)
+
+	return bill
+}
+
+func (suite *RiskTestSuite) TestRisk_GetRiskOverview_Ok() {
+	bill := suite.getBillingMock(10)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.cfg.RiskChargebackRatioThreshold = 0.5
+	suite.router.cfg.RiskRefundRatioThreshold = 0.5
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + riskOverviewPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	overview := &riskOverview{}
+	err = json.Unmarshal(res.Body.Bytes(), overview)
+	assert.NoError(suite.T(), err)
+	assert.EqualValues(suite.T(), 10, overview.Chargebacks)
+	assert.InDelta(suite.T(), 1.0/3, overview.ChargebackRatio, 0.0001)
+	assert.False(suite.T(), overview.ThresholdsExceeded)
+	assert.False(suite.T(), overview.Flagged)
+	bill.AssertNotCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.Anything)
+}
+
+func (suite *RiskTestSuite) TestRisk_GetRiskOverview_FlagMerchant_Ok() {
+	bill := suite.getBillingMock(10)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.cfg.RiskChargebackRatioThreshold = 0.01
+	suite.router.cfg.RiskRefundRatioThreshold = 0.1
+	suite.router.cfg.RiskReviewMerchantStatus = 6
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterMerchantId, bson.NewObjectId().Hex()).
+		Path(common.SystemGroupPath + riskReviewMerchantPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	overview := &riskOverview{}
+	err = json.Unmarshal(res.Body.Bytes(), overview)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), overview.ThresholdsExceeded)
+	assert.True(suite.T(), overview.Flagged)
+	bill.AssertCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.Anything)
+	bill.AssertCalled(suite.T(), "CreateNotification", mock2.Anything, mock2.Anything)
+}
+
+func (suite *RiskTestSuite) TestRisk_GetRiskOverview_BillingServerError() {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).Return(nil, errors.New("error"))
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + riskOverviewPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusInternalServerError, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorUnknown, httpErr.Message)
+}
+
+func (suite *RiskTestSuite) TestRisk_GetMerchantRiskOverview_ThresholdsExceeded_NotFlagged() {
+	bill := suite.getBillingMock(10)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.cfg.RiskChargebackRatioThreshold = 0.01
+	suite.router.cfg.RiskRefundRatioThreshold = 0.1
+	suite.router.cfg.RiskReviewMerchantStatus = 6
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterMerchantId, bson.NewObjectId().Hex()).
+		Path(common.SystemGroupPath + riskOverviewMerchantPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	overview := &riskOverview{}
+	err = json.Unmarshal(res.Body.Bytes(), overview)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), overview.ThresholdsExceeded)
+	assert.False(suite.T(), overview.Flagged)
+	bill.AssertNotCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.Anything)
+	bill.AssertNotCalled(suite.T(), "CreateNotification", mock2.Anything, mock2.Anything)
+}
+
+func (suite *RiskTestSuite) TestRisk_GetRiskOverview_OwnMerchantOnly() {
+	bill := suite.getBillingMock(10)
+	suite.router.dispatch.Services.Billing = bill
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Params(":"+common.RequestParameterMerchantId, bson.NewObjectId().Hex()).
+		Path(common.AuthUserGroupPath + riskOverviewMerchantPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+	bill.AssertCalled(suite.T(), "GetMerchantBy", mock2.Anything, &grpc.GetMerchantByRequest{UserId: "ffffffffffffffffffffffff"})
+}
+
+func (suite *RiskTestSuite) TestRisk_ReviewMerchantRisk_OperatorRequired() {
+	bill := suite.getBillingMock(10)
+	suite.router.dispatch.Services.Billing = bill
+	suite.router.cfg.RiskReviewMerchantStatus = 6
+
+	_, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Params(":"+common.RequestParameterMerchantId, bson.NewObjectId().Hex()).
+		Path(common.SystemGroupPath + riskReviewMerchantPath).
+		Init(func(request *http.Request, middleware test.Middleware) {
+			middleware.Pre(test.PreAuthUserMiddleware(&common.AuthUser{}))
+		}).
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusForbidden, httpErr.Code)
+	bill.AssertNotCalled(suite.T(), "ChangeMerchantStatus", mock2.Anything, mock2.Anything)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/ProtocolONE/go-core/v2/pkg/provider"
 	"github.com/labstack/echo/v4"
 	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"net/http"
@@ -19,9 +20,21 @@ const (
 	royaltyReportsChangePath       = "/royalty_reports/:id/change"
 )
 
+// royaltyReportOrder is royalty report transaction with platform of key products selected on order create
+type royaltyReportOrder struct {
+	*billing.OrderViewPublic
+	Platform string `json:"platform,omitempty"`
+}
+
+type royaltyReportOrders struct {
+	Count int32                 `json:"count"`
+	Items []*royaltyReportOrder `json:"items"`
+}
+
 type RoyaltyReportsRoute struct {
-	dispatch common.HandlerSet
-	cfg      common.Config
+	dispatch     common.HandlerSet
+	cfg          common.Config
+	attributions *common.OrderAttributions
 	provider.LMT
 }
 
@@ -35,6 +48,8 @@ func NewRoyaltyReportsRoute(set common.HandlerSet, cfg *common.Config) *RoyaltyR
 }
 
 func (h *RoyaltyReportsRoute) Route(groups *common.Groups) {
+	h.attributions = common.NewOrderAttributions(groups.Redis, h.cfg.OrderAttributionTTL)
+
 	groups.AuthUser.GET(royaltyReportsPath, h.getRoyaltyReportsList)
 	groups.AuthUser.GET(royaltyReportsIdPath, h.getRoyaltyReport)
 	groups.AuthUser.GET(royaltyReportsTransactionsPath, h.listRoyaltyReportOrders)
@@ -68,7 +83,9 @@ func (h *RoyaltyReportsRoute) getRoyaltyReportsList(ctx echo.Context) error {
 	if res.Status != http.StatusOK {
 		return echo.NewHTTPError(int(res.Status), res.Message)
 	}
-	return ctx.JSON(http.StatusOK, res.Data)
+
+	meta := &common.EnvelopeMeta{Count: res.GetData().GetCount(), Limit: req.Limit, Offset: req.Offset}
+	return common.ListResponse(ctx, res.Data, res.GetData().GetItems(), meta)
 }
 
 // Get royalty reports list by id
@@ -119,7 +136,30 @@ func (h *RoyaltyReportsRoute) listRoyaltyReportOrders(ctx echo.Context) error {
 	if res.Status != http.StatusOK {
 		return echo.NewHTTPError(int(res.Status), res.Message)
 	}
-	return ctx.JSON(http.StatusOK, res.Data)
+
+	rsp := &royaltyReportOrders{Count: res.GetData().GetCount(), Items: []*royaltyReportOrder{}}
+	ids := make([]string, 0, len(res.GetData().GetItems()))
+
+	for _, order := range res.GetData().GetItems() {
+		ids = append(ids, order.GetUuid())
+	}
+
+	// platform is kept in order parameters index, billing server doesn't return additional parameters of order
+	params, err := h.attributions.Get(ids)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "report_id", req.ReportId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	for _, order := range res.GetData().GetItems() {
+		rsp.Items = append(rsp.Items, &royaltyReportOrder{
+			OrderViewPublic: order,
+			Platform:        params[order.GetUuid()][common.RequestParameterPlatform],
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, rsp)
 }
 
 // Accept royalty report by merchant
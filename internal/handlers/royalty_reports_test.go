@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"encoding/json"
 	"github.com/globalsign/mgo/bson"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	"github.com/paysuper/paysuper-management-api/internal/mock"
 	"github.com/paysuper/paysuper-management-api/internal/test"
 	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 )
 
 type RoyaltyReportsTestSuite struct {
@@ -111,6 +118,47 @@ func (suite *RoyaltyReportsTestSuite) TestRoyaltyReports_listRoyaltyReportOrders
 	}
 }
 
+func (suite *RoyaltyReportsTestSuite) TestRoyaltyReports_listRoyaltyReportOrders_Platform() {
+	orders := []*billing.OrderViewPublic{{Uuid: "order1"}, {Uuid: "order2"}}
+	attributions := common.NewOrderAttributions(test.Redis(), time.Hour)
+	err := attributions.Record(
+		bson.NewObjectId().Hex(), "order1", map[string]string{common.RequestParameterPlatform: "steam"}, time.Now(),
+	)
+	assert.NoError(suite.T(), err)
+
+	bill := &billMock.BillingService{}
+	bill.On("ListRoyaltyReportOrders", mock2.Anything, mock2.Anything).
+		Return(&grpc.TransactionsResponse{
+			Status: pkg.ResponseStatusOk,
+			Data:   &grpc.TransactionsPaginate{Count: 2, Items: orders},
+		}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	res, err := suite.caller.Builder().
+		Params(":"+common.RequestParameterId, bson.NewObjectId().Hex()).
+		Method(http.MethodGet).
+		Path(common.AuthUserGroupPath + royaltyReportsTransactionsPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &struct {
+		Count int32 `json:"count"`
+		Items []struct {
+			Uuid     string `json:"uuid"`
+			Platform string `json:"platform"`
+		} `json:"items"`
+	}{}
+	assert.NoError(suite.T(), json.Unmarshal(res.Body.Bytes(), rsp))
+	assert.EqualValues(suite.T(), 2, rsp.Count)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), "order1", rsp.Items[0].Uuid)
+	assert.Equal(suite.T(), "steam", rsp.Items[0].Platform)
+	assert.Equal(suite.T(), "", rsp.Items[1].Platform)
+}
+
 func (suite *RoyaltyReportsTestSuite) TestRoyaltyReports_MerchantReviewRoyaltyReport() {
 
 	res, err := suite.caller.Builder().
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+)
+
+const (
+	sandboxSeedPath = "/sandbox/seed"
+)
+
+const (
+	sandboxLanguage       = "en"
+	sandboxOrderParameter = "sandbox_seed"
+	sandboxCustomerEmail  = "customer%d@sandbox.paysuper.online"
+
+	sandboxOrderStatusCreated  = "created"
+	sandboxOrderStatusCanceled = "canceled"
+)
+
+type sandboxProject struct {
+	name     string
+	currency string
+	min, max float64
+}
+
+type sandboxProduct struct {
+	sku, name string
+	amount    float64
+}
+
+var (
+	sandboxProjects = []*sandboxProject{
+		{name: "Sandbox game store", currency: "USD", min: 1, max: 1000},
+		{name: "Sandbox subscriptions", currency: "EUR", min: 1, max: 500},
+	}
+	sandboxProducts = []*sandboxProduct{
+		{sku: "sandbox_starter_pack", name: "Starter pack", amount: 4.99},
+		{sku: "sandbox_season_pass", name: "Season pass", amount: 19.99},
+		{sku: "sandbox_deluxe_edition", name: "Deluxe edition", amount: 59.99},
+	}
+	// orders are spread over the currencies, countries and amounts in round robin
+	sandboxOrderCurrencies = []string{"USD", "EUR", "RUB", "GBP"}
+	sandboxOrderCountries  = []string{"US", "DE", "RU", "GB", "FR", "BR"}
+	sandboxOrderAmounts    = []float64{4.99, 9.99, 19.99, 49.99, 99.99}
+	// public statuses sample orders are moved to after creation, so reports and analytics of the sandbox
+	// aren't made of created orders only
+	sandboxOrderStatuses = []string{
+		sandboxOrderStatusCreated,
+		riskOrderStatusProcessed,
+		riskOrderStatusProcessed,
+		riskOrderStatusProcessed,
+		riskOrderStatusRefunded,
+		sandboxOrderStatusCanceled,
+		riskOrderStatusChargeback,
+	}
+)
+
+type sandboxSeedResponse struct {
+	Projects []string `json:"projects"`
+	Products []string `json:"products"`
+	Orders   []string `json:"orders"`
+}
+
+type SandboxRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	provider.LMT
+	seeds *common.SandboxSeeds
+}
+
+// NewSandboxRoute
+func NewSandboxRoute(set common.HandlerSet, cfg *common.Config) *SandboxRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "SandboxRoute"})
+	return &SandboxRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+	}
+}
+
+func (h *SandboxRoute) Route(groups *common.Groups) {
+	h.seeds = common.NewSandboxSeeds(groups.Redis)
+	groups.AuthUser.POST(sandboxSeedPath, h.seed)
+}
+
+// @Description Populate test environment of authenticated merchant with sample projects, products and orders,
+// @Description repeated request resumes failed seeding and returns result of completed one
+// @Example curl -X POST -H 'Authorization: Bearer %access_token_here%' \
+//  https://api.paysuper.online/admin/api/v1/sandbox/seed
+func (h *SandboxRoute) seed(ctx echo.Context) error {
+	// seeding creates entities in billing server the api is connected to
+	if !h.cfg.SandboxSeedEnabled {
+		return echo.NewHTTPError(http.StatusForbidden, common.ErrorMessageSandboxSeedDisabled)
+	}
+
+	authUser := common.ExtractUserContext(ctx)
+	reqCtx := ctx.Request().Context()
+
+	mReq := &grpc.GetMerchantByRequest{UserId: authUser.Id}
+	merchant, err := h.dispatch.Services.Billing.GetMerchantBy(reqCtx, mReq)
+
+	if err != nil {
+		common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "GetMerchantBy", mReq)
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if merchant.Status != pkg.ResponseStatusOk {
+		return echo.NewHTTPError(int(merchant.Status), merchant.Message)
+	}
+
+	merchantId := merchant.Item.Id
+	locked, err := h.seeds.Lock(merchantId)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if !locked {
+		return echo.NewHTTPError(http.StatusConflict, common.ErrorMessageSandboxSeedInProgress)
+	}
+
+	defer h.seeds.Unlock(merchantId)
+
+	state, err := h.seeds.Get(merchantId)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	if state.Completed {
+		return ctx.JSON(http.StatusOK, newSandboxSeedResponse(state))
+	}
+
+	if err = h.checkMerchantNotLive(ctx, merchantId); err != nil {
+		return err
+	}
+
+	for i, sample := range sandboxProjects {
+		// projects created by failed seeding are reused
+		if i >= len(state.Projects) {
+			project := &billing.Project{
+				MerchantId:       merchantId,
+				Name:             map[string]string{sandboxLanguage: sample.name},
+				CallbackCurrency: sample.currency,
+				CallbackProtocol: pkg.ProjectCallbackProtocolEmpty,
+				LimitsCurrency:   sample.currency,
+				MinPaymentAmount: sample.min,
+				MaxPaymentAmount: sample.max,
+				Status:           pkg.ProjectStatusDraft,
+			}
+			projectRes, err := h.dispatch.Services.Billing.ChangeProject(reqCtx, project)
+
+			if err != nil {
+				common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ChangeProject", project)
+				return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+			}
+
+			if projectRes.Status != pkg.ResponseStatusOk {
+				return echo.NewHTTPError(int(projectRes.Status), projectRes.Message)
+			}
+
+			state.Projects = append(state.Projects, projectRes.Item.Id)
+
+			if err = h.saveSeed(merchantId, state); err != nil {
+				return err
+			}
+		}
+
+		projectId := state.Projects[i]
+
+		// catalog is created for the first project only, the second one sells by amount
+		if i == 0 {
+			if err = h.seedProducts(ctx, merchantId, projectId, sample.currency, state); err != nil {
+				return err
+			}
+		}
+
+		if err = h.seedOrders(ctx, merchantId, projectId, state); err != nil {
+			return err
+		}
+	}
+
+	state.Completed = true
+
+	if err = h.saveSeed(merchantId, state); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, newSandboxSeedResponse(state))
+}
+
+// checkMerchantNotLive rejects seeding of merchant having project in production on any page of projects,
+// sample orders would get into reports and payouts of live merchant
+func (h *SandboxRoute) checkMerchantNotLive(ctx echo.Context, merchantId string) error {
+	req := &grpc.ListProjectsRequest{MerchantId: merchantId, Limit: h.cfg.LimitMax}
+
+	for {
+		res, err := h.dispatch.Services.Billing.ListProjects(ctx.Request().Context(), req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "ListProjects", req)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		for _, project := range res.Items {
+			if project.Status == pkg.ProjectStatusInProduction {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageSandboxMerchantLive)
+			}
+		}
+
+		req.Offset += int32(len(res.Items))
+
+		if len(res.Items) == 0 || req.Offset >= res.Count {
+			return nil
+		}
+	}
+}
+
+func (h *SandboxRoute) seedProducts(
+	ctx echo.Context,
+	merchantId, projectId, currency string,
+	state *common.SandboxSeed,
+) error {
+	for _, sample := range sandboxProducts[len(state.Products):] {
+		product := &grpc.Product{
+			Object:          "product",
+			Type:            "simple_product",
+			Sku:             sample.sku,
+			Name:            map[string]string{sandboxLanguage: sample.name},
+			Description:     map[string]string{sandboxLanguage: sample.name + " of the sandbox game"},
+			DefaultCurrency: currency,
+			Enabled:         true,
+			Prices:          []*billing.ProductPrice{{Amount: sample.amount, Currency: currency}},
+			MerchantId:      merchantId,
+			ProjectId:       projectId,
+		}
+		productRes, err := h.dispatch.Services.Billing.CreateOrUpdateProduct(ctx.Request().Context(), product)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "CreateOrUpdateProduct", product)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		state.Products = append(state.Products, productRes.Id)
+
+		if err = h.saveSeed(merchantId, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedOrders creates orders of the project, billing server creates orders in created status only,
+// so orders are moved to statuses of sandboxOrderStatuses by update after creation
+func (h *SandboxRoute) seedOrders(ctx echo.Context, merchantId, projectId string, state *common.SandboxSeed) error {
+	for i := len(state.Orders[projectId]); i < h.cfg.SandboxSeedOrdersCount; i++ {
+		email := fmt.Sprintf(sandboxCustomerEmail, i%len(sandboxOrderCountries))
+		req := &billing.OrderCreateRequest{
+			ProjectId:   projectId,
+			Amount:      sandboxOrderAmounts[i%len(sandboxOrderAmounts)],
+			Currency:    sandboxOrderCurrencies[i%len(sandboxOrderCurrencies)],
+			Account:     email,
+			Description: "Sandbox order",
+			User: &billing.OrderUser{
+				Email:   email,
+				Address: &billing.OrderBillingAddress{Country: sandboxOrderCountries[i%len(sandboxOrderCountries)]},
+			},
+			Other: map[string]string{sandboxOrderParameter: "true"},
+		}
+		orderRes, err := h.dispatch.Services.Billing.OrderCreateProcess(ctx.Request().Context(), req)
+
+		if err != nil {
+			common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "OrderCreateProcess", req)
+			return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+		}
+
+		if orderRes.Status != pkg.ResponseStatusOk {
+			return echo.NewHTTPError(int(orderRes.Status), orderRes.Message)
+		}
+
+		if status := sandboxOrderStatuses[i%len(sandboxOrderStatuses)]; status != sandboxOrderStatusCreated {
+			order := orderRes.Item
+			order.Status = status
+
+			if _, err = h.dispatch.Services.Billing.UpdateOrder(ctx.Request().Context(), order); err != nil {
+				common.LogSrvCallFailedGRPC(h.L(), err, pkg.ServiceName, "UpdateOrder", order)
+				return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+			}
+		}
+
+		state.Orders[projectId] = append(state.Orders[projectId], orderRes.Item.Uuid)
+
+		if err = h.saveSeed(merchantId, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *SandboxRoute) saveSeed(merchantId string, state *common.SandboxSeed) error {
+	if err := h.seeds.Save(merchantId, state); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "merchant_id", merchantId))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorUnknown)
+	}
+
+	return nil
+}
+
+func newSandboxSeedResponse(state *common.SandboxSeed) *sandboxSeedResponse {
+	res := &sandboxSeedResponse{Projects: state.Projects, Products: state.Products, Orders: []string{}}
+
+	if res.Products == nil {
+		res.Products = []string{}
+	}
+
+	for _, projectId := range state.Projects {
+		res.Orders = append(res.Orders, state.Orders[projectId]...)
+	}
+
+	return res
+}
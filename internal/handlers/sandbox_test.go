@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/globalsign/mgo/bson"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	mock2 "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"testing"
+)
+
+type SandboxTestSuite struct {
+	suite.Suite
+	router *SandboxRoute
+	caller *test.EchoReqResCaller
+}
+
+func Test_Sandbox(t *testing.T) {
+	suite.Run(t, new(SandboxTestSuite))
+}
+
+func (suite *SandboxTestSuite) SetupTest() {
+	user := &common.AuthUser{
+		Id:    "ffffffffffffffffffffffff",
+		Email: "test@unit.test",
+	}
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		mw.Pre(test.PreAuthUserMiddleware(user))
+		suite.router = NewSandboxRoute(set.HandlerSet, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	suite.router.cfg.SandboxSeedEnabled = true
+	suite.router.cfg.SandboxSeedOrdersCount = 5
+}
+
+func (suite *SandboxTestSuite) TearDownTest() {}
+
+// getBillingMock returns billing mock seeding the sandbox, failOrder is number of order create call failing once,
+// zero means order creates don't fail
+func (suite *SandboxTestSuite) getBillingMock(projects []*billing.Project, failOrder int) *billMock.BillingService {
+	bill := &billMock.BillingService{}
+	bill.On("GetMerchantBy", mock2.Anything, mock2.Anything).
+		Return(&grpc.GetMerchantResponse{Status: pkg.ResponseStatusOk, Item: mock.OnboardingMerchantMock}, nil)
+	bill.On("ListProjects", mock2.Anything, mock2.Anything).
+		Return(&grpc.ListProjectsResponse{Count: int32(len(projects)), Items: projects}, nil)
+	bill.On("ChangeProject", mock2.Anything, mock2.Anything).
+		Return(&grpc.ChangeProjectResponse{Status: pkg.ResponseStatusOk, Item: &billing.Project{Id: bson.NewObjectId().Hex()}}, nil)
+	bill.On("CreateOrUpdateProduct", mock2.Anything, mock2.Anything).
+		Return(&grpc.Product{Id: bson.NewObjectId().Hex()}, nil)
+
+	orderRes := &grpc.OrderCreateProcessResponse{Status: pkg.ResponseStatusOk, Item: &billing.Order{Uuid: bson.NewObjectId().Hex()}}
+
+	if failOrder > 0 {
+		if failOrder > 1 {
+			bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).Return(orderRes, nil).Times(failOrder - 1)
+		}
+
+		bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).Return(nil, errors.New("some error")).Once()
+	}
+
+	bill.On("OrderCreateProcess", mock2.Anything, mock2.Anything).Return(orderRes, nil)
+	bill.On("UpdateOrder", mock2.Anything, mock2.Anything).Return(&grpc.EmptyResponse{}, nil)
+	suite.router.dispatch.Services.Billing = bill
+
+	return bill
+}
+
+func (suite *SandboxTestSuite) seed() (*sandboxSeedResponse, int, error) {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthUserGroupPath + sandboxSeedPath).
+		Init(test.ReqInitJSON()).
+		Exec(suite.T())
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	seeded := &sandboxSeedResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), seeded)
+
+	return seeded, res.Code, err
+}
+
+func (suite *SandboxTestSuite) assertHTTPError(err error, code int, msg interface{}) {
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), code, httpErr.Code)
+	assert.Equal(suite.T(), msg, httpErr.Message)
+}
+
+func (suite *SandboxTestSuite) TestSandbox_Seed_Ok() {
+	bill := suite.getBillingMock([]*billing.Project{{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusDraft}}, 0)
+
+	seeded, code, err := suite.seed()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusCreated, code)
+	assert.Len(suite.T(), seeded.Projects, len(sandboxProjects))
+	assert.Len(suite.T(), seeded.Products, len(sandboxProducts))
+	assert.Len(suite.T(), seeded.Orders, len(sandboxProjects)*5)
+
+	bill.AssertCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.MatchedBy(func(req *billing.Project) bool {
+		return req.MerchantId == mock.OnboardingMerchantMock.Id && req.Status == pkg.ProjectStatusDraft
+	}))
+	bill.AssertCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.MatchedBy(func(req *billing.OrderCreateRequest) bool {
+		return req.Currency == "EUR" && req.User.Address.Country == "DE" && req.Other[sandboxOrderParameter] == "true"
+	}))
+	// first order of the project stays created, the rest are moved to processed and refunded statuses
+	bill.AssertNumberOfCalls(suite.T(), "UpdateOrder", len(sandboxProjects)*4)
+	bill.AssertCalled(suite.T(), "UpdateOrder", mock2.Anything, mock2.MatchedBy(func(req *billing.Order) bool {
+		return req.Status == riskOrderStatusProcessed
+	}))
+}
+
+func (suite *SandboxTestSuite) TestSandbox_Seed_Completed_Ok() {
+	suite.getBillingMock([]*billing.Project{}, 0)
+
+	seeded, code, err := suite.seed()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusCreated, code)
+
+	bill := suite.getBillingMock([]*billing.Project{}, 0)
+
+	repeated, code, err := suite.seed()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), seeded, repeated)
+	bill.AssertNotCalled(suite.T(), "ListProjects", mock2.Anything, mock2.Anything)
+	bill.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+	bill.AssertNotCalled(suite.T(), "OrderCreateProcess", mock2.Anything, mock2.Anything)
+}
+
+func (suite *SandboxTestSuite) TestSandbox_Seed_ResumeAfterFailure_Ok() {
+	// the third order of the first project fails
+	bill := suite.getBillingMock([]*billing.Project{}, 3)
+
+	_, _, err := suite.seed()
+	suite.assertHTTPError(err, http.StatusInternalServerError, common.ErrorUnknown)
+	bill.AssertNumberOfCalls(suite.T(), "ChangeProject", 1)
+
+	seeded, code, err := suite.seed()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusCreated, code)
+	assert.Len(suite.T(), seeded.Projects, len(sandboxProjects))
+	assert.Len(suite.T(), seeded.Products, len(sandboxProducts))
+	assert.Len(suite.T(), seeded.Orders, len(sandboxProjects)*5)
+
+	// entities created before the failure aren't created again
+	bill.AssertNumberOfCalls(suite.T(), "ChangeProject", len(sandboxProjects))
+	bill.AssertNumberOfCalls(suite.T(), "CreateOrUpdateProduct", len(sandboxProducts))
+	bill.AssertNumberOfCalls(suite.T(), "OrderCreateProcess", len(sandboxProjects)*5+1)
+}
+
+func (suite *SandboxTestSuite) TestSandbox_Seed_MerchantLive() {
+	bill := suite.getBillingMock([]*billing.Project{{Id: bson.NewObjectId().Hex(), Status: pkg.ProjectStatusInProduction}}, 0)
+
+	_, _, err := suite.seed()
+	suite.assertHTTPError(err, http.StatusBadRequest, common.ErrorMessageSandboxMerchantLive)
+	bill.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
+
+func (suite *SandboxTestSuite) TestSandbox_Seed_Disabled() {
+	bill := suite.getBillingMock([]*billing.Project{}, 0)
+	suite.router.cfg.SandboxSeedEnabled = false
+
+	_, _, err := suite.seed()
+	suite.assertHTTPError(err, http.StatusForbidden, common.ErrorMessageSandboxSeedDisabled)
+	bill.AssertNotCalled(suite.T(), "GetMerchantBy", mock2.Anything, mock2.Anything)
+}
+
+func (suite *SandboxTestSuite) TestSandbox_Seed_InProgress() {
+	bill := suite.getBillingMock([]*billing.Project{}, 0)
+
+	locked, err := common.NewSandboxSeeds(test.Redis()).Lock(mock.OnboardingMerchantMock.Id)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), locked)
+
+	_, _, err = suite.seed()
+	suite.assertHTTPError(err, http.StatusConflict, common.ErrorMessageSandboxSeedInProgress)
+	bill.AssertNotCalled(suite.T(), "ChangeProject", mock2.Anything, mock2.Anything)
+}
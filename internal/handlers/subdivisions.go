@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	countrySubdivisionsPath = "/country/:code/subdivisions"
+	subdivisionsPath        = "/subdivisions/:code"
+)
+
+var subdivisionsCountryRegex = regexp.MustCompile("^[A-Za-z]{2}$")
+
+type subdivisionsRequest struct {
+	Items []*common.Subdivision `json:"items" validate:"max=1000,dive"`
+}
+
+type subdivisionsResponse struct {
+	Country string                `json:"country"`
+	Items   []*common.Subdivision `json:"items"`
+}
+
+type SubdivisionsRoute struct {
+	dispatch     common.HandlerSet
+	cfg          common.Config
+	subdivisions *common.Subdivisions
+	overrides    *common.SubdivisionOverrides
+	provider.LMT
+}
+
+// NewSubdivisionsRoute
+func NewSubdivisionsRoute(set common.HandlerSet, subdivisions *common.Subdivisions, cfg *common.Config) *SubdivisionsRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "SubdivisionsRoute"})
+	return &SubdivisionsRoute{
+		dispatch:     set,
+		LMT:          &set.AwareSet,
+		cfg:          *cfg,
+		subdivisions: subdivisions,
+	}
+}
+
+func (h *SubdivisionsRoute) Route(groups *common.Groups) {
+	h.overrides = common.NewSubdivisionOverrides(groups.Redis)
+
+	groups.AuthProject.GET(countrySubdivisionsPath, h.getSubdivisions)
+	groups.System.PUT(subdivisionsPath, h.setSubdivisions)
+	groups.System.DELETE(subdivisionsPath, h.deleteSubdivisions)
+}
+
+// @Description ISO 3166-2 subdivisions (states, provinces) of the country with names by locale sorted by code,
+// @Description the list is empty for countries without subdivisions in catalog, subdivisions set through system api
+// @Description replace the ones of catalog
+// @Example curl -X GET 'https://api.paysuper.online/api/v1/country/US/subdivisions'
+func (h *SubdivisionsRoute) getSubdivisions(ctx echo.Context) error {
+	country := ctx.Param(common.RequestParameterCode)
+
+	if !subdivisionsCountryRegex.MatchString(country) {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectCountryIdentifier)
+	}
+
+	country = strings.ToUpper(country)
+	items, err := h.overrides.Get(country)
+
+	if err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "country", country))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	if items == nil {
+		items = h.subdivisions.Get(country)
+	}
+
+	return ctx.JSON(http.StatusOK, &subdivisionsResponse{Country: country, Items: items})
+}
+
+// @Description Replace subdivisions of the country in catalog on all instances of the api, empty list leaves
+// @Description the country without subdivisions
+// @Example curl -X PUT -H 'Authorization: Bearer %system_api_token_here%' -H 'Content-Type: application/json' \
+//  -d '{"items": [{"code": "US-CA", "type": "state", "name": {"en": "California"}}]}' \
+//  https://api.paysuper.online/system/api/v1/subdivisions/US
+func (h *SubdivisionsRoute) setSubdivisions(ctx echo.Context) error {
+	country := ctx.Param(common.RequestParameterCode)
+
+	if !subdivisionsCountryRegex.MatchString(country) {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectCountryIdentifier)
+	}
+
+	country = strings.ToUpper(country)
+	req := &subdivisionsRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	for _, item := range req.Items {
+		item.Code = strings.ToUpper(item.Code)
+
+		item.Parent = strings.ToUpper(item.Parent)
+
+		if !strings.HasPrefix(item.Code, country+"-") || item.Name[common.SubdivisionsDefaultLocale] == "" ||
+			(item.Parent != "" && !strings.HasPrefix(item.Parent, country+"-")) {
+			rspErr := *common.ErrorMessageSubdivisionInvalid
+			rspErr.Details = item.Code
+			return echo.NewHTTPError(http.StatusBadRequest, &rspErr)
+		}
+	}
+
+	if err := h.overrides.Set(country, req.Items); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "country", country))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	if req.Items == nil {
+		req.Items = make([]*common.Subdivision, 0)
+	}
+
+	return ctx.JSON(http.StatusOK, &subdivisionsResponse{Country: country, Items: req.Items})
+}
+
+// @Description Remove subdivisions of the country set through system api, so subdivisions of catalog are served again
+// @Example curl -X DELETE -H 'Authorization: Bearer %system_api_token_here%' \
+//  https://api.paysuper.online/system/api/v1/subdivisions/US
+func (h *SubdivisionsRoute) deleteSubdivisions(ctx echo.Context) error {
+	country := ctx.Param(common.RequestParameterCode)
+
+	if !subdivisionsCountryRegex.MatchString(country) {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorIncorrectCountryIdentifier)
+	}
+
+	if err := h.overrides.Delete(country); err != nil {
+		h.L().Error(common.InternalErrorTemplate, logger.PairArgs("err", err.Error(), "country", country))
+		return echo.NewHTTPError(http.StatusInternalServerError, common.ErrorInternal)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	subdivisionsTestCatalog = `{"us": [
+		{"code": "US-NY", "type": "state", "name": {"en": "New York"}},
+		{"code": "US-CA", "type": "state", "name": {"en": "California", "es": "California"}}
+	]}`
+)
+
+type SubdivisionsTestSuite struct {
+	suite.Suite
+	router *SubdivisionsRoute
+	caller *test.EchoReqResCaller
+	dir    string
+	path   string
+}
+
+func Test_Subdivisions(t *testing.T) {
+	suite.Run(t, new(SubdivisionsTestSuite))
+}
+
+func (suite *SubdivisionsTestSuite) SetupTest() {
+	var e error
+	suite.dir, e = ioutil.TempDir("", "subdivisions")
+	if e != nil {
+		panic(e)
+	}
+
+	suite.path = filepath.Join(suite.dir, "subdivisions.json")
+	if e = ioutil.WriteFile(suite.path, []byte(subdivisionsTestCatalog), 0644); e != nil {
+		panic(e)
+	}
+
+	subdivisions, e := common.NewSubdivisions(suite.path)
+	if e != nil {
+		panic(e)
+	}
+
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		suite.router = NewSubdivisionsRoute(set.HandlerSet, subdivisions, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+}
+
+func (suite *SubdivisionsTestSuite) TearDownTest() {
+	_ = os.RemoveAll(suite.dir)
+}
+
+func (suite *SubdivisionsTestSuite) get(country string) *subdivisionsResponse {
+	res, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath+countrySubdivisionsPath).
+		Params(":"+common.RequestParameterCode, country).
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := &subdivisionsResponse{}
+	err = json.Unmarshal(res.Body.Bytes(), rsp)
+	assert.NoError(suite.T(), err)
+
+	return rsp
+}
+
+func (suite *SubdivisionsTestSuite) set(country, body string) (*httptest.ResponseRecorder, error) {
+	return suite.caller.Builder().
+		Method(http.MethodPut).
+		Path(common.SystemGroupPath+subdivisionsPath).
+		Params(":"+common.RequestParameterCode, country).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Get_Ok() {
+	rsp := suite.get("us")
+
+	assert.Equal(suite.T(), "US", rsp.Country)
+	assert.Len(suite.T(), rsp.Items, 2)
+	assert.Equal(suite.T(), "US-CA", rsp.Items[0].Code)
+	assert.Equal(suite.T(), "California", rsp.Items[0].Name["es"])
+	assert.Equal(suite.T(), "US-NY", rsp.Items[1].Code)
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Get_CountryNotInCatalog() {
+	rsp := suite.get("DE")
+
+	assert.Equal(suite.T(), "DE", rsp.Country)
+	assert.Empty(suite.T(), rsp.Items)
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Get_IncorrectCountry() {
+	_, err := suite.caller.Builder().
+		Method(http.MethodGet).
+		Path(common.AuthProjectGroupPath+countrySubdivisionsPath).
+		Params(":"+common.RequestParameterCode, "USA").
+		Exec(suite.T())
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorIncorrectCountryIdentifier, httpErr.Message)
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Get_MissingFile() {
+	subdivisions, err := common.NewSubdivisions(filepath.Join(suite.dir, "unknown.json"))
+
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), subdivisions.Get("US"))
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Get_ShippedCatalog() {
+	subdivisions, err := common.NewSubdivisions(filepath.Join("..", "..", "assets", "data", "subdivisions.json"))
+	assert.NoError(suite.T(), err)
+
+	assert.Len(suite.T(), subdivisions.Get("US"), 57)
+	assert.Len(suite.T(), subdivisions.Get("CA"), 13)
+
+	items := subdivisions.Get("FR")
+	assert.NotEmpty(suite.T(), items)
+
+	for _, item := range items {
+		assert.True(suite.T(), strings.HasPrefix(item.Code, "FR-"))
+		assert.NotEmpty(suite.T(), item.Name[common.SubdivisionsDefaultLocale])
+	}
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Set_Ok() {
+	body := `{"items": [{"code": "ca-on", "type": "province", "name": {"en": "Ontario", "fr": "Ontario"}}]}`
+
+	res, err := suite.set("CA", body)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	rsp := suite.get("CA")
+	assert.Len(suite.T(), rsp.Items, 1)
+	assert.Equal(suite.T(), "CA-ON", rsp.Items[0].Code)
+
+	// subdivisions are kept in redis for all instances, the catalog file isn't changed
+	items, err := common.NewSubdivisionOverrides(test.Redis()).Get("ca")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), items, 1)
+
+	saved, err := common.NewSubdivisions(suite.path)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), saved.Get("CA"))
+	assert.Len(suite.T(), saved.Get("US"), 2)
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Set_EmptyList() {
+	res, err := suite.set("US", `{"items": []}`)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+	assert.Empty(suite.T(), suite.get("US").Items)
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Set_CodeOfOtherCountry() {
+	_, err := suite.set("CA", `{"items": [{"code": "US-TX", "type": "state", "name": {"en": "Texas"}}]}`)
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageSubdivisionInvalid.Code, msg.Code)
+	assert.Equal(suite.T(), "US-TX", msg.Details)
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Set_ParentOfOtherCountry() {
+	body := `{"items": [{"code": "FR-75", "type": "metropolitan department", "parent": "BE-BRU", "name": {"en": "Paris"}}]}`
+
+	_, err := suite.set("FR", body)
+
+	assert.Error(suite.T(), err)
+
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+
+	msg, ok := httpErr.Message.(*grpc.ResponseErrorMessage)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), common.ErrorMessageSubdivisionInvalid.Code, msg.Code)
+	assert.Equal(suite.T(), "FR-75", msg.Details)
+}
+
+func (suite *SubdivisionsTestSuite) TestSubdivisions_Delete_Ok() {
+	_, err := suite.set("US", `{"items": []}`)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), suite.get("US").Items)
+
+	res, err := suite.caller.Builder().
+		Method(http.MethodDelete).
+		Path(common.SystemGroupPath+subdivisionsPath).
+		Params(":"+common.RequestParameterCode, "us").
+		Exec(suite.T())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusNoContent, res.Code)
+	assert.Len(suite.T(), suite.get("US").Items, 2)
+}
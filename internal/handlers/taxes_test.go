@@ -212,7 +212,17 @@ func createNewTaxServiceMock() tax_service.TaxService {
 }
 
 func (ts *TaxServiceMock) GetRate(ctx context.Context, in *tax_service.GetRateRequest, opts ...client.CallOption) (*tax_service.GetRateResponse, error) {
-	panic("this method is not implemented in mock")
+	if in.IpData.City == "fail" {
+		return nil, errors.New("Invalid request")
+	}
+
+	rate := &tax_service.TaxRate{Country: in.IpData.Country, City: in.IpData.City, Zip: in.IpData.Zip, Rate: 0.1}
+
+	if in.IpData.Zip != "" {
+		rate.Rate = 0.08
+	}
+
+	return &tax_service.GetRateResponse{Rate: rate}, nil
 }
 
 func (ts *TaxServiceMock) GetRates(ctx context.Context, in *tax_service.GetRatesRequest, opts ...client.CallOption) (*tax_service.GetRatesResponse, error) {
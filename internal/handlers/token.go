@@ -36,6 +36,7 @@ func (h *TokenRoute) Route(groups *common.Groups) {
 func (h *TokenRoute) createToken(ctx echo.Context) error {
 	req := &grpc.TokenRequest{}
 	err := ctx.Bind(req)
+	common.SetRequestLogProject(ctx, req.GetSettings().GetProjectId())
 
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
@@ -47,7 +48,7 @@ func (h *TokenRoute) createToken(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
 	}
 
-	err = common.CheckProjectAuthRequestSignature(h.dispatch, ctx, req.Settings.ProjectId)
+	err = common.CheckProjectAuthRequestSignature(h.dispatch, ctx, req.Settings.ProjectId, nil)
 
 	if err != nil {
 		return err
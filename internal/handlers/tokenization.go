@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"github.com/ProtocolONE/go-core/v2/pkg/logger"
+	"github.com/ProtocolONE/go-core/v2/pkg/provider"
+	"github.com/labstack/echo/v4"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/vault"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	paymentTokenizePath = "/payment/tokenize"
+)
+
+type paymentTokenizeRequest struct {
+	PaymentMethodId string `json:"payment_method_id" validate:"required,hexadecimal,len=24"`
+	Pan             string `json:"pan" validate:"required,numeric,min=12,max=19"`
+	Cvv             string `json:"cvv" validate:"required,numeric,min=3,max=4"`
+	Month           string `json:"month" validate:"required,numeric,min=1,max=2"`
+	Year            string `json:"year" validate:"required,numeric,len=4"`
+	Holder          string `json:"card_holder" validate:"omitempty,max=255"`
+}
+
+type TokenizationRoute struct {
+	dispatch common.HandlerSet
+	cfg      common.Config
+	vault    vault.Provider
+	provider.LMT
+}
+
+// NewTokenizationRoute
+func NewTokenizationRoute(set common.HandlerSet, vault vault.Provider, cfg *common.Config) *TokenizationRoute {
+	set.AwareSet.Logger = set.AwareSet.Logger.WithFields(logger.Fields{"router": "TokenizationRoute"})
+	return &TokenizationRoute{
+		dispatch: set,
+		LMT:      &set.AwareSet,
+		cfg:      *cfg,
+		vault:    vault,
+	}
+}
+
+func (h *TokenizationRoute) Route(groups *common.Groups) {
+	groups.AuthProject.POST(paymentTokenizePath, h.tokenize, common.RedactBodyDump)
+}
+
+// @Description Exchange payment card data for card token of the vault configured for payment method, the token is sent
+// @Description in card_token field of payment create request instead of card data.
+// @Description Works on instances deployed in the cardholder data environment only, card data is never logged
+// @Example curl -X POST -H 'Content-Type: application/json' \
+//  -d '{"payment_method_id": "5be2d0b4b0b30d0007383ce6", "pan": "4000000000000002", "cvv": "123", "month": "12", "year": "2030"}' \
+//  https://api.paysuper.online/api/v1/payment/tokenize
+func (h *TokenizationRoute) tokenize(ctx echo.Context) error {
+	req := &paymentTokenizeRequest{}
+
+	if err := ctx.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorRequestParamsIncorrect)
+	}
+
+	req.Pan = strings.Replace(req.Pan, " ", "", -1)
+
+	if err := h.dispatch.Validate.Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, common.GetValidationError(err))
+	}
+
+	if h.cfg.CardTokenizationVaultUrl == "" {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageCardTokenizationNotConfigured)
+	}
+
+	paymentSystem, ok := h.cfg.CardTokenizationVaults[req.PaymentMethodId]
+
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageCardTokenizationNotSupported)
+	}
+
+	if !vault.IsLuhnValid(req.Pan) || !h.isExpiryValid(req.Month, req.Year) {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageCardInvalid)
+	}
+
+	card := &vault.Card{
+		Pan:    req.Pan,
+		Cvv:    req.Cvv,
+		Month:  req.Month,
+		Year:   req.Year,
+		Holder: req.Holder,
+	}
+	token, err := h.vault.Tokenize(ctx.Request().Context(), paymentSystem, card)
+
+	if err == vault.ErrorProviderNotConfigured {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, common.ErrorMessageCardTokenizationNotConfigured)
+	}
+
+	if err == vault.ErrorCardRejected {
+		return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessageCardRejected)
+	}
+
+	if err != nil {
+		h.L().Error(
+			common.InternalErrorTemplate,
+			logger.PairArgs("err", err.Error(), "payment_method_id", req.PaymentMethodId, "pan", vault.MaskPan(req.Pan)),
+		)
+		return echo.NewHTTPError(http.StatusBadGateway, common.ErrorMessageCardTokenizationFailed)
+	}
+
+	if token.MaskedPan == "" {
+		token.MaskedPan = vault.MaskPan(req.Pan)
+	}
+
+	return ctx.JSON(http.StatusOK, token)
+}
+
+func (h *TokenizationRoute) isExpiryValid(month, year string) bool {
+	m, err := strconv.Atoi(month)
+
+	if err != nil || m < 1 || m > 12 {
+		return false
+	}
+
+	y, err := strconv.Atoi(year)
+
+	if err != nil {
+		return false
+	}
+
+	now := time.Now().UTC()
+
+	return y > now.Year() || (y == now.Year() && m >= int(now.Month()))
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
+	"github.com/paysuper/paysuper-management-api/internal/mock"
+	"github.com/paysuper/paysuper-management-api/internal/test"
+	"github.com/paysuper/paysuper-management-api/internal/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type cardVaultMock struct {
+	paymentSystem string
+	card          *vault.Card
+	token         *vault.Token
+	err           error
+}
+
+func (p *cardVaultMock) Tokenize(ctx context.Context, paymentSystem string, card *vault.Card) (*vault.Token, error) {
+	p.paymentSystem = paymentSystem
+	p.card = card
+	return p.token, p.err
+}
+
+type TokenizationTestSuite struct {
+	suite.Suite
+	router   *TokenizationRoute
+	caller   *test.EchoReqResCaller
+	vault    *cardVaultMock
+	methodId string
+	year     string
+}
+
+func Test_Tokenization(t *testing.T) {
+	suite.Run(t, new(TokenizationTestSuite))
+}
+
+func (suite *TokenizationTestSuite) SetupTest() {
+	var e error
+	settings := test.DefaultSettings()
+	srv := common.Services{
+		Billing: mock.NewBillingServerOkMock(),
+	}
+	suite.vault = &cardVaultMock{token: &vault.Token{Token: "tok_1"}}
+	suite.caller, e = test.SetUp(settings, srv, func(set *test.TestSet, mw test.Middleware) common.Handlers {
+		suite.router = NewTokenizationRoute(set.HandlerSet, suite.vault, set.GlobalConfig)
+		return common.Handlers{
+			suite.router,
+		}
+	})
+	if e != nil {
+		panic(e)
+	}
+
+	suite.methodId = "5be2d0b4b0b30d0007383ce6"
+	suite.year = strconv.Itoa(time.Now().Year() + 1)
+	suite.router.cfg.CardTokenizationVaultUrl = "http://vault.local"
+	suite.router.cfg.CardTokenizationVaults = map[string]string{suite.methodId: "cardpay"}
+}
+
+func (suite *TokenizationTestSuite) TearDownTest() {}
+
+func (suite *TokenizationTestSuite) tokenize(body string) (*echo.HTTPError, string) {
+	res, err := suite.caller.Builder().
+		Method(http.MethodPost).
+		Path(common.AuthProjectGroupPath + paymentTokenizePath).
+		Init(test.ReqInitJSON()).
+		BodyString(body).
+		Exec(suite.T())
+
+	if err != nil {
+		httpErr, ok := err.(*echo.HTTPError)
+		assert.True(suite.T(), ok)
+		return httpErr, ""
+	}
+
+	assert.Equal(suite.T(), http.StatusOK, res.Code)
+
+	return nil, res.Body.String()
+}
+
+func (suite *TokenizationTestSuite) getBody(methodId, pan, year string) string {
+	return `{"payment_method_id": "` + methodId + `", "pan": "` + pan + `", "cvv": "123", "month": "12", "year": "` + year + `"}`
+}
+
+func (suite *TokenizationTestSuite) TestTokenization_Ok() {
+	httpErr, body := suite.tokenize(suite.getBody(suite.methodId, "4000 0000 0000 0002", suite.year))
+	assert.Nil(suite.T(), httpErr)
+	assert.Contains(suite.T(), body, `"card_token":"tok_1"`)
+	assert.Contains(suite.T(), body, `"masked_pan":"400000******0002"`)
+	assert.NotContains(suite.T(), body, "4000000000000002")
+	assert.Equal(suite.T(), "cardpay", suite.vault.paymentSystem)
+	assert.Equal(suite.T(), "4000000000000002", suite.vault.card.Pan)
+}
+
+func (suite *TokenizationTestSuite) TestTokenization_BodyDumpRedacted_Ok() {
+	for _, pan := range []string{"4000000000000002", "4000000000000001"} {
+		dump := &bytes.Buffer{}
+		_, err := suite.caller.Builder().
+			Method(http.MethodPost).
+			Path(common.AuthProjectGroupPath + paymentTokenizePath).
+			Init(test.ReqInitJSON()).
+			Init(func(request *http.Request, mw test.Middleware) {
+				mw.Post(middleware.BodyDump(func(ctx echo.Context, reqBody, resBody []byte) {
+					dump.WriteString(fmt.Sprint(common.BodyDumpFields(ctx, reqBody, resBody)))
+				}))
+			}).
+			BodyString(suite.getBody(suite.methodId, pan, suite.year)).
+			Exec(suite.T())
+
+		if pan == "4000000000000002" {
+			assert.NoError(suite.T(), err)
+		} else {
+			assert.Error(suite.T(), err)
+		}
+
+		assert.Contains(suite.T(), dump.String(), common.BodyDumpRedacted)
+		assert.NotContains(suite.T(), dump.String(), pan)
+		assert.NotContains(suite.T(), dump.String(), `"cvv"`)
+		assert.NotContains(suite.T(), dump.String(), "tok_1")
+	}
+}
+
+func (suite *TokenizationTestSuite) TestTokenization_NotConfigured_Error() {
+	suite.router.cfg.CardTokenizationVaultUrl = ""
+
+	httpErr, _ := suite.tokenize(suite.getBody(suite.methodId, "4000000000000002", suite.year))
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardTokenizationNotConfigured, httpErr.Message)
+	assert.Nil(suite.T(), suite.vault.card)
+}
+
+func (suite *TokenizationTestSuite) TestTokenization_PaymentMethodNotSupported_Error() {
+	httpErr, _ := suite.tokenize(suite.getBody("5be2d0b4b0b30d0007383ce7", "4000000000000002", suite.year))
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardTokenizationNotSupported, httpErr.Message)
+}
+
+func (suite *TokenizationTestSuite) TestTokenization_CardInvalid_Error() {
+	httpErr, _ := suite.tokenize(suite.getBody(suite.methodId, "4000000000000001", suite.year))
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardInvalid, httpErr.Message)
+
+	httpErr, _ = suite.tokenize(suite.getBody(suite.methodId, "4000000000000002", strconv.Itoa(time.Now().Year()-1)))
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardInvalid, httpErr.Message)
+	assert.Nil(suite.T(), suite.vault.card)
+}
+
+func (suite *TokenizationTestSuite) TestTokenization_CardRejected_Error() {
+	suite.vault.err = vault.ErrorCardRejected
+
+	httpErr, _ := suite.tokenize(suite.getBody(suite.methodId, "4000000000000002", suite.year))
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardRejected, httpErr.Message)
+}
+
+func (suite *TokenizationTestSuite) TestTokenization_VaultFailed_Error() {
+	suite.vault.err = errors.New("some error")
+
+	httpErr, _ := suite.tokenize(suite.getBody(suite.methodId, "4000000000000002", suite.year))
+	assert.Equal(suite.T(), http.StatusBadGateway, httpErr.Code)
+	assert.Equal(suite.T(), common.ErrorMessageCardTokenizationFailed, httpErr.Message)
+}
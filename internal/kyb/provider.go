@@ -0,0 +1,167 @@
+package kyb
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	DecisionApproved = "approved"
+	DecisionRejected = "rejected"
+	DecisionReview   = "review"
+
+	// SignatureHeader contains hex encoded HMAC-SHA256 of the callback body signed by callback secret
+	SignatureHeader = "X-KYB-SIGNATURE"
+	apiKeyHeader    = "X-API-KEY"
+
+	maxResponseSize = 64 * 1024
+
+	// DecisionMaxAge is the max difference between decision timestamp and current time, older decisions are
+	// rejected as replayed, so nonce of the decision has to be remembered for this time only
+	DecisionMaxAge = 5 * time.Minute
+)
+
+var (
+	ErrorProviderNotConfigured = errors.New("kyb provider isn't configured")
+	ErrorSignatureInvalid      = errors.New("kyb decision signature is invalid")
+	ErrorDecisionInvalid       = errors.New("kyb decision is invalid")
+	ErrorDecisionExpired       = errors.New("kyb decision is expired")
+)
+
+// Submission is the merchant onboarding data sent to the provider for verification
+type Submission struct {
+	ReferenceId string                       `json:"reference_id"`
+	Company     *billing.MerchantCompanyInfo `json:"company"`
+	Contacts    *billing.MerchantContact     `json:"contacts"`
+	CallbackUrl string                       `json:"callback_url"`
+}
+
+// Decision is the verification result received from the provider callback, nonce and timestamp are signed with
+// the decision and protect the callback from replay
+type Decision struct {
+	ReferenceId string `json:"reference_id"`
+	Decision    string `json:"decision"`
+	Reason      string `json:"reason"`
+	Nonce       string `json:"nonce"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+// Provider describes an external KYB (know your business) compliance provider
+type Provider interface {
+	// Submit sends merchant data for verification, decision comes later to the callback url
+	Submit(ctx context.Context, submission *Submission) error
+	// ParseDecision verifies callback signature and timestamp and decodes the decision, uniqueness of the nonce
+	// is checked by caller
+	ParseDecision(body []byte, signature string) (*Decision, error)
+}
+
+// HttpProvider is a provider speaking plain JSON over HTTP
+type HttpProvider struct {
+	url        string
+	apiKey     string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewHttpProvider
+func NewHttpProvider(url, apiKey, secret string, timeout time.Duration) *HttpProvider {
+	return &HttpProvider{
+		url:        url,
+		apiKey:     apiKey,
+		secret:     secret,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Submit
+func (p *HttpProvider) Submit(ctx context.Context, submission *Submission) error {
+	if p.url == "" {
+		return ErrorProviderNotConfigured
+	}
+
+	body, err := json.Marshal(submission)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apiKeyHeader, p.apiKey)
+
+	rsp, err := p.httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer rsp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, maxResponseSize))
+
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("kyb provider responded with status %d", rsp.StatusCode)
+	}
+
+	return nil
+}
+
+// ParseDecision
+func (p *HttpProvider) ParseDecision(body []byte, signature string) (*Decision, error) {
+	if p.secret == "" {
+		return nil, ErrorProviderNotConfigured
+	}
+
+	expected, err := hex.DecodeString(signature)
+
+	if err != nil || !hmac.Equal(expected, Sign(body, p.secret)) {
+		return nil, ErrorSignatureInvalid
+	}
+
+	decision := &Decision{}
+
+	if err := json.Unmarshal(body, decision); err != nil {
+		return nil, ErrorDecisionInvalid
+	}
+
+	if decision.ReferenceId == "" || decision.Nonce == "" || decision.Timestamp <= 0 {
+		return nil, ErrorDecisionInvalid
+	}
+
+	age := time.Since(time.Unix(decision.Timestamp, 0))
+
+	if age > DecisionMaxAge || age < -DecisionMaxAge {
+		return nil, ErrorDecisionExpired
+	}
+
+	switch decision.Decision {
+	case DecisionApproved, DecisionRejected, DecisionReview:
+		return decision, nil
+	}
+
+	return nil, ErrorDecisionInvalid
+}
+
+// Sign returns HMAC-SHA256 of the body
+func Sign(body []byte, secret string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,89 @@
+package kyb
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHttpProvider_Submit(t *testing.T) {
+	var received *Submission
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(apiKeyHeader) != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		received = &Submission{}
+		_ = json.Unmarshal(body, received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	submission := &Submission{
+		ReferenceId: "ffffffffffffffffffffffff",
+		Company:     &billing.MerchantCompanyInfo{Name: "merchant1", Country: "RU"},
+		CallbackUrl: "https://api.paysuper.online/webhook/kyb/decision",
+	}
+
+	err := NewHttpProvider(srv.URL, "key", "secret", time.Second).Submit(context.Background(), submission)
+	assert.NoError(t, err)
+	assert.Equal(t, submission.ReferenceId, received.ReferenceId)
+	assert.Equal(t, "merchant1", received.Company.Name)
+
+	err = NewHttpProvider(srv.URL, "wrong", "secret", time.Second).Submit(context.Background(), submission)
+	assert.Error(t, err)
+
+	err = NewHttpProvider("", "key", "secret", time.Second).Submit(context.Background(), submission)
+	assert.Equal(t, ErrorProviderNotConfigured, err)
+}
+
+func TestHttpProvider_ParseDecision(t *testing.T) {
+	p := NewHttpProvider("", "", "secret", time.Second)
+	body := []byte(fmt.Sprintf(
+		`{"reference_id": "ffffffffffffffffffffffff", "decision": "approved", "nonce": "n1", "timestamp": %d}`,
+		time.Now().Unix(),
+	))
+
+	decision, err := p.ParseDecision(body, hex.EncodeToString(Sign(body, "secret")))
+	assert.NoError(t, err)
+	assert.Equal(t, DecisionApproved, decision.Decision)
+	assert.Equal(t, "n1", decision.Nonce)
+
+	_, err = p.ParseDecision(body, hex.EncodeToString(Sign(body, "other")))
+	assert.Equal(t, ErrorSignatureInvalid, err)
+
+	_, err = p.ParseDecision(body, "not_hex")
+	assert.Equal(t, ErrorSignatureInvalid, err)
+
+	body = []byte(`{"reference_id": "ffffffffffffffffffffffff", "decision": "approved"}`)
+	_, err = p.ParseDecision(body, hex.EncodeToString(Sign(body, "secret")))
+	assert.Equal(t, ErrorDecisionInvalid, err)
+
+	body = []byte(fmt.Sprintf(
+		`{"reference_id": "ffffffffffffffffffffffff", "decision": "approved", "nonce": "n1", "timestamp": %d}`,
+		time.Now().Add(-DecisionMaxAge-time.Minute).Unix(),
+	))
+	_, err = p.ParseDecision(body, hex.EncodeToString(Sign(body, "secret")))
+	assert.Equal(t, ErrorDecisionExpired, err)
+
+	body = []byte(fmt.Sprintf(
+		`{"reference_id": "ffffffffffffffffffffffff", "decision": "maybe", "nonce": "n1", "timestamp": %d}`,
+		time.Now().Unix(),
+	))
+	_, err = p.ParseDecision(body, hex.EncodeToString(Sign(body, "secret")))
+	assert.Equal(t, ErrorDecisionInvalid, err)
+
+	_, err = NewHttpProvider("", "", "", time.Second).ParseDecision(body, "")
+	assert.Equal(t, ErrorProviderNotConfigured, err)
+}
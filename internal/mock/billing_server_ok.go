@@ -1025,7 +1025,14 @@ func (s *BillingServerOkMock) GetOrderPublic(
 	in *grpc.GetOrderRequest,
 	opts ...client.CallOption,
 ) (*grpc.GetOrderPublicResponse, error) {
-	return &grpc.GetOrderPublicResponse{}, nil
+	return &grpc.GetOrderPublicResponse{
+		Status: pkg.ResponseStatusOk,
+		Item: &billing.OrderViewPublic{
+			Uuid:            in.Id,
+			Project:         &billing.ProjectOrder{Id: bson.NewObjectId().Hex()},
+			TransactionDate: ptypes.TimestampNow(),
+		},
+	}, nil
 }
 
 func (s *BillingServerOkMock) GetOrderPrivate(
@@ -0,0 +1,237 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	billMock "github.com/paysuper/paysuper-billing-server/pkg/mocks"
+	mock2 "github.com/stretchr/testify/mock"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type billingCallResult struct {
+	response interface{}
+	err      error
+}
+
+// BillingServerBuilder programs behaviour of billing service mock per method: queued responses,
+// injected latency and random failures, to test retry, timeout and partial failure paths of handlers
+type BillingServerBuilder struct {
+	mx      sync.Mutex
+	rnd     *rand.Rand
+	methods map[string]*BillingMethodBuilder
+	order   []string
+	// pending keeps errors of calls by request until the mock takes them after the response
+	pending map[interface{}][]error
+}
+
+// BillingMethodBuilder holds behaviour of single billing service method,
+// queued results are returned first, default result is returned when queue is exhausted
+type BillingMethodBuilder struct {
+	builder   *BillingServerBuilder
+	name      string
+	queue     []*billingCallResult
+	def       *billingCallResult
+	latency   time.Duration
+	errorRate float64
+	rateErr   error
+	calls     int
+}
+
+func NewBillingServerBuilder() *BillingServerBuilder {
+	return &BillingServerBuilder{
+		rnd:     rand.New(rand.NewSource(1)),
+		methods: make(map[string]*BillingMethodBuilder),
+		pending: make(map[interface{}][]error),
+	}
+}
+
+// Seed sets seed of random generator used for error rates, generator is seeded with 1 by default
+// to keep tests reproducible
+func (b *BillingServerBuilder) Seed(seed int64) *BillingServerBuilder {
+	b.rnd = rand.New(rand.NewSource(seed))
+	return b
+}
+
+// Method returns behaviour of billing service method with the name, creating it on first use
+func (b *BillingServerBuilder) Method(name string) *BillingMethodBuilder {
+	if m, ok := b.methods[name]; ok {
+		return m
+	}
+
+	m := &BillingMethodBuilder{builder: b, name: name}
+	b.methods[name] = m
+	b.order = append(b.order, name)
+
+	return m
+}
+
+// Calls returns count of calls of billing service method made since mock was built
+func (b *BillingServerBuilder) Calls(name string) int {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if m, ok := b.methods[name]; ok {
+		return m.calls
+	}
+
+	return 0
+}
+
+// Build returns billing service mock, methods which weren't programmed panic on call
+// as any other testify mock does. Results are returned by functions the mock calls with arguments
+// of each call, so concurrent calls of the same method get their own results
+func (b *BillingServerBuilder) Build() *billMock.BillingService {
+	bill := &billMock.BillingService{}
+
+	for _, name := range b.order {
+		method, ok := reflect.TypeOf(bill).MethodByName(name)
+
+		if !ok {
+			panic(fmt.Sprintf("billing mock: method %s isn't found", name))
+		}
+
+		rsp, err := b.methods[name].returnFuncs(method.Type)
+		bill.On(name, mock2.Anything, mock2.Anything).Return(rsp, err)
+	}
+
+	return bill
+}
+
+// Respond adds successful response to the queue of method
+func (m *BillingMethodBuilder) Respond(rsp interface{}) *BillingMethodBuilder {
+	m.queue = append(m.queue, &billingCallResult{response: rsp})
+	return m
+}
+
+// Fail adds error to the queue of method
+func (m *BillingMethodBuilder) Fail(err error) *BillingMethodBuilder {
+	m.queue = append(m.queue, &billingCallResult{err: err})
+	return m
+}
+
+// Always sets response returned after queue of method is exhausted
+func (m *BillingMethodBuilder) Always(rsp interface{}) *BillingMethodBuilder {
+	m.def = &billingCallResult{response: rsp}
+	return m
+}
+
+// AlwaysFail sets error returned after queue of method is exhausted
+func (m *BillingMethodBuilder) AlwaysFail(err error) *BillingMethodBuilder {
+	m.def = &billingCallResult{err: err}
+	return m
+}
+
+// Latency delays every call of method, call is interrupted with context error when request context is done earlier
+func (m *BillingMethodBuilder) Latency(latency time.Duration) *BillingMethodBuilder {
+	m.latency = latency
+	return m
+}
+
+// ErrorRate makes share of calls of method fail with the error regardless of queue, rate is in range from 0 to 1
+func (m *BillingMethodBuilder) ErrorRate(rate float64, err error) *BillingMethodBuilder {
+	m.errorRate = rate
+	m.rateErr = err
+	return m
+}
+
+// Method switches to another method of the same mock
+func (m *BillingMethodBuilder) Method(name string) *BillingMethodBuilder {
+	return m.builder.Method(name)
+}
+
+// Build returns billing service mock with all programmed methods
+func (m *BillingMethodBuilder) Build() *billMock.BillingService {
+	return m.builder.Build()
+}
+
+func (m *BillingMethodBuilder) next(ctx context.Context) *billingCallResult {
+	if m.latency > 0 {
+		select {
+		case <-ctx.Done():
+			m.count()
+			return &billingCallResult{err: ctx.Err()}
+		case <-time.After(m.latency):
+		}
+	}
+
+	b := m.builder
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	m.calls++
+
+	if m.errorRate > 0 && b.rnd.Float64() < m.errorRate {
+		return &billingCallResult{err: m.rateErr}
+	}
+
+	if len(m.queue) > 0 {
+		res := m.queue[0]
+		m.queue = m.queue[1:]
+		return res
+	}
+
+	if m.def != nil {
+		return m.def
+	}
+
+	return &billingCallResult{err: fmt.Errorf("billing mock: responses of %s are exhausted", m.name)}
+}
+
+func (m *BillingMethodBuilder) count() {
+	m.builder.mx.Lock()
+	m.calls++
+	m.builder.mx.Unlock()
+}
+
+// returnFuncs makes response and error functions in the form the mock calls them for results of call, the mock calls
+// the response function first, so result of the call is taken there and its error is handed over by request
+func (m *BillingMethodBuilder) returnFuncs(method reflect.Type) (interface{}, interface{}) {
+	in := []reflect.Type{method.In(1), method.In(2), method.In(3)}
+	b := m.builder
+
+	rsp := reflect.MakeFunc(reflect.FuncOf(in, []reflect.Type{method.Out(0)}, true), func(args []reflect.Value) []reflect.Value {
+		ctx, ok := args[0].Interface().(context.Context)
+
+		if !ok {
+			ctx = context.Background()
+		}
+
+		res := m.next(ctx)
+		req := args[1].Interface()
+
+		b.mx.Lock()
+		b.pending[req] = append(b.pending[req], res.err)
+		b.mx.Unlock()
+
+		if res.response == nil {
+			return []reflect.Value{reflect.Zero(method.Out(0))}
+		}
+
+		return []reflect.Value{reflect.ValueOf(res.response)}
+	})
+
+	err := reflect.MakeFunc(reflect.FuncOf(in, []reflect.Type{method.Out(1)}, true), func(args []reflect.Value) []reflect.Value {
+		req := args[1].Interface()
+
+		b.mx.Lock()
+		errs := b.pending[req]
+		b.pending[req] = errs[1:]
+
+		if len(b.pending[req]) == 0 {
+			delete(b.pending, req)
+		}
+
+		b.mx.Unlock()
+
+		if errs[0] == nil {
+			return []reflect.Value{reflect.Zero(method.Out(1))}
+		}
+
+		return []reflect.Value{reflect.ValueOf(&errs[0]).Elem()}
+	})
+
+	return rsp.Interface(), err.Interface()
+}
@@ -0,0 +1,67 @@
+package mock
+
+import (
+	"context"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestBillingServerBuilder_ConcurrentCalls_Ok(t *testing.T) {
+	const calls = 50
+
+	method := NewBillingServerBuilder().Method("GetMerchantBy")
+
+	for i := 0; i < calls; i++ {
+		method.Respond(&grpc.GetMerchantResponse{Status: int32(i)})
+	}
+
+	bill := method.Fail(SomeError).Build()
+
+	var (
+		wg       sync.WaitGroup
+		mx       sync.Mutex
+		statuses = make(map[int32]bool)
+		errs     int
+	)
+
+	for i := 0; i < calls+1; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			rsp, err := bill.GetMerchantBy(context.Background(), &grpc.GetMerchantByRequest{})
+
+			mx.Lock()
+			defer mx.Unlock()
+
+			if err != nil {
+				assert.Nil(t, rsp)
+				errs++
+				return
+			}
+
+			statuses[rsp.Status] = true
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, statuses, calls)
+	assert.Equal(t, 1, errs)
+	bill.AssertNumberOfCalls(t, "GetMerchantBy", calls+1)
+}
+
+func TestBillingServerBuilder_Exhausted_Error(t *testing.T) {
+	bill := NewBillingServerBuilder().
+		Method("GetMerchantBy").Respond(&grpc.GetMerchantResponse{}).
+		Build()
+
+	_, err := bill.GetMerchantBy(context.Background(), &grpc.GetMerchantByRequest{})
+	assert.NoError(t, err)
+
+	_, err = bill.GetMerchantBy(context.Background(), &grpc.GetMerchantByRequest{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package money
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultExponent = 2
+)
+
+var (
+	// currencyExponents contains ISO 4217 currencies with minor unit other than two decimals
+	currencyExponents = map[string]int{
+		"BIF": 0,
+		"CLP": 0,
+		"DJF": 0,
+		"GNF": 0,
+		"ISK": 0,
+		"JPY": 0,
+		"KMF": 0,
+		"KRW": 0,
+		"PYG": 0,
+		"RWF": 0,
+		"UGX": 0,
+		"UYI": 0,
+		"VND": 0,
+		"VUV": 0,
+		"XAF": 0,
+		"XOF": 0,
+		"XPF": 0,
+		"BHD": 3,
+		"IQD": 3,
+		"JOD": 3,
+		"KWD": 3,
+		"LYD": 3,
+		"OMR": 3,
+		"TND": 3,
+		"CLF": 4,
+		"UYW": 4,
+	}
+
+	// currencyRounding contains currencies which rounding rule differs from RoundHalfUp, rules are set on start
+	// from configuration by SetCurrencyRounding
+	currencyRounding   = map[string]RoundingMode{}
+	currencyRoundingMu sync.RWMutex
+)
+
+// CurrencyInfo
+type CurrencyInfo struct {
+	Code     string
+	Exponent int
+	Rounding RoundingMode
+}
+
+// Info returns minor unit and rounding metadata of the currency
+func Info(currency string) *CurrencyInfo {
+	code := strings.ToUpper(currency)
+	info := &CurrencyInfo{Code: code, Exponent: Exponent(code), Rounding: RoundHalfUp}
+
+	currencyRoundingMu.RLock()
+	defer currencyRoundingMu.RUnlock()
+
+	if mode, ok := currencyRounding[code]; ok {
+		info.Rounding = mode
+	}
+
+	return info
+}
+
+// SetCurrencyRounding replaces rounding rules of currencies by map of currency to rounding mode name,
+// e.g. {"JPY": "down", "EUR": "half_even"}
+func SetCurrencyRounding(rules map[string]string) error {
+	modes := make(map[string]RoundingMode, len(rules))
+
+	if err := parseRoundingModes(rules, modes); err != nil {
+		return err
+	}
+
+	currencyRoundingMu.Lock()
+	currencyRounding = modes
+	currencyRoundingMu.Unlock()
+
+	return nil
+}
+
+// Round rounds decimal amount to minor unit of the currency by the currency rule
+func Round(val float64, currency string) float64 {
+	info := Info(currency)
+	m, err := FromFloat(val, info.Code, info.Rounding)
+
+	if err != nil {
+		return val
+	}
+
+	return m.Float64()
+}
+
+// HasValidPrecision checks that amount has no more fraction digits than the currency exponent allows
+func HasValidPrecision(val float64, currency string) bool {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(val, 'f', -1, 64))
+
+	if !ok {
+		return false
+	}
+
+	return r.Mul(r, new(big.Rat).SetInt(pow10(Exponent(currency)))).IsInt()
+}
+
+// Format returns decimal amount rounded by the currency rule with as many fraction digits as the currency exponent
+func Format(val float64, currency string) string {
+	info := Info(currency)
+	m, err := FromFloat(val, info.Code, info.Rounding)
+
+	if err != nil {
+		return strconv.FormatFloat(val, 'f', info.Exponent, 64)
+	}
+
+	return m.String()
+}
+
+// Exponent returns number of minor unit digits for currency
+func Exponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+
+	return defaultExponent
+}
@@ -0,0 +1,248 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds half away from zero, 2.5 -> 3, -2.5 -> -3
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds half to the nearest even digit (bankers' rounding), 2.5 -> 2, 3.5 -> 4
+	RoundHalfEven
+	// RoundDown truncates towards zero
+	RoundDown
+	// RoundUp rounds away from zero
+	RoundUp
+)
+
+const (
+	RoundingModeHalfUp   = "half_up"
+	RoundingModeHalfEven = "half_even"
+	RoundingModeDown     = "down"
+	RoundingModeUp       = "up"
+)
+
+var (
+	ErrorCurrencyMismatch    = errors.New("money: currency mismatch")
+	ErrorInvalidAmount       = errors.New("money: invalid amount")
+	ErrorInvalidRoundingMode = errors.New("money: invalid rounding mode")
+	ErrorAmountOverflow      = errors.New("money: amount overflow")
+
+	roundingModeNames = map[RoundingMode]string{
+		RoundHalfUp:   RoundingModeHalfUp,
+		RoundHalfEven: RoundingModeHalfEven,
+		RoundDown:     RoundingModeDown,
+		RoundUp:       RoundingModeUp,
+	}
+)
+
+// ParseRoundingMode
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	for mode, modeName := range roundingModeNames {
+		if modeName == strings.ToLower(strings.TrimSpace(name)) {
+			return mode, nil
+		}
+	}
+
+	return RoundHalfUp, ErrorInvalidRoundingMode
+}
+
+// String
+func (m RoundingMode) String() string {
+	return roundingModeNames[m]
+}
+
+// MarshalText
+func (m RoundingMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText
+func (m *RoundingMode) UnmarshalText(text []byte) (err error) {
+	*m, err = ParseRoundingMode(string(text))
+	return err
+}
+
+// Money is an amount in minor units of the currency (cents for USD, yens for JPY)
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// FromFloat converts decimal amount to minor units using the currency exponent
+func FromFloat(val float64, currency string, mode RoundingMode) (Money, error) {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(val, 'f', -1, 64))
+
+	if !ok {
+		return Money{}, ErrorInvalidAmount
+	}
+
+	return fromRat(r, currency, mode), nil
+}
+
+// Parse converts decimal string amount to minor units using the currency exponent
+func Parse(val string, currency string, mode RoundingMode) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(val))
+
+	if !ok {
+		return Money{}, ErrorInvalidAmount
+	}
+
+	return fromRat(r, currency, mode), nil
+}
+
+// Float64 returns decimal amount in major units
+func (m Money) Float64() float64 {
+	f, _ := m.rat().Float64()
+	return f
+}
+
+// String returns decimal amount with exactly as many fraction digits as the currency exponent
+func (m Money) String() string {
+	return m.rat().FloatString(Exponent(m.Currency))
+}
+
+// IsZero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrorCurrencyMismatch
+	}
+
+	return New(m.Amount+o.Amount, m.Currency), nil
+}
+
+// Sub
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrorCurrencyMismatch
+	}
+
+	return New(m.Amount-o.Amount, m.Currency), nil
+}
+
+// Mul multiplies amount by the number of units, ErrorAmountOverflow is returned when the result doesn't fit
+// into minor units
+func (m Money) Mul(n int64) (Money, error) {
+	amount := m.Amount * n
+
+	if m.Amount != 0 && (amount/m.Amount != n || (m.Amount == -1 && n == math.MinInt64)) {
+		return Money{}, ErrorAmountOverflow
+	}
+
+	return New(amount, m.Currency), nil
+}
+
+// MulRate multiplies amount by rate (percent, VAT rate, conversion rate in the same currency) and rounds the result
+func (m Money) MulRate(rate float64, mode RoundingMode) Money {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+
+	if !ok {
+		return New(0, m.Currency)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt64(m.Amount))
+
+	return New(round(r, mode), m.Currency)
+}
+
+// Allocate splits amount into parts proportional to ratios, remainder goes to the first parts one minor unit each
+// so sum of parts is always equal to the original amount
+func (m Money) Allocate(ratios ...int64) []Money {
+	var total int64
+
+	for _, r := range ratios {
+		total += r
+	}
+
+	parts := make([]Money, len(ratios))
+
+	if total == 0 {
+		for i := range parts {
+			parts[i] = New(0, m.Currency)
+		}
+
+		return parts
+	}
+
+	remainder := m.Amount
+
+	for i, r := range ratios {
+		parts[i] = New(m.Amount*r/total, m.Currency)
+		remainder -= parts[i].Amount
+	}
+
+	step := int64(1)
+
+	if remainder < 0 {
+		step = -1
+	}
+
+	for i := 0; remainder != 0; i = (i + 1) % len(parts) {
+		parts[i].Amount += step
+		remainder -= step
+	}
+
+	return parts
+}
+
+func (m Money) rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(Exponent(m.Currency)))
+}
+
+func fromRat(r *big.Rat, currency string, mode RoundingMode) Money {
+	r = new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(Exponent(currency))))
+	return New(round(r, mode), currency)
+}
+
+func round(r *big.Rat, mode RoundingMode) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	neg := num.Sign() < 0
+	num.Abs(num)
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if rem.Sign() != 0 {
+		cmp := new(big.Int).Mul(rem, big.NewInt(2)).Cmp(den)
+
+		switch mode {
+		case RoundUp:
+			quo.Add(quo, big.NewInt(1))
+		case RoundHalfUp:
+			if cmp >= 0 {
+				quo.Add(quo, big.NewInt(1))
+			}
+		case RoundHalfEven:
+			if cmp > 0 || (cmp == 0 && quo.Bit(0) == 1) {
+				quo.Add(quo, big.NewInt(1))
+			}
+		}
+	}
+
+	if neg {
+		quo.Neg(quo)
+	}
+
+	return quo.Int64()
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
@@ -0,0 +1,210 @@
+package money
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestExponent(t *testing.T) {
+	assert.Equal(t, 2, Exponent("USD"))
+	assert.Equal(t, 0, Exponent("JPY"))
+	assert.Equal(t, 0, Exponent("krw"))
+	assert.Equal(t, 3, Exponent("KWD"))
+	assert.Equal(t, 2, Exponent("unknown"))
+}
+
+func TestInfo(t *testing.T) {
+	info := Info("jpy")
+	assert.Equal(t, "JPY", info.Code)
+	assert.Equal(t, 0, info.Exponent)
+	assert.Equal(t, RoundHalfUp, info.Rounding)
+}
+
+func TestSetCurrencyRounding(t *testing.T) {
+	defer func() { _ = SetCurrencyRounding(nil) }()
+
+	err := SetCurrencyRounding(map[string]string{"jpy": "down", "EUR": "half_even"})
+	assert.NoError(t, err)
+	assert.Equal(t, RoundDown, Info("JPY").Rounding)
+	assert.Equal(t, RoundHalfEven, Info("EUR").Rounding)
+	assert.Equal(t, RoundHalfUp, Info("USD").Rounding)
+	assert.Equal(t, "1000", Format(1000.9, "JPY"))
+	assert.Equal(t, "2.12", Format(2.125, "EUR"))
+	assert.Equal(t, 1000.0, Round(1000.9, "JPY"))
+
+	err = SetCurrencyRounding(map[string]string{"JPY": "unknown"})
+	assert.Equal(t, ErrorInvalidRoundingMode, err)
+	assert.Equal(t, RoundDown, Info("JPY").Rounding)
+}
+
+func TestRound(t *testing.T) {
+	assert.Equal(t, 10.99, Round(10.985, "USD"))
+	assert.Equal(t, 1001.0, Round(1000.5, "JPY"))
+	assert.Equal(t, 1.126, Round(1.1255, "KWD"))
+}
+
+func TestHasValidPrecision(t *testing.T) {
+	assert.True(t, HasValidPrecision(10.99, "USD"))
+	assert.True(t, HasValidPrecision(10, "USD"))
+	assert.False(t, HasValidPrecision(10.999, "USD"))
+	assert.True(t, HasValidPrecision(1000, "JPY"))
+	assert.False(t, HasValidPrecision(1000.5, "JPY"))
+	assert.False(t, HasValidPrecision(100.01, "KRW"))
+	assert.True(t, HasValidPrecision(1.125, "KWD"))
+	assert.False(t, HasValidPrecision(1.1255, "KWD"))
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "10.99", Format(10.99, "USD"))
+	assert.Equal(t, "11.00", Format(10.999, "USD"))
+	assert.Equal(t, "1001", Format(1000.5, "JPY"))
+	assert.Equal(t, "1.126", Format(1.1255, "KWD"))
+}
+
+func TestFromFloat_RoundingModes(t *testing.T) {
+	cases := []struct {
+		val      float64
+		currency string
+		mode     RoundingMode
+		expected int64
+	}{
+		{1.005, "USD", RoundHalfUp, 101},
+		{1.005, "USD", RoundHalfEven, 100},
+		{1.015, "USD", RoundHalfEven, 102},
+		{1.005, "USD", RoundDown, 100},
+		{1.001, "USD", RoundUp, 101},
+		{1.001, "USD", RoundHalfUp, 100},
+		{-1.005, "USD", RoundHalfUp, -101},
+		{-1.005, "USD", RoundHalfEven, -100},
+		{-1.009, "USD", RoundDown, -100},
+		{-1.001, "USD", RoundUp, -101},
+		{2.5, "JPY", RoundHalfUp, 3},
+		{2.5, "JPY", RoundHalfEven, 2},
+		{3.5, "JPY", RoundHalfEven, 4},
+		{2.4, "JPY", RoundUp, 3},
+		{1.0005, "KWD", RoundHalfUp, 1001},
+		{1.0005, "KWD", RoundHalfEven, 1000},
+		{0.1 + 0.2, "USD", RoundHalfUp, 30},
+		{100, "USD", RoundHalfUp, 10000},
+		{0, "USD", RoundUp, 0},
+	}
+
+	for _, c := range cases {
+		m, err := FromFloat(c.val, c.currency, c.mode)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, m.Amount, "value %v in %s with mode %d", c.val, c.currency, c.mode)
+	}
+}
+
+func TestParse(t *testing.T) {
+	m, err := Parse("10.125", "USD", RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1012), m.Amount)
+
+	m, err = Parse(" 1000 ", "JPY", RoundHalfUp)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), m.Amount)
+
+	_, err = Parse("1,00", "USD", RoundHalfUp)
+	assert.Equal(t, ErrorInvalidAmount, err)
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "10.50", New(1050, "USD").String())
+	assert.Equal(t, "-0.05", New(-5, "EUR").String())
+	assert.Equal(t, "1050", New(1050, "JPY").String())
+	assert.Equal(t, "1.050", New(1050, "KWD").String())
+	assert.Equal(t, 10.5, New(1050, "USD").Float64())
+}
+
+func TestAddSub(t *testing.T) {
+	m, err := New(1050, "USD").Add(New(50, "usd"))
+	assert.NoError(t, err)
+	assert.Equal(t, New(1100, "USD"), m)
+
+	m, err = New(1050, "USD").Sub(New(2000, "USD"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-950), m.Amount)
+
+	_, err = New(1050, "USD").Add(New(50, "EUR"))
+	assert.Equal(t, ErrorCurrencyMismatch, err)
+
+	_, err = New(1050, "USD").Sub(New(50, "EUR"))
+	assert.Equal(t, ErrorCurrencyMismatch, err)
+}
+
+func TestMul(t *testing.T) {
+	m, err := New(15, "USD").Mul(100)
+	assert.NoError(t, err)
+	assert.Equal(t, New(1500, "USD"), m)
+
+	m, err = New(0, "USD").Mul(math.MaxInt64)
+	assert.NoError(t, err)
+	assert.True(t, m.IsZero())
+
+	_, err = New(100, "USD").Mul(4611686018427387905)
+	assert.Equal(t, ErrorAmountOverflow, err)
+
+	_, err = New(-1, "USD").Mul(math.MinInt64)
+	assert.Equal(t, ErrorAmountOverflow, err)
+}
+
+func TestMulRate(t *testing.T) {
+	m := New(1999, "USD")
+
+	assert.Equal(t, int64(400), m.MulRate(0.2, RoundHalfUp).Amount)
+	assert.Equal(t, int64(399), m.MulRate(0.2, RoundDown).Amount)
+	assert.Equal(t, int64(50), New(1000, "USD").MulRate(0.05, RoundHalfEven).Amount)
+	assert.Equal(t, int64(2), New(25, "USD").MulRate(0.1, RoundHalfEven).Amount)
+	assert.Equal(t, int64(3), New(25, "USD").MulRate(0.1, RoundHalfUp).Amount)
+	assert.Equal(t, int64(-3), New(-25, "USD").MulRate(0.1, RoundHalfUp).Amount)
+	assert.Equal(t, int64(0), New(1000, "JPY").MulRate(0, RoundUp).Amount)
+}
+
+func TestAllocate(t *testing.T) {
+	parts := New(1000, "USD").Allocate(1, 1, 1)
+	assert.Equal(t, []Money{New(334, "USD"), New(333, "USD"), New(333, "USD")}, parts)
+
+	parts = New(-1000, "USD").Allocate(1, 1, 1)
+	assert.Equal(t, []Money{New(-334, "USD"), New(-333, "USD"), New(-333, "USD")}, parts)
+
+	parts = New(100, "JPY").Allocate(70, 30)
+	assert.Equal(t, []Money{New(70, "JPY"), New(30, "JPY")}, parts)
+
+	parts = New(100, "USD").Allocate(0, 0)
+	assert.Equal(t, []Money{New(0, "USD"), New(0, "USD")}, parts)
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	mode, err := ParseRoundingMode(" Half_Even ")
+	assert.NoError(t, err)
+	assert.Equal(t, RoundHalfEven, mode)
+
+	_, err = ParseRoundingMode("ceil")
+	assert.Equal(t, ErrorInvalidRoundingMode, err)
+
+	b, err := RoundDown.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "down", string(b))
+}
+
+func TestRoundingPolicies(t *testing.T) {
+	p, err := NewRoundingPolicies(map[string]string{"de": "half_even"}, map[string]string{"RU": "down"})
+	assert.NoError(t, err)
+
+	policy := p.Get("DE")
+	assert.Equal(t, RoundHalfEven, policy.Vat)
+	assert.Equal(t, RoundHalfUp, policy.Commission)
+
+	policy = p.Get("ru")
+	assert.Equal(t, "RU", policy.Country)
+	assert.Equal(t, RoundHalfUp, policy.Vat)
+	assert.Equal(t, RoundDown, policy.Commission)
+
+	_, err = NewRoundingPolicies(map[string]string{"DE": "ceil"}, nil)
+	assert.Equal(t, ErrorInvalidRoundingMode, err)
+
+	var empty *RoundingPolicies
+	assert.Equal(t, RoundHalfUp, empty.Get("DE").Vat)
+}
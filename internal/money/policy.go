@@ -0,0 +1,68 @@
+package money
+
+import "strings"
+
+// RoundingPolicy describes how VAT and commissions are rounded in a country
+type RoundingPolicy struct {
+	Country    string       `json:"country"`
+	Vat        RoundingMode `json:"vat"`
+	Commission RoundingMode `json:"commission"`
+}
+
+// RoundingPolicies holds country specific rounding rules, countries without a rule use RoundHalfUp
+type RoundingPolicies struct {
+	vat        map[string]RoundingMode
+	commission map[string]RoundingMode
+}
+
+// NewRoundingPolicies creates policies from country to rounding mode name maps, e.g. {"DE": "half_even"}
+func NewRoundingPolicies(vat, commission map[string]string) (*RoundingPolicies, error) {
+	p := &RoundingPolicies{
+		vat:        make(map[string]RoundingMode),
+		commission: make(map[string]RoundingMode),
+	}
+
+	if err := parseRoundingModes(vat, p.vat); err != nil {
+		return nil, err
+	}
+
+	if err := parseRoundingModes(commission, p.commission); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Get
+func (p *RoundingPolicies) Get(country string) *RoundingPolicy {
+	country = strings.ToUpper(country)
+	policy := &RoundingPolicy{Country: country, Vat: RoundHalfUp, Commission: RoundHalfUp}
+
+	if p == nil {
+		return policy
+	}
+
+	if mode, ok := p.vat[country]; ok {
+		policy.Vat = mode
+	}
+
+	if mode, ok := p.commission[country]; ok {
+		policy.Commission = mode
+	}
+
+	return policy
+}
+
+func parseRoundingModes(src map[string]string, dst map[string]RoundingMode) error {
+	for country, name := range src {
+		mode, err := ParseRoundingMode(name)
+
+		if err != nil {
+			return err
+		}
+
+		dst[strings.ToUpper(strings.TrimSpace(country))] = mode
+	}
+
+	return nil
+}
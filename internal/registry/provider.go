@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	apiKeyHeader = "X-API-KEY"
+
+	queryCountry            = "country"
+	queryRegistrationNumber = "registration_number"
+
+	maxResponseSize = 64 * 1024
+)
+
+var (
+	ErrorProviderNotConfigured = errors.New("company registry provider isn't configured")
+	ErrorCompanyNotFound       = errors.New("company not found in registry")
+	ErrorCompanyInvalid        = errors.New("company registry response is invalid")
+)
+
+// Company is the company record found in registry by registration number
+type Company struct {
+	Name               string `json:"name"`
+	AlternativeName    string `json:"alternative_name"`
+	RegistrationNumber string `json:"registration_number"`
+	Country            string `json:"country"`
+	State              string `json:"state"`
+	City               string `json:"city"`
+	Zip                string `json:"zip"`
+	Address            string `json:"address"`
+	Website            string `json:"website"`
+}
+
+// Provider describes an external registry of companies (OpenCorporates, national registries or aggregator in front of them)
+type Provider interface {
+	// Lookup finds the company registered in the country by registration number
+	Lookup(ctx context.Context, country, registrationNumber string) (*Company, error)
+}
+
+// HttpProvider is a provider speaking plain JSON over HTTP,
+// the company is requested by GET url?country=XX&registration_number=N
+type HttpProvider struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewHttpProvider
+func NewHttpProvider(url, apiKey string, timeout time.Duration) *HttpProvider {
+	return &HttpProvider{
+		url:        url,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Lookup
+func (p *HttpProvider) Lookup(ctx context.Context, country, registrationNumber string) (*Company, error) {
+	if p.url == "" {
+		return nil, ErrorProviderNotConfigured
+	}
+
+	u, err := url.Parse(p.url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	query.Set(queryCountry, country)
+	query.Set(queryRegistrationNumber, registrationNumber)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(apiKeyHeader, p.apiKey)
+
+	rsp, err := p.httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusNotFound {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, maxResponseSize))
+		return nil, ErrorCompanyNotFound
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, maxResponseSize))
+		return nil, fmt.Errorf("company registry provider responded with status %d", rsp.StatusCode)
+	}
+
+	company := &Company{}
+
+	if err = json.NewDecoder(io.LimitReader(rsp.Body, maxResponseSize)).Decode(company); err != nil {
+		return nil, ErrorCompanyInvalid
+	}
+
+	if company.Name == "" {
+		return nil, ErrorCompanyInvalid
+	}
+
+	return company, nil
+}
@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHttpProvider_Lookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(apiKeyHeader) != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Query().Get(queryRegistrationNumber) {
+		case "1234567890":
+			if r.URL.Query().Get(queryCountry) != "GB" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name": "Company Ltd", "registration_number": "1234567890", "country": "GB", "city": "London"}`))
+		case "0000000000":
+			_, _ = w.Write([]byte(`{"registration_number": "0000000000"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewHttpProvider(srv.URL, "key", time.Second)
+
+	company, err := p.Lookup(context.Background(), "GB", "1234567890")
+	assert.NoError(t, err)
+	assert.Equal(t, "Company Ltd", company.Name)
+	assert.Equal(t, "London", company.City)
+
+	_, err = p.Lookup(context.Background(), "DE", "1234567890")
+	assert.Equal(t, ErrorCompanyNotFound, err)
+
+	_, err = p.Lookup(context.Background(), "GB", "0000000000")
+	assert.Equal(t, ErrorCompanyInvalid, err)
+
+	_, err = NewHttpProvider(srv.URL, "wrong", time.Second).Lookup(context.Background(), "GB", "1234567890")
+	assert.Error(t, err)
+
+	_, err = NewHttpProvider("", "key", time.Second).Lookup(context.Background(), "GB", "1234567890")
+	assert.Equal(t, ErrorProviderNotConfigured, err)
+}
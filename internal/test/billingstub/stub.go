@@ -0,0 +1,131 @@
+// Package billingstub is billing server stub for integration tests. The stub is go-micro service with the name of
+// billing server, so requests of the application are serialized and sent over network as in production. Responses
+// are set and received requests are read by tests through admin http api of the stub
+package billingstub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
+	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	responsesPathPrefix = "/responses/"
+	requestsPathPrefix  = "/requests/"
+	requestsPath        = "/requests"
+)
+
+// Stub keeps responses of billing server methods and requests received by them
+type Stub struct {
+	mu        sync.Mutex
+	responses map[string]json.RawMessage
+	requests  map[string][]json.RawMessage
+}
+
+// New
+func New() *Stub {
+	return &Stub{
+		responses: make(map[string]json.RawMessage),
+		requests:  make(map[string][]json.RawMessage),
+	}
+}
+
+// ServeHTTP is admin api of the stub. PUT /responses/:method sets json response of the method,
+// GET /requests/:method returns json array of requests received by the method and DELETE /requests
+// drops responses and received requests
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, responsesPathPrefix):
+		body, err := ioutil.ReadAll(r.Body)
+
+		if err != nil || !json.Valid(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.responses[strings.TrimPrefix(r.URL.Path, responsesPathPrefix)] = body
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, requestsPathPrefix):
+		requests := s.requests[strings.TrimPrefix(r.URL.Path, requestsPathPrefix)]
+
+		if requests == nil {
+			requests = []json.RawMessage{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(requests)
+	case r.Method == http.MethodDelete && r.URL.Path == requestsPath:
+		s.responses = make(map[string]json.RawMessage)
+		s.requests = make(map[string][]json.RawMessage)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *Stub) handle(method string, in, out interface{}) error {
+	req, err := json.Marshal(in)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[method] = append(s.requests[method], req)
+	rsp, ok := s.responses[method]
+
+	if !ok {
+		return fmt.Errorf("response of %s isn't set", method)
+	}
+
+	return json.Unmarshal(rsp, out)
+}
+
+// BillingService is registered as handler of the stub service, go-micro takes endpoint names from the type name,
+// so they match endpoints called by billing server client. Only methods used by integration tests are stubbed
+type BillingService struct {
+	stub *Stub
+}
+
+// NewBillingService
+func NewBillingService(stub *Stub) *BillingService {
+	return &BillingService{stub: stub}
+}
+
+// OrderCreateProcess
+func (h *BillingService) OrderCreateProcess(
+	ctx context.Context,
+	in *billing.OrderCreateRequest,
+	out *grpc.OrderCreateProcessResponse,
+) error {
+	return h.stub.handle("OrderCreateProcess", in, out)
+}
+
+// PaymentCreateProcess
+func (h *BillingService) PaymentCreateProcess(
+	ctx context.Context,
+	in *grpc.PaymentCreateRequest,
+	out *grpc.PaymentCreateResponse,
+) error {
+	return h.stub.handle("PaymentCreateProcess", in, out)
+}
+
+// PaymentCallbackProcess
+func (h *BillingService) PaymentCallbackProcess(
+	ctx context.Context,
+	in *grpc.PaymentNotifyRequest,
+	out *grpc.PaymentNotifyResponse,
+) error {
+	return h.stub.handle("PaymentCallbackProcess", in, out)
+}
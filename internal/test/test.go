@@ -3,7 +3,9 @@ package test
 import (
 	"bytes"
 	"context"
+	"github.com/alicebob/miniredis"
 	"github.com/globalsign/mgo/bson"
+	"github.com/go-redis/redis"
 	"github.com/labstack/echo/v4"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
 	httpEcho "github.com/paysuper/paysuper-management-api/pkg/http"
@@ -16,13 +18,38 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var (
 	HexId = bson.NewObjectId().Hex()
+
+	redisServer     *miniredis.Miniredis
+	redisServerOnce sync.Once
 )
 
+// RedisServer returns in-memory redis server shared by tests of the package, its data is dropped by SetUp
+func RedisServer() *miniredis.Miniredis {
+	redisServerOnce.Do(func() {
+		srv, err := miniredis.Run()
+
+		if err != nil {
+			panic(err)
+		}
+
+		redisServer = srv
+	})
+
+	return redisServer
+}
+
+// Redis returns client of RedisServer
+func Redis() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: RedisServer().Addr()})
+}
+
 // EchoReqResCaller
 type EchoReqResCaller struct {
 	dispatcher      httpEcho.Dispatcher
@@ -236,6 +263,19 @@ func (c *EchoReqResCaller) Request(method, target string, body io.Reader, init f
 	return
 }
 
+// Server starts real http server with all dispatcher routes and middlewares, used by integration tests
+func (c *EchoReqResCaller) Server() (*httptest.Server, *echo.Echo, error) {
+	he := echo.New()
+	he.Pre(c.middlewareSetUp.ListPre()...)
+	he.Use(c.middlewareSetUp.ListUse()...)
+
+	if err := c.dispatcher.Dispatch(he); err != nil {
+		return nil, nil, err
+	}
+
+	return httptest.NewServer(he), he, nil
+}
+
 // Send
 func (c *EchoReqResCaller) Builder() *QueryBuilder {
 	return NewQueryBuilder(c)
@@ -270,6 +310,8 @@ func DefaultSettings() map[string]interface{} {
 				"awsBucketReporterr":           "eu-west-1",
 				"customerTokenCookiesLifetime": "2592000s",
 				"orderInlineFormUrlMask":       "http://localhost",
+				"subdivisionsCatalogPath":      "assets/data/subdivisions.json",
+				"redisHost":                    RedisServer().Addr(),
 				"auth1": map[string]interface{}{
 					"clientId":     "unknown",
 					"clientSecret": "unknown",
@@ -282,6 +324,17 @@ func DefaultSettings() map[string]interface{} {
 
 // SetUp
 func SetUp(settings map[string]interface{}, services common.Services, setUp func(*TestSet, Middleware) common.Handlers) (*EchoReqResCaller, error) {
+	RedisServer().FlushAll()
+
+	// policies of projects are initialized on deploy, handlers fail closed without them
+	if err := common.NewRefundPolicies(Redis()).Init(); err != nil {
+		return nil, err
+	}
+
+	if err := common.NewEmailScreenings(Redis(), time.Hour).InitPolicies(); err != nil {
+		return nil, err
+	}
+
 	middlewareSetUp := &MiddlewareTestUp{}
 	testSet, _, e := BuildTestSet(
 		context.Background(),
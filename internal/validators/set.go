@@ -10,9 +10,9 @@ import (
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/billing"
 	"github.com/paysuper/paysuper-billing-server/pkg/proto/grpc"
 	"github.com/paysuper/paysuper-management-api/internal/dispatcher/common"
-	"github.com/ttacon/libphonenumber"
 	"gopkg.in/go-playground/validator.v9"
 	"regexp"
+	"strings"
 )
 
 type ValidatorSet struct {
@@ -58,7 +58,7 @@ var (
 
 // PhoneValidator
 func (v *ValidatorSet) PhoneValidator(fl validator.FieldLevel) bool {
-	_, err := libphonenumber.Parse(fl.Field().String(), "US")
+	_, err := common.NormalizePhone(fl.Field().String(), "")
 	return err == nil
 }
 
@@ -138,17 +138,35 @@ func (v *ValidatorSet) CompanyNameValidator(fl validator.FieldLevel) bool {
 func (v *ValidatorSet) MerchantCompanyValidator(sl validator.StructLevel) {
 	company := sl.Current().Interface().(billing.MerchantCompanyInfo)
 
-	reg := zipGeneralRegexp
+	if !ZipValid(company.Country, company.Zip, true) {
+		sl.ReportError(company.Zip, "Zip", "zip", "zip", "")
+	}
+}
 
-	if v, ok := common.ZipRegexp[company.Country]; ok {
-		reg = v
+// BillingAddressValidator checks zip of payer address entered on payment form by format of the country,
+// zips of countries without known format are accepted as is
+func (v *ValidatorSet) BillingAddressValidator(sl validator.StructLevel) {
+	address := sl.Current().Interface().(grpc.ProcessBillingAddressRequest)
+
+	if address.Zip != "" && !ZipValid(address.Country, address.Zip, false) {
+		sl.ReportError(address.Zip, "Zip", "zip", "zip", "")
 	}
+}
 
-	match := reg.MatchString(company.Zip)
+// ZipValid checks postal code by format of the country, strict check of countries without known format
+// allows digits only
+func ZipValid(country, zip string, strict bool) bool {
+	reg, ok := common.ZipRegexp[strings.ToUpper(country)]
 
-	if !match {
-		sl.ReportError(company.Zip, "Zip", "zip", "zip", "")
+	if !ok {
+		if !strict {
+			return true
+		}
+
+		reg = zipGeneralRegexp
 	}
+
+	return reg.MatchString(zip)
 }
 
 // SwiftValidator
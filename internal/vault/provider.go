@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyHeader = "X-API-KEY"
+
+	tokensPathMask = "%s/%s/tokens"
+
+	maxResponseSize = 64 * 1024
+)
+
+var (
+	ErrorProviderNotConfigured = errors.New("card vault isn't configured")
+	ErrorCardRejected          = errors.New("card is rejected by vault")
+	ErrorTokenInvalid          = errors.New("card vault response is invalid")
+)
+
+// Card is raw payment card data exchanged for token, it must never be logged or stored
+type Card struct {
+	Pan    string `json:"pan"`
+	Cvv    string `json:"cvv"`
+	Month  string `json:"month"`
+	Year   string `json:"year"`
+	Holder string `json:"card_holder"`
+}
+
+// Token is the vault token replacing card data in payment create requests
+type Token struct {
+	Token     string `json:"card_token"`
+	MaskedPan string `json:"masked_pan"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// Provider describes card vault of the cardholder data environment
+type Provider interface {
+	// Tokenize exchanges card data for token usable with the payment system
+	Tokenize(ctx context.Context, paymentSystem string, card *Card) (*Token, error)
+}
+
+// HttpProvider is a vault speaking plain JSON over HTTP, card of payment system is posted to url/payment_system/tokens
+type HttpProvider struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewHttpProvider
+func NewHttpProvider(url, apiKey string, timeout time.Duration) *HttpProvider {
+	return &HttpProvider{
+		url:    strings.TrimSuffix(url, "/"),
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Tokenize
+func (p *HttpProvider) Tokenize(ctx context.Context, paymentSystem string, card *Card) (*Token, error) {
+	if p.url == "" || paymentSystem == "" {
+		return nil, ErrorProviderNotConfigured
+	}
+
+	body, err := json.Marshal(card)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tokensPathMask, p.url, paymentSystem), bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apiKeyHeader, p.apiKey)
+
+	rsp, err := p.httpClient.Do(req)
+
+	if err != nil {
+		// error of http client contains url only, card data never gets into it
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusUnprocessableEntity {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, maxResponseSize))
+		return nil, ErrorCardRejected
+	}
+
+	if rsp.StatusCode != http.StatusOK && rsp.StatusCode != http.StatusCreated {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, maxResponseSize))
+		return nil, fmt.Errorf("card vault responded with status %d", rsp.StatusCode)
+	}
+
+	token := &Token{}
+
+	if err = json.NewDecoder(io.LimitReader(rsp.Body, maxResponseSize)).Decode(token); err != nil || token.Token == "" {
+		return nil, ErrorTokenInvalid
+	}
+
+	return token, nil
+}
+
+// MaskPan keeps the first six and the last four digits of card number
+func MaskPan(pan string) string {
+	if len(pan) < 10 {
+		return strings.Repeat("*", len(pan))
+	}
+
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+// IsLuhnValid checks card number check digit
+func IsLuhnValid(pan string) bool {
+	sum := 0
+	double := false
+
+	for i := len(pan) - 1; i >= 0; i-- {
+		d := int(pan[i] - '0')
+
+		if d < 0 || d > 9 {
+			return false
+		}
+
+		if double {
+			d *= 2
+
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		double = !double
+	}
+
+	return len(pan) > 0 && sum%10 == 0
+}
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHttpProvider_Tokenize(t *testing.T) {
+	var received *Card
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(apiKeyHeader) != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/cardpay/tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		received = &Card{}
+		_ = json.Unmarshal(body, received)
+
+		if received.Cvv == "000" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"card_token": "tok_1", "masked_pan": "400000******0002"}`))
+	}))
+	defer srv.Close()
+
+	card := &Card{Pan: "4000000000000002", Cvv: "123", Month: "12", Year: "2030"}
+	p := NewHttpProvider(srv.URL+"/", "key", time.Second)
+
+	token, err := p.Tokenize(context.Background(), "cardpay", card)
+	assert.NoError(t, err)
+	assert.Equal(t, "tok_1", token.Token)
+	assert.Equal(t, card.Pan, received.Pan)
+
+	_, err = p.Tokenize(context.Background(), "cardpay", &Card{Pan: "4000000000000002", Cvv: "000"})
+	assert.Equal(t, ErrorCardRejected, err)
+
+	_, err = p.Tokenize(context.Background(), "other", card)
+	assert.Error(t, err)
+
+	_, err = p.Tokenize(context.Background(), "", card)
+	assert.Equal(t, ErrorProviderNotConfigured, err)
+
+	_, err = NewHttpProvider("", "key", time.Second).Tokenize(context.Background(), "cardpay", card)
+	assert.Equal(t, ErrorProviderNotConfigured, err)
+}
+
+func TestMaskPan(t *testing.T) {
+	assert.Equal(t, "400000******0002", MaskPan("4000000000000002"))
+	assert.Equal(t, "*****", MaskPan("40000"))
+}
+
+func TestIsLuhnValid(t *testing.T) {
+	assert.True(t, IsLuhnValid("4000000000000002"))
+	assert.True(t, IsLuhnValid("4242424242424242"))
+	assert.False(t, IsLuhnValid("4000000000000001"))
+	assert.False(t, IsLuhnValid("40000000000a0002"))
+	assert.False(t, IsLuhnValid(""))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/paysuper/paysuper-management-api/cmd/http"
+	"github.com/paysuper/paysuper-management-api/cmd/loadgen"
 	"github.com/paysuper/paysuper-management-api/cmd/root"
 )
 
@@ -9,5 +10,5 @@ func main() {
 	args := []string{
 		"http", "-c", "configs/local.yaml", "-d",
 	}
-	root.ExecuteDefault(args, http.Cmd)
+	root.ExecuteDefault(args, http.Cmd, loadgen.Cmd)
 }
@@ -0,0 +1,43 @@
+// Billing server stub for integration tests, see internal/test/billingstub. The stub serves go-micro requests on
+// address set by MICRO_SERVER_ADDRESS and admin api on address set by BILLING_STUB_ADMIN_ADDRESS
+package main
+
+import (
+	"github.com/micro/go-micro"
+	"github.com/paysuper/paysuper-billing-server/pkg"
+	"github.com/paysuper/paysuper-management-api/internal/test/billingstub"
+	"log"
+	"net/http"
+	"os"
+)
+
+const (
+	adminAddressEnv     = "BILLING_STUB_ADMIN_ADDRESS"
+	adminAddressDefault = ":8081"
+)
+
+func main() {
+	stub := billingstub.New()
+	service := micro.NewService(micro.Name(pkg.ServiceName))
+	service.Init()
+
+	err := service.Server().Handle(service.Server().NewHandler(billingstub.NewBillingService(stub)))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	adminAddress := os.Getenv(adminAddressEnv)
+
+	if adminAddress == "" {
+		adminAddress = adminAddressDefault
+	}
+
+	go func() {
+		log.Fatal(http.ListenAndServe(adminAddress, stub))
+	}()
+
+	if err = service.Run(); err != nil {
+		log.Fatal(err)
+	}
+}